@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+ id UUID,
+ family_id UUID NOT NULL,
+ username VARCHAR(30) REFERENCES users(username) ON DELETE CASCADE NOT NULL,
+ token_hash TEXT NOT NULL UNIQUE,
+ created_at TIMESTAMP NOT NULL,
+ expires_at TIMESTAMP NOT NULL,
+ revoked_at TIMESTAMP,
+ PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
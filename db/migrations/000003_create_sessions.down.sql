@@ -0,0 +1,4 @@
+ALTER TABLE refresh_tokens DROP CONSTRAINT IF EXISTS refresh_tokens_family_id_fkey;
+
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS sessions;
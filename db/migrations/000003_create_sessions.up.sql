@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS sessions (
+ id UUID,
+ username VARCHAR(30) REFERENCES users(username) ON DELETE CASCADE NOT NULL,
+ user_agent TEXT NOT NULL DEFAULT '',
+ client_ip TEXT NOT NULL DEFAULT '',
+ created_at TIMESTAMP NOT NULL,
+ last_seen_at TIMESTAMP NOT NULL,
+ expires_at TIMESTAMP NOT NULL,
+ revoked_at TIMESTAMP,
+ PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS sessions_username_idx ON sessions (username);
+
+-- revoked PASETO token IDs and session IDs, kept until the token would have expired anyway
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+ id UUID,
+ username VARCHAR(30) REFERENCES users(username) ON DELETE CASCADE NOT NULL,
+ revoked_at TIMESTAMP NOT NULL,
+ expires_at TIMESTAMP NOT NULL,
+ PRIMARY KEY (id)
+);
+
+-- every refresh token family is a session from now on, older tokens can't be mapped to one
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+  ADD CONSTRAINT refresh_tokens_family_id_fkey FOREIGN KEY (family_id) REFERENCES sessions(id) ON DELETE CASCADE;
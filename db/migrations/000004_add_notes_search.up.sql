@@ -0,0 +1,9 @@
+-- Weighted document for full-text search, title ranks higher than text.
+-- The 'simple' config doesn't stem, so prefix and phrase queries match what the user typed.
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS search TSVECTOR
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(text, '')), 'B')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS notes_search_idx ON notes USING GIN (search);
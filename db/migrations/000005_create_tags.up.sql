@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS tags (
+ id UUID,
+ username VARCHAR(30) REFERENCES users(username) ON DELETE CASCADE NOT NULL,
+ name TEXT NOT NULL,
+ PRIMARY KEY (id),
+ UNIQUE (username, name)
+);
+
+CREATE TABLE IF NOT EXISTS note_tags (
+ note_id UUID REFERENCES notes(id) ON DELETE CASCADE NOT NULL,
+ tag_id UUID REFERENCES tags(id) ON DELETE CASCADE NOT NULL,
+ PRIMARY KEY (note_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS note_tags_tag_id_idx ON note_tags (tag_id);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS notebooks (
+ id UUID,
+ username VARCHAR(30) REFERENCES users(username) ON DELETE CASCADE NOT NULL,
+ parent_id UUID REFERENCES notebooks(id) ON DELETE CASCADE,
+ name TEXT NOT NULL,
+ created_at TIMESTAMP NOT NULL,
+ updated_at TIMESTAMP NOT NULL,
+ PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS notebooks_username_idx ON notebooks (username);
+CREATE INDEX IF NOT EXISTS notebooks_parent_id_idx ON notebooks (parent_id);
+
+-- sibling notebooks can't share a name, top level ones included
+CREATE UNIQUE INDEX IF NOT EXISTS notebooks_sibling_name_idx
+  ON notebooks (username, coalesce(parent_id, '00000000-0000-0000-0000-000000000000'), name);
+
+-- notes of a deleted notebook are kept and become unfiled
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS notebook_id UUID REFERENCES notebooks(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS notes_notebook_id_idx ON notes (notebook_id);
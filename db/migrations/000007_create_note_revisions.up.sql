@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS note_revisions (
+ note_id UUID REFERENCES notes(id) ON DELETE CASCADE NOT NULL,
+ revision INT NOT NULL,
+ title TEXT NOT NULL,
+ text TEXT,
+ created_at TIMESTAMP NOT NULL,
+ PRIMARY KEY (note_id, revision)
+);
+
+-- the current state of existing notes is their first revision
+INSERT INTO note_revisions (note_id, revision, title, text, created_at)
+SELECT id, 1, title, text, updated_at
+FROM notes
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+-- bumped on every change of a note, used for optimistic concurrency control
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS notes_deleted_at_idx;
+ALTER TABLE notes DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS attachments (
+ id UUID,
+ note_id UUID REFERENCES notes(id) ON DELETE CASCADE NOT NULL,
+ username VARCHAR(30) REFERENCES users(username) ON DELETE CASCADE NOT NULL,
+ filename TEXT NOT NULL,
+ content_type TEXT NOT NULL,
+ size BIGINT NOT NULL,
+ storage_key TEXT NOT NULL UNIQUE,
+ created_at TIMESTAMP NOT NULL,
+ PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS attachments_note_id_idx ON attachments (note_id);
+
+-- quota checks sum the sizes per user
+CREATE INDEX IF NOT EXISTS attachments_username_idx ON attachments (username);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS notes_title_unique_idx;
+
+ALTER TABLE notes DROP COLUMN IF EXISTS unique_title;
+
+ALTER TABLE users DROP COLUMN IF EXISTS allow_duplicate_titles;
+
+-- fails while titles are shared, rename those notes first
+ALTER TABLE notes ADD CONSTRAINT notes_title_key UNIQUE (title);
@@ -0,0 +1,11 @@
+-- titles were unique across all users, now only among the active notes of a notebook
+ALTER TABLE notes DROP CONSTRAINT IF EXISTS notes_title_key;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS allow_duplicate_titles BOOLEAN NOT NULL DEFAULT false;
+
+-- copy of the owner's setting, the index can't look at the users table
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS unique_title BOOLEAN NOT NULL DEFAULT true;
+
+CREATE UNIQUE INDEX IF NOT EXISTS notes_title_unique_idx
+  ON notes (username, coalesce(notebook_id, '00000000-0000-0000-0000-000000000000'), title)
+  WHERE unique_title AND deleted_at IS NULL;
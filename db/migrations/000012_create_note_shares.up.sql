@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS note_shares (
+ note_id UUID REFERENCES notes(id) ON DELETE CASCADE NOT NULL,
+ username VARCHAR(30) REFERENCES users(username) ON DELETE CASCADE NOT NULL,
+ permission VARCHAR(10) NOT NULL CHECK (permission IN ('read', 'edit')),
+ created_at TIMESTAMP NOT NULL,
+ PRIMARY KEY (note_id, username)
+);
+
+-- "shared with me" looks the shares up by grantee
+CREATE INDEX IF NOT EXISTS note_shares_username_idx ON note_shares (username);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS note_links (
+ id UUID,
+ note_id UUID REFERENCES notes(id) ON DELETE CASCADE NOT NULL,
+ -- only a hash of the token is kept, the token itself is shown once
+ token_hash TEXT NOT NULL UNIQUE,
+ password_hash TEXT,
+ expires_at TIMESTAMP,
+ views BIGINT NOT NULL DEFAULT 0,
+ created_at TIMESTAMP NOT NULL,
+ PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS note_links_note_id_idx ON note_links (note_id);
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS tags_log_change ON tags;
+DROP TRIGGER IF EXISTS note_tags_log_change ON note_tags;
+DROP TRIGGER IF EXISTS notes_log_update ON notes;
+DROP TRIGGER IF EXISTS notes_log_change ON notes;
+DROP FUNCTION IF EXISTS tags_log_change();
+DROP FUNCTION IF EXISTS note_tags_log_change();
+DROP FUNCTION IF EXISTS notes_log_change();
+DROP FUNCTION IF EXISTS log_note_change(VARCHAR, UUID);
+DROP TABLE IF EXISTS note_changes;
+DROP SEQUENCE IF EXISTS note_changes_seq;
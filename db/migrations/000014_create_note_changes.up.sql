@@ -0,0 +1,88 @@
+-- one row per note of a user with the sequence number of its latest change, deleted notes stay as tombstones.
+-- Sync lets clients pick note IDs, so a purged note's ID may come back as a note of another user.
+CREATE SEQUENCE IF NOT EXISTS note_changes_seq;
+
+CREATE TABLE IF NOT EXISTS note_changes (
+ note_id UUID NOT NULL,
+ username VARCHAR(30) REFERENCES users(username) ON DELETE CASCADE NOT NULL,
+ seq BIGINT NOT NULL UNIQUE,
+ changed_at TIMESTAMP NOT NULL DEFAULT now(),
+ PRIMARY KEY (username, note_id)
+);
+
+CREATE INDEX IF NOT EXISTS note_changes_username_seq_idx ON note_changes (username, seq);
+
+CREATE OR REPLACE FUNCTION log_note_change(p_username VARCHAR, p_note_id UUID) RETURNS void AS $$
+BEGIN
+  -- numbers changes of a user in commit order, so a sync never skips a change that commits late
+  PERFORM pg_advisory_xact_lock(hashtext('note_changes:' || p_username));
+
+  INSERT INTO note_changes (note_id, username, seq, changed_at)
+  VALUES (p_note_id, p_username, nextval('note_changes_seq'), now())
+  ON CONFLICT (username, note_id) DO UPDATE SET seq = EXCLUDED.seq, changed_at = EXCLUDED.changed_at;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notes_log_change() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    PERFORM log_note_change(OLD.username, OLD.id);
+  ELSE
+    PERFORM log_note_change(NEW.username, NEW.id);
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS notes_log_change ON notes;
+CREATE TRIGGER notes_log_change
+  AFTER INSERT OR DELETE ON notes
+  FOR EACH ROW EXECUTE FUNCTION notes_log_change();
+
+-- e.g. changing the title setting rewrites every note of the user, mostly without a change
+DROP TRIGGER IF EXISTS notes_log_update ON notes;
+CREATE TRIGGER notes_log_update
+  AFTER UPDATE ON notes
+  FOR EACH ROW
+  WHEN (OLD.* IS DISTINCT FROM NEW.*)
+  EXECUTE FUNCTION notes_log_change();
+
+-- tags are part of a synced note
+CREATE OR REPLACE FUNCTION note_tags_log_change() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    -- finds nothing when the note itself was deleted, that is logged already
+    PERFORM log_note_change(n.username, n.id) FROM notes n WHERE n.id = OLD.note_id;
+  ELSE
+    PERFORM log_note_change(n.username, n.id) FROM notes n WHERE n.id = NEW.note_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS note_tags_log_change ON note_tags;
+CREATE TRIGGER note_tags_log_change
+  AFTER INSERT OR DELETE ON note_tags
+  FOR EACH ROW EXECUTE FUNCTION note_tags_log_change();
+
+CREATE OR REPLACE FUNCTION tags_log_change() RETURNS trigger AS $$
+BEGIN
+  PERFORM log_note_change(n.username, n.id)
+  FROM note_tags nt
+  JOIN notes n ON n.id = nt.note_id
+  WHERE nt.tag_id = NEW.id;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tags_log_change ON tags;
+CREATE TRIGGER tags_log_change
+  AFTER UPDATE OF name ON tags
+  FOR EACH ROW EXECUTE FUNCTION tags_log_change();
+
+-- notes written before the log existed are all changes since the start
+INSERT INTO note_changes (note_id, username, seq)
+SELECT id, username, nextval('note_changes_seq')
+FROM notes
+ORDER BY updated_at
+ON CONFLICT (note_id) DO NOTHING;
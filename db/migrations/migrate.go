@@ -1,7 +1,174 @@
 package migrations
 
-import "github.com/rs/zerolog"
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"time"
 
-func MigrateDB(config string, path string, logger *zerolog.Logger) error {
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultAttempts = 5
+	defaultTimeout  = 2 * time.Second
+)
+
+var (
+	ErrEmptyDBURL = errors.New("migration error: DB URL cannot be empty")
+	ErrDirty      = errors.New("migration error: the DB is in a dirty state, fix it and force a version")
+)
+
+// Numbered up/down migrations, embedded into the binary
+//
+//go:embed *.sql
+var migrationFS embed.FS
+
+type Migrator struct {
+	m      *migrate.Migrate
+	logger *zerolog.Logger
+}
+
+// Adapter so golang-migrate logs through zerolog
+type migrateLogger struct {
+	l *zerolog.Logger
+}
+
+func (ml migrateLogger) Printf(format string, v ...interface{}) {
+	ml.l.Info().Msgf(format, v...)
+}
+
+func (ml migrateLogger) Verbose() bool {
+	return false
+}
+
+// Create a new Migrator using the embedded migrations.
+// The postgres driver keeps its bookkeeping in the schema_migrations table
+// and takes an advisory lock, so concurrent replicas can't run the same migration twice.
+func New(dbURL string, l *zerolog.Logger) (*Migrator, error) {
+	if dbURL == "" {
+		l.Error().Msg("DB URL cannot be empty!")
+		return nil, ErrEmptyDBURL
+	}
+
+	src, err := iofs.New(migrationFS, ".")
+	if err != nil {
+		l.Error().Err(err).Msgf("could not read the embedded migrations. %v", err)
+		return nil, err
+	}
+
+	var m *migrate.Migrate
+
+	for attempts := defaultAttempts; attempts > 0; attempts-- {
+		m, err = migrate.NewWithSourceInstance("iofs", src, dbURL)
+		if err == nil {
+			break
+		}
+
+		l.Error().Msgf("could not connect to the DB for migration: %v. Attempts left: %d", err, attempts-1)
+		if attempts > 1 {
+			time.Sleep(defaultTimeout)
+		}
+	}
+
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+
+	m.Log = migrateLogger{l}
+	m.LockTimeout = 30 * time.Second
+
+	return &Migrator{m: m, logger: l}, nil
+}
+
+// Apply all pending up migrations
+func (mg *Migrator) Up() error {
+	err := mg.m.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		mg.logger.Info().Msg("there were no changes since the last migration")
+		return nil
+	}
+	if err != nil {
+		return mg.wrap("up", err)
+	}
+
+	mg.logger.Info().Msg("migrating the DB up was successful")
+	return nil
+}
+
+// Roll back the last applied migration
+func (mg *Migrator) Down() error {
+	err := mg.m.Steps(-1)
+	if errors.Is(err, migrate.ErrNoChange) {
+		mg.logger.Info().Msg("there is nothing to roll back")
+		return nil
+	}
+	if err != nil {
+		return mg.wrap("down", err)
+	}
+
+	mg.logger.Info().Msg("rolling back the last migration was successful")
 	return nil
-}
\ No newline at end of file
+}
+
+// Migrate up or down until the given version is reached
+func (mg *Migrator) Goto(version uint) error {
+	err := mg.m.Migrate(version)
+	if errors.Is(err, migrate.ErrNoChange) {
+		mg.logger.Info().Msgf("the DB is already at version %d", version)
+		return nil
+	}
+	if err != nil {
+		return mg.wrap(fmt.Sprintf("goto %d", version), err)
+	}
+
+	mg.logger.Info().Msgf("migrating the DB to version %d was successful", version)
+	return nil
+}
+
+// Return the current version and whether the last migration failed halfway.
+// Version is 0 when no migration has been applied yet.
+func (mg *Migrator) Status() (uint, bool, error) {
+	version, dirty, err := mg.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+// Close the source and the DB connection of the migrator
+func (mg *Migrator) Close() error {
+	srcErr, dbErr := mg.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+
+	return dbErr
+}
+
+func (mg *Migrator) wrap(op string, err error) error {
+	var dirtyErr migrate.ErrDirty
+	if errors.As(err, &dirtyErr) {
+		mg.logger.Error().Msgf("migrate %s failed, DB is dirty at version %d", op, dirtyErr.Version)
+		return fmt.Errorf("%w (version %d)", ErrDirty, dirtyErr.Version)
+	}
+
+	mg.logger.Error().Msgf("migrate %s error. %v", op, err)
+	return err
+}
+
+// Apply all pending migrations, used on server start
+func MigrateDB(dbURL string, l *zerolog.Logger) error {
+	mg, err := New(dbURL, l)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	return mg.Up()
+}
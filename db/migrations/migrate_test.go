@@ -1 +1,57 @@
-package migrations_test
\ No newline at end of file
+package migrations
+
+import (
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	l := zerolog.New(io.Discard)
+
+	t.Run("returns error empty connection string", func(t *testing.T) {
+		mg, err := New("", &l)
+		require.ErrorIs(t, err, ErrEmptyDBURL)
+		require.Nil(t, mg)
+	})
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	files, err := fs.Glob(migrationFS, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	ups := map[uint]bool{}
+	downs := map[uint]bool{}
+
+	for _, f := range files {
+		m, err := source.DefaultParse(f)
+		require.NoError(t, err, "migration %s is not named as NNNNNN_title.(up|down).sql", f)
+
+		b, err := migrationFS.ReadFile(f)
+		require.NoError(t, err)
+		require.NotEmpty(t, strings.TrimSpace(string(b)), "migration %s is empty", f)
+
+		switch m.Direction {
+		case source.Up:
+			ups[m.Version] = true
+		case source.Down:
+			downs[m.Version] = true
+		}
+	}
+
+	t.Run("every up migration has a down migration", func(t *testing.T) {
+		require.Equal(t, ups, downs)
+	})
+
+	t.Run("versions are sequential from 1", func(t *testing.T) {
+		for v := uint(1); v <= uint(len(ups)); v++ {
+			require.True(t, ups[v], "migration version %d is missing", v)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/alekslesik/online-note-z/lib/random"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// Postgres the migrations are run against, e.g. the one of make pg-up. Tests needing it are skipped without it.
+const testDBEnv = "TEST_DB_CONN_STRING"
+
+func testDB(t *testing.T) *sql.DB {
+	dbURL := os.Getenv(testDBEnv)
+	if dbURL == "" {
+		t.Skipf("%s is not set", testDBEnv)
+	}
+
+	l := zerolog.New(io.Discard)
+	require.NoError(t, MigrateDB(dbURL, &l))
+
+	conn, err := sql.Open("postgres", dbURL)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestNoteChangeLog(t *testing.T) {
+	conn := testDB(t)
+
+	// everything is rolled back, so the DB is left as it was
+	tx, err := conn.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	first, second := random.NewString(10), random.NewString(10)
+	for _, username := range []string{first, second} {
+		_, err = tx.Exec(`INSERT INTO users (username, password, email) VALUES ($1, 'secret', $2)`, username, username+"@example.com")
+		require.NoError(t, err)
+	}
+
+	createNote := func(id uuid.UUID, username string) {
+		_, err := tx.Exec(`INSERT INTO notes (id, title, username, text, created_at, updated_at) VALUES ($1, $2, $3, 'text', now(), now())`,
+			id, random.NewString(15), username)
+		require.NoError(t, err)
+	}
+
+	changes := func(id uuid.UUID) map[string]int64 {
+		rows, err := tx.Query(`SELECT username, seq FROM note_changes WHERE note_id = $1`, id)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		seqs := map[string]int64{}
+		for rows.Next() {
+			var (
+				username string
+				seq      int64
+			)
+			require.NoError(t, rows.Scan(&username, &seq))
+			seqs[username] = seq
+		}
+		require.NoError(t, rows.Err())
+
+		return seqs
+	}
+
+	t.Run("an ID purged and created again by another user is logged for both", func(t *testing.T) {
+		id := uuid.New()
+		createNote(id, first)
+
+		_, err := tx.Exec(`DELETE FROM notes WHERE id = $1`, id)
+		require.NoError(t, err)
+		purged := changes(id)
+		require.Contains(t, purged, first)
+
+		createNote(id, second)
+
+		// the first user keeps the tombstone, the second one gets the new note
+		recreated := changes(id)
+		require.Len(t, recreated, 2)
+		require.Equal(t, purged[first], recreated[first])
+		require.Greater(t, recreated[second], recreated[first])
+	})
+}
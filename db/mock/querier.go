@@ -1 +1,1164 @@
-package mock
\ No newline at end of file
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/alekslesik/online-note-z/db/sqlc (interfaces: Querier)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	sqlc "github.com/alekslesik/online-note-z/db/sqlc"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockQuerier is a mock of Querier interface.
+type MockQuerier struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuerierMockRecorder
+}
+
+// MockQuerierMockRecorder is the mock recorder for MockQuerier.
+type MockQuerierMockRecorder struct {
+	mock *MockQuerier
+}
+
+// NewMockQuerier creates a new mock instance.
+func NewMockQuerier(ctrl *gomock.Controller) *MockQuerier {
+	mock := &MockQuerier{ctrl: ctrl}
+	mock.recorder = &MockQuerierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuerier) EXPECT() *MockQuerierMockRecorder {
+	return m.recorder
+}
+
+// AddNoteTags mocks base method.
+func (m *MockQuerier) AddNoteTags(arg0 context.Context, arg1 *sqlc.AddNoteTagsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddNoteTags", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddNoteTags indicates an expected call of AddNoteTags.
+func (mr *MockQuerierMockRecorder) AddNoteTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNoteTags", reflect.TypeOf((*MockQuerier)(nil).AddNoteTags), arg0, arg1)
+}
+
+// BumpTaggedNotes mocks base method.
+func (m *MockQuerier) BumpTaggedNotes(arg0 context.Context, arg1 *sqlc.BumpTaggedNotesParams) ([]sqlc.BumpTaggedNotesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BumpTaggedNotes", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.BumpTaggedNotesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BumpTaggedNotes indicates an expected call of BumpTaggedNotes.
+func (mr *MockQuerierMockRecorder) BumpTaggedNotes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BumpTaggedNotes", reflect.TypeOf((*MockQuerier)(nil).BumpTaggedNotes), arg0, arg1)
+}
+
+// ClearNoteTags mocks base method.
+func (m *MockQuerier) ClearNoteTags(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearNoteTags", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearNoteTags indicates an expected call of ClearNoteTags.
+func (mr *MockQuerierMockRecorder) ClearNoteTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearNoteTags", reflect.TypeOf((*MockQuerier)(nil).ClearNoteTags), arg0, arg1)
+}
+
+// CountLinkView mocks base method.
+func (m *MockQuerier) CountLinkView(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountLinkView", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CountLinkView indicates an expected call of CountLinkView.
+func (mr *MockQuerierMockRecorder) CountLinkView(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountLinkView", reflect.TypeOf((*MockQuerier)(nil).CountLinkView), arg0, arg1)
+}
+
+// CountNotes mocks base method.
+func (m *MockQuerier) CountNotes(arg0 context.Context, arg1 *sqlc.CountNotesParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountNotes", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountNotes indicates an expected call of CountNotes.
+func (mr *MockQuerierMockRecorder) CountNotes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountNotes", reflect.TypeOf((*MockQuerier)(nil).CountNotes), arg0, arg1)
+}
+
+// CreateAttachment mocks base method.
+func (m *MockQuerier) CreateAttachment(arg0 context.Context, arg1 *sqlc.CreateAttachmentParams) (sqlc.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttachment", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAttachment indicates an expected call of CreateAttachment.
+func (mr *MockQuerierMockRecorder) CreateAttachment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttachment", reflect.TypeOf((*MockQuerier)(nil).CreateAttachment), arg0, arg1)
+}
+
+// CreateNote mocks base method.
+func (m *MockQuerier) CreateNote(arg0 context.Context, arg1 *sqlc.CreateNoteParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNote", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNote indicates an expected call of CreateNote.
+func (mr *MockQuerierMockRecorder) CreateNote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNote", reflect.TypeOf((*MockQuerier)(nil).CreateNote), arg0, arg1)
+}
+
+// CreateNoteLink mocks base method.
+func (m *MockQuerier) CreateNoteLink(arg0 context.Context, arg1 *sqlc.CreateNoteLinkParams) (sqlc.NoteLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNoteLink", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.NoteLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNoteLink indicates an expected call of CreateNoteLink.
+func (mr *MockQuerierMockRecorder) CreateNoteLink(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNoteLink", reflect.TypeOf((*MockQuerier)(nil).CreateNoteLink), arg0, arg1)
+}
+
+// CreateNoteRevision mocks base method.
+func (m *MockQuerier) CreateNoteRevision(arg0 context.Context, arg1 uuid.UUID) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNoteRevision", arg0, arg1)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNoteRevision indicates an expected call of CreateNoteRevision.
+func (mr *MockQuerierMockRecorder) CreateNoteRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNoteRevision", reflect.TypeOf((*MockQuerier)(nil).CreateNoteRevision), arg0, arg1)
+}
+
+// CreateNotebook mocks base method.
+func (m *MockQuerier) CreateNotebook(arg0 context.Context, arg1 *sqlc.CreateNotebookParams) (sqlc.Notebook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNotebook", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.Notebook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNotebook indicates an expected call of CreateNotebook.
+func (mr *MockQuerierMockRecorder) CreateNotebook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotebook", reflect.TypeOf((*MockQuerier)(nil).CreateNotebook), arg0, arg1)
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockQuerier) CreateRefreshToken(arg0 context.Context, arg1 *sqlc.CreateRefreshTokenParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockQuerierMockRecorder) CreateRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockQuerier)(nil).CreateRefreshToken), arg0, arg1)
+}
+
+// CreateRevokedToken mocks base method.
+func (m *MockQuerier) CreateRevokedToken(arg0 context.Context, arg1 *sqlc.CreateRevokedTokenParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRevokedToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRevokedToken indicates an expected call of CreateRevokedToken.
+func (mr *MockQuerierMockRecorder) CreateRevokedToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRevokedToken", reflect.TypeOf((*MockQuerier)(nil).CreateRevokedToken), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockQuerier) CreateSession(arg0 context.Context, arg1 *sqlc.CreateSessionParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockQuerierMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockQuerier)(nil).CreateSession), arg0, arg1)
+}
+
+// CreateTags mocks base method.
+func (m *MockQuerier) CreateTags(arg0 context.Context, arg1 *sqlc.CreateTagsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTags", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTags indicates an expected call of CreateTags.
+func (mr *MockQuerierMockRecorder) CreateTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTags", reflect.TypeOf((*MockQuerier)(nil).CreateTags), arg0, arg1)
+}
+
+// DeleteAttachment mocks base method.
+func (m *MockQuerier) DeleteAttachment(arg0 context.Context, arg1 *sqlc.DeleteAttachmentParams) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttachment", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAttachment indicates an expected call of DeleteAttachment.
+func (mr *MockQuerierMockRecorder) DeleteAttachment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttachment", reflect.TypeOf((*MockQuerier)(nil).DeleteAttachment), arg0, arg1)
+}
+
+// DeleteNoteLink mocks base method.
+func (m *MockQuerier) DeleteNoteLink(arg0 context.Context, arg1 *sqlc.DeleteNoteLinkParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNoteLink", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteNoteLink indicates an expected call of DeleteNoteLink.
+func (mr *MockQuerierMockRecorder) DeleteNoteLink(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNoteLink", reflect.TypeOf((*MockQuerier)(nil).DeleteNoteLink), arg0, arg1)
+}
+
+// DeleteNoteShare mocks base method.
+func (m *MockQuerier) DeleteNoteShare(arg0 context.Context, arg1 *sqlc.DeleteNoteShareParams) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNoteShare", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteNoteShare indicates an expected call of DeleteNoteShare.
+func (mr *MockQuerierMockRecorder) DeleteNoteShare(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNoteShare", reflect.TypeOf((*MockQuerier)(nil).DeleteNoteShare), arg0, arg1)
+}
+
+// DeleteNotebook mocks base method.
+func (m *MockQuerier) DeleteNotebook(arg0 context.Context, arg1 *sqlc.DeleteNotebookParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotebook", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteNotebook indicates an expected call of DeleteNotebook.
+func (mr *MockQuerierMockRecorder) DeleteNotebook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotebook", reflect.TypeOf((*MockQuerier)(nil).DeleteNotebook), arg0, arg1)
+}
+
+// DeletePurgeableAttachments mocks base method.
+func (m *MockQuerier) DeletePurgeableAttachments(arg0 context.Context, arg1 time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePurgeableAttachments", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePurgeableAttachments indicates an expected call of DeletePurgeableAttachments.
+func (mr *MockQuerierMockRecorder) DeletePurgeableAttachments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePurgeableAttachments", reflect.TypeOf((*MockQuerier)(nil).DeletePurgeableAttachments), arg0, arg1)
+}
+
+// DeleteTag mocks base method.
+func (m *MockQuerier) DeleteTag(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockQuerierMockRecorder) DeleteTag(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockQuerier)(nil).DeleteTag), arg0, arg1)
+}
+
+// DeleteTrashedAttachments mocks base method.
+func (m *MockQuerier) DeleteTrashedAttachments(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTrashedAttachments", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTrashedAttachments indicates an expected call of DeleteTrashedAttachments.
+func (mr *MockQuerierMockRecorder) DeleteTrashedAttachments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTrashedAttachments", reflect.TypeOf((*MockQuerier)(nil).DeleteTrashedAttachments), arg0, arg1)
+}
+
+// DeleteUnusedTags mocks base method.
+func (m *MockQuerier) DeleteUnusedTags(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnusedTags", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUnusedTags indicates an expected call of DeleteUnusedTags.
+func (mr *MockQuerierMockRecorder) DeleteUnusedTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnusedTags", reflect.TypeOf((*MockQuerier)(nil).DeleteUnusedTags), arg0, arg1)
+}
+
+// EmptyTrash mocks base method.
+func (m *MockQuerier) EmptyTrash(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmptyTrash", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EmptyTrash indicates an expected call of EmptyTrash.
+func (mr *MockQuerierMockRecorder) EmptyTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmptyTrash", reflect.TypeOf((*MockQuerier)(nil).EmptyTrash), arg0, arg1)
+}
+
+// FindTitleConflict mocks base method.
+func (m *MockQuerier) FindTitleConflict(arg0 context.Context, arg1 *sqlc.FindTitleConflictParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTitleConflict", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTitleConflict indicates an expected call of FindTitleConflict.
+func (mr *MockQuerierMockRecorder) FindTitleConflict(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTitleConflict", reflect.TypeOf((*MockQuerier)(nil).FindTitleConflict), arg0, arg1)
+}
+
+// GetAllNotesFromUser mocks base method.
+func (m *MockQuerier) GetAllNotesFromUser(arg0 context.Context, arg1 string) ([]sqlc.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllNotesFromUser", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllNotesFromUser indicates an expected call of GetAllNotesFromUser.
+func (mr *MockQuerierMockRecorder) GetAllNotesFromUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllNotesFromUser", reflect.TypeOf((*MockQuerier)(nil).GetAllNotesFromUser), arg0, arg1)
+}
+
+// GetAttachment mocks base method.
+func (m *MockQuerier) GetAttachment(arg0 context.Context, arg1 *sqlc.GetAttachmentParams) (sqlc.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachment", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachment indicates an expected call of GetAttachment.
+func (mr *MockQuerierMockRecorder) GetAttachment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachment", reflect.TypeOf((*MockQuerier)(nil).GetAttachment), arg0, arg1)
+}
+
+// GetAttachmentUsage mocks base method.
+func (m *MockQuerier) GetAttachmentUsage(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachmentUsage", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachmentUsage indicates an expected call of GetAttachmentUsage.
+func (mr *MockQuerierMockRecorder) GetAttachmentUsage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachmentUsage", reflect.TypeOf((*MockQuerier)(nil).GetAttachmentUsage), arg0, arg1)
+}
+
+// GetLatestNoteRevision mocks base method.
+func (m *MockQuerier) GetLatestNoteRevision(arg0 context.Context, arg1 *sqlc.GetLatestNoteRevisionParams) (sqlc.NoteRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestNoteRevision", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.NoteRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestNoteRevision indicates an expected call of GetLatestNoteRevision.
+func (mr *MockQuerierMockRecorder) GetLatestNoteRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestNoteRevision", reflect.TypeOf((*MockQuerier)(nil).GetLatestNoteRevision), arg0, arg1)
+}
+
+// GetLinkedNote mocks base method.
+func (m *MockQuerier) GetLinkedNote(arg0 context.Context, arg1 string) (sqlc.GetLinkedNoteRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLinkedNote", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.GetLinkedNoteRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLinkedNote indicates an expected call of GetLinkedNote.
+func (mr *MockQuerierMockRecorder) GetLinkedNote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLinkedNote", reflect.TypeOf((*MockQuerier)(nil).GetLinkedNote), arg0, arg1)
+}
+
+// GetNote mocks base method.
+func (m *MockQuerier) GetNote(arg0 context.Context, arg1 *sqlc.GetNoteParams) (sqlc.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNote", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNote indicates an expected call of GetNote.
+func (mr *MockQuerierMockRecorder) GetNote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNote", reflect.TypeOf((*MockQuerier)(nil).GetNote), arg0, arg1)
+}
+
+// GetNoteAccess mocks base method.
+func (m *MockQuerier) GetNoteAccess(arg0 context.Context, arg1 *sqlc.GetNoteAccessParams) (sqlc.GetNoteAccessRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNoteAccess", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.GetNoteAccessRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNoteAccess indicates an expected call of GetNoteAccess.
+func (mr *MockQuerierMockRecorder) GetNoteAccess(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNoteAccess", reflect.TypeOf((*MockQuerier)(nil).GetNoteAccess), arg0, arg1)
+}
+
+// GetNoteRevision mocks base method.
+func (m *MockQuerier) GetNoteRevision(arg0 context.Context, arg1 *sqlc.GetNoteRevisionParams) (sqlc.NoteRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNoteRevision", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.NoteRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNoteRevision indicates an expected call of GetNoteRevision.
+func (mr *MockQuerierMockRecorder) GetNoteRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNoteRevision", reflect.TypeOf((*MockQuerier)(nil).GetNoteRevision), arg0, arg1)
+}
+
+// GetNotebook mocks base method.
+func (m *MockQuerier) GetNotebook(arg0 context.Context, arg1 *sqlc.GetNotebookParams) (sqlc.Notebook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotebook", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.Notebook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotebook indicates an expected call of GetNotebook.
+func (mr *MockQuerierMockRecorder) GetNotebook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotebook", reflect.TypeOf((*MockQuerier)(nil).GetNotebook), arg0, arg1)
+}
+
+// GetNotebookTree mocks base method.
+func (m *MockQuerier) GetNotebookTree(arg0 context.Context, arg1 *sqlc.GetNotebookTreeParams) ([]sqlc.GetNotebookTreeRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotebookTree", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.GetNotebookTreeRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotebookTree indicates an expected call of GetNotebookTree.
+func (mr *MockQuerierMockRecorder) GetNotebookTree(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotebookTree", reflect.TypeOf((*MockQuerier)(nil).GetNotebookTree), arg0, arg1)
+}
+
+// GetNotesByIDs mocks base method.
+func (m *MockQuerier) GetNotesByIDs(arg0 context.Context, arg1 *sqlc.GetNotesByIDsParams) ([]sqlc.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotesByIDs", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotesByIDs indicates an expected call of GetNotesByIDs.
+func (mr *MockQuerierMockRecorder) GetNotesByIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotesByIDs", reflect.TypeOf((*MockQuerier)(nil).GetNotesByIDs), arg0, arg1)
+}
+
+// GetNotesInNotebooks mocks base method.
+func (m *MockQuerier) GetNotesInNotebooks(arg0 context.Context, arg1 *sqlc.GetNotesInNotebooksParams) ([]sqlc.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotesInNotebooks", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotesInNotebooks indicates an expected call of GetNotesInNotebooks.
+func (mr *MockQuerierMockRecorder) GetNotesInNotebooks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotesInNotebooks", reflect.TypeOf((*MockQuerier)(nil).GetNotesInNotebooks), arg0, arg1)
+}
+
+// GetRefreshTokenByHash mocks base method.
+func (m *MockQuerier) GetRefreshTokenByHash(arg0 context.Context, arg1 string) (sqlc.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshTokenByHash", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshTokenByHash indicates an expected call of GetRefreshTokenByHash.
+func (mr *MockQuerierMockRecorder) GetRefreshTokenByHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshTokenByHash", reflect.TypeOf((*MockQuerier)(nil).GetRefreshTokenByHash), arg0, arg1)
+}
+
+// GetRevokedTokens mocks base method.
+func (m *MockQuerier) GetRevokedTokens(arg0 context.Context, arg1 *sqlc.GetRevokedTokensParams) ([]sqlc.GetRevokedTokensRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevokedTokens", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.GetRevokedTokensRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevokedTokens indicates an expected call of GetRevokedTokens.
+func (mr *MockQuerierMockRecorder) GetRevokedTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevokedTokens", reflect.TypeOf((*MockQuerier)(nil).GetRevokedTokens), arg0, arg1)
+}
+
+// GetSharedNote mocks base method.
+func (m *MockQuerier) GetSharedNote(arg0 context.Context, arg1 *sqlc.GetSharedNoteParams) (sqlc.GetSharedNoteRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharedNote", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.GetSharedNoteRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSharedNote indicates an expected call of GetSharedNote.
+func (mr *MockQuerierMockRecorder) GetSharedNote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedNote", reflect.TypeOf((*MockQuerier)(nil).GetSharedNote), arg0, arg1)
+}
+
+// GetTag mocks base method.
+func (m *MockQuerier) GetTag(arg0 context.Context, arg1 *sqlc.GetTagParams) (sqlc.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTag", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTag indicates an expected call of GetTag.
+func (mr *MockQuerierMockRecorder) GetTag(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTag", reflect.TypeOf((*MockQuerier)(nil).GetTag), arg0, arg1)
+}
+
+// GetTagsOfNotes mocks base method.
+func (m *MockQuerier) GetTagsOfNotes(arg0 context.Context, arg1 []uuid.UUID) ([]sqlc.GetTagsOfNotesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTagsOfNotes", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.GetTagsOfNotesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTagsOfNotes indicates an expected call of GetTagsOfNotes.
+func (mr *MockQuerierMockRecorder) GetTagsOfNotes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagsOfNotes", reflect.TypeOf((*MockQuerier)(nil).GetTagsOfNotes), arg0, arg1)
+}
+
+// GetTrashedNote mocks base method.
+func (m *MockQuerier) GetTrashedNote(arg0 context.Context, arg1 *sqlc.GetTrashedNoteParams) (sqlc.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrashedNote", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrashedNote indicates an expected call of GetTrashedNote.
+func (mr *MockQuerierMockRecorder) GetTrashedNote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrashedNote", reflect.TypeOf((*MockQuerier)(nil).GetTrashedNote), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockQuerier) GetUser(arg0 context.Context, arg1 string) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockQuerierMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockQuerier)(nil).GetUser), arg0, arg1)
+}
+
+// GetUserSettings mocks base method.
+func (m *MockQuerier) GetUserSettings(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSettings", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSettings indicates an expected call of GetUserSettings.
+func (mr *MockQuerierMockRecorder) GetUserSettings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSettings", reflect.TypeOf((*MockQuerier)(nil).GetUserSettings), arg0, arg1)
+}
+
+// IsNotebookInSubtree mocks base method.
+func (m *MockQuerier) IsNotebookInSubtree(arg0 context.Context, arg1 *sqlc.IsNotebookInSubtreeParams) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsNotebookInSubtree", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsNotebookInSubtree indicates an expected call of IsNotebookInSubtree.
+func (mr *MockQuerierMockRecorder) IsNotebookInSubtree(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsNotebookInSubtree", reflect.TypeOf((*MockQuerier)(nil).IsNotebookInSubtree), arg0, arg1)
+}
+
+// ListActiveSessions mocks base method.
+func (m *MockQuerier) ListActiveSessions(arg0 context.Context, arg1 *sqlc.ListActiveSessionsParams) ([]sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveSessions", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveSessions indicates an expected call of ListActiveSessions.
+func (mr *MockQuerierMockRecorder) ListActiveSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveSessions", reflect.TypeOf((*MockQuerier)(nil).ListActiveSessions), arg0, arg1)
+}
+
+// ListAttachments mocks base method.
+func (m *MockQuerier) ListAttachments(arg0 context.Context, arg1 *sqlc.ListAttachmentsParams) ([]sqlc.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAttachments", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAttachments indicates an expected call of ListAttachments.
+func (mr *MockQuerierMockRecorder) ListAttachments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAttachments", reflect.TypeOf((*MockQuerier)(nil).ListAttachments), arg0, arg1)
+}
+
+// ListNoteChanges mocks base method.
+func (m *MockQuerier) ListNoteChanges(arg0 context.Context, arg1 *sqlc.ListNoteChangesParams) ([]sqlc.ListNoteChangesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNoteChanges", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.ListNoteChangesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNoteChanges indicates an expected call of ListNoteChanges.
+func (mr *MockQuerierMockRecorder) ListNoteChanges(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNoteChanges", reflect.TypeOf((*MockQuerier)(nil).ListNoteChanges), arg0, arg1)
+}
+
+// ListNoteLinks mocks base method.
+func (m *MockQuerier) ListNoteLinks(arg0 context.Context, arg1 *sqlc.ListNoteLinksParams) ([]sqlc.NoteLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNoteLinks", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.NoteLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNoteLinks indicates an expected call of ListNoteLinks.
+func (mr *MockQuerierMockRecorder) ListNoteLinks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNoteLinks", reflect.TypeOf((*MockQuerier)(nil).ListNoteLinks), arg0, arg1)
+}
+
+// ListNoteRevisions mocks base method.
+func (m *MockQuerier) ListNoteRevisions(arg0 context.Context, arg1 *sqlc.ListNoteRevisionsParams) ([]sqlc.ListNoteRevisionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNoteRevisions", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.ListNoteRevisionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNoteRevisions indicates an expected call of ListNoteRevisions.
+func (mr *MockQuerierMockRecorder) ListNoteRevisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNoteRevisions", reflect.TypeOf((*MockQuerier)(nil).ListNoteRevisions), arg0, arg1)
+}
+
+// ListNoteShares mocks base method.
+func (m *MockQuerier) ListNoteShares(arg0 context.Context, arg1 *sqlc.ListNoteSharesParams) ([]sqlc.NoteShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNoteShares", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.NoteShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNoteShares indicates an expected call of ListNoteShares.
+func (mr *MockQuerierMockRecorder) ListNoteShares(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNoteShares", reflect.TypeOf((*MockQuerier)(nil).ListNoteShares), arg0, arg1)
+}
+
+// ListNotebooks mocks base method.
+func (m *MockQuerier) ListNotebooks(arg0 context.Context, arg1 string) ([]sqlc.Notebook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotebooks", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Notebook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotebooks indicates an expected call of ListNotebooks.
+func (mr *MockQuerierMockRecorder) ListNotebooks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotebooks", reflect.TypeOf((*MockQuerier)(nil).ListNotebooks), arg0, arg1)
+}
+
+// ListNotes mocks base method.
+func (m *MockQuerier) ListNotes(arg0 context.Context, arg1 *sqlc.ListNotesParams) ([]sqlc.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotes", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotes indicates an expected call of ListNotes.
+func (mr *MockQuerierMockRecorder) ListNotes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotes", reflect.TypeOf((*MockQuerier)(nil).ListNotes), arg0, arg1)
+}
+
+// ListSharedNotes mocks base method.
+func (m *MockQuerier) ListSharedNotes(arg0 context.Context, arg1 string) ([]sqlc.ListSharedNotesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSharedNotes", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.ListSharedNotesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSharedNotes indicates an expected call of ListSharedNotes.
+func (mr *MockQuerierMockRecorder) ListSharedNotes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSharedNotes", reflect.TypeOf((*MockQuerier)(nil).ListSharedNotes), arg0, arg1)
+}
+
+// ListTags mocks base method.
+func (m *MockQuerier) ListTags(arg0 context.Context, arg1 string) ([]sqlc.ListTagsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTags", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.ListTagsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTags indicates an expected call of ListTags.
+func (mr *MockQuerierMockRecorder) ListTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTags", reflect.TypeOf((*MockQuerier)(nil).ListTags), arg0, arg1)
+}
+
+// ListTrash mocks base method.
+func (m *MockQuerier) ListTrash(arg0 context.Context, arg1 string) ([]sqlc.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrash", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockQuerierMockRecorder) ListTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockQuerier)(nil).ListTrash), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockQuerier) ListUsers(arg0 context.Context) ([]sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0)
+	ret0, _ := ret[0].([]sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockQuerierMockRecorder) ListUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockQuerier)(nil).ListUsers), arg0)
+}
+
+// MoveNote mocks base method.
+func (m *MockQuerier) MoveNote(arg0 context.Context, arg1 *sqlc.MoveNoteParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveNote", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveNote indicates an expected call of MoveNote.
+func (mr *MockQuerierMockRecorder) MoveNote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveNote", reflect.TypeOf((*MockQuerier)(nil).MoveNote), arg0, arg1)
+}
+
+// MoveNoteTags mocks base method.
+func (m *MockQuerier) MoveNoteTags(arg0 context.Context, arg1 *sqlc.MoveNoteTagsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveNoteTags", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveNoteTags indicates an expected call of MoveNoteTags.
+func (mr *MockQuerierMockRecorder) MoveNoteTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveNoteTags", reflect.TypeOf((*MockQuerier)(nil).MoveNoteTags), arg0, arg1)
+}
+
+// NotifyNoteEvent mocks base method.
+func (m *MockQuerier) NotifyNoteEvent(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NotifyNoteEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NotifyNoteEvent indicates an expected call of NotifyNoteEvent.
+func (mr *MockQuerierMockRecorder) NotifyNoteEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyNoteEvent", reflect.TypeOf((*MockQuerier)(nil).NotifyNoteEvent), arg0, arg1)
+}
+
+// PruneNoteRevisions mocks base method.
+func (m *MockQuerier) PruneNoteRevisions(arg0 context.Context, arg1 *sqlc.PruneNoteRevisionsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneNoteRevisions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PruneNoteRevisions indicates an expected call of PruneNoteRevisions.
+func (mr *MockQuerierMockRecorder) PruneNoteRevisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneNoteRevisions", reflect.TypeOf((*MockQuerier)(nil).PruneNoteRevisions), arg0, arg1)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockQuerier) PurgeTrash(arg0 context.Context, arg1 *sqlc.PurgeTrashParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockQuerierMockRecorder) PurgeTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockQuerier)(nil).PurgeTrash), arg0, arg1)
+}
+
+// RegisterUser mocks base method.
+func (m *MockQuerier) RegisterUser(arg0 context.Context, arg1 *sqlc.RegisterUserParams) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterUser", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterUser indicates an expected call of RegisterUser.
+func (mr *MockQuerierMockRecorder) RegisterUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockQuerier)(nil).RegisterUser), arg0, arg1)
+}
+
+// RenameTag mocks base method.
+func (m *MockQuerier) RenameTag(arg0 context.Context, arg1 *sqlc.RenameTagParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameTag", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameTag indicates an expected call of RenameTag.
+func (mr *MockQuerierMockRecorder) RenameTag(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameTag", reflect.TypeOf((*MockQuerier)(nil).RenameTag), arg0, arg1)
+}
+
+// RestoreNote mocks base method.
+func (m *MockQuerier) RestoreNote(arg0 context.Context, arg1 *sqlc.RestoreNoteParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreNote", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreNote indicates an expected call of RestoreNote.
+func (mr *MockQuerierMockRecorder) RestoreNote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreNote", reflect.TypeOf((*MockQuerier)(nil).RestoreNote), arg0, arg1)
+}
+
+// RevokeActiveRefreshToken mocks base method.
+func (m *MockQuerier) RevokeActiveRefreshToken(arg0 context.Context, arg1 *sqlc.RevokeActiveRefreshTokenParams) (sqlc.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeActiveRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeActiveRefreshToken indicates an expected call of RevokeActiveRefreshToken.
+func (mr *MockQuerierMockRecorder) RevokeActiveRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeActiveRefreshToken", reflect.TypeOf((*MockQuerier)(nil).RevokeActiveRefreshToken), arg0, arg1)
+}
+
+// RevokeRefreshTokenFamily mocks base method.
+func (m *MockQuerier) RevokeRefreshTokenFamily(arg0 context.Context, arg1 *sqlc.RevokeRefreshTokenFamilyParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenFamily", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenFamily indicates an expected call of RevokeRefreshTokenFamily.
+func (mr *MockQuerierMockRecorder) RevokeRefreshTokenFamily(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenFamily", reflect.TypeOf((*MockQuerier)(nil).RevokeRefreshTokenFamily), arg0, arg1)
+}
+
+// RevokeSession mocks base method.
+func (m *MockQuerier) RevokeSession(arg0 context.Context, arg1 *sqlc.RevokeSessionParams) (sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockQuerierMockRecorder) RevokeSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockQuerier)(nil).RevokeSession), arg0, arg1)
+}
+
+// SearchNotes mocks base method.
+func (m *MockQuerier) SearchNotes(arg0 context.Context, arg1 *sqlc.SearchNotesParams) ([]sqlc.SearchNotesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchNotes", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.SearchNotesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchNotes indicates an expected call of SearchNotes.
+func (mr *MockQuerierMockRecorder) SearchNotes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchNotes", reflect.TypeOf((*MockQuerier)(nil).SearchNotes), arg0, arg1)
+}
+
+// SetAllowDuplicateTitles mocks base method.
+func (m *MockQuerier) SetAllowDuplicateTitles(arg0 context.Context, arg1 *sqlc.SetAllowDuplicateTitlesParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAllowDuplicateTitles", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAllowDuplicateTitles indicates an expected call of SetAllowDuplicateTitles.
+func (mr *MockQuerierMockRecorder) SetAllowDuplicateTitles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAllowDuplicateTitles", reflect.TypeOf((*MockQuerier)(nil).SetAllowDuplicateTitles), arg0, arg1)
+}
+
+// SetNotesUniqueTitle mocks base method.
+func (m *MockQuerier) SetNotesUniqueTitle(arg0 context.Context, arg1 *sqlc.SetNotesUniqueTitleParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotesUniqueTitle", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNotesUniqueTitle indicates an expected call of SetNotesUniqueTitle.
+func (mr *MockQuerierMockRecorder) SetNotesUniqueTitle(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotesUniqueTitle", reflect.TypeOf((*MockQuerier)(nil).SetNotesUniqueTitle), arg0, arg1)
+}
+
+// TouchSession mocks base method.
+func (m *MockQuerier) TouchSession(arg0 context.Context, arg1 *sqlc.TouchSessionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockQuerierMockRecorder) TouchSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockQuerier)(nil).TouchSession), arg0, arg1)
+}
+
+// TrashNote mocks base method.
+func (m *MockQuerier) TrashNote(arg0 context.Context, arg1 *sqlc.TrashNoteParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrashNote", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TrashNote indicates an expected call of TrashNote.
+func (mr *MockQuerierMockRecorder) TrashNote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrashNote", reflect.TypeOf((*MockQuerier)(nil).TrashNote), arg0, arg1)
+}
+
+// UpdateNote mocks base method.
+func (m *MockQuerier) UpdateNote(arg0 context.Context, arg1 *sqlc.UpdateNoteParams) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNote", arg0, arg1)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateNote indicates an expected call of UpdateNote.
+func (mr *MockQuerierMockRecorder) UpdateNote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNote", reflect.TypeOf((*MockQuerier)(nil).UpdateNote), arg0, arg1)
+}
+
+// UpdateNotebook mocks base method.
+func (m *MockQuerier) UpdateNotebook(arg0 context.Context, arg1 *sqlc.UpdateNotebookParams) (sqlc.Notebook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNotebook", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.Notebook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateNotebook indicates an expected call of UpdateNotebook.
+func (mr *MockQuerierMockRecorder) UpdateNotebook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNotebook", reflect.TypeOf((*MockQuerier)(nil).UpdateNotebook), arg0, arg1)
+}
+
+// UpsertNoteShare mocks base method.
+func (m *MockQuerier) UpsertNoteShare(arg0 context.Context, arg1 *sqlc.UpsertNoteShareParams) (sqlc.NoteShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertNoteShare", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.NoteShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertNoteShare indicates an expected call of UpsertNoteShare.
+func (mr *MockQuerierMockRecorder) UpsertNoteShare(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertNoteShare", reflect.TypeOf((*MockQuerier)(nil).UpsertNoteShare), arg0, arg1)
+}
@@ -1,9 +1,98 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sqlc "github.com/alekslesik/online-note-z/db/sqlc"
+	_ "github.com/lib/pq"
 	"github.com/rs/zerolog"
 )
 
-func NewSQL(dbType string, config string, logger *zerolog.Logger) (string, error)  {
-	return "", nil
-}
\ No newline at end of file
+const (
+	defaultConnAttempts    = 5
+	defaultConnTimeout     = 2 * time.Second
+	defaultMaxOpenConns    = 25
+	defaultMaxIdleConns    = 25
+	defaultConnMaxLifetime = 5 * time.Minute
+	defaultConnMaxIdleTime = 1 * time.Minute
+)
+
+type sqlDB struct {
+	logger *zerolog.Logger
+	*sqlc.Queries
+	db           *sql.DB
+	connAttempts int
+	connTimeout  time.Duration
+}
+
+// Open a pooled connection to the DB and wrap it with the generated queries
+func NewSQL(driver string, url string, l *zerolog.Logger) (*sqlDB, error) {
+	sqlDB := &sqlDB{
+		connAttempts: defaultConnAttempts,
+		connTimeout:  defaultConnTimeout,
+		logger:       l,
+	}
+
+	db, err := sql.Open(driver, url)
+	if err != nil {
+		l.Error().Err(err).Msgf("could not open the DB. %v", err)
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(defaultMaxOpenConns)
+	db.SetMaxIdleConns(defaultMaxIdleConns)
+	db.SetConnMaxLifetime(defaultConnMaxLifetime)
+	db.SetConnMaxIdleTime(defaultConnMaxIdleTime)
+
+	// the DB may still be starting up (e.g. in docker compose), so retry the ping
+	for sqlDB.connAttempts > 0 {
+		err = db.Ping()
+		if err == nil {
+			break
+		}
+
+		sqlDB.connAttempts--
+		l.Error().Msgf("error trying to connect to the DB: %v. Attempts left: %d", err, sqlDB.connAttempts)
+
+		if sqlDB.connAttempts == 0 {
+			l.Error().Msgf("could not establish connection to the database")
+			db.Close()
+			return nil, err
+		}
+		time.Sleep(sqlDB.connTimeout)
+	}
+
+	sqlDB.db = db
+	sqlDB.Queries = sqlc.New(db)
+	l.Info().Msg("db connection is successful.")
+
+	return sqlDB, nil
+}
+
+// Close the DB connection pool
+func (s *sqlDB) Close() error {
+	s.logger.Info().Msg("closing the db connection pool.")
+	return s.db.Close()
+}
+
+// Run fn inside a transaction, rolling back if it returns an error
+func (s *sqlDB) ExecTx(ctx context.Context, fn func(q sqlc.Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(s.Queries.WithTx(tx))
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			s.logger.Error().Err(rbErr).Msgf("could not roll back the transaction. %v", rbErr)
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
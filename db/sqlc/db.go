@@ -1,17 +1,31 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+
 package sqlc
 
 import (
+	"context"
 	"database/sql"
-	"time"
-
-	"github.com/google/uuid"
 )
 
-type Note struct {
-	ID  uuid.UUID
-	Title  string
-	Username  string
-	Text  sql.NullString
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-}
\ No newline at end of file
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+type Queries struct {
+	db DBTX
+}
+
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return &Queries{
+		db: tx,
+	}
+}
@@ -1 +1,124 @@
-package sqlc
\ No newline at end of file
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+
+package sqlc
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Attachment struct {
+	ID          uuid.UUID
+	NoteID      uuid.UUID
+	Username    string
+	Filename    string
+	ContentType string
+	Size        int64
+	StorageKey  string
+	CreatedAt   time.Time
+}
+
+type Note struct {
+	ID          uuid.UUID
+	Title       string
+	Username    string
+	Text        sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Search      string `json:"-"`
+	NotebookID  uuid.NullUUID
+	Version     int32
+	DeletedAt   sql.NullTime
+	UniqueTitle bool
+}
+
+type NoteChange struct {
+	NoteID    uuid.UUID
+	Username  string
+	Seq       int64
+	ChangedAt time.Time
+}
+
+type NoteLink struct {
+	ID           uuid.UUID
+	NoteID       uuid.UUID
+	TokenHash    string
+	PasswordHash sql.NullString
+	ExpiresAt    sql.NullTime
+	Views        int64
+	CreatedAt    time.Time
+}
+
+type NoteRevision struct {
+	NoteID    uuid.UUID
+	Revision  int32
+	Title     string
+	Text      sql.NullString
+	CreatedAt time.Time
+}
+
+type NoteShare struct {
+	NoteID     uuid.UUID
+	Username   string
+	Permission string
+	CreatedAt  time.Time
+}
+
+type NoteTag struct {
+	NoteID uuid.UUID
+	TagID  uuid.UUID
+}
+
+type Notebook struct {
+	ID        uuid.UUID
+	Username  string
+	ParentID  uuid.NullUUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type RefreshToken struct {
+	ID        uuid.UUID
+	FamilyID  uuid.UUID
+	Username  string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt sql.NullTime
+}
+
+type RevokedToken struct {
+	ID        uuid.UUID
+	Username  string
+	RevokedAt time.Time
+	ExpiresAt time.Time
+}
+
+type Session struct {
+	ID         uuid.UUID
+	Username   string
+	UserAgent  string
+	ClientIp   string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+}
+
+type Tag struct {
+	ID       uuid.UUID
+	Username string
+	Name     string
+}
+
+type User struct {
+	Username             string
+	Password             string
+	Email                string
+	AllowDuplicateTitles bool
+}
@@ -1 +1,93 @@
-package sqlc
\ No newline at end of file
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+
+package sqlc
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Querier interface {
+	AddNoteTags(ctx context.Context, arg *AddNoteTagsParams) error
+	BumpTaggedNotes(ctx context.Context, arg *BumpTaggedNotesParams) ([]BumpTaggedNotesRow, error)
+	ClearNoteTags(ctx context.Context, noteID uuid.UUID) error
+	CountLinkView(ctx context.Context, id uuid.UUID) error
+	CountNotes(ctx context.Context, arg *CountNotesParams) (int64, error)
+	CreateAttachment(ctx context.Context, arg *CreateAttachmentParams) (Attachment, error)
+	CreateNote(ctx context.Context, arg *CreateNoteParams) (uuid.UUID, error)
+	CreateNoteLink(ctx context.Context, arg *CreateNoteLinkParams) (NoteLink, error)
+	CreateNoteRevision(ctx context.Context, id uuid.UUID) (int32, error)
+	CreateNotebook(ctx context.Context, arg *CreateNotebookParams) (Notebook, error)
+	CreateRefreshToken(ctx context.Context, arg *CreateRefreshTokenParams) (uuid.UUID, error)
+	CreateRevokedToken(ctx context.Context, arg *CreateRevokedTokenParams) error
+	CreateSession(ctx context.Context, arg *CreateSessionParams) (uuid.UUID, error)
+	CreateTags(ctx context.Context, arg *CreateTagsParams) error
+	DeleteAttachment(ctx context.Context, arg *DeleteAttachmentParams) (string, error)
+	DeleteNoteLink(ctx context.Context, arg *DeleteNoteLinkParams) (uuid.UUID, error)
+	DeleteNoteShare(ctx context.Context, arg *DeleteNoteShareParams) (string, error)
+	DeleteNotebook(ctx context.Context, arg *DeleteNotebookParams) (uuid.UUID, error)
+	DeletePurgeableAttachments(ctx context.Context, deletedBefore time.Time) ([]string, error)
+	DeleteTag(ctx context.Context, id uuid.UUID) error
+	DeleteTrashedAttachments(ctx context.Context, username string) ([]string, error)
+	DeleteUnusedTags(ctx context.Context, username string) error
+	EmptyTrash(ctx context.Context, username string) (int64, error)
+	FindTitleConflict(ctx context.Context, arg *FindTitleConflictParams) (uuid.UUID, error)
+	GetAllNotesFromUser(ctx context.Context, username string) ([]Note, error)
+	GetAttachment(ctx context.Context, arg *GetAttachmentParams) (Attachment, error)
+	GetAttachmentUsage(ctx context.Context, username string) (int64, error)
+	GetLatestNoteRevision(ctx context.Context, arg *GetLatestNoteRevisionParams) (NoteRevision, error)
+	GetLinkedNote(ctx context.Context, tokenHash string) (GetLinkedNoteRow, error)
+	GetNote(ctx context.Context, arg *GetNoteParams) (Note, error)
+	GetNoteAccess(ctx context.Context, arg *GetNoteAccessParams) (GetNoteAccessRow, error)
+	GetNoteRevision(ctx context.Context, arg *GetNoteRevisionParams) (NoteRevision, error)
+	GetNotebook(ctx context.Context, arg *GetNotebookParams) (Notebook, error)
+	GetNotebookTree(ctx context.Context, arg *GetNotebookTreeParams) ([]GetNotebookTreeRow, error)
+	GetNotesByIDs(ctx context.Context, arg *GetNotesByIDsParams) ([]Note, error)
+	GetNotesInNotebooks(ctx context.Context, arg *GetNotesInNotebooksParams) ([]Note, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error)
+	GetRevokedTokens(ctx context.Context, arg *GetRevokedTokensParams) ([]GetRevokedTokensRow, error)
+	GetSharedNote(ctx context.Context, arg *GetSharedNoteParams) (GetSharedNoteRow, error)
+	GetTag(ctx context.Context, arg *GetTagParams) (Tag, error)
+	GetTagsOfNotes(ctx context.Context, noteIds []uuid.UUID) ([]GetTagsOfNotesRow, error)
+	GetTrashedNote(ctx context.Context, arg *GetTrashedNoteParams) (Note, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	GetUserSettings(ctx context.Context, username string) (bool, error)
+	IsNotebookInSubtree(ctx context.Context, arg *IsNotebookInSubtreeParams) (bool, error)
+	ListActiveSessions(ctx context.Context, arg *ListActiveSessionsParams) ([]Session, error)
+	ListAttachments(ctx context.Context, arg *ListAttachmentsParams) ([]Attachment, error)
+	ListNoteChanges(ctx context.Context, arg *ListNoteChangesParams) ([]ListNoteChangesRow, error)
+	ListNoteLinks(ctx context.Context, arg *ListNoteLinksParams) ([]NoteLink, error)
+	ListNoteRevisions(ctx context.Context, arg *ListNoteRevisionsParams) ([]ListNoteRevisionsRow, error)
+	ListNoteShares(ctx context.Context, arg *ListNoteSharesParams) ([]NoteShare, error)
+	ListNotebooks(ctx context.Context, username string) ([]Notebook, error)
+	ListNotes(ctx context.Context, arg *ListNotesParams) ([]Note, error)
+	ListSharedNotes(ctx context.Context, username string) ([]ListSharedNotesRow, error)
+	ListTags(ctx context.Context, username string) ([]ListTagsRow, error)
+	ListTrash(ctx context.Context, username string) ([]Note, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	MoveNote(ctx context.Context, arg *MoveNoteParams) (uuid.UUID, error)
+	MoveNoteTags(ctx context.Context, arg *MoveNoteTagsParams) error
+	NotifyNoteEvent(ctx context.Context, payload string) error
+	PruneNoteRevisions(ctx context.Context, arg *PruneNoteRevisionsParams) error
+	PurgeTrash(ctx context.Context, arg *PurgeTrashParams) (int64, error)
+	RegisterUser(ctx context.Context, arg *RegisterUserParams) (string, error)
+	RenameTag(ctx context.Context, arg *RenameTagParams) (uuid.UUID, error)
+	RestoreNote(ctx context.Context, arg *RestoreNoteParams) (uuid.UUID, error)
+	RevokeActiveRefreshToken(ctx context.Context, arg *RevokeActiveRefreshTokenParams) (RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, arg *RevokeRefreshTokenFamilyParams) error
+	RevokeSession(ctx context.Context, arg *RevokeSessionParams) (Session, error)
+	SearchNotes(ctx context.Context, arg *SearchNotesParams) ([]SearchNotesRow, error)
+	SetAllowDuplicateTitles(ctx context.Context, arg *SetAllowDuplicateTitlesParams) error
+	SetNotesUniqueTitle(ctx context.Context, arg *SetNotesUniqueTitleParams) error
+	TouchSession(ctx context.Context, arg *TouchSessionParams) error
+	TrashNote(ctx context.Context, arg *TrashNoteParams) (uuid.UUID, error)
+	UpdateNote(ctx context.Context, arg *UpdateNoteParams) (int32, error)
+	UpdateNotebook(ctx context.Context, arg *UpdateNotebookParams) (Notebook, error)
+	UpsertNoteShare(ctx context.Context, arg *UpsertNoteShareParams) (NoteShare, error)
+}
+
+var _ Querier = (*Queries)(nil)
@@ -0,0 +1,543 @@
+-- name: RegisterUser :one
+INSERT INTO users (username, password, email)
+VALUES ($1,$2,$3)
+RETURNING username;
+
+-- name: ListUsers :many
+SELECT *
+FROM users
+ORDER BY username;
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE username = $1;
+
+-- name: CreateNote :one
+INSERT INTO notes (id, title, username, text, created_at, updated_at, notebook_id, unique_title)
+VALUES ($1,$2,$3,$4,$5,$6,$7, NOT (SELECT u.allow_duplicate_titles FROM users u WHERE u.username = $3))
+RETURNING id;
+
+-- name: UpdateNote :one
+UPDATE notes
+SET
+  title = COALESCE(sqlc.narg(title), title),
+  text = COALESCE(sqlc.narg(text), text),
+  updated_at = COALESCE(sqlc.narg(updated_at), updated_at),
+  version = version + 1
+WHERE
+  id = sqlc.arg(id) AND username = sqlc.arg(username) AND deleted_at IS NULL
+  AND (sqlc.arg(version)::int = 0 OR version = sqlc.arg(version))
+RETURNING version;
+
+-- name: GetAllNotesFromUser :many
+SELECT *
+FROM notes
+WHERE username = $1 AND deleted_at IS NULL
+ORDER BY created_at;
+
+-- name: SearchNotes :many
+SELECT
+  n.id, n.title, n.username, n.text, n.created_at, n.updated_at,
+  ts_rank(n.search, q.query)::real AS rank,
+  ts_headline('simple', n.title, q.query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS title_headline,
+  ts_headline('simple', coalesce(n.text, ''), q.query, 'MaxFragments=2, MinWords=5, MaxWords=25, StartSel=<mark>, StopSel=</mark>')::text AS text_headline
+FROM notes n, to_tsquery('simple', sqlc.arg(query)) AS q(query)
+WHERE n.username = sqlc.arg(username) AND n.deleted_at IS NULL AND n.search @@ q.query
+ORDER BY rank DESC, n.updated_at DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: ListNotes :many
+SELECT n.*
+FROM notes n
+WHERE
+  n.username = sqlc.arg(username) AND n.deleted_at IS NULL
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR n.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamp IS NULL OR n.created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(updated_from)::timestamp IS NULL OR n.updated_at >= sqlc.narg(updated_from))
+  AND (sqlc.narg(updated_to)::timestamp IS NULL OR n.updated_at < sqlc.narg(updated_to))
+  AND (coalesce(cardinality(sqlc.arg(tags)::text[]), 0) = 0 OR n.id IN (
+    SELECT nt.note_id
+    FROM note_tags nt
+    JOIN tags t ON t.id = nt.tag_id
+    WHERE t.username = sqlc.arg(username) AND t.name = ANY(sqlc.arg(tags)::text[])
+    GROUP BY nt.note_id
+    HAVING count(*) >= sqlc.arg(min_tag_matches)::int
+  ))
+  AND (NOT sqlc.arg(has_cursor)::bool OR CASE sqlc.arg(sort_by)::text
+    WHEN 'updated_at' THEN
+      CASE WHEN sqlc.arg(descending)::bool
+        THEN (n.updated_at, n.id) < (sqlc.arg(cursor_time)::timestamp, sqlc.arg(cursor_id)::uuid)
+        ELSE (n.updated_at, n.id) > (sqlc.arg(cursor_time)::timestamp, sqlc.arg(cursor_id)::uuid)
+      END
+    WHEN 'title' THEN
+      CASE WHEN sqlc.arg(descending)::bool
+        THEN (n.title, n.id) < (sqlc.arg(cursor_title)::text, sqlc.arg(cursor_id)::uuid)
+        ELSE (n.title, n.id) > (sqlc.arg(cursor_title)::text, sqlc.arg(cursor_id)::uuid)
+      END
+    ELSE
+      CASE WHEN sqlc.arg(descending)::bool
+        THEN (n.created_at, n.id) < (sqlc.arg(cursor_time)::timestamp, sqlc.arg(cursor_id)::uuid)
+        ELSE (n.created_at, n.id) > (sqlc.arg(cursor_time)::timestamp, sqlc.arg(cursor_id)::uuid)
+      END
+  END)
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by) = 'updated_at' AND NOT sqlc.arg(descending) THEN n.updated_at END ASC,
+  CASE WHEN sqlc.arg(sort_by) = 'updated_at' AND sqlc.arg(descending) THEN n.updated_at END DESC,
+  CASE WHEN sqlc.arg(sort_by) = 'title' AND NOT sqlc.arg(descending) THEN n.title END ASC,
+  CASE WHEN sqlc.arg(sort_by) = 'title' AND sqlc.arg(descending) THEN n.title END DESC,
+  CASE WHEN sqlc.arg(sort_by) = 'created_at' AND NOT sqlc.arg(descending) THEN n.created_at END ASC,
+  CASE WHEN sqlc.arg(sort_by) = 'created_at' AND sqlc.arg(descending) THEN n.created_at END DESC,
+  CASE WHEN NOT sqlc.arg(descending) THEN n.id END ASC,
+  CASE WHEN sqlc.arg(descending) THEN n.id END DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: CountNotes :one
+SELECT count(*)
+FROM notes n
+WHERE
+  n.username = sqlc.arg(username) AND n.deleted_at IS NULL
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR n.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamp IS NULL OR n.created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(updated_from)::timestamp IS NULL OR n.updated_at >= sqlc.narg(updated_from))
+  AND (sqlc.narg(updated_to)::timestamp IS NULL OR n.updated_at < sqlc.narg(updated_to))
+  AND (coalesce(cardinality(sqlc.arg(tags)::text[]), 0) = 0 OR n.id IN (
+    SELECT nt.note_id
+    FROM note_tags nt
+    JOIN tags t ON t.id = nt.tag_id
+    WHERE t.username = sqlc.arg(username) AND t.name = ANY(sqlc.arg(tags)::text[])
+    GROUP BY nt.note_id
+    HAVING count(*) >= sqlc.arg(min_tag_matches)::int
+  ));
+
+-- name: TrashNote :one
+UPDATE notes
+SET deleted_at = sqlc.arg(deleted_at)::timestamp, version = version + 1
+WHERE
+  id = sqlc.arg(id) AND username = sqlc.arg(username) AND deleted_at IS NULL
+  AND (sqlc.arg(version)::int = 0 OR version = sqlc.arg(version))
+RETURNING id;
+
+-- name: ListTrash :many
+SELECT *
+FROM notes
+WHERE username = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: GetTrashedNote :one
+SELECT *
+FROM notes
+WHERE id = $1 AND username = $2 AND deleted_at IS NOT NULL;
+
+-- name: RestoreNote :one
+UPDATE notes
+SET deleted_at = NULL, version = version + 1
+WHERE id = $1 AND username = $2 AND deleted_at IS NOT NULL
+RETURNING id;
+
+-- name: EmptyTrash :execrows
+DELETE
+FROM notes
+WHERE username = $1 AND deleted_at IS NOT NULL;
+
+-- name: PurgeTrash :execrows
+DELETE
+FROM notes
+WHERE id IN (
+  SELECT id
+  FROM notes
+  WHERE deleted_at < sqlc.arg(deleted_before)::timestamp
+  LIMIT sqlc.arg(max_rows)
+);
+
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (id, family_id, username, token_hash, created_at, expires_at)
+VALUES ($1,$2,$3,$4,$5,$6)
+RETURNING id;
+
+-- name: GetRefreshTokenByHash :one
+SELECT *
+FROM refresh_tokens
+WHERE token_hash = $1;
+
+-- name: RevokeActiveRefreshToken :one
+UPDATE refresh_tokens
+SET revoked_at = sqlc.arg(now)::timestamp
+WHERE
+  token_hash = sqlc.arg(token_hash) AND revoked_at IS NULL AND expires_at > sqlc.arg(now)
+RETURNING *;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = $2
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: CreateSession :one
+INSERT INTO sessions (id, username, user_agent, client_ip, created_at, last_seen_at, expires_at)
+VALUES ($1,$2,$3,$4,$5,$6,$7)
+RETURNING id;
+
+-- name: ListActiveSessions :many
+SELECT *
+FROM sessions
+WHERE username = $1 AND revoked_at IS NULL AND expires_at > $2
+ORDER BY last_seen_at DESC;
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET last_seen_at = $2, expires_at = $3
+WHERE id = $1;
+
+-- name: RevokeSession :one
+UPDATE sessions
+SET revoked_at = sqlc.arg(now)::timestamp
+WHERE
+  id = sqlc.arg(id) AND username = sqlc.arg(username) AND revoked_at IS NULL
+RETURNING *;
+
+-- name: CreateRevokedToken :exec
+INSERT INTO revoked_tokens (id, username, revoked_at, expires_at)
+VALUES ($1,$2,$3,$4)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: GetRevokedTokens :many
+SELECT id, expires_at
+FROM revoked_tokens
+WHERE id = ANY(sqlc.arg(ids)::uuid[]) AND expires_at > sqlc.arg(now);
+
+-- name: CreateTags :exec
+INSERT INTO tags (id, username, name)
+SELECT unnest(sqlc.arg(ids)::uuid[]), sqlc.arg(username), unnest(sqlc.arg(names)::text[])
+ON CONFLICT (username, name) DO NOTHING;
+
+-- name: ClearNoteTags :exec
+DELETE
+FROM note_tags
+WHERE note_id = $1;
+
+-- name: AddNoteTags :exec
+INSERT INTO note_tags (note_id, tag_id)
+SELECT sqlc.arg(note_id), t.id
+FROM tags t
+WHERE t.username = sqlc.arg(username) AND t.name = ANY(sqlc.arg(names)::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteUnusedTags :exec
+DELETE
+FROM tags t
+WHERE t.username = $1 AND NOT EXISTS (SELECT 1 FROM note_tags nt WHERE nt.tag_id = t.id);
+
+-- name: GetTagsOfNotes :many
+SELECT nt.note_id, t.name
+FROM note_tags nt
+JOIN tags t ON t.id = nt.tag_id
+WHERE nt.note_id = ANY(sqlc.arg(note_ids)::uuid[])
+ORDER BY t.name;
+
+-- name: ListTags :many
+SELECT t.name, count(nt.note_id) AS note_count
+FROM tags t
+JOIN note_tags nt ON nt.tag_id = t.id
+JOIN notes n ON n.id = nt.note_id AND n.deleted_at IS NULL
+WHERE t.username = $1
+GROUP BY t.id, t.name
+ORDER BY t.name;
+
+-- name: GetTag :one
+SELECT *
+FROM tags
+WHERE username = $1 AND name = $2;
+
+-- name: RenameTag :one
+UPDATE tags
+SET name = sqlc.arg(new_name)
+WHERE username = sqlc.arg(username) AND name = sqlc.arg(name)
+RETURNING id;
+
+-- name: MoveNoteTags :exec
+INSERT INTO note_tags (note_id, tag_id)
+SELECT nt.note_id, sqlc.arg(to_tag_id)
+FROM note_tags nt
+WHERE nt.tag_id = sqlc.arg(from_tag_id)
+ON CONFLICT DO NOTHING;
+
+-- name: BumpTaggedNotes :many
+UPDATE notes n
+SET version = n.version + 1, updated_at = sqlc.arg(updated_at)
+FROM note_tags nt
+WHERE nt.note_id = n.id AND nt.tag_id = sqlc.arg(tag_id) AND n.deleted_at IS NULL
+RETURNING n.id, n.version;
+
+-- name: DeleteTag :exec
+DELETE
+FROM tags
+WHERE id = $1;
+
+-- name: CreateNotebook :one
+INSERT INTO notebooks (id, username, parent_id, name, created_at, updated_at)
+VALUES ($1,$2,$3,$4,$5,$6)
+RETURNING *;
+
+-- name: GetNotebook :one
+SELECT *
+FROM notebooks
+WHERE id = $1 AND username = $2;
+
+-- name: ListNotebooks :many
+SELECT *
+FROM notebooks
+WHERE username = $1
+ORDER BY name;
+
+-- name: UpdateNotebook :one
+UPDATE notebooks
+SET name = $3, parent_id = $4, updated_at = $5
+WHERE id = $1 AND username = $2
+RETURNING *;
+
+-- name: DeleteNotebook :one
+DELETE
+FROM notebooks
+WHERE id = $1 AND username = $2
+RETURNING id;
+
+-- name: GetNotebookTree :many
+WITH RECURSIVE tree AS (
+  SELECT nb.*
+  FROM notebooks nb
+  WHERE nb.id = sqlc.arg(id) AND nb.username = sqlc.arg(username)
+  UNION ALL
+  SELECT child.*
+  FROM notebooks child
+  JOIN tree ON child.parent_id = tree.id
+)
+SELECT *
+FROM tree
+ORDER BY name;
+
+-- name: IsNotebookInSubtree :one
+WITH RECURSIVE tree AS (
+  SELECT nb.id
+  FROM notebooks nb
+  WHERE nb.id = sqlc.arg(root_id)
+  UNION ALL
+  SELECT child.id
+  FROM notebooks child
+  JOIN tree ON child.parent_id = tree.id
+)
+SELECT count(*) > 0 AS in_subtree
+FROM tree
+WHERE id = sqlc.arg(id)::uuid;
+
+-- name: GetNotesInNotebooks :many
+SELECT *
+FROM notes
+WHERE username = sqlc.arg(username) AND deleted_at IS NULL AND notebook_id = ANY(sqlc.arg(notebook_ids)::uuid[])
+ORDER BY created_at;
+
+-- name: MoveNote :one
+UPDATE notes
+SET notebook_id = $3, updated_at = $4, version = version + 1
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL
+RETURNING id;
+
+-- name: GetNote :one
+SELECT *
+FROM notes
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL;
+
+-- name: CreateNoteRevision :one
+INSERT INTO note_revisions (note_id, revision, title, text, created_at)
+SELECT
+  n.id,
+  coalesce((SELECT max(r.revision) FROM note_revisions r WHERE r.note_id = n.id), 0) + 1,
+  n.title,
+  n.text,
+  n.updated_at
+FROM notes n
+WHERE n.id = $1
+RETURNING revision;
+
+-- name: PruneNoteRevisions :exec
+DELETE
+FROM note_revisions
+WHERE note_id = $1 AND revision <= $2;
+
+-- name: ListNoteRevisions :many
+SELECT r.revision, r.title, r.created_at, length(coalesce(r.text, ''))::int AS text_length
+FROM note_revisions r
+JOIN notes n ON n.id = r.note_id
+WHERE r.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY r.revision DESC;
+
+-- name: GetNoteRevision :one
+SELECT r.*
+FROM note_revisions r
+JOIN notes n ON n.id = r.note_id
+WHERE r.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL AND r.revision = $3;
+
+-- name: GetLatestNoteRevision :one
+SELECT r.*
+FROM note_revisions r
+JOIN notes n ON n.id = r.note_id
+WHERE r.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY r.revision DESC
+LIMIT 1;
+
+-- name: CreateAttachment :one
+INSERT INTO attachments (id, note_id, username, filename, content_type, size, storage_key, created_at)
+SELECT
+  sqlc.arg(id)::uuid, sqlc.arg(note_id)::uuid, sqlc.arg(username)::text, sqlc.arg(filename)::text,
+  sqlc.arg(content_type)::text, sqlc.arg(size)::bigint, sqlc.arg(storage_key)::text, sqlc.arg(created_at)::timestamp
+WHERE
+  sqlc.arg(quota)::bigint = 0
+  OR (SELECT coalesce(sum(a.size), 0) FROM attachments a WHERE a.username = sqlc.arg(username)) + sqlc.arg(size) <= sqlc.arg(quota)
+RETURNING *;
+
+-- name: GetAttachmentUsage :one
+SELECT coalesce(sum(size), 0)::bigint
+FROM attachments
+WHERE username = $1;
+
+-- name: ListAttachments :many
+SELECT a.*
+FROM attachments a
+JOIN notes n ON n.id = a.note_id
+WHERE a.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY a.created_at;
+
+-- name: GetAttachment :one
+SELECT a.*
+FROM attachments a
+JOIN notes n ON n.id = a.note_id
+WHERE a.id = $1 AND a.note_id = $2 AND n.username = $3 AND n.deleted_at IS NULL;
+
+-- name: DeleteAttachment :one
+DELETE
+FROM attachments a
+USING notes n
+WHERE n.id = a.note_id AND a.id = $1 AND a.note_id = $2 AND n.username = $3 AND n.deleted_at IS NULL
+RETURNING a.storage_key;
+
+-- name: DeleteTrashedAttachments :many
+DELETE
+FROM attachments a
+USING notes n
+WHERE n.id = a.note_id AND n.username = $1 AND n.deleted_at IS NOT NULL
+RETURNING a.storage_key;
+
+-- name: DeletePurgeableAttachments :many
+DELETE
+FROM attachments a
+USING notes n
+WHERE n.id = a.note_id AND n.deleted_at < sqlc.arg(deleted_before)::timestamp
+RETURNING a.storage_key;
+
+-- name: FindTitleConflict :one
+SELECT id
+FROM notes
+WHERE
+  username = sqlc.arg(username) AND title = sqlc.arg(title)
+  AND notebook_id IS NOT DISTINCT FROM sqlc.narg(notebook_id)::uuid
+  AND id <> sqlc.arg(exclude_id) AND unique_title AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: GetUserSettings :one
+SELECT allow_duplicate_titles
+FROM users
+WHERE username = $1;
+
+-- name: SetAllowDuplicateTitles :exec
+UPDATE users
+SET allow_duplicate_titles = $2
+WHERE username = $1;
+
+-- name: SetNotesUniqueTitle :exec
+UPDATE notes
+SET unique_title = $2
+WHERE username = $1;
+
+-- name: UpsertNoteShare :one
+INSERT INTO note_shares (note_id, username, permission, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (note_id, username) DO UPDATE SET permission = EXCLUDED.permission
+RETURNING *;
+
+-- name: ListNoteShares :many
+SELECT s.*
+FROM note_shares s
+JOIN notes n ON n.id = s.note_id
+WHERE s.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY s.created_at, s.username;
+
+-- name: DeleteNoteShare :one
+DELETE FROM note_shares s
+USING notes n
+WHERE
+  n.id = s.note_id AND s.note_id = sqlc.arg(note_id) AND s.username = sqlc.arg(grantee)
+  AND (n.username = sqlc.arg(username) OR s.username = sqlc.arg(username))
+RETURNING s.username;
+
+-- name: GetNoteAccess :one
+SELECT n.username AS owner, coalesce(s.permission, 'owner')::text AS permission
+FROM notes n
+LEFT JOIN note_shares s ON s.note_id = n.id AND s.username = sqlc.arg(username)
+WHERE
+  n.id = sqlc.arg(id) AND n.deleted_at IS NULL
+  AND (n.username = sqlc.arg(username) OR s.username IS NOT NULL);
+
+-- name: GetSharedNote :one
+SELECT sqlc.embed(n), s.permission
+FROM notes n
+JOIN note_shares s ON s.note_id = n.id
+WHERE n.id = $1 AND s.username = $2 AND n.deleted_at IS NULL;
+
+-- name: ListSharedNotes :many
+SELECT sqlc.embed(n), s.permission
+FROM notes n
+JOIN note_shares s ON s.note_id = n.id
+WHERE s.username = $1 AND n.deleted_at IS NULL
+ORDER BY n.updated_at DESC, n.id;
+
+-- name: CreateNoteLink :one
+INSERT INTO note_links (id, note_id, token_hash, password_hash, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListNoteLinks :many
+SELECT l.*
+FROM note_links l
+JOIN notes n ON n.id = l.note_id
+WHERE l.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY l.created_at;
+
+-- name: DeleteNoteLink :one
+DELETE FROM note_links l
+USING notes n
+WHERE n.id = l.note_id AND l.id = $1 AND l.note_id = $2 AND n.username = $3
+RETURNING l.id;
+
+-- name: GetLinkedNote :one
+SELECT sqlc.embed(l), sqlc.embed(n)
+FROM note_links l
+JOIN notes n ON n.id = l.note_id
+WHERE l.token_hash = $1 AND n.deleted_at IS NULL;
+
+-- name: CountLinkView :exec
+UPDATE note_links
+SET views = views + 1
+WHERE id = $1;
+
+-- name: NotifyNoteEvent :exec
+SELECT pg_notify('note_events', sqlc.arg(payload)::text);
+
+-- name: ListNoteChanges :many
+SELECT note_id, seq
+FROM note_changes
+WHERE username = $1 AND seq > $2
+ORDER BY seq
+LIMIT $3;
+
+-- name: GetNotesByIDs :many
+SELECT *
+FROM notes
+WHERE username = $1 AND id = ANY(sqlc.arg(ids)::uuid[]) AND deleted_at IS NULL;
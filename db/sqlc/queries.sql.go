@@ -1 +1,2313 @@
-package sqlc
\ No newline at end of file
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: queries.sql
+
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const addNoteTags = `-- name: AddNoteTags :exec
+INSERT INTO note_tags (note_id, tag_id)
+SELECT $1, t.id
+FROM tags t
+WHERE t.username = $2 AND t.name = ANY($3::text[])
+ON CONFLICT DO NOTHING
+`
+
+type AddNoteTagsParams struct {
+	NoteID   uuid.UUID
+	Username string
+	Names    []string
+}
+
+func (q *Queries) AddNoteTags(ctx context.Context, arg *AddNoteTagsParams) error {
+	_, err := q.db.ExecContext(ctx, addNoteTags, arg.NoteID, arg.Username, pq.Array(arg.Names))
+	return err
+}
+
+const bumpTaggedNotes = `-- name: BumpTaggedNotes :many
+UPDATE notes n
+SET version = n.version + 1, updated_at = $1
+FROM note_tags nt
+WHERE nt.note_id = n.id AND nt.tag_id = $2 AND n.deleted_at IS NULL
+RETURNING n.id, n.version
+`
+
+type BumpTaggedNotesParams struct {
+	UpdatedAt time.Time
+	TagID     uuid.UUID
+}
+
+type BumpTaggedNotesRow struct {
+	ID      uuid.UUID
+	Version int32
+}
+
+func (q *Queries) BumpTaggedNotes(ctx context.Context, arg *BumpTaggedNotesParams) ([]BumpTaggedNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, bumpTaggedNotes, arg.UpdatedAt, arg.TagID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BumpTaggedNotesRow{}
+	for rows.Next() {
+		var i BumpTaggedNotesRow
+		if err := rows.Scan(&i.ID, &i.Version); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const clearNoteTags = `-- name: ClearNoteTags :exec
+DELETE
+FROM note_tags
+WHERE note_id = $1
+`
+
+func (q *Queries) ClearNoteTags(ctx context.Context, noteID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, clearNoteTags, noteID)
+	return err
+}
+
+const countLinkView = `-- name: CountLinkView :exec
+UPDATE note_links
+SET views = views + 1
+WHERE id = $1
+`
+
+func (q *Queries) CountLinkView(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, countLinkView, id)
+	return err
+}
+
+const countNotes = `-- name: CountNotes :one
+SELECT count(*)
+FROM notes n
+WHERE
+  n.username = $1 AND n.deleted_at IS NULL
+  AND ($2::timestamp IS NULL OR n.created_at >= $2)
+  AND ($3::timestamp IS NULL OR n.created_at < $3)
+  AND ($4::timestamp IS NULL OR n.updated_at >= $4)
+  AND ($5::timestamp IS NULL OR n.updated_at < $5)
+  AND (coalesce(cardinality($6::text[]), 0) = 0 OR n.id IN (
+    SELECT nt.note_id
+    FROM note_tags nt
+    JOIN tags t ON t.id = nt.tag_id
+    WHERE t.username = $1 AND t.name = ANY($6::text[])
+    GROUP BY nt.note_id
+    HAVING count(*) >= $7::int
+  ))
+`
+
+type CountNotesParams struct {
+	Username      string
+	CreatedFrom   sql.NullTime
+	CreatedTo     sql.NullTime
+	UpdatedFrom   sql.NullTime
+	UpdatedTo     sql.NullTime
+	Tags          []string
+	MinTagMatches int32
+}
+
+func (q *Queries) CountNotes(ctx context.Context, arg *CountNotesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNotes,
+		arg.Username,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.UpdatedFrom,
+		arg.UpdatedTo,
+		pq.Array(arg.Tags),
+		arg.MinTagMatches,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAttachment = `-- name: CreateAttachment :one
+INSERT INTO attachments (id, note_id, username, filename, content_type, size, storage_key, created_at)
+SELECT
+  $1::uuid, $2::uuid, $3::text, $4::text,
+  $5::text, $6::bigint, $7::text, $8::timestamp
+WHERE
+  $9::bigint = 0
+  OR (SELECT coalesce(sum(a.size), 0) FROM attachments a WHERE a.username = $3) + $6 <= $9
+RETURNING id, note_id, username, filename, content_type, size, storage_key, created_at
+`
+
+type CreateAttachmentParams struct {
+	ID          uuid.UUID
+	NoteID      uuid.UUID
+	Username    string
+	Filename    string
+	ContentType string
+	Size        int64
+	StorageKey  string
+	CreatedAt   time.Time
+	Quota       int64
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, arg *CreateAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, createAttachment,
+		arg.ID,
+		arg.NoteID,
+		arg.Username,
+		arg.Filename,
+		arg.ContentType,
+		arg.Size,
+		arg.StorageKey,
+		arg.CreatedAt,
+		arg.Quota,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.Username,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.StorageKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createNote = `-- name: CreateNote :one
+INSERT INTO notes (id, title, username, text, created_at, updated_at, notebook_id, unique_title)
+VALUES ($1,$2,$3,$4,$5,$6,$7, NOT (SELECT u.allow_duplicate_titles FROM users u WHERE u.username = $3))
+RETURNING id
+`
+
+type CreateNoteParams struct {
+	ID         uuid.UUID
+	Title      string
+	Username   string
+	Text       sql.NullString
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	NotebookID uuid.NullUUID
+}
+
+func (q *Queries) CreateNote(ctx context.Context, arg *CreateNoteParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, createNote,
+		arg.ID,
+		arg.Title,
+		arg.Username,
+		arg.Text,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.NotebookID,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createNoteLink = `-- name: CreateNoteLink :one
+INSERT INTO note_links (id, note_id, token_hash, password_hash, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, note_id, token_hash, password_hash, expires_at, views, created_at
+`
+
+type CreateNoteLinkParams struct {
+	ID           uuid.UUID
+	NoteID       uuid.UUID
+	TokenHash    string
+	PasswordHash sql.NullString
+	ExpiresAt    sql.NullTime
+	CreatedAt    time.Time
+}
+
+func (q *Queries) CreateNoteLink(ctx context.Context, arg *CreateNoteLinkParams) (NoteLink, error) {
+	row := q.db.QueryRowContext(ctx, createNoteLink,
+		arg.ID,
+		arg.NoteID,
+		arg.TokenHash,
+		arg.PasswordHash,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	var i NoteLink
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.TokenHash,
+		&i.PasswordHash,
+		&i.ExpiresAt,
+		&i.Views,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createNoteRevision = `-- name: CreateNoteRevision :one
+INSERT INTO note_revisions (note_id, revision, title, text, created_at)
+SELECT
+  n.id,
+  coalesce((SELECT max(r.revision) FROM note_revisions r WHERE r.note_id = n.id), 0) + 1,
+  n.title,
+  n.text,
+  n.updated_at
+FROM notes n
+WHERE n.id = $1
+RETURNING revision
+`
+
+func (q *Queries) CreateNoteRevision(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, createNoteRevision, id)
+	var revision int32
+	err := row.Scan(&revision)
+	return revision, err
+}
+
+const createNotebook = `-- name: CreateNotebook :one
+INSERT INTO notebooks (id, username, parent_id, name, created_at, updated_at)
+VALUES ($1,$2,$3,$4,$5,$6)
+RETURNING id, username, parent_id, name, created_at, updated_at
+`
+
+type CreateNotebookParams struct {
+	ID        uuid.UUID
+	Username  string
+	ParentID  uuid.NullUUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (q *Queries) CreateNotebook(ctx context.Context, arg *CreateNotebookParams) (Notebook, error) {
+	row := q.db.QueryRowContext(ctx, createNotebook,
+		arg.ID,
+		arg.Username,
+		arg.ParentID,
+		arg.Name,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i Notebook
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.ParentID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createRefreshToken = `-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (id, family_id, username, token_hash, created_at, expires_at)
+VALUES ($1,$2,$3,$4,$5,$6)
+RETURNING id
+`
+
+type CreateRefreshTokenParams struct {
+	ID        uuid.UUID
+	FamilyID  uuid.UUID
+	Username  string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg *CreateRefreshTokenParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.ID,
+		arg.FamilyID,
+		arg.Username,
+		arg.TokenHash,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createRevokedToken = `-- name: CreateRevokedToken :exec
+INSERT INTO revoked_tokens (id, username, revoked_at, expires_at)
+VALUES ($1,$2,$3,$4)
+ON CONFLICT (id) DO NOTHING
+`
+
+type CreateRevokedTokenParams struct {
+	ID        uuid.UUID
+	Username  string
+	RevokedAt time.Time
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateRevokedToken(ctx context.Context, arg *CreateRevokedTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createRevokedToken,
+		arg.ID,
+		arg.Username,
+		arg.RevokedAt,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (id, username, user_agent, client_ip, created_at, last_seen_at, expires_at)
+VALUES ($1,$2,$3,$4,$5,$6,$7)
+RETURNING id
+`
+
+type CreateSessionParams struct {
+	ID         uuid.UUID
+	Username   string
+	UserAgent  string
+	ClientIp   string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg *CreateSessionParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.ID,
+		arg.Username,
+		arg.UserAgent,
+		arg.ClientIp,
+		arg.CreatedAt,
+		arg.LastSeenAt,
+		arg.ExpiresAt,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createTags = `-- name: CreateTags :exec
+INSERT INTO tags (id, username, name)
+SELECT unnest($1::uuid[]), $2, unnest($3::text[])
+ON CONFLICT (username, name) DO NOTHING
+`
+
+type CreateTagsParams struct {
+	Ids      []uuid.UUID
+	Username string
+	Names    []string
+}
+
+func (q *Queries) CreateTags(ctx context.Context, arg *CreateTagsParams) error {
+	_, err := q.db.ExecContext(ctx, createTags, pq.Array(arg.Ids), arg.Username, pq.Array(arg.Names))
+	return err
+}
+
+const deleteAttachment = `-- name: DeleteAttachment :one
+DELETE
+FROM attachments a
+USING notes n
+WHERE n.id = a.note_id AND a.id = $1 AND a.note_id = $2 AND n.username = $3 AND n.deleted_at IS NULL
+RETURNING a.storage_key
+`
+
+type DeleteAttachmentParams struct {
+	ID       uuid.UUID
+	NoteID   uuid.UUID
+	Username string
+}
+
+func (q *Queries) DeleteAttachment(ctx context.Context, arg *DeleteAttachmentParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, deleteAttachment, arg.ID, arg.NoteID, arg.Username)
+	var storage_key string
+	err := row.Scan(&storage_key)
+	return storage_key, err
+}
+
+const deleteNoteLink = `-- name: DeleteNoteLink :one
+DELETE FROM note_links l
+USING notes n
+WHERE n.id = l.note_id AND l.id = $1 AND l.note_id = $2 AND n.username = $3
+RETURNING l.id
+`
+
+type DeleteNoteLinkParams struct {
+	ID       uuid.UUID
+	NoteID   uuid.UUID
+	Username string
+}
+
+func (q *Queries) DeleteNoteLink(ctx context.Context, arg *DeleteNoteLinkParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, deleteNoteLink, arg.ID, arg.NoteID, arg.Username)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteNoteShare = `-- name: DeleteNoteShare :one
+DELETE FROM note_shares s
+USING notes n
+WHERE
+  n.id = s.note_id AND s.note_id = $1 AND s.username = $2
+  AND (n.username = $3 OR s.username = $3)
+RETURNING s.username
+`
+
+type DeleteNoteShareParams struct {
+	NoteID   uuid.UUID
+	Grantee  string
+	Username string
+}
+
+func (q *Queries) DeleteNoteShare(ctx context.Context, arg *DeleteNoteShareParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, deleteNoteShare, arg.NoteID, arg.Grantee, arg.Username)
+	var username string
+	err := row.Scan(&username)
+	return username, err
+}
+
+const deleteNotebook = `-- name: DeleteNotebook :one
+DELETE
+FROM notebooks
+WHERE id = $1 AND username = $2
+RETURNING id
+`
+
+type DeleteNotebookParams struct {
+	ID       uuid.UUID
+	Username string
+}
+
+func (q *Queries) DeleteNotebook(ctx context.Context, arg *DeleteNotebookParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, deleteNotebook, arg.ID, arg.Username)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deletePurgeableAttachments = `-- name: DeletePurgeableAttachments :many
+DELETE
+FROM attachments a
+USING notes n
+WHERE n.id = a.note_id AND n.deleted_at < $1::timestamp
+RETURNING a.storage_key
+`
+
+func (q *Queries) DeletePurgeableAttachments(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, deletePurgeableAttachments, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var storage_key string
+		if err := rows.Scan(&storage_key); err != nil {
+			return nil, err
+		}
+		items = append(items, storage_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteTag = `-- name: DeleteTag :exec
+DELETE
+FROM tags
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTag(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteTag, id)
+	return err
+}
+
+const deleteTrashedAttachments = `-- name: DeleteTrashedAttachments :many
+DELETE
+FROM attachments a
+USING notes n
+WHERE n.id = a.note_id AND n.username = $1 AND n.deleted_at IS NOT NULL
+RETURNING a.storage_key
+`
+
+func (q *Queries) DeleteTrashedAttachments(ctx context.Context, username string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, deleteTrashedAttachments, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var storage_key string
+		if err := rows.Scan(&storage_key); err != nil {
+			return nil, err
+		}
+		items = append(items, storage_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteUnusedTags = `-- name: DeleteUnusedTags :exec
+DELETE
+FROM tags t
+WHERE t.username = $1 AND NOT EXISTS (SELECT 1 FROM note_tags nt WHERE nt.tag_id = t.id)
+`
+
+func (q *Queries) DeleteUnusedTags(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, deleteUnusedTags, username)
+	return err
+}
+
+const emptyTrash = `-- name: EmptyTrash :execrows
+DELETE
+FROM notes
+WHERE username = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) EmptyTrash(ctx context.Context, username string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, emptyTrash, username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const findTitleConflict = `-- name: FindTitleConflict :one
+SELECT id
+FROM notes
+WHERE
+  username = $1 AND title = $2
+  AND notebook_id IS NOT DISTINCT FROM $3::uuid
+  AND id <> $4 AND unique_title AND deleted_at IS NULL
+LIMIT 1
+`
+
+type FindTitleConflictParams struct {
+	Username   string
+	Title      string
+	NotebookID uuid.NullUUID
+	ExcludeID  uuid.UUID
+}
+
+func (q *Queries) FindTitleConflict(ctx context.Context, arg *FindTitleConflictParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, findTitleConflict,
+		arg.Username,
+		arg.Title,
+		arg.NotebookID,
+		arg.ExcludeID,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getAllNotesFromUser = `-- name: GetAllNotesFromUser :many
+SELECT id, title, username, text, created_at, updated_at, search, notebook_id, version, deleted_at, unique_title
+FROM notes
+WHERE username = $1 AND deleted_at IS NULL
+ORDER BY created_at
+`
+
+func (q *Queries) GetAllNotesFromUser(ctx context.Context, username string) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, getAllNotesFromUser, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Note{}
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Username,
+			&i.Text,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Search,
+			&i.NotebookID,
+			&i.Version,
+			&i.DeletedAt,
+			&i.UniqueTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAttachment = `-- name: GetAttachment :one
+SELECT a.id, a.note_id, a.username, a.filename, a.content_type, a.size, a.storage_key, a.created_at
+FROM attachments a
+JOIN notes n ON n.id = a.note_id
+WHERE a.id = $1 AND a.note_id = $2 AND n.username = $3 AND n.deleted_at IS NULL
+`
+
+type GetAttachmentParams struct {
+	ID       uuid.UUID
+	NoteID   uuid.UUID
+	Username string
+}
+
+func (q *Queries) GetAttachment(ctx context.Context, arg *GetAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, getAttachment, arg.ID, arg.NoteID, arg.Username)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.Username,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.StorageKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAttachmentUsage = `-- name: GetAttachmentUsage :one
+SELECT coalesce(sum(size), 0)::bigint
+FROM attachments
+WHERE username = $1
+`
+
+func (q *Queries) GetAttachmentUsage(ctx context.Context, username string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getAttachmentUsage, username)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getLatestNoteRevision = `-- name: GetLatestNoteRevision :one
+SELECT r.note_id, r.revision, r.title, r.text, r.created_at
+FROM note_revisions r
+JOIN notes n ON n.id = r.note_id
+WHERE r.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY r.revision DESC
+LIMIT 1
+`
+
+type GetLatestNoteRevisionParams struct {
+	NoteID   uuid.UUID
+	Username string
+}
+
+func (q *Queries) GetLatestNoteRevision(ctx context.Context, arg *GetLatestNoteRevisionParams) (NoteRevision, error) {
+	row := q.db.QueryRowContext(ctx, getLatestNoteRevision, arg.NoteID, arg.Username)
+	var i NoteRevision
+	err := row.Scan(
+		&i.NoteID,
+		&i.Revision,
+		&i.Title,
+		&i.Text,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLinkedNote = `-- name: GetLinkedNote :one
+SELECT l.id, l.note_id, l.token_hash, l.password_hash, l.expires_at, l.views, l.created_at, n.id, n.title, n.username, n.text, n.created_at, n.updated_at, n.search, n.notebook_id, n.version, n.deleted_at, n.unique_title
+FROM note_links l
+JOIN notes n ON n.id = l.note_id
+WHERE l.token_hash = $1 AND n.deleted_at IS NULL
+`
+
+type GetLinkedNoteRow struct {
+	NoteLink NoteLink
+	Note     Note
+}
+
+func (q *Queries) GetLinkedNote(ctx context.Context, tokenHash string) (GetLinkedNoteRow, error) {
+	row := q.db.QueryRowContext(ctx, getLinkedNote, tokenHash)
+	var i GetLinkedNoteRow
+	err := row.Scan(
+		&i.NoteLink.ID,
+		&i.NoteLink.NoteID,
+		&i.NoteLink.TokenHash,
+		&i.NoteLink.PasswordHash,
+		&i.NoteLink.ExpiresAt,
+		&i.NoteLink.Views,
+		&i.NoteLink.CreatedAt,
+		&i.Note.ID,
+		&i.Note.Title,
+		&i.Note.Username,
+		&i.Note.Text,
+		&i.Note.CreatedAt,
+		&i.Note.UpdatedAt,
+		&i.Note.Search,
+		&i.Note.NotebookID,
+		&i.Note.Version,
+		&i.Note.DeletedAt,
+		&i.Note.UniqueTitle,
+	)
+	return i, err
+}
+
+const getNote = `-- name: GetNote :one
+SELECT id, title, username, text, created_at, updated_at, search, notebook_id, version, deleted_at, unique_title
+FROM notes
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL
+`
+
+type GetNoteParams struct {
+	ID       uuid.UUID
+	Username string
+}
+
+func (q *Queries) GetNote(ctx context.Context, arg *GetNoteParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, getNote, arg.ID, arg.Username)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Username,
+		&i.Text,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Search,
+		&i.NotebookID,
+		&i.Version,
+		&i.DeletedAt,
+		&i.UniqueTitle,
+	)
+	return i, err
+}
+
+const getNoteAccess = `-- name: GetNoteAccess :one
+SELECT n.username AS owner, coalesce(s.permission, 'owner')::text AS permission
+FROM notes n
+LEFT JOIN note_shares s ON s.note_id = n.id AND s.username = $1
+WHERE
+  n.id = $2 AND n.deleted_at IS NULL
+  AND (n.username = $1 OR s.username IS NOT NULL)
+`
+
+type GetNoteAccessParams struct {
+	Username string
+	ID       uuid.UUID
+}
+
+type GetNoteAccessRow struct {
+	Owner      string
+	Permission string
+}
+
+func (q *Queries) GetNoteAccess(ctx context.Context, arg *GetNoteAccessParams) (GetNoteAccessRow, error) {
+	row := q.db.QueryRowContext(ctx, getNoteAccess, arg.Username, arg.ID)
+	var i GetNoteAccessRow
+	err := row.Scan(&i.Owner, &i.Permission)
+	return i, err
+}
+
+const getNoteRevision = `-- name: GetNoteRevision :one
+SELECT r.note_id, r.revision, r.title, r.text, r.created_at
+FROM note_revisions r
+JOIN notes n ON n.id = r.note_id
+WHERE r.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL AND r.revision = $3
+`
+
+type GetNoteRevisionParams struct {
+	NoteID   uuid.UUID
+	Username string
+	Revision int32
+}
+
+func (q *Queries) GetNoteRevision(ctx context.Context, arg *GetNoteRevisionParams) (NoteRevision, error) {
+	row := q.db.QueryRowContext(ctx, getNoteRevision, arg.NoteID, arg.Username, arg.Revision)
+	var i NoteRevision
+	err := row.Scan(
+		&i.NoteID,
+		&i.Revision,
+		&i.Title,
+		&i.Text,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getNotebook = `-- name: GetNotebook :one
+SELECT id, username, parent_id, name, created_at, updated_at
+FROM notebooks
+WHERE id = $1 AND username = $2
+`
+
+type GetNotebookParams struct {
+	ID       uuid.UUID
+	Username string
+}
+
+func (q *Queries) GetNotebook(ctx context.Context, arg *GetNotebookParams) (Notebook, error) {
+	row := q.db.QueryRowContext(ctx, getNotebook, arg.ID, arg.Username)
+	var i Notebook
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.ParentID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getNotebookTree = `-- name: GetNotebookTree :many
+WITH RECURSIVE tree AS (
+  SELECT nb.id, nb.username, nb.parent_id, nb.name, nb.created_at, nb.updated_at
+  FROM notebooks nb
+  WHERE nb.id = $1 AND nb.username = $2
+  UNION ALL
+  SELECT child.id, child.username, child.parent_id, child.name, child.created_at, child.updated_at
+  FROM notebooks child
+  JOIN tree ON child.parent_id = tree.id
+)
+SELECT id, username, parent_id, name, created_at, updated_at
+FROM tree
+ORDER BY name
+`
+
+type GetNotebookTreeParams struct {
+	ID       uuid.UUID
+	Username string
+}
+
+type GetNotebookTreeRow struct {
+	ID        uuid.UUID
+	Username  string
+	ParentID  uuid.NullUUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (q *Queries) GetNotebookTree(ctx context.Context, arg *GetNotebookTreeParams) ([]GetNotebookTreeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNotebookTree, arg.ID, arg.Username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetNotebookTreeRow{}
+	for rows.Next() {
+		var i GetNotebookTreeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.ParentID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotesByIDs = `-- name: GetNotesByIDs :many
+SELECT id, title, username, text, created_at, updated_at, search, notebook_id, version, deleted_at, unique_title
+FROM notes
+WHERE username = $1 AND id = ANY($2::uuid[]) AND deleted_at IS NULL
+`
+
+type GetNotesByIDsParams struct {
+	Username string
+	Ids      []uuid.UUID
+}
+
+func (q *Queries) GetNotesByIDs(ctx context.Context, arg *GetNotesByIDsParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, getNotesByIDs, arg.Username, pq.Array(arg.Ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Note{}
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Username,
+			&i.Text,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Search,
+			&i.NotebookID,
+			&i.Version,
+			&i.DeletedAt,
+			&i.UniqueTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotesInNotebooks = `-- name: GetNotesInNotebooks :many
+SELECT id, title, username, text, created_at, updated_at, search, notebook_id, version, deleted_at, unique_title
+FROM notes
+WHERE username = $1 AND deleted_at IS NULL AND notebook_id = ANY($2::uuid[])
+ORDER BY created_at
+`
+
+type GetNotesInNotebooksParams struct {
+	Username    string
+	NotebookIds []uuid.UUID
+}
+
+func (q *Queries) GetNotesInNotebooks(ctx context.Context, arg *GetNotesInNotebooksParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, getNotesInNotebooks, arg.Username, pq.Array(arg.NotebookIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Note{}
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Username,
+			&i.Text,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Search,
+			&i.NotebookID,
+			&i.Version,
+			&i.DeletedAt,
+			&i.UniqueTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRefreshTokenByHash = `-- name: GetRefreshTokenByHash :one
+SELECT id, family_id, username, token_hash, created_at, expires_at, revoked_at
+FROM refresh_tokens
+WHERE token_hash = $1
+`
+
+func (q *Queries) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenByHash, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.FamilyID,
+		&i.Username,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getRevokedTokens = `-- name: GetRevokedTokens :many
+SELECT id, expires_at
+FROM revoked_tokens
+WHERE id = ANY($1::uuid[]) AND expires_at > $2
+`
+
+type GetRevokedTokensParams struct {
+	Ids []uuid.UUID
+	Now time.Time
+}
+
+type GetRevokedTokensRow struct {
+	ID        uuid.UUID
+	ExpiresAt time.Time
+}
+
+func (q *Queries) GetRevokedTokens(ctx context.Context, arg *GetRevokedTokensParams) ([]GetRevokedTokensRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRevokedTokens, pq.Array(arg.Ids), arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRevokedTokensRow{}
+	for rows.Next() {
+		var i GetRevokedTokensRow
+		if err := rows.Scan(&i.ID, &i.ExpiresAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSharedNote = `-- name: GetSharedNote :one
+SELECT n.id, n.title, n.username, n.text, n.created_at, n.updated_at, n.search, n.notebook_id, n.version, n.deleted_at, n.unique_title, s.permission
+FROM notes n
+JOIN note_shares s ON s.note_id = n.id
+WHERE n.id = $1 AND s.username = $2 AND n.deleted_at IS NULL
+`
+
+type GetSharedNoteParams struct {
+	ID       uuid.UUID
+	Username string
+}
+
+type GetSharedNoteRow struct {
+	Note       Note
+	Permission string
+}
+
+func (q *Queries) GetSharedNote(ctx context.Context, arg *GetSharedNoteParams) (GetSharedNoteRow, error) {
+	row := q.db.QueryRowContext(ctx, getSharedNote, arg.ID, arg.Username)
+	var i GetSharedNoteRow
+	err := row.Scan(
+		&i.Note.ID,
+		&i.Note.Title,
+		&i.Note.Username,
+		&i.Note.Text,
+		&i.Note.CreatedAt,
+		&i.Note.UpdatedAt,
+		&i.Note.Search,
+		&i.Note.NotebookID,
+		&i.Note.Version,
+		&i.Note.DeletedAt,
+		&i.Note.UniqueTitle,
+		&i.Permission,
+	)
+	return i, err
+}
+
+const getTag = `-- name: GetTag :one
+SELECT id, username, name
+FROM tags
+WHERE username = $1 AND name = $2
+`
+
+type GetTagParams struct {
+	Username string
+	Name     string
+}
+
+func (q *Queries) GetTag(ctx context.Context, arg *GetTagParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, getTag, arg.Username, arg.Name)
+	var i Tag
+	err := row.Scan(&i.ID, &i.Username, &i.Name)
+	return i, err
+}
+
+const getTagsOfNotes = `-- name: GetTagsOfNotes :many
+SELECT nt.note_id, t.name
+FROM note_tags nt
+JOIN tags t ON t.id = nt.tag_id
+WHERE nt.note_id = ANY($1::uuid[])
+ORDER BY t.name
+`
+
+type GetTagsOfNotesRow struct {
+	NoteID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) GetTagsOfNotes(ctx context.Context, noteIds []uuid.UUID) ([]GetTagsOfNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTagsOfNotes, pq.Array(noteIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTagsOfNotesRow{}
+	for rows.Next() {
+		var i GetTagsOfNotesRow
+		if err := rows.Scan(&i.NoteID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrashedNote = `-- name: GetTrashedNote :one
+SELECT id, title, username, text, created_at, updated_at, search, notebook_id, version, deleted_at, unique_title
+FROM notes
+WHERE id = $1 AND username = $2 AND deleted_at IS NOT NULL
+`
+
+type GetTrashedNoteParams struct {
+	ID       uuid.UUID
+	Username string
+}
+
+func (q *Queries) GetTrashedNote(ctx context.Context, arg *GetTrashedNoteParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, getTrashedNote, arg.ID, arg.Username)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Username,
+		&i.Text,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Search,
+		&i.NotebookID,
+		&i.Version,
+		&i.DeletedAt,
+		&i.UniqueTitle,
+	)
+	return i, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT username, password, email, allow_duplicate_titles FROM users
+WHERE username = $1
+`
+
+func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.AllowDuplicateTitles,
+	)
+	return i, err
+}
+
+const getUserSettings = `-- name: GetUserSettings :one
+SELECT allow_duplicate_titles
+FROM users
+WHERE username = $1
+`
+
+func (q *Queries) GetUserSettings(ctx context.Context, username string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, getUserSettings, username)
+	var allow_duplicate_titles bool
+	err := row.Scan(&allow_duplicate_titles)
+	return allow_duplicate_titles, err
+}
+
+const isNotebookInSubtree = `-- name: IsNotebookInSubtree :one
+WITH RECURSIVE tree AS (
+  SELECT nb.id
+  FROM notebooks nb
+  WHERE nb.id = $2
+  UNION ALL
+  SELECT child.id
+  FROM notebooks child
+  JOIN tree ON child.parent_id = tree.id
+)
+SELECT count(*) > 0 AS in_subtree
+FROM tree
+WHERE id = $1::uuid
+`
+
+type IsNotebookInSubtreeParams struct {
+	ID     uuid.UUID
+	RootID uuid.UUID
+}
+
+func (q *Queries) IsNotebookInSubtree(ctx context.Context, arg *IsNotebookInSubtreeParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isNotebookInSubtree, arg.ID, arg.RootID)
+	var in_subtree bool
+	err := row.Scan(&in_subtree)
+	return in_subtree, err
+}
+
+const listActiveSessions = `-- name: ListActiveSessions :many
+SELECT id, username, user_agent, client_ip, created_at, last_seen_at, expires_at, revoked_at
+FROM sessions
+WHERE username = $1 AND revoked_at IS NULL AND expires_at > $2
+ORDER BY last_seen_at DESC
+`
+
+type ListActiveSessionsParams struct {
+	Username  string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) ListActiveSessions(ctx context.Context, arg *ListActiveSessionsParams) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveSessions, arg.Username, arg.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAttachments = `-- name: ListAttachments :many
+SELECT a.id, a.note_id, a.username, a.filename, a.content_type, a.size, a.storage_key, a.created_at
+FROM attachments a
+JOIN notes n ON n.id = a.note_id
+WHERE a.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY a.created_at
+`
+
+type ListAttachmentsParams struct {
+	NoteID   uuid.UUID
+	Username string
+}
+
+func (q *Queries) ListAttachments(ctx context.Context, arg *ListAttachmentsParams) ([]Attachment, error) {
+	rows, err := q.db.QueryContext(ctx, listAttachments, arg.NoteID, arg.Username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Attachment{}
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.Username,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.StorageKey,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteChanges = `-- name: ListNoteChanges :many
+SELECT note_id, seq
+FROM note_changes
+WHERE username = $1 AND seq > $2
+ORDER BY seq
+LIMIT $3
+`
+
+type ListNoteChangesParams struct {
+	Username string
+	Seq      int64
+	Limit    int32
+}
+
+type ListNoteChangesRow struct {
+	NoteID uuid.UUID
+	Seq    int64
+}
+
+func (q *Queries) ListNoteChanges(ctx context.Context, arg *ListNoteChangesParams) ([]ListNoteChangesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteChanges, arg.Username, arg.Seq, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListNoteChangesRow{}
+	for rows.Next() {
+		var i ListNoteChangesRow
+		if err := rows.Scan(&i.NoteID, &i.Seq); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteLinks = `-- name: ListNoteLinks :many
+SELECT l.id, l.note_id, l.token_hash, l.password_hash, l.expires_at, l.views, l.created_at
+FROM note_links l
+JOIN notes n ON n.id = l.note_id
+WHERE l.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY l.created_at
+`
+
+type ListNoteLinksParams struct {
+	NoteID   uuid.UUID
+	Username string
+}
+
+func (q *Queries) ListNoteLinks(ctx context.Context, arg *ListNoteLinksParams) ([]NoteLink, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteLinks, arg.NoteID, arg.Username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NoteLink{}
+	for rows.Next() {
+		var i NoteLink
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.TokenHash,
+			&i.PasswordHash,
+			&i.ExpiresAt,
+			&i.Views,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteRevisions = `-- name: ListNoteRevisions :many
+SELECT r.revision, r.title, r.created_at, length(coalesce(r.text, ''))::int AS text_length
+FROM note_revisions r
+JOIN notes n ON n.id = r.note_id
+WHERE r.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY r.revision DESC
+`
+
+type ListNoteRevisionsParams struct {
+	NoteID   uuid.UUID
+	Username string
+}
+
+type ListNoteRevisionsRow struct {
+	Revision   int32
+	Title      string
+	CreatedAt  time.Time
+	TextLength int32
+}
+
+func (q *Queries) ListNoteRevisions(ctx context.Context, arg *ListNoteRevisionsParams) ([]ListNoteRevisionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteRevisions, arg.NoteID, arg.Username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListNoteRevisionsRow{}
+	for rows.Next() {
+		var i ListNoteRevisionsRow
+		if err := rows.Scan(
+			&i.Revision,
+			&i.Title,
+			&i.CreatedAt,
+			&i.TextLength,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteShares = `-- name: ListNoteShares :many
+SELECT s.note_id, s.username, s.permission, s.created_at
+FROM note_shares s
+JOIN notes n ON n.id = s.note_id
+WHERE s.note_id = $1 AND n.username = $2 AND n.deleted_at IS NULL
+ORDER BY s.created_at, s.username
+`
+
+type ListNoteSharesParams struct {
+	NoteID   uuid.UUID
+	Username string
+}
+
+func (q *Queries) ListNoteShares(ctx context.Context, arg *ListNoteSharesParams) ([]NoteShare, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteShares, arg.NoteID, arg.Username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NoteShare{}
+	for rows.Next() {
+		var i NoteShare
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Username,
+			&i.Permission,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotebooks = `-- name: ListNotebooks :many
+SELECT id, username, parent_id, name, created_at, updated_at
+FROM notebooks
+WHERE username = $1
+ORDER BY name
+`
+
+func (q *Queries) ListNotebooks(ctx context.Context, username string) ([]Notebook, error) {
+	rows, err := q.db.QueryContext(ctx, listNotebooks, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Notebook{}
+	for rows.Next() {
+		var i Notebook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.ParentID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotes = `-- name: ListNotes :many
+SELECT n.id, n.title, n.username, n.text, n.created_at, n.updated_at, n.search, n.notebook_id, n.version, n.deleted_at, n.unique_title
+FROM notes n
+WHERE
+  n.username = $1 AND n.deleted_at IS NULL
+  AND ($2::timestamp IS NULL OR n.created_at >= $2)
+  AND ($3::timestamp IS NULL OR n.created_at < $3)
+  AND ($4::timestamp IS NULL OR n.updated_at >= $4)
+  AND ($5::timestamp IS NULL OR n.updated_at < $5)
+  AND (coalesce(cardinality($6::text[]), 0) = 0 OR n.id IN (
+    SELECT nt.note_id
+    FROM note_tags nt
+    JOIN tags t ON t.id = nt.tag_id
+    WHERE t.username = $1 AND t.name = ANY($6::text[])
+    GROUP BY nt.note_id
+    HAVING count(*) >= $7::int
+  ))
+  AND (NOT $8::bool OR CASE $9::text
+    WHEN 'updated_at' THEN
+      CASE WHEN $10::bool
+        THEN (n.updated_at, n.id) < ($11::timestamp, $12::uuid)
+        ELSE (n.updated_at, n.id) > ($11::timestamp, $12::uuid)
+      END
+    WHEN 'title' THEN
+      CASE WHEN $10::bool
+        THEN (n.title, n.id) < ($13::text, $12::uuid)
+        ELSE (n.title, n.id) > ($13::text, $12::uuid)
+      END
+    ELSE
+      CASE WHEN $10::bool
+        THEN (n.created_at, n.id) < ($11::timestamp, $12::uuid)
+        ELSE (n.created_at, n.id) > ($11::timestamp, $12::uuid)
+      END
+  END)
+ORDER BY
+  CASE WHEN $9 = 'updated_at' AND NOT $10 THEN n.updated_at END ASC,
+  CASE WHEN $9 = 'updated_at' AND $10 THEN n.updated_at END DESC,
+  CASE WHEN $9 = 'title' AND NOT $10 THEN n.title END ASC,
+  CASE WHEN $9 = 'title' AND $10 THEN n.title END DESC,
+  CASE WHEN $9 = 'created_at' AND NOT $10 THEN n.created_at END ASC,
+  CASE WHEN $9 = 'created_at' AND $10 THEN n.created_at END DESC,
+  CASE WHEN NOT $10 THEN n.id END ASC,
+  CASE WHEN $10 THEN n.id END DESC
+LIMIT $14
+`
+
+type ListNotesParams struct {
+	Username      string
+	CreatedFrom   sql.NullTime
+	CreatedTo     sql.NullTime
+	UpdatedFrom   sql.NullTime
+	UpdatedTo     sql.NullTime
+	Tags          []string
+	MinTagMatches int32
+	HasCursor     bool
+	SortBy        string
+	Descending    bool
+	CursorTime    time.Time
+	CursorID      uuid.UUID
+	CursorTitle   string
+	MaxResults    int32
+}
+
+func (q *Queries) ListNotes(ctx context.Context, arg *ListNotesParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listNotes,
+		arg.Username,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.UpdatedFrom,
+		arg.UpdatedTo,
+		pq.Array(arg.Tags),
+		arg.MinTagMatches,
+		arg.HasCursor,
+		arg.SortBy,
+		arg.Descending,
+		arg.CursorTime,
+		arg.CursorID,
+		arg.CursorTitle,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Note{}
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Username,
+			&i.Text,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Search,
+			&i.NotebookID,
+			&i.Version,
+			&i.DeletedAt,
+			&i.UniqueTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSharedNotes = `-- name: ListSharedNotes :many
+SELECT n.id, n.title, n.username, n.text, n.created_at, n.updated_at, n.search, n.notebook_id, n.version, n.deleted_at, n.unique_title, s.permission
+FROM notes n
+JOIN note_shares s ON s.note_id = n.id
+WHERE s.username = $1 AND n.deleted_at IS NULL
+ORDER BY n.updated_at DESC, n.id
+`
+
+type ListSharedNotesRow struct {
+	Note       Note
+	Permission string
+}
+
+func (q *Queries) ListSharedNotes(ctx context.Context, username string) ([]ListSharedNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSharedNotes, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSharedNotesRow{}
+	for rows.Next() {
+		var i ListSharedNotesRow
+		if err := rows.Scan(
+			&i.Note.ID,
+			&i.Note.Title,
+			&i.Note.Username,
+			&i.Note.Text,
+			&i.Note.CreatedAt,
+			&i.Note.UpdatedAt,
+			&i.Note.Search,
+			&i.Note.NotebookID,
+			&i.Note.Version,
+			&i.Note.DeletedAt,
+			&i.Note.UniqueTitle,
+			&i.Permission,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT t.name, count(nt.note_id) AS note_count
+FROM tags t
+JOIN note_tags nt ON nt.tag_id = t.id
+JOIN notes n ON n.id = nt.note_id AND n.deleted_at IS NULL
+WHERE t.username = $1
+GROUP BY t.id, t.name
+ORDER BY t.name
+`
+
+type ListTagsRow struct {
+	Name      string
+	NoteCount int64
+}
+
+func (q *Queries) ListTags(ctx context.Context, username string) ([]ListTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTags, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTagsRow{}
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(&i.Name, &i.NoteCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrash = `-- name: ListTrash :many
+SELECT id, title, username, text, created_at, updated_at, search, notebook_id, version, deleted_at, unique_title
+FROM notes
+WHERE username = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) ListTrash(ctx context.Context, username string) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listTrash, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Note{}
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Username,
+			&i.Text,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Search,
+			&i.NotebookID,
+			&i.Version,
+			&i.DeletedAt,
+			&i.UniqueTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT username, password, email, allow_duplicate_titles
+FROM users
+ORDER BY username
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.Username,
+			&i.Password,
+			&i.Email,
+			&i.AllowDuplicateTitles,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveNote = `-- name: MoveNote :one
+UPDATE notes
+SET notebook_id = $3, updated_at = $4, version = version + 1
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL
+RETURNING id
+`
+
+type MoveNoteParams struct {
+	ID         uuid.UUID
+	Username   string
+	NotebookID uuid.NullUUID
+	UpdatedAt  time.Time
+}
+
+func (q *Queries) MoveNote(ctx context.Context, arg *MoveNoteParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, moveNote,
+		arg.ID,
+		arg.Username,
+		arg.NotebookID,
+		arg.UpdatedAt,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const moveNoteTags = `-- name: MoveNoteTags :exec
+INSERT INTO note_tags (note_id, tag_id)
+SELECT nt.note_id, $1
+FROM note_tags nt
+WHERE nt.tag_id = $2
+ON CONFLICT DO NOTHING
+`
+
+type MoveNoteTagsParams struct {
+	ToTagID   uuid.UUID
+	FromTagID uuid.UUID
+}
+
+func (q *Queries) MoveNoteTags(ctx context.Context, arg *MoveNoteTagsParams) error {
+	_, err := q.db.ExecContext(ctx, moveNoteTags, arg.ToTagID, arg.FromTagID)
+	return err
+}
+
+const notifyNoteEvent = `-- name: NotifyNoteEvent :exec
+SELECT pg_notify('note_events', $1::text)
+`
+
+func (q *Queries) NotifyNoteEvent(ctx context.Context, payload string) error {
+	_, err := q.db.ExecContext(ctx, notifyNoteEvent, payload)
+	return err
+}
+
+const pruneNoteRevisions = `-- name: PruneNoteRevisions :exec
+DELETE
+FROM note_revisions
+WHERE note_id = $1 AND revision <= $2
+`
+
+type PruneNoteRevisionsParams struct {
+	NoteID   uuid.UUID
+	Revision int32
+}
+
+func (q *Queries) PruneNoteRevisions(ctx context.Context, arg *PruneNoteRevisionsParams) error {
+	_, err := q.db.ExecContext(ctx, pruneNoteRevisions, arg.NoteID, arg.Revision)
+	return err
+}
+
+const purgeTrash = `-- name: PurgeTrash :execrows
+DELETE
+FROM notes
+WHERE id IN (
+  SELECT id
+  FROM notes
+  WHERE deleted_at < $1::timestamp
+  LIMIT $2
+)
+`
+
+type PurgeTrashParams struct {
+	DeletedBefore time.Time
+	MaxRows       int32
+}
+
+func (q *Queries) PurgeTrash(ctx context.Context, arg *PurgeTrashParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeTrash, arg.DeletedBefore, arg.MaxRows)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const registerUser = `-- name: RegisterUser :one
+INSERT INTO users (username, password, email)
+VALUES ($1,$2,$3)
+RETURNING username
+`
+
+type RegisterUserParams struct {
+	Username string
+	Password string
+	Email    string
+}
+
+func (q *Queries) RegisterUser(ctx context.Context, arg *RegisterUserParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, registerUser, arg.Username, arg.Password, arg.Email)
+	var username string
+	err := row.Scan(&username)
+	return username, err
+}
+
+const renameTag = `-- name: RenameTag :one
+UPDATE tags
+SET name = $1
+WHERE username = $2 AND name = $3
+RETURNING id
+`
+
+type RenameTagParams struct {
+	NewName  string
+	Username string
+	Name     string
+}
+
+func (q *Queries) RenameTag(ctx context.Context, arg *RenameTagParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, renameTag, arg.NewName, arg.Username, arg.Name)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const restoreNote = `-- name: RestoreNote :one
+UPDATE notes
+SET deleted_at = NULL, version = version + 1
+WHERE id = $1 AND username = $2 AND deleted_at IS NOT NULL
+RETURNING id
+`
+
+type RestoreNoteParams struct {
+	ID       uuid.UUID
+	Username string
+}
+
+func (q *Queries) RestoreNote(ctx context.Context, arg *RestoreNoteParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, restoreNote, arg.ID, arg.Username)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const revokeActiveRefreshToken = `-- name: RevokeActiveRefreshToken :one
+UPDATE refresh_tokens
+SET revoked_at = $1::timestamp
+WHERE
+  token_hash = $2 AND revoked_at IS NULL AND expires_at > $1
+RETURNING id, family_id, username, token_hash, created_at, expires_at, revoked_at
+`
+
+type RevokeActiveRefreshTokenParams struct {
+	Now       time.Time
+	TokenHash string
+}
+
+func (q *Queries) RevokeActiveRefreshToken(ctx context.Context, arg *RevokeActiveRefreshTokenParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, revokeActiveRefreshToken, arg.Now, arg.TokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.FamilyID,
+		&i.Username,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = $2
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+type RevokeRefreshTokenFamilyParams struct {
+	FamilyID  uuid.UUID
+	RevokedAt sql.NullTime
+}
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, arg *RevokeRefreshTokenFamilyParams) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, arg.FamilyID, arg.RevokedAt)
+	return err
+}
+
+const revokeSession = `-- name: RevokeSession :one
+UPDATE sessions
+SET revoked_at = $1::timestamp
+WHERE
+  id = $2 AND username = $3 AND revoked_at IS NULL
+RETURNING id, username, user_agent, client_ip, created_at, last_seen_at, expires_at, revoked_at
+`
+
+type RevokeSessionParams struct {
+	Now      time.Time
+	ID       uuid.UUID
+	Username string
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg *RevokeSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, revokeSession, arg.Now, arg.ID, arg.Username)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.CreatedAt,
+		&i.LastSeenAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const searchNotes = `-- name: SearchNotes :many
+SELECT
+  n.id, n.title, n.username, n.text, n.created_at, n.updated_at,
+  ts_rank(n.search, q.query)::real AS rank,
+  ts_headline('simple', n.title, q.query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS title_headline,
+  ts_headline('simple', coalesce(n.text, ''), q.query, 'MaxFragments=2, MinWords=5, MaxWords=25, StartSel=<mark>, StopSel=</mark>')::text AS text_headline
+FROM notes n, to_tsquery('simple', $1) AS q(query)
+WHERE n.username = $2 AND n.deleted_at IS NULL AND n.search @@ q.query
+ORDER BY rank DESC, n.updated_at DESC
+LIMIT $3
+`
+
+type SearchNotesParams struct {
+	Query      string
+	Username   string
+	MaxResults int32
+}
+
+type SearchNotesRow struct {
+	ID            uuid.UUID
+	Title         string
+	Username      string
+	Text          sql.NullString
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Rank          float32
+	TitleHeadline string
+	TextHeadline  string
+}
+
+func (q *Queries) SearchNotes(ctx context.Context, arg *SearchNotesParams) ([]SearchNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchNotes, arg.Query, arg.Username, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchNotesRow{}
+	for rows.Next() {
+		var i SearchNotesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Username,
+			&i.Text,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rank,
+			&i.TitleHeadline,
+			&i.TextHeadline,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setAllowDuplicateTitles = `-- name: SetAllowDuplicateTitles :exec
+UPDATE users
+SET allow_duplicate_titles = $2
+WHERE username = $1
+`
+
+type SetAllowDuplicateTitlesParams struct {
+	Username             string
+	AllowDuplicateTitles bool
+}
+
+func (q *Queries) SetAllowDuplicateTitles(ctx context.Context, arg *SetAllowDuplicateTitlesParams) error {
+	_, err := q.db.ExecContext(ctx, setAllowDuplicateTitles, arg.Username, arg.AllowDuplicateTitles)
+	return err
+}
+
+const setNotesUniqueTitle = `-- name: SetNotesUniqueTitle :exec
+UPDATE notes
+SET unique_title = $2
+WHERE username = $1
+`
+
+type SetNotesUniqueTitleParams struct {
+	Username    string
+	UniqueTitle bool
+}
+
+func (q *Queries) SetNotesUniqueTitle(ctx context.Context, arg *SetNotesUniqueTitleParams) error {
+	_, err := q.db.ExecContext(ctx, setNotesUniqueTitle, arg.Username, arg.UniqueTitle)
+	return err
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE sessions
+SET last_seen_at = $2, expires_at = $3
+WHERE id = $1
+`
+
+type TouchSessionParams struct {
+	ID         uuid.UUID
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
+
+func (q *Queries) TouchSession(ctx context.Context, arg *TouchSessionParams) error {
+	_, err := q.db.ExecContext(ctx, touchSession, arg.ID, arg.LastSeenAt, arg.ExpiresAt)
+	return err
+}
+
+const trashNote = `-- name: TrashNote :one
+UPDATE notes
+SET deleted_at = $1::timestamp, version = version + 1
+WHERE
+  id = $2 AND username = $3 AND deleted_at IS NULL
+  AND ($4::int = 0 OR version = $4)
+RETURNING id
+`
+
+type TrashNoteParams struct {
+	DeletedAt time.Time
+	ID        uuid.UUID
+	Username  string
+	Version   int32
+}
+
+func (q *Queries) TrashNote(ctx context.Context, arg *TrashNoteParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, trashNote,
+		arg.DeletedAt,
+		arg.ID,
+		arg.Username,
+		arg.Version,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const updateNote = `-- name: UpdateNote :one
+UPDATE notes
+SET
+  title = COALESCE($1, title),
+  text = COALESCE($2, text),
+  updated_at = COALESCE($3, updated_at),
+  version = version + 1
+WHERE
+  id = $4 AND username = $5 AND deleted_at IS NULL
+  AND ($6::int = 0 OR version = $6)
+RETURNING version
+`
+
+type UpdateNoteParams struct {
+	Title     sql.NullString
+	Text      sql.NullString
+	UpdatedAt sql.NullTime
+	ID        uuid.UUID
+	Username  string
+	Version   int32
+}
+
+func (q *Queries) UpdateNote(ctx context.Context, arg *UpdateNoteParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, updateNote,
+		arg.Title,
+		arg.Text,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.Username,
+		arg.Version,
+	)
+	var version int32
+	err := row.Scan(&version)
+	return version, err
+}
+
+const updateNotebook = `-- name: UpdateNotebook :one
+UPDATE notebooks
+SET name = $3, parent_id = $4, updated_at = $5
+WHERE id = $1 AND username = $2
+RETURNING id, username, parent_id, name, created_at, updated_at
+`
+
+type UpdateNotebookParams struct {
+	ID        uuid.UUID
+	Username  string
+	Name      string
+	ParentID  uuid.NullUUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) UpdateNotebook(ctx context.Context, arg *UpdateNotebookParams) (Notebook, error) {
+	row := q.db.QueryRowContext(ctx, updateNotebook,
+		arg.ID,
+		arg.Username,
+		arg.Name,
+		arg.ParentID,
+		arg.UpdatedAt,
+	)
+	var i Notebook
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.ParentID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertNoteShare = `-- name: UpsertNoteShare :one
+INSERT INTO note_shares (note_id, username, permission, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (note_id, username) DO UPDATE SET permission = EXCLUDED.permission
+RETURNING note_id, username, permission, created_at
+`
+
+type UpsertNoteShareParams struct {
+	NoteID     uuid.UUID
+	Username   string
+	Permission string
+	CreatedAt  time.Time
+}
+
+func (q *Queries) UpsertNoteShare(ctx context.Context, arg *UpsertNoteShareParams) (NoteShare, error) {
+	row := q.db.QueryRowContext(ctx, upsertNoteShare,
+		arg.NoteID,
+		arg.Username,
+		arg.Permission,
+		arg.CreatedAt,
+	)
+	var i NoteShare
+	err := row.Scan(
+		&i.NoteID,
+		&i.Username,
+		&i.Permission,
+		&i.CreatedAt,
+	)
+	return i, err
+}
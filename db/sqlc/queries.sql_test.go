@@ -1 +1,113 @@
-package sqlc_test
\ No newline at end of file
+package sqlc_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/random"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBMethods(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	randTitle := random.NewString(15)
+	randUsername := random.NewString(10)
+	randText := sql.NullString{String: random.NewString(50), Valid: true}
+	randPassword := random.NewString(15)
+	randEmail := "random@random.com"
+
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+
+	t.Run("CreateNote OK", func(t *testing.T) {
+		args := db.CreateNoteParams{
+			ID:        id,
+			Title:     randTitle,
+			Username:  randUsername,
+			Text:      randText,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		mockdb.EXPECT().CreateNote(ctx, &args).Return(args.ID, nil)
+		retID, err := mockdb.CreateNote(ctx, &args)
+
+		assert.NoError(t, err)
+		assert.Equal(t, args.ID, retID)
+	})
+
+	t.Run("UpdateNote OK", func(t *testing.T) {
+		args := &db.UpdateNoteParams{
+			ID:        id,
+			Username:  randUsername,
+			Title:     sql.NullString{String: "updated title", Valid: true},
+			Text:      sql.NullString{String: "updated text", Valid: true},
+			UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			Version:   1,
+		}
+
+		mockdb.EXPECT().UpdateNote(ctx, args).Return(args.Version+1, nil)
+		version, err := mockdb.UpdateNote(ctx, args)
+
+		require.NoError(t, err)
+		require.Equal(t, int32(2), version)
+	})
+
+	t.Run("TrashNote OK", func(t *testing.T) {
+		args := &db.TrashNoteParams{ID: id, Username: randUsername, DeletedAt: time.Now()}
+
+		mockdb.EXPECT().TrashNote(ctx, args).Return(id, nil)
+		retID, err := mockdb.TrashNote(ctx, args)
+
+		assert.NoError(t, err)
+		assert.Equal(t, id, retID)
+	})
+
+	t.Run("GetAllNotesFromUser OK", func(t *testing.T) {
+		randomNotes := []db.Note{
+			*random.NewDBNote(uuid.New()),
+			*random.NewDBNote(uuid.New()),
+		}
+		randomNotes[0].Username = randUsername
+		randomNotes[1].Username = randUsername
+
+		mockdb.EXPECT().GetAllNotesFromUser(ctx, randUsername).Return(randomNotes, nil)
+		notes, err := mockdb.GetAllNotesFromUser(ctx, randUsername)
+
+		assert.NoError(t, err)
+		assert.Len(t, notes, 2)
+		assert.NotEmpty(t, notes[0].Title)
+		assert.NotEmpty(t, notes[1].Title)
+		assert.Equal(t, notes[0].Username, notes[1].Username)
+	})
+
+	t.Run("RegisterUser OK", func(t *testing.T) {
+		args := db.RegisterUserParams{
+			Username: randUsername,
+			Password: randPassword,
+			Email:    randEmail,
+		}
+
+		mockdb.EXPECT().RegisterUser(ctx, &args).Return(args.Username, nil)
+		user, err := mockdb.RegisterUser(ctx, &args)
+
+		require.NoError(t, err)
+		require.Equal(t, args.Username, user)
+	})
+
+	t.Run("GetUser OK", func(t *testing.T) {
+		mockdb.EXPECT().GetUser(ctx, randUsername).Return(db.User{Username: randUsername, Email: randEmail}, nil)
+		user, err := mockdb.GetUser(ctx, randUsername)
+
+		require.NoError(t, err)
+		require.Equal(t, randUsername, user.Username)
+	})
+}
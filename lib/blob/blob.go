@@ -0,0 +1,26 @@
+package blob
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob is not found")
+	ErrInvalidKey = errors.New("blob key is empty or not a relative slash separated path")
+)
+
+// Keys are relative paths like "user/note/attachment", both stores use them as is
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return false
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." || strings.ContainsRune(part, '\\') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,108 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Stores blobs as files under a root directory
+type LocalStore struct {
+	dir string
+}
+
+// Create the root directory if needed and return a store using it
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, errors.New("blob directory cannot be empty")
+	}
+
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the blob directory. %w", err)
+	}
+
+	return &LocalStore{dir: dir}, nil
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Write the blob to a temporary file first, so readers never see a partial blob
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("blob %s: wrote %d bytes, expected %d", key, n, size)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Open the blob and skip to offset
+func (s *LocalStore) Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,49 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(filepath.Join(dir, "blobs"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	content := "hello attachments"
+
+	err = store.Put(ctx, "user1/note/file", strings.NewReader(content), int64(len(content)), "text/plain")
+	require.NoError(t, err)
+
+	rc, err := store.Get(ctx, "user1/note/file", 6)
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	require.Equal(t, "attachments", string(data))
+
+	// a short upload leaves nothing behind
+	err = store.Put(ctx, "user1/note/short", strings.NewReader("abc"), 10, "text/plain")
+	require.Error(t, err)
+	entries, err := os.ReadDir(filepath.Join(dir, "blobs", "user1", "note"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	require.NoError(t, store.Delete(ctx, "user1/note/file"))
+	require.ErrorIs(t, store.Delete(ctx, "user1/note/file"), ErrNotFound)
+
+	_, err = store.Get(ctx, "user1/note/file", 0)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	for _, key := range []string{"", "/abs", "a/../../b", "a//b", "a/"} {
+		_, err = store.Get(ctx, key, 0)
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,240 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3Service         = "s3"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+)
+
+type S3Config struct {
+	Endpoint  string // e.g. http://localhost:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// Stores blobs in a bucket of an S3 compatible service (AWS S3, MinIO, ...).
+// Objects are addressed path style, so the bucket doesn't need a DNS name.
+type S3Store struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("S3 bucket and credentials cannot be empty")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+
+	return &S3Store{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{},
+		now:      time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// Get the object from offset to its end
+func (s *S3Store) Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+func (s *S3Store) newRequest(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	if !validKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Bucket + "/" + key
+	u.RawPath = ""
+
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// Sign and send the request, turn error responses into errors
+func (s *S3Store) do(req *http.Request) (*http.Response, error) {
+	// the body is streamed, so it's not part of the signature
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	signV4(req, s3UnsignedPayload, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("S3 %s %s: %s %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// Add the AWS Signature Version 4 Authorization header.
+// Host, Content-Type, Range and every X-Amz-* header are signed.
+func signV4(req *http.Request, payloadHash string, accessKey string, secretKey string, region string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format(s3TimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format(s3DateFormat), region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hexSHA256([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), t.Format(s3DateFormat))
+	for _, part := range []string{region, s3Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, accessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// URI encode everything but the unreserved characters, as SigV4 requires
+func s3Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
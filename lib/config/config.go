@@ -8,12 +8,26 @@ import (
 )
 
 type Config struct {
-	Environment         string        `mapstructure:"ENV"`
-	LogLevel            string        `mapstructure:"LOG_LEVEL"`
-	DBConnString        string        `mapstructure:"DB_CONN_STRING"`
-	HTTPServerAddress   string        `mapstructure:"HTTP_SERVER_ADDRESS"`
-	PASETOSecret        string        `mapstructure:"PASETO_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	Environment          string        `mapstructure:"ENV"`
+	LogLevel             string        `mapstructure:"LOG_LEVEL"`
+	DBConnString         string        `mapstructure:"DB_CONN_STRING"`
+	HTTPServerAddress    string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	GRPCServerAddress    string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	PASETOSecret         string        `mapstructure:"PASETO_SYMMETRIC_KEY"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	ShutdownTimeout      time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	NoteRevisionLimit    int           `mapstructure:"NOTE_REVISION_LIMIT"`
+	TrashRetention       time.Duration `mapstructure:"TRASH_RETENTION"`
+	TrashPurgeInterval   time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`
+	BlobStore            string        `mapstructure:"BLOB_STORE"`
+	BlobDir              string        `mapstructure:"BLOB_DIR"`
+	S3Endpoint           string        `mapstructure:"S3_ENDPOINT"`
+	S3Region             string        `mapstructure:"S3_REGION"`
+	S3Bucket             string        `mapstructure:"S3_BUCKET"`
+	S3AccessKey          string        `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey          string        `mapstructure:"S3_SECRET_KEY"`
+	AttachmentQuota      int64         `mapstructure:"ATTACHMENT_QUOTA"`
 }
 
 // Load reads configuration from file or environment variables.
@@ -22,6 +36,17 @@ func Load(path string) (config Config, err error) {
 	viper.SetConfigName("development")
 	viper.SetConfigType("env")
 
+	viper.SetDefault("GRPC_SERVER_ADDRESS", ":9090")
+	viper.SetDefault("SHUTDOWN_TIMEOUT", 10*time.Second)
+	viper.SetDefault("REFRESH_TOKEN_DURATION", 30*24*time.Hour)
+	viper.SetDefault("NOTE_REVISION_LIMIT", 50)
+	viper.SetDefault("TRASH_RETENTION", 30*24*time.Hour)
+	viper.SetDefault("TRASH_PURGE_INTERVAL", time.Hour)
+	viper.SetDefault("BLOB_STORE", "local")
+	viper.SetDefault("BLOB_DIR", "data/attachments")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("ATTACHMENT_QUOTA", 100<<20)
+
 	viper.AutomaticEnv()
 
 	err = viper.ReadInConfig()
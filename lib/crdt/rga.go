@@ -0,0 +1,249 @@
+// Package crdt holds a replicated text that replicas can edit concurrently and merge in any order.
+//
+// The text is an RGA (replicated growable array): every character is an element with an ID
+// that never changes, inserts name the element they follow, deletes only mark elements as
+// deleted. Replicas that applied the same ops hold the same text, whatever the order.
+package crdt
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var (
+	ErrInvalidOp      = errors.New("op is malformed")
+	ErrUnknownElement = errors.New("op refers to an element that doesn't exist")
+)
+
+// Identifies an element by the Lamport clock of the insert and the replica (site) that made it.
+// A replica has to pick a Seq above every Seq it has seen.
+type ID struct {
+	Seq  uint64 `json:"seq"`
+	Site string `json:"site"`
+}
+
+func (id ID) IsZero() bool {
+	return id.Seq == 0 && id.Site == ""
+}
+
+// Report whether id sorts before other in a run of concurrent inserts at the same place
+func (id ID) before(other ID) bool {
+	if id.Seq != other.Seq {
+		return id.Seq > other.Seq
+	}
+
+	return id.Site > other.Site
+}
+
+type OpType string
+
+const (
+	OpInsert OpType = "insert"
+	OpDelete OpType = "delete"
+)
+
+// One change of the text. An insert puts Value, a single character, right after the element
+// After, a zero After is the start of the text. A delete removes the element ID.
+type Op struct {
+	Type  OpType `json:"type"`
+	ID    ID     `json:"id"`
+	After ID     `json:"after"`
+	Value string `json:"value,omitempty"`
+}
+
+// One character of the text, deleted ones are kept so later ops can still refer to them
+type Element struct {
+	ID      ID     `json:"id"`
+	Value   string `json:"value"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+type node struct {
+	Element
+	next *node
+}
+
+// Replicated text
+type Doc struct {
+	head  node
+	nodes map[ID]*node
+	clock uint64
+}
+
+func New() *Doc {
+	return &Doc{nodes: make(map[ID]*node)}
+}
+
+// Start a doc from plain text, its characters are inserted by site one after another
+func FromText(site string, text string) *Doc {
+	d := New()
+
+	after := ID{}
+	for _, r := range text {
+		op := Op{Type: OpInsert, ID: d.NextID(site), After: after, Value: string(r)}
+		d.Apply(op)
+		after = op.ID
+	}
+
+	return d
+}
+
+// Return an unused ID for an insert of site
+func (d *Doc) NextID(site string) ID {
+	d.clock++
+	return ID{Seq: d.clock, Site: site}
+}
+
+// Apply op. Applying an op again has no effect.
+func (d *Doc) Apply(op Op) error {
+	switch op.Type {
+	case OpInsert:
+		return d.insert(op)
+	case OpDelete:
+		n, ok := d.nodes[op.ID]
+		if !ok {
+			return ErrUnknownElement
+		}
+		n.Deleted = true
+		return nil
+	default:
+		return ErrInvalidOp
+	}
+}
+
+func (d *Doc) insert(op Op) error {
+	if op.ID.IsZero() || utf8.RuneCountInString(op.Value) != 1 || !utf8.ValidString(op.Value) {
+		return ErrInvalidOp
+	}
+	if _, ok := d.nodes[op.ID]; ok {
+		return nil
+	}
+
+	p := &d.head
+	if !op.After.IsZero() {
+		var ok bool
+		if p, ok = d.nodes[op.After]; !ok {
+			return ErrUnknownElement
+		}
+	}
+
+	// concurrent inserts after the same element, and whatever follows them, stay in front of older ones
+	for p.next != nil && p.next.ID.before(op.ID) {
+		p = p.next
+	}
+
+	n := &node{Element: Element{ID: op.ID, Value: op.Value}, next: p.next}
+	p.next = n
+	d.nodes[op.ID] = n
+
+	if op.ID.Seq > d.clock {
+		d.clock = op.ID.Seq
+	}
+
+	return nil
+}
+
+// Number of elements, deleted ones included
+func (d *Doc) Len() int {
+	return len(d.nodes)
+}
+
+// Report whether the element exists, deleted or not
+func (d *Doc) Has(id ID) bool {
+	_, ok := d.nodes[id]
+	return ok
+}
+
+func (d *Doc) Text() string {
+	var b strings.Builder
+	for n := d.head.next; n != nil; n = n.next {
+		if !n.Deleted {
+			b.WriteString(n.Value)
+		}
+	}
+
+	return b.String()
+}
+
+// Every element in order, deleted ones included. Applying them as inserts in this order
+// gives another replica the same doc.
+func (d *Doc) Elements() []Element {
+	elems := make([]Element, 0, len(d.nodes))
+	for n := d.head.next; n != nil; n = n.next {
+		elems = append(elems, n.Element)
+	}
+
+	return elems
+}
+
+// The elements that make up the text
+func (d *Doc) Visible() []Element {
+	var elems []Element
+	for n := d.head.next; n != nil; n = n.next {
+		if !n.Deleted {
+			elems = append(elems, n.Element)
+		}
+	}
+
+	return elems
+}
+
+// Merge a change made outside of the doc. base are the visible elements at the time
+// the text was taken from the doc, text is what it was changed into. The difference is
+// applied as ops of site and returned, edits made to the doc since base are kept.
+// The elements that make up text are returned too, as the base of the next change.
+func (d *Doc) Rebase(site string, base []Element, text string) ([]Op, []Element) {
+	a := make([]string, len(base))
+	for i := range base {
+		a[i] = base[i].Value
+	}
+	b := strings.Split(text, "")
+
+	var (
+		ops     []Op
+		rebased []Element
+	)
+	apply := func(op Op) bool {
+		if d.Apply(op) != nil {
+			return false
+		}
+		ops = append(ops, op)
+		return true
+	}
+
+	m := difflib.NewMatcherWithJunk(a, b, false, nil)
+	for _, c := range m.GetOpCodes() {
+		after := ID{}
+		if c.I1 > 0 {
+			after = base[c.I1-1].ID
+		}
+
+		if c.Tag == 'e' {
+			rebased = append(rebased, base[c.I1:c.I2]...)
+		}
+
+		if c.Tag == 'd' || c.Tag == 'r' {
+			for _, e := range base[c.I1:c.I2] {
+				if !d.nodes[e.ID].Deleted {
+					apply(Op{Type: OpDelete, ID: e.ID})
+				}
+			}
+			after = base[c.I2-1].ID
+		}
+
+		if c.Tag == 'i' || c.Tag == 'r' {
+			for _, v := range b[c.J1:c.J2] {
+				op := Op{Type: OpInsert, ID: d.NextID(site), After: after, Value: v}
+				if apply(op) {
+					rebased = append(rebased, Element{ID: op.ID, Value: op.Value})
+					after = op.ID
+				}
+			}
+		}
+	}
+
+	return ops, rebased
+}
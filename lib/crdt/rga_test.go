@@ -0,0 +1,152 @@
+package crdt
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Ops of site typing text after the element after
+func typeText(d *Doc, site string, after ID, text string) []Op {
+	var ops []Op
+	for _, r := range text {
+		op := Op{Type: OpInsert, ID: d.NextID(site), After: after, Value: string(r)}
+		ops = append(ops, op)
+		after = op.ID
+	}
+
+	return ops
+}
+
+func applyAll(t *testing.T, d *Doc, ops []Op) {
+	for _, op := range ops {
+		require.NoError(t, d.Apply(op))
+	}
+}
+
+func TestDoc(t *testing.T) {
+	t.Run("from text", func(t *testing.T) {
+		d := FromText("base", "héllo")
+		require.Equal(t, "héllo", d.Text())
+		require.Equal(t, 5, d.Len())
+		require.Equal(t, ID{Seq: 6, Site: "a"}, d.NextID("a"))
+	})
+
+	t.Run("concurrent inserts at the same place converge", func(t *testing.T) {
+		base := FromText("base", "ac")
+		b := base.Elements()[0].ID
+
+		a1, a2 := FromText("base", "ac"), FromText("base", "ac")
+		ops1 := typeText(a1, "site1", b, "XY")
+		ops2 := typeText(a2, "site2", b, "12")
+		applyAll(t, a1, ops1)
+		applyAll(t, a2, ops2)
+
+		applyAll(t, a1, ops2)
+		applyAll(t, a2, ops1)
+		require.Equal(t, a1.Text(), a2.Text())
+		// runs typed concurrently are not interleaved
+		require.Contains(t, []string{"aXY12c", "a12XYc"}, a1.Text())
+	})
+
+	t.Run("deletes and duplicates", func(t *testing.T) {
+		d := FromText("base", "abc")
+		del := Op{Type: OpDelete, ID: d.Elements()[1].ID}
+		require.NoError(t, d.Apply(del))
+		require.NoError(t, d.Apply(del))
+		require.Equal(t, "ac", d.Text())
+
+		// inserting after a deleted element still works
+		ins := Op{Type: OpInsert, ID: d.NextID("a"), After: del.ID, Value: "x"}
+		require.NoError(t, d.Apply(ins))
+		require.NoError(t, d.Apply(ins))
+		require.Equal(t, "axc", d.Text())
+		require.Len(t, d.Visible(), 3)
+		require.Len(t, d.Elements(), 4)
+	})
+
+	t.Run("returns errors", func(t *testing.T) {
+		d := FromText("base", "a")
+		require.ErrorIs(t, d.Apply(Op{Type: OpDelete, ID: ID{Seq: 9, Site: "x"}}), ErrUnknownElement)
+		require.ErrorIs(t, d.Apply(Op{Type: OpInsert, ID: ID{Seq: 2, Site: "x"}, After: ID{Seq: 9, Site: "x"}, Value: "b"}), ErrUnknownElement)
+		require.ErrorIs(t, d.Apply(Op{Type: OpInsert, ID: ID{Seq: 2, Site: "x"}, Value: "bc"}), ErrInvalidOp)
+		require.ErrorIs(t, d.Apply(Op{Type: OpInsert, Value: "b"}), ErrInvalidOp)
+		require.ErrorIs(t, d.Apply(Op{Type: "move"}), ErrInvalidOp)
+	})
+
+	t.Run("random concurrent edits converge in any order", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+		sites := []string{"s1", "s2", "s3"}
+		docs := []*Doc{FromText("base", "shared"), FromText("base", "shared"), FromText("base", "shared")}
+
+		var all []Op
+		for i, d := range docs {
+			for n := 0; n < 50; n++ {
+				elems := d.Elements()
+				var op Op
+				if len(elems) > 0 && rnd.Intn(3) == 0 {
+					op = Op{Type: OpDelete, ID: elems[rnd.Intn(len(elems))].ID}
+				} else {
+					after := ID{}
+					if len(elems) > 0 {
+						after = elems[rnd.Intn(len(elems))].ID
+					}
+					op = Op{Type: OpInsert, ID: d.NextID(sites[i]), After: after, Value: string(rune('a' + rnd.Intn(26)))}
+				}
+				require.NoError(t, d.Apply(op))
+				all = append(all, op)
+			}
+		}
+
+		// replay the others' ops, inserts before the ops that depend on them
+		for _, d := range docs {
+			for _, op := range all {
+				require.NoError(t, d.Apply(op))
+			}
+		}
+		require.Equal(t, docs[0].Text(), docs[1].Text())
+		require.Equal(t, docs[0].Text(), docs[2].Text())
+	})
+}
+
+func TestRebase(t *testing.T) {
+	d := FromText("base", "hello world")
+	base := d.Visible()
+
+	// edits in the doc since base was taken
+	applyAll(t, d, typeText(d, "s1", base[len(base)-1].ID, "!"))
+	require.NoError(t, d.Apply(Op{Type: OpDelete, ID: base[0].ID}))
+	require.Equal(t, "ello world!", d.Text())
+
+	// meanwhile the saved text was changed elsewhere
+	ops, rebased := d.Rebase("server", base, "hello brave world")
+	require.Equal(t, "ello brave world!", d.Text())
+
+	var b strings.Builder
+	for _, e := range rebased {
+		b.WriteString(e.Value)
+	}
+	require.Equal(t, "hello brave world", b.String())
+
+	// a second change elsewhere is merged against the first one
+	d.Rebase("server", rebased, "hello brave new world")
+	require.Equal(t, "ello brave new world!", d.Text())
+
+	// the returned ops bring other replicas along
+	other := FromText("base", "hello world")
+	applyAll(t, other, typeText(other, "s1", base[len(base)-1].ID, "!"))
+	require.NoError(t, other.Apply(Op{Type: OpDelete, ID: base[0].ID}))
+	applyAll(t, other, ops)
+	require.Equal(t, "ello brave world!", other.Text())
+
+	t.Run("deleting everything", func(t *testing.T) {
+		d := FromText("base", "abc")
+		_, rebased := d.Rebase("server", d.Visible(), "")
+		require.Equal(t, "", d.Text())
+		require.Empty(t, rebased)
+		d.Rebase("server", rebased, "new")
+		require.Equal(t, "new", d.Text())
+	})
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type string
+
+const (
+	NoteCreated Type = "note.created"
+	NoteUpdated Type = "note.updated"
+	NoteDeleted Type = "note.deleted"
+)
+
+// Change of a note, delivered to the clients of the user it belongs to
+type Event struct {
+	ID       uuid.UUID `json:"id"`
+	Type     Type      `json:"type"`
+	NoteID   uuid.UUID `json:"noteId"`
+	Username string    `json:"username"`
+	// Version of the note after the change, 0 when it isn't known
+	Version int32     `json:"version,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// How many events may wait for a slow subscriber before it is dropped
+const DefaultBufferSize = 64
+
+// Stream of the events of one user. C is closed when the subscription is
+// closed or dropped for falling behind, the client should resync then.
+type Subscription struct {
+	C <-chan Event
+
+	ch       chan Event
+	bus      *Bus
+	username string
+}
+
+// Stop receiving events, safe to call more than once
+func (s *Subscription) Close() {
+	s.bus.remove(s)
+}
+
+// In-process fan-out of events to the subscriptions of each user
+type Bus struct {
+	mu         sync.Mutex
+	subs       map[string]map[*Subscription]struct{}
+	bufferSize int
+}
+
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &Bus{
+		subs:       make(map[string]map[*Subscription]struct{}),
+		bufferSize: bufferSize,
+	}
+}
+
+// Receive the events of username until the subscription is closed
+func (b *Bus) Subscribe(username string) *Subscription {
+	ch := make(chan Event, b.bufferSize)
+	s := &Subscription{C: ch, ch: ch, bus: b, username: username}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subs[username] == nil {
+		b.subs[username] = make(map[*Subscription]struct{})
+	}
+	b.subs[username][s] = struct{}{}
+
+	return s
+}
+
+// Deliver e to the subscriptions of its user. Never blocks, a subscription
+// with a full buffer is dropped rather than slowing down the publisher.
+func (b *Bus) Publish(_ context.Context, e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs[e.Username] {
+		select {
+		case s.ch <- e:
+		default:
+			b.removeLocked(s)
+		}
+	}
+}
+
+func (b *Bus) remove(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.removeLocked(s)
+}
+
+func (b *Bus) removeLocked(s *Subscription) {
+	subs, ok := b.subs[s.username]
+	if !ok {
+		return
+	}
+	if _, ok := subs[s]; !ok {
+		return
+	}
+
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(b.subs, s.username)
+	}
+	close(s.ch)
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("delivers events to the subscriptions of their user", func(t *testing.T) {
+		bus := NewBus(4)
+		sub1 := bus.Subscribe("user1")
+		sub2 := bus.Subscribe("user1")
+		other := bus.Subscribe("user2")
+
+		e := Event{ID: uuid.New(), Type: NoteCreated, NoteID: uuid.New(), Username: "user1"}
+		bus.Publish(context.Background(), e)
+
+		require.Equal(t, e, <-sub1.C)
+		require.Equal(t, e, <-sub2.C)
+		require.Empty(t, other.C)
+	})
+
+	t.Run("closing stops delivery", func(t *testing.T) {
+		bus := NewBus(4)
+		sub := bus.Subscribe("user1")
+		sub.Close()
+		sub.Close()
+
+		bus.Publish(context.Background(), Event{Username: "user1"})
+
+		_, ok := <-sub.C
+		require.False(t, ok)
+		require.Empty(t, bus.subs)
+	})
+
+	t.Run("drops a subscription that falls behind", func(t *testing.T) {
+		bus := NewBus(1)
+		slow := bus.Subscribe("user1")
+
+		bus.Publish(context.Background(), Event{Type: NoteCreated, Username: "user1"})
+		bus.Publish(context.Background(), Event{Type: NoteUpdated, Username: "user1"})
+
+		e, ok := <-slow.C
+		require.True(t, ok)
+		require.Equal(t, NoteCreated, e.Type)
+		_, ok = <-slow.C
+		require.False(t, ok)
+
+		// a new subscription still works
+		sub := bus.Subscribe("user1")
+		bus.Publish(context.Background(), Event{Type: NoteDeleted, Username: "user1"})
+		require.Equal(t, NoteDeleted, (<-sub.C).Type)
+	})
+}
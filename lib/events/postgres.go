@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+// Postgres channel the replicas exchange events on
+const pgChannel = "note_events"
+
+const (
+	pgMinReconnect = 100 * time.Millisecond
+	pgMaxReconnect = time.Minute
+	// an idle LISTEN connection may be dropped silently, ping it now and then
+	pgPingInterval = time.Minute
+)
+
+// Sends a NOTIFY on the events channel, implemented by the DB returned from db.NewSQL
+type Notifier interface {
+	NotifyNoteEvent(ctx context.Context, payload string) error
+}
+
+// Event as it travels between replicas
+type pgEvent struct {
+	Origin string `json:"origin"`
+	Event  Event  `json:"event"`
+}
+
+// Shares the events of a Bus with the other server replicas through Postgres LISTEN/NOTIFY.
+// Local subscribers get events at once, the ones from other replicas after a round trip through the DB.
+type PGRelay struct {
+	bus      *Bus
+	notifier Notifier
+	connStr  string
+	origin   string
+	logger   *zerolog.Logger
+}
+
+func NewPGRelay(bus *Bus, notifier Notifier, connStr string, l *zerolog.Logger) *PGRelay {
+	return &PGRelay{
+		bus:      bus,
+		notifier: notifier,
+		connStr:  connStr,
+		origin:   uuid.NewString(),
+		logger:   l,
+	}
+}
+
+func (r *PGRelay) Subscribe(username string) *Subscription {
+	return r.bus.Subscribe(username)
+}
+
+// Deliver e locally and notify the other replicas. A failed NOTIFY is only
+// logged, the change itself has been committed already.
+func (r *PGRelay) Publish(ctx context.Context, e Event) {
+	r.bus.Publish(ctx, e)
+
+	payload, err := json.Marshal(pgEvent{Origin: r.origin, Event: e})
+	if err != nil {
+		r.logger.Error().Err(err).Msgf("could not encode event %v. %v", e.ID, err)
+		return
+	}
+
+	err = r.notifier.NotifyNoteEvent(ctx, string(payload))
+	if err != nil {
+		r.logger.Error().Err(err).Msgf("could not notify the other replicas of event %v. %v", e.ID, err)
+	}
+}
+
+// Hand an event from another replica to the local subscribers
+func (r *PGRelay) receive(payload string) {
+	var pe pgEvent
+	err := json.Unmarshal([]byte(payload), &pe)
+	if err != nil {
+		r.logger.Error().Err(err).Msgf("could not decode a note event from the DB. %v", err)
+		return
+	}
+
+	// delivered locally when it was published
+	if pe.Origin == r.origin {
+		return
+	}
+
+	r.bus.Publish(context.Background(), pe.Event)
+}
+
+// Listen for the events of the other replicas until ctx is done
+func (r *PGRelay) Run(ctx context.Context) error {
+	listener := pq.NewListener(r.connStr, pgMinReconnect, pgMaxReconnect, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			r.logger.Error().Err(err).Msgf("lost the connection listening for note events. %v", err)
+		case pq.ListenerEventReconnected:
+			r.logger.Info().Msg("listening for note events again, events sent meanwhile are lost")
+		case pq.ListenerEventConnectionAttemptFailed:
+			r.logger.Error().Err(err).Msgf("could not reconnect to listen for note events. %v", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(pgChannel)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(pgPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			// nil after a reconnect
+			if n != nil {
+				r.receive(n.Extra)
+			}
+		case <-ticker.C:
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type notifierFunc func(ctx context.Context, payload string) error
+
+func (f notifierFunc) NotifyNoteEvent(ctx context.Context, payload string) error {
+	return f(ctx, payload)
+}
+
+func TestPGRelay(t *testing.T) {
+	l := zerolog.Nop()
+	e := Event{ID: uuid.New(), Type: NoteUpdated, NoteID: uuid.New(), Username: "user1", Version: 3}
+
+	t.Run("publishing delivers locally and notifies the other replicas", func(t *testing.T) {
+		var sent string
+		relay := NewPGRelay(NewBus(4), notifierFunc(func(_ context.Context, payload string) error {
+			sent = payload
+			return nil
+		}), "", &l)
+		sub := relay.Subscribe("user1")
+
+		relay.Publish(context.Background(), e)
+		require.Equal(t, e, <-sub.C)
+
+		var pe pgEvent
+		require.NoError(t, json.Unmarshal([]byte(sent), &pe))
+		require.Equal(t, relay.origin, pe.Origin)
+		require.Equal(t, e.ID, pe.Event.ID)
+
+		// its own notification comes back from the DB and is skipped
+		relay.receive(sent)
+		require.Empty(t, sub.C)
+	})
+
+	t.Run("a failed notify still delivers locally", func(t *testing.T) {
+		relay := NewPGRelay(NewBus(4), notifierFunc(func(context.Context, string) error {
+			return errors.New("connection refused")
+		}), "", &l)
+		sub := relay.Subscribe("user1")
+
+		relay.Publish(context.Background(), e)
+		require.Equal(t, e, <-sub.C)
+	})
+
+	t.Run("receiving delivers the events of other replicas", func(t *testing.T) {
+		relay := NewPGRelay(NewBus(4), notifierFunc(func(context.Context, string) error { return nil }), "", &l)
+		sub := relay.Subscribe("user1")
+
+		payload, err := json.Marshal(pgEvent{Origin: uuid.NewString(), Event: e})
+		require.NoError(t, err)
+
+		relay.receive("not json")
+		relay.receive(string(payload))
+
+		got := <-sub.C
+		require.Equal(t, e.ID, got.ID)
+		require.Equal(t, e.Version, got.Version)
+		require.Empty(t, sub.C)
+	})
+}
@@ -36,6 +36,7 @@ func SetCookie(w http.ResponseWriter, name string, token string, expiresAt time.
 	http.SetCookie(w, &http.Cookie{
 		Name: name,
 		Value: token,
+		Path: "/",
 		Expires: expiresAt,
 		HttpOnly: true,
 		Secure: true,
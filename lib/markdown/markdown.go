@@ -0,0 +1,58 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+var (
+	// CommonMark with the GitHub extensions: tables, task lists, strikethrough and autolinks.
+	// Table alignment is an attribute, the policy drops inline styles.
+	// Raw HTML is passed through and removed by the policy below.
+	md = goldmark.New(
+		goldmark.WithExtensions(
+			extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+			extension.Strikethrough,
+			extension.Linkify,
+			extension.TaskList,
+		),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+
+	policy = newPolicy()
+)
+
+// User generated content policy extended with what the GFM output needs
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// language of fenced code blocks
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+
+	// task list items
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^$|^checked$|^disabled$`)).OnElements("input")
+
+	// table cell alignment
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+
+	return p
+}
+
+// Render Markdown to HTML that is safe to embed in a page
+func Render(src string) (string, error) {
+	var buf bytes.Buffer
+
+	err := md.Convert([]byte(src), &buf)
+	if err != nil {
+		return "", fmt.Errorf("could not render markdown! %v", err)
+	}
+
+	return policy.Sanitize(buf.String()), nil
+}
@@ -0,0 +1,85 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		name        string
+		src         string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:     "commonmark",
+			src:      "# Title\n\nSome *emphasis* and a [link](https://example.com).",
+			contains: []string{"<h1>Title</h1>", "<em>emphasis</em>", `<a href="https://example.com" rel="nofollow">link</a>`},
+		},
+		{
+			name:     "table",
+			src:      "| a | b |\n|:--|--:|\n| 1 | 2 |",
+			contains: []string{"<table>", `<th align="left">a</th>`, `<td align="right">2</td>`},
+		},
+		{
+			name:     "task list",
+			src:      "- [x] done\n- [ ] todo",
+			contains: []string{`<input checked="" disabled="" type="checkbox"> done`, `<input disabled="" type="checkbox"> todo`},
+		},
+		{
+			name:     "fenced code",
+			src:      "```go\nfmt.Println(\"<hi>\")\n```",
+			contains: []string{`<code class="language-go">`, "&lt;hi&gt;"},
+		},
+		{
+			name:     "strikethrough and autolink",
+			src:      "~~old~~ see https://example.com",
+			contains: []string{"<del>old</del>", `<a href="https://example.com" rel="nofollow">`},
+		},
+		{
+			name:        "script is removed",
+			src:         "hello <script>alert(1)</script>",
+			contains:    []string{"hello"},
+			notContains: []string{"<script", "alert(1)"},
+		},
+		{
+			name:        "event handlers are removed",
+			src:         `<img src="x.png" onerror="alert(1)"> <a href="#" onclick="alert(1)">x</a>`,
+			contains:    []string{`<img src="x.png">`},
+			notContains: []string{"onerror", "onclick"},
+		},
+		{
+			name:        "javascript links are removed",
+			src:         "[click](javascript:alert(1)) <a href=\"javascript:alert(1)\">raw</a>",
+			notContains: []string{"javascript:"},
+		},
+		{
+			name:        "inputs other than checkboxes are removed",
+			src:         `<input type="text" value="x"> <form action="/x"><button>go</button></form>`,
+			notContains: []string{"<input", "<form", "<button"},
+		},
+		{
+			name:        "iframes and styles are removed",
+			src:         `<iframe src="https://evil.example"></iframe><style>body{}</style><p style="color:red">p</p>`,
+			notContains: []string{"<iframe", "<style", "style="},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Render(tc.src)
+			require.NoError(t, err)
+
+			for _, s := range tc.contains {
+				require.Contains(t, got, s)
+			}
+			for _, s := range tc.notContains {
+				require.NotContains(t, got, s)
+			}
+		})
+	}
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/alekslesik/online-note-z/db"
 	"github.com/alekslesik/online-note-z/db/migrations"
+	"github.com/alekslesik/online-note-z/lib/blob"
 	"github.com/alekslesik/online-note-z/lib/config"
+	"github.com/alekslesik/online-note-z/lib/events"
 	logger "github.com/alekslesik/online-note-z/lib/logger"
 	"github.com/alekslesik/online-note-z/note"
+	grpcserver "github.com/alekslesik/online-note-z/server/grpc"
 	server "github.com/alekslesik/online-note-z/server/http"
 )
 
@@ -17,39 +23,147 @@ func main() {
 	if err != nil {
 		log.Fatalf("Could not load config. %v", err)
 	}
-	
+
 	// Set logger
 	l := logger.New(cfg.LogLevel)
 
+	// Run migrations independently of the server: app migrate up|down|status|goto N
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(cfg.DBConnString, os.Args[2:], &l)
+		if err != nil {
+			l.Fatal().Err(err).Send()
+		}
+		return
+	}
+
 	// Set data base
 	sqldb, err := db.NewSQL("postgres", cfg.DBConnString, &l)
 	if err != nil {
 		l.Fatal().Err(err).Send()
 	}
 
-	// Do migrations	
-	err = migrations.MigrateDB(cfg.DBConnString, "file://db/migrations/", &l)
+	// Do migrations
+	err = migrations.MigrateDB(cfg.DBConnString, &l)
+	if err != nil {
+		l.Fatal().Err(err).Send()
+	}
+
+	// Set storage of attachment contents
+	blobs, err := newBlobStore(cfg)
 	if err != nil {
 		l.Fatal().Err(err).Send()
 	}
 
-	s := note.NewService(sqldb)
+	// Share note events with the other replicas through Postgres LISTEN/NOTIFY
+	relay := events.NewPGRelay(events.NewBus(events.DefaultBufferSize), sqldb, cfg.DBConnString, &l)
+
+	s := note.NewService(sqldb,
+		note.WithRevisionLimit(cfg.NoteRevisionLimit),
+		note.WithBlobStore(blobs),
+		note.WithAttachmentQuota(cfg.AttachmentQuota),
+		note.WithEvents(relay),
+		note.WithLogger(&l),
+	)
 
 	// Set router
-	r, err := server.NewChiRouter(s, cfg.PASETOSecret, cfg.AccessTokenDuration, &l)
+	r, err := server.NewChiRouter(s, cfg.PASETOSecret, cfg.AccessTokenDuration, cfg.RefreshTokenDuration, &l)
 	if err != nil {
 		l.Fatal().Err(err).Send()
 	}
 
 	// Set server
-	httpServer, err := server.NewHTTP(r, cfg.HTTPServerAddress, &l)
+	httpServer, err := server.NewHTTP(r, cfg.HTTPServerAddress, cfg.ShutdownTimeout, &l)
 	if err != nil {
 		l.Fatal().Err(err).Send()
 	}
 
-	// Shutdown server
-	err = httpServer.Shutdown()
+	// Serve the same API over gRPC on its own address
+	grpcServer, err := grpcserver.NewGRPC(s, cfg.PASETOSecret, cfg.AccessTokenDuration, cfg.RefreshTokenDuration, cfg.GRPCServerAddress, cfg.ShutdownTimeout, &l)
 	if err != nil {
 		l.Fatal().Err(err).Send()
 	}
+
+	// Permanently delete old notes from the trash in the background
+	purgeCtx, stopPurger := context.WithCancel(context.Background())
+	purgerDone := make(chan struct{})
+	go func() {
+		defer close(purgerDone)
+		s.RunTrashPurger(purgeCtx, cfg.TrashRetention, cfg.TrashPurgeInterval, &l)
+	}()
+
+	// Deliver the events of the other replicas to this one's subscribers
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		err := relay.Run(relayCtx)
+		if err != nil {
+			l.Error().Err(err).Msgf("stopped listening for note events of other replicas. %v", err)
+		}
+	}()
+
+	grpcCtx, stopGRPC := context.WithCancel(context.Background())
+	grpcDone := make(chan struct{})
+	go func() {
+		defer close(grpcDone)
+		err := grpcServer.Run(grpcCtx)
+		if err != nil {
+			l.Error().Err(err).Msgf("gRPC server stopped. %v", err)
+		}
+	}()
+
+	// Serve until SIGINT/SIGTERM, then drain in-flight requests
+	runErr := httpServer.Run(context.Background())
+	stopGRPC()
+	<-grpcDone
+
+	// Co-edited notes are saved once their last participant is gone
+	collabCtx, cancelCollab := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	err = s.WaitCollab(collabCtx)
+	cancelCollab()
+	if err != nil {
+		l.Error().Err(err).Msg("could not save all co-edited notes in time")
+	}
+
+	// The purger and the relay use the DB too, wait for them before closing the pool
+	stopPurger()
+	<-purgerDone
+	stopRelay()
+	<-relayDone
+
+	// Close the DB pool only after the listener has stopped
+	err = sqldb.Close()
+	if err != nil {
+		l.Error().Err(err).Msg("could not close the DB connection pool")
+	}
+
+	if runErr != nil {
+		l.Fatal().Err(runErr).Send()
+	}
+}
+
+// Pick the attachment storage, BLOB_STORE is either local or s3
+func newBlobStore(cfg config.Config) (note.BlobStore, error) {
+	switch cfg.BlobStore {
+	case "", "local":
+		store, err := blob.NewLocalStore(cfg.BlobDir)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "s3":
+		store, err := blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q, expected local or s3", cfg.BlobStore)
+	}
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/alekslesik/online-note-z/db/migrations"
+	"github.com/rs/zerolog"
+)
+
+const migrateUsage = "usage: migrate up|down|status|goto N"
+
+var ErrMigrateUsage = errors.New(migrateUsage)
+
+// Run the migrate subcommand: migrate up|down|status|goto N
+func runMigrate(dbURL string, args []string, l *zerolog.Logger) error {
+	if len(args) == 0 {
+		return ErrMigrateUsage
+	}
+
+	// validate the arguments before touching the DB
+	var version uint64
+	switch args[0] {
+	case "up", "down", "status":
+		if len(args) != 1 {
+			return ErrMigrateUsage
+		}
+	case "goto":
+		if len(args) != 2 {
+			return ErrMigrateUsage
+		}
+		v, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid migration version %q. %w", args[1], ErrMigrateUsage)
+		}
+		version = v
+	default:
+		return ErrMigrateUsage
+	}
+
+	mg, err := migrations.New(dbURL, l)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	switch args[0] {
+	case "up":
+		return mg.Up()
+	case "down":
+		return mg.Down()
+	case "goto":
+		return mg.Goto(uint(version))
+	default:
+		v, dirty, err := mg.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %t\n", v, dirty)
+		return nil
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMigrateUsage(t *testing.T) {
+	l := zerolog.New(io.Discard)
+
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{name: "no subcommand", args: []string{}},
+		{name: "unknown subcommand", args: []string{"sideways"}},
+		{name: "up with extra args", args: []string{"up", "2"}},
+		{name: "goto without version", args: []string{"goto"}},
+		{name: "goto with invalid version", args: []string{"goto", "abc"}},
+		{name: "goto with negative version", args: []string{"goto", "-1"}},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := runMigrate("postgresql://user:pw@localhost:5432/notes", tc.args, &l)
+			require.ErrorIs(t, err, ErrMigrateUsage)
+		})
+	}
+}
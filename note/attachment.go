@@ -0,0 +1,291 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/blob"
+	"github.com/google/uuid"
+)
+
+const (
+	maxFilenameLength  = 255
+	defaultContentType = "application/octet-stream"
+)
+
+// Storage of attachment contents, implemented by blob.LocalStore and blob.S3Store
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Keep only the base name of an uploaded file
+func normalizeFilename(name string) (string, error) {
+	name = strings.TrimSpace(strings.ReplaceAll(name, "\\", "/"))
+	if strings.HasSuffix(name, "/") {
+		return "", ErrInvalidAttachment
+	}
+
+	name = strings.TrimSpace(path.Base(name))
+	if name == "" || name == "." || name == ".." || len(name) > maxFilenameLength || !utf8.ValidString(name) {
+		return "", ErrInvalidAttachment
+	}
+
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f {
+			return "", ErrInvalidAttachment
+		}
+	}
+
+	return name, nil
+}
+
+// Keep the media type and its parameters, fall back to a generic binary type
+func normalizeContentType(contentType string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return defaultContentType
+	}
+
+	return mime.FormatMediaType(mediaType, params)
+}
+
+func attachmentKey(username string, noteID uuid.UUID, id uuid.UUID) string {
+	return username + "/" + noteID.String() + "/" + id.String()
+}
+
+// Upload the content of an attachment and add it to the note
+func (s *service) AddAttachment(ctx context.Context, username string, noteID uuid.UUID, filename string, contentType string, size int64, r io.Reader) (db.Attachment, error) {
+	if s.blobs == nil {
+		return db.Attachment{}, ErrStorageInternal
+	}
+
+	filename, err := normalizeFilename(filename)
+	if err != nil {
+		return db.Attachment{}, err
+	}
+	contentType = normalizeContentType(contentType)
+
+	_, err = s.q.GetNote(ctx, &db.GetNoteParams{ID: noteID, Username: username})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return db.Attachment{}, ErrNotFound
+	case err != nil:
+		return db.Attachment{}, ErrDBInternal
+	}
+
+	// don't upload what can't be stored anyway, CreateAttachment checks the quota again
+	if s.attachmentQuota > 0 {
+		used, err := s.q.GetAttachmentUsage(ctx, username)
+		if err != nil {
+			return db.Attachment{}, ErrDBInternal
+		}
+		if used+size > s.attachmentQuota {
+			return db.Attachment{}, ErrQuotaExceeded
+		}
+	}
+
+	id := uuid.New()
+	key := attachmentKey(username, noteID, id)
+
+	err = s.blobs.Put(ctx, key, r, size, contentType)
+	if err != nil {
+		return db.Attachment{}, ErrStorageInternal
+	}
+
+	a, err := s.q.CreateAttachment(ctx, &db.CreateAttachmentParams{
+		ID:          id,
+		NoteID:      noteID,
+		Username:    username,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		StorageKey:  key,
+		CreatedAt:   time.Now(),
+		Quota:       s.attachmentQuota,
+	})
+	if err != nil {
+		// the blob is useless without its row
+		s.blobs.Delete(ctx, key)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Attachment{}, ErrQuotaExceeded
+		}
+		return db.Attachment{}, ErrDBInternal
+	}
+
+	return a, nil
+}
+
+// Return the attachments of a note, oldest first
+func (s *service) ListAttachments(ctx context.Context, username string, noteID uuid.UUID) ([]db.Attachment, error) {
+	attachments, err := s.q.ListAttachments(ctx, &db.ListAttachmentsParams{NoteID: noteID, Username: username})
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	// tell a note without attachments from a missing one
+	if len(attachments) == 0 {
+		_, err = s.q.GetNote(ctx, &db.GetNoteParams{ID: noteID, Username: username})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		case err != nil:
+			return nil, ErrDBInternal
+		}
+	}
+
+	return attachments, nil
+}
+
+// Return an attachment together with a seekable reader of its content, the reader must be closed
+func (s *service) OpenAttachment(ctx context.Context, username string, noteID uuid.UUID, id uuid.UUID) (db.Attachment, io.ReadSeekCloser, error) {
+	if s.blobs == nil {
+		return db.Attachment{}, nil, ErrStorageInternal
+	}
+
+	a, err := s.q.GetAttachment(ctx, &db.GetAttachmentParams{ID: id, NoteID: noteID, Username: username})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return db.Attachment{}, nil, ErrAttachmentNotFound
+	case err != nil:
+		return db.Attachment{}, nil, ErrDBInternal
+	}
+
+	// open it right away, so a missing blob is reported before anything is sent
+	rc, err := s.blobs.Get(ctx, a.StorageKey, 0)
+	switch {
+	case errors.Is(err, blob.ErrNotFound):
+		return db.Attachment{}, nil, ErrAttachmentNotFound
+	case err != nil:
+		return db.Attachment{}, nil, ErrStorageInternal
+	}
+
+	return a, &blobReader{ctx: ctx, store: s.blobs, key: a.StorageKey, size: a.Size, rc: rc}, nil
+}
+
+// Delete an attachment of a note together with its content
+func (s *service) DeleteAttachment(ctx context.Context, username string, noteID uuid.UUID, id uuid.UUID) error {
+	if s.blobs == nil {
+		return ErrStorageInternal
+	}
+
+	err := s.execTx(ctx, func(q db.Querier) error {
+		key, err := q.DeleteAttachment(ctx, &db.DeleteAttachmentParams{ID: id, NoteID: noteID, Username: username})
+		if err != nil {
+			return err
+		}
+
+		// the row is kept if the content can't be deleted
+		err = s.blobs.Delete(ctx, key)
+		if err != nil && !errors.Is(err, blob.ErrNotFound) {
+			return ErrStorageInternal
+		}
+
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrAttachmentNotFound
+	case errors.Is(err, ErrStorageInternal):
+		return err
+	case err != nil:
+		return ErrDBInternal
+	default:
+		return nil
+	}
+}
+
+// Delete the contents of attachments whose rows are already gone, return the first failure
+func (s *service) deleteBlobs(ctx context.Context, keys []string) error {
+	if s.blobs == nil {
+		return nil
+	}
+
+	var firstErr error
+	for _, key := range keys {
+		err := s.blobs.Delete(ctx, key)
+		if err != nil && !errors.Is(err, blob.ErrNotFound) && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Seekable view of a blob for range requests.
+// A seek is free, the blob is reopened at the new offset on the next read.
+type blobReader struct {
+	ctx   context.Context
+	store BlobStore
+	key   string
+	size  int64
+	pos   int64
+	rc    io.ReadCloser
+	rcPos int64
+}
+
+func (b *blobReader) Read(p []byte) (int, error) {
+	if b.pos >= b.size {
+		return 0, io.EOF
+	}
+
+	if b.rc != nil && b.rcPos != b.pos {
+		b.rc.Close()
+		b.rc = nil
+	}
+
+	if b.rc == nil {
+		rc, err := b.store.Get(b.ctx, b.key, b.pos)
+		if err != nil {
+			return 0, err
+		}
+		b.rc, b.rcPos = rc, b.pos
+	}
+
+	n, err := b.rc.Read(p)
+	b.pos += int64(n)
+	b.rcPos += int64(n)
+
+	return n, err
+}
+
+func (b *blobReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.pos
+	case io.SeekEnd:
+		offset += b.size
+	default:
+		return 0, errors.New("blob reader: invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("blob reader: negative position")
+	}
+	b.pos = offset
+
+	return offset, nil
+}
+
+func (b *blobReader) Close() error {
+	if b.rc == nil {
+		return nil
+	}
+
+	err := b.rc.Close()
+	b.rc = nil
+
+	return err
+}
@@ -0,0 +1,327 @@
+package note
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/blob"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// In-memory BlobStore, counts Get calls to check that seeking is lazy
+type memBlobStore struct {
+	mu        sync.Mutex
+	objects   map[string][]byte
+	gets      int
+	putErr    error
+	deleteErr error
+}
+
+func newMemBlobStore(keys ...string) *memBlobStore {
+	m := &memBlobStore{objects: map[string][]byte{}}
+	for _, key := range keys {
+		m.objects[key] = []byte(key)
+	}
+	return m
+}
+
+func (m *memBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if m.putErr != nil {
+		return m.putErr
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memBlobStore) Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.gets++
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, blob.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data[offset:])), nil
+}
+
+func (m *memBlobStore) Delete(ctx context.Context, key string) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[key]; !ok {
+		return blob.ErrNotFound
+	}
+	delete(m.objects, key)
+	return nil
+}
+
+func TestNormalizeFilename(t *testing.T) {
+	testCases := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr error
+	}{
+		{name: "plain name", in: " report.pdf ", want: "report.pdf"},
+		{name: "strips unix directories", in: "../../etc/passwd", want: "passwd"},
+		{name: "strips windows directories", in: `C:\Users\me\photo.png`, want: "photo.png"},
+		{name: "empty", in: "  ", wantErr: ErrInvalidAttachment},
+		{name: "directory only", in: "dir/", wantErr: ErrInvalidAttachment},
+		{name: "control characters", in: "a\nb.txt", wantErr: ErrInvalidAttachment},
+		{name: "too long", in: strings.Repeat("a", maxFilenameLength+1), wantErr: ErrInvalidAttachment},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := normalizeFilename(tc.in)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAddAttachment(t *testing.T) {
+	const username = "user1"
+	noteID := uuid.New()
+	content := "hello"
+
+	testCases := []struct {
+		name        string
+		filename    string
+		quota       int64
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+		wantBlobs   int
+	}{
+		{
+			name:     "adding attachment OK",
+			filename: "hello.txt",
+			quota:    100,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: noteID, Username: username}).Times(1).Return(db.Note{ID: noteID}, nil)
+				mockdb.EXPECT().GetAttachmentUsage(gomock.Any(), username).Times(1).Return(int64(10), nil)
+				mockdb.EXPECT().CreateAttachment(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.CreateAttachmentParams) (db.Attachment, error) {
+						require.Equal(t, "hello.txt", arg.Filename)
+						require.Equal(t, "text/plain; charset=utf-8", arg.ContentType)
+						require.Equal(t, int64(len(content)), arg.Size)
+						require.Equal(t, int64(100), arg.Quota)
+						require.Equal(t, attachmentKey(username, noteID, arg.ID), arg.StorageKey)
+						return db.Attachment{ID: arg.ID, StorageKey: arg.StorageKey}, nil
+					})
+			},
+			wantBlobs: 1,
+		},
+		{
+			name:     "returns ErrNotFound - note of another user",
+			filename: "hello.txt",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(db.Note{}, sql.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:     "returns ErrQuotaExceeded before uploading",
+			filename: "hello.txt",
+			quota:    100,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(db.Note{ID: noteID}, nil)
+				mockdb.EXPECT().GetAttachmentUsage(gomock.Any(), username).Times(1).Return(int64(98), nil)
+			},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:     "returns ErrQuotaExceeded - concurrent upload took the space",
+			filename: "hello.txt",
+			quota:    100,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(db.Note{ID: noteID}, nil)
+				mockdb.EXPECT().GetAttachmentUsage(gomock.Any(), username).Times(1).Return(int64(10), nil)
+				mockdb.EXPECT().CreateAttachment(gomock.Any(), gomock.Any()).Times(1).Return(db.Attachment{}, sql.ErrNoRows)
+			},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:     "returns ErrInvalidAttachment",
+			filename: "",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+			},
+			wantErr: ErrInvalidAttachment,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			blobs := newMemBlobStore()
+			ns := NewService(mockdb, WithBlobStore(blobs), WithAttachmentQuota(tc.quota))
+
+			tc.mockdbCalls(mockdb)
+			_, err := ns.AddAttachment(context.Background(), username, noteID, tc.filename, "text/plain; charset=utf-8", int64(len(content)), strings.NewReader(content))
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+			require.Len(t, blobs.objects, tc.wantBlobs)
+		})
+	}
+}
+
+func TestOpenAttachment(t *testing.T) {
+	const username = "user1"
+	noteID, id := uuid.New(), uuid.New()
+	key := attachmentKey(username, noteID, id)
+	args := &db.GetAttachmentParams{ID: id, NoteID: noteID, Username: username}
+
+	t.Run("reading ranges OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		blobs := newMemBlobStore()
+		blobs.objects[key] = []byte("0123456789")
+		ns := NewService(mockdb, WithBlobStore(blobs))
+
+		mockdb.EXPECT().GetAttachment(gomock.Any(), args).Times(1).Return(db.Attachment{ID: id, StorageKey: key, Size: 10}, nil)
+
+		_, content, err := ns.OpenAttachment(context.Background(), username, noteID, id)
+		require.NoError(t, err)
+		defer content.Close()
+
+		// what http.ServeContent does: find the size, go back and read
+		end, err := content.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		require.Equal(t, int64(10), end)
+		_, err = content.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+
+		head := make([]byte, 3)
+		_, err = io.ReadFull(content, head)
+		require.NoError(t, err)
+		require.Equal(t, "012", string(head))
+		require.Equal(t, 1, blobs.gets)
+
+		_, err = content.Seek(7, io.SeekStart)
+		require.NoError(t, err)
+		rest, err := io.ReadAll(content)
+		require.NoError(t, err)
+		require.Equal(t, "789", string(rest))
+		require.Equal(t, 2, blobs.gets)
+	})
+
+	t.Run("returns ErrAttachmentNotFound - missing blob", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb, WithBlobStore(newMemBlobStore()))
+
+		mockdb.EXPECT().GetAttachment(gomock.Any(), args).Times(1).Return(db.Attachment{ID: id, StorageKey: key, Size: 10}, nil)
+
+		_, _, err := ns.OpenAttachment(context.Background(), username, noteID, id)
+		require.ErrorIs(t, err, ErrAttachmentNotFound)
+	})
+
+	t.Run("returns ErrAttachmentNotFound - attachment of another user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb, WithBlobStore(newMemBlobStore()))
+
+		mockdb.EXPECT().GetAttachment(gomock.Any(), args).Times(1).Return(db.Attachment{}, sql.ErrNoRows)
+
+		_, _, err := ns.OpenAttachment(context.Background(), username, noteID, id)
+		require.ErrorIs(t, err, ErrAttachmentNotFound)
+	})
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	const username = "user1"
+	noteID, id := uuid.New(), uuid.New()
+	key := attachmentKey(username, noteID, id)
+	args := &db.DeleteAttachmentParams{ID: id, NoteID: noteID, Username: username}
+
+	testCases := []struct {
+		name        string
+		blobs       *memBlobStore
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:  "deleting attachment OK",
+			blobs: newMemBlobStore(key),
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeleteAttachment(gomock.Any(), args).Times(1).Return(key, nil)
+			},
+		},
+		{
+			name:  "deleting attachment with a missing blob OK",
+			blobs: newMemBlobStore(),
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeleteAttachment(gomock.Any(), args).Times(1).Return(key, nil)
+			},
+		},
+		{
+			name:  "returns ErrAttachmentNotFound",
+			blobs: newMemBlobStore(),
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeleteAttachment(gomock.Any(), args).Times(1).Return("", sql.ErrNoRows)
+			},
+			wantErr: ErrAttachmentNotFound,
+		},
+		{
+			name:  "returns ErrStorageInternal",
+			blobs: &memBlobStore{objects: map[string][]byte{}, deleteErr: errors.New("connection refused")},
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeleteAttachment(gomock.Any(), args).Times(1).Return(key, nil)
+			},
+			wantErr: ErrStorageInternal,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb, WithBlobStore(tc.blobs))
+
+			tc.mockdbCalls(mockdb)
+			err := ns.DeleteAttachment(context.Background(), username, noteID, id)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+				require.NotContains(t, tc.blobs.objects, key)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
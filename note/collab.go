@@ -0,0 +1,501 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/crdt"
+	"github.com/google/uuid"
+)
+
+const (
+	// how often a session saves its text and picks up changes made outside of it
+	collabSaveInterval = 5 * time.Second
+	collabSaveTimeout  = 10 * time.Second
+	// how often at most a session stores a revision, it stores one when it ends as well
+	collabRevisionInterval = 10 * time.Minute
+	// tries of the final save, nothing saves the edits after it
+	collabFinalSaveAttempts   = 5
+	collabFinalSaveRetryDelay = 200 * time.Millisecond
+	// messages that may wait for a slow participant before it is dropped
+	collabPeerBuffer = 256
+	// characters a session holds, deleted ones included
+	maxCollabElements = 1 << 20
+
+	// site of the note text a session starts from
+	collabBaseSite = "base"
+	// site of the changes made to the note outside of the session
+	collabServerSite = "server"
+)
+
+type CollabMessageType string
+
+const (
+	// ops of another participant
+	CollabUpdate CollabMessageType = "update"
+	// another participant joined or moved their cursor
+	CollabPresence CollabMessageType = "presence"
+	// another participant left
+	CollabLeave CollabMessageType = "leave"
+	// the text was saved as the note version
+	CollabSaved CollabMessageType = "saved"
+	// the text could not be saved, the session goes on
+	CollabError CollabMessageType = "error"
+	// the session ended, e.g. the note was deleted
+	CollabClosed CollabMessageType = "closed"
+)
+
+// Sent to the participants of a co-editing session
+type CollabMessage struct {
+	Type     CollabMessageType
+	Site     string
+	Username string
+	Ops      []crdt.Op
+	Cursor   *Cursor
+	Version  int32
+	Err      error
+}
+
+// Caret and selection end of a participant, as the elements they follow so concurrent
+// edits don't move them. A zero ID is the start of the text.
+type Cursor struct {
+	Anchor crdt.ID
+	Head   crdt.ID
+}
+
+// What a participant starts from
+type CollabState struct {
+	Version  int32
+	Elements []crdt.Element
+	// presence of the other participants
+	Peers []CollabMessage
+}
+
+// Participant of a co-editing session. C delivers the messages of the session
+// and is closed when the participant is dropped or the session ends.
+type CollabPeer struct {
+	// replica ID, the IDs of the participant's inserts have to carry it
+	Site     string
+	Username string
+	ReadOnly bool
+	C        <-chan CollabMessage
+
+	ch      chan CollabMessage
+	cursor  *Cursor
+	session *collabSession
+}
+
+// Co-editing sessions of the notes being edited on this server. Participants
+// of a note have to be served by the same replica.
+type collabHub struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*collabSession
+	running  sync.WaitGroup
+}
+
+func newCollabHub() *collabHub {
+	return &collabHub{sessions: make(map[uuid.UUID]*collabSession)}
+}
+
+// Merged text of one note and its participants
+type collabSession struct {
+	s      *service
+	noteID uuid.UUID
+	owner  string
+
+	mu  sync.Mutex
+	doc *crdt.Doc
+	// visible elements of the text stored as version
+	base    []crdt.Element
+	version int32
+	// changes so far and how many of them are saved
+	edits uint64
+	saved uint64
+	// when the session last stored a revision, and whether a version saved since has none
+	revised   time.Time
+	unrevised bool
+	peers     map[string]*CollabPeer
+	closing   bool
+	// closed when the session has to end, and once it has saved for the last time
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Join the co-editing session of a note of the user or one shared with them, starting one if needed.
+// Users the note is shared with for reading follow the edits without making any.
+func (s *service) JoinCollab(ctx context.Context, username string, noteID uuid.UUID) (*CollabPeer, CollabState, error) {
+	for {
+		n, err := s.GetNote(ctx, username, noteID)
+		if err != nil {
+			return nil, CollabState{}, err
+		}
+
+		perm := n.Permission
+		if perm == "" {
+			perm = permissionOwner
+		}
+
+		peer, state, wait := s.collab.join(s, n.Note, username, !perm.allows(PermissionEdit))
+		if wait == nil {
+			return peer, state, nil
+		}
+
+		// the previous session of the note is still saving, start from what it saves
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, CollabState{}, ErrCollabClosed
+		}
+	}
+}
+
+// Wait until every session has saved for the last time, or ctx is done
+func (s *service) WaitCollab(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.collab.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Add a participant to the session of n. Returns a channel to wait on instead when the session is ending.
+func (h *collabHub) join(s *service, n db.Note, username string, readOnly bool) (*CollabPeer, CollabState, <-chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cs, ok := h.sessions[n.ID]
+	if !ok {
+		cs = &collabSession{
+			s:       s,
+			noteID:  n.ID,
+			owner:   n.Username,
+			doc:     crdt.FromText(collabBaseSite, n.Text.String),
+			version: n.Version,
+			revised: time.Now(),
+			peers:   make(map[string]*CollabPeer),
+			stop:    make(chan struct{}),
+			done:    make(chan struct{}),
+		}
+		cs.base = cs.doc.Visible()
+
+		h.sessions[n.ID] = cs
+		h.running.Add(1)
+		go cs.run()
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.closing {
+		return nil, CollabState{}, cs.done
+	}
+
+	ch := make(chan CollabMessage, collabPeerBuffer)
+	peer := &CollabPeer{
+		Site:     uuid.NewString(),
+		Username: username,
+		ReadOnly: readOnly,
+		C:        ch,
+		ch:       ch,
+		session:  cs,
+	}
+
+	state := CollabState{
+		Version:  cs.version,
+		Elements: cs.doc.Elements(),
+		Peers:    make([]CollabMessage, 0, len(cs.peers)),
+	}
+	for _, p := range cs.peers {
+		state.Peers = append(state.Peers, p.presence())
+	}
+
+	cs.peers[peer.Site] = peer
+	cs.broadcastLocked(peer.presence(), peer.Site)
+
+	return peer, state, nil
+}
+
+func (p *CollabPeer) presence() CollabMessage {
+	return CollabMessage{Type: CollabPresence, Site: p.Site, Username: p.Username, Cursor: p.cursor}
+}
+
+// Apply ops of the participant and pass them on to the others. Ops before an invalid one stay applied.
+func (p *CollabPeer) Update(ops []crdt.Op) error {
+	if p.ReadOnly {
+		return ErrForbidden
+	}
+
+	cs := p.session
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if _, ok := cs.peers[p.Site]; !ok {
+		return ErrCollabClosed
+	}
+
+	var err error
+	applied := make([]crdt.Op, 0, len(ops))
+	for _, op := range ops {
+		if op.Type == crdt.OpInsert && !cs.doc.Has(op.ID) {
+			if op.ID.Site != p.Site {
+				err = ErrInvalidCollabUpdate
+				break
+			}
+			if cs.doc.Len() >= maxCollabElements {
+				err = ErrCollabTooLarge
+				break
+			}
+		}
+
+		if cs.doc.Apply(op) != nil {
+			err = ErrInvalidCollabUpdate
+			break
+		}
+		applied = append(applied, op)
+	}
+
+	if len(applied) > 0 {
+		cs.edits++
+		cs.broadcastLocked(CollabMessage{Type: CollabUpdate, Site: p.Site, Username: p.Username, Ops: applied}, p.Site)
+	}
+
+	return err
+}
+
+// Move the cursor of the participant and show it to the others
+func (p *CollabPeer) SetCursor(c Cursor) error {
+	cs := p.session
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if _, ok := cs.peers[p.Site]; !ok {
+		return ErrCollabClosed
+	}
+
+	for _, id := range []crdt.ID{c.Anchor, c.Head} {
+		if !id.IsZero() && !cs.doc.Has(id) {
+			return ErrInvalidCollabUpdate
+		}
+	}
+
+	p.cursor = &c
+	cs.broadcastLocked(p.presence(), p.Site)
+
+	return nil
+}
+
+// Leave the session, safe to call more than once. The last one to leave ends the session.
+func (p *CollabPeer) Leave() {
+	cs := p.session
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.removeLocked(p)
+}
+
+// Send msg to every participant but the one of site except, dropping the ones that fall behind
+func (cs *collabSession) broadcastLocked(msg CollabMessage, except string) {
+	var slow []*CollabPeer
+	for site, p := range cs.peers {
+		if site == except {
+			continue
+		}
+
+		select {
+		case p.ch <- msg:
+		default:
+			slow = append(slow, p)
+		}
+	}
+
+	for _, p := range slow {
+		cs.removeLocked(p)
+	}
+}
+
+func (cs *collabSession) removeLocked(p *CollabPeer) {
+	if _, ok := cs.peers[p.Site]; !ok {
+		return
+	}
+
+	delete(cs.peers, p.Site)
+	close(p.ch)
+	cs.broadcastLocked(CollabMessage{Type: CollabLeave, Site: p.Site, Username: p.Username}, "")
+
+	if len(cs.peers) == 0 {
+		cs.stopLocked()
+	}
+}
+
+func (cs *collabSession) stopLocked() {
+	if !cs.closing {
+		cs.closing = true
+		close(cs.stop)
+	}
+}
+
+// End the session for everybody, telling them why
+func (cs *collabSession) close(err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for site, p := range cs.peers {
+		select {
+		case p.ch <- CollabMessage{Type: CollabClosed, Err: err}:
+		default:
+		}
+		delete(cs.peers, site)
+		close(p.ch)
+	}
+
+	cs.stopLocked()
+}
+
+// Save the text now and then until the session ends, then save it a last time
+func (cs *collabSession) run() {
+	defer cs.s.collab.running.Done()
+
+	ticker := time.NewTicker(collabSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cs.save(false)
+		case <-cs.stop:
+			cs.saveFinal()
+
+			cs.s.collab.mu.Lock()
+			delete(cs.s.collab.sessions, cs.noteID)
+			cs.s.collab.mu.Unlock()
+
+			close(cs.done)
+			return
+		}
+	}
+}
+
+// Save until it succeeds or the attempts run out. A version mismatch is merged and saved again right away.
+func (cs *collabSession) saveFinal() {
+	var err error
+	for attempt := 1; attempt <= collabFinalSaveAttempts; attempt++ {
+		err = cs.save(true)
+		if err == nil {
+			return
+		}
+
+		if !errors.Is(err, ErrVersionMismatch) && attempt < collabFinalSaveAttempts {
+			time.Sleep(collabFinalSaveRetryDelay)
+		}
+	}
+
+	cs.s.log.Error().Err(err).Msgf("Final save of the co-editing session of note %v failed, its last edits are lost", cs.noteID)
+}
+
+// Merge in changes made to the note outside of the session, then store the text as a new
+// version of the note when it has changed. A failed save is retried on the next run, the
+// error is returned so the final save can retry it at once. Versions get a revision at most
+// every collabRevisionInterval and on the final save, so a long session doesn't push the
+// older revisions out.
+func (cs *collabSession) save(final bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), collabSaveTimeout)
+	defer cancel()
+
+	current, err := cs.s.q.GetNote(ctx, &db.GetNoteParams{ID: cs.noteID, Username: cs.owner})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		cs.close(ErrNotFound)
+		return nil
+	case err != nil:
+		cs.report(ErrDBInternal)
+		return ErrDBInternal
+	}
+
+	cs.mu.Lock()
+	if current.Version != cs.version {
+		// e.g. edited through the API meanwhile, both changes are kept
+		ops, base := cs.doc.Rebase(collabServerSite, cs.base, current.Text.String)
+		cs.base, cs.version = base, current.Version
+		// the outside write stored a revision holding what the session saved before
+		cs.unrevised = false
+		if len(ops) > 0 {
+			cs.broadcastLocked(CollabMessage{Type: CollabUpdate, Site: collabServerSite, Ops: ops}, "")
+		}
+	}
+	revise := final || time.Since(cs.revised) >= collabRevisionInterval
+	if cs.edits == cs.saved {
+		unrevised := cs.unrevised
+		cs.mu.Unlock()
+
+		if final && unrevised {
+			return cs.revise(ctx)
+		}
+		return nil
+	}
+	text, visible, edits := cs.doc.Text(), cs.doc.Visible(), cs.edits
+	cs.mu.Unlock()
+
+	// the owner can always write the note, whoever made the edits
+	version, err := cs.s.updateNote(ctx, cs.owner, cs.noteID, current.Title, text, true, nil, current.Version, updateOptions{revise: revise})
+	switch {
+	case errors.Is(err, ErrVersionMismatch):
+		// changed again meanwhile, merged on the next run or right away by the final save
+		return err
+	case errors.Is(err, ErrNotFound):
+		cs.close(ErrNotFound)
+		return nil
+	case err != nil:
+		cs.report(err)
+		return err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.base, cs.version, cs.saved = visible, version, edits
+	if revise {
+		cs.revised, cs.unrevised = time.Now(), false
+	} else {
+		cs.unrevised = true
+	}
+	cs.broadcastLocked(CollabMessage{Type: CollabSaved, Version: version}, "")
+
+	return nil
+}
+
+// Store the saved version as a revision
+func (cs *collabSession) revise(ctx context.Context) error {
+	err := cs.s.execTx(ctx, func(q db.Querier) error {
+		return cs.s.addRevision(ctx, q, cs.noteID)
+	})
+	if err != nil {
+		cs.report(ErrDBInternal)
+		return ErrDBInternal
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.revised, cs.unrevised = time.Now(), false
+
+	return nil
+}
+
+// Tell the participants that the text couldn't be saved
+func (cs *collabSession) report(err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.broadcastLocked(CollabMessage{Type: CollabError, Err: err}, "")
+}
@@ -0,0 +1,336 @@
+package note
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/crdt"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// Expect the lookups of users joining the session of n, grantee gets it shared with perm
+func expectCollabJoin(mockdb *mockdb.MockQuerier, n db.Note, grantee string, perm Permission) {
+	mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: n.ID, Username: n.Username}).Times(1).Return(n, nil)
+	if grantee != "" {
+		mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: n.ID, Username: grantee}).Times(1).Return(db.Note{}, sql.ErrNoRows)
+		mockdb.EXPECT().GetSharedNote(gomock.Any(), &db.GetSharedNoteParams{ID: n.ID, Username: grantee}).Times(1).
+			Return(db.GetSharedNoteRow{Note: n, Permission: string(perm)}, nil)
+	}
+	mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), []uuid.UUID{n.ID}).AnyTimes().Return([]db.GetTagsOfNotesRow{}, nil)
+}
+
+// Ops of site typing text at the start of the doc
+func typeAtStart(site string, seq uint64, text string) []crdt.Op {
+	var ops []crdt.Op
+	after := crdt.ID{}
+	for _, r := range text {
+		seq++
+		op := crdt.Op{Type: crdt.OpInsert, ID: crdt.ID{Seq: seq, Site: site}, After: after, Value: string(r)}
+		ops = append(ops, op)
+		after = op.ID
+	}
+
+	return ops
+}
+
+func nextMessage(t *testing.T, p *CollabPeer) CollabMessage {
+	select {
+	case m, ok := <-p.C:
+		require.True(t, ok, "channel of %s is closed", p.Username)
+		return m
+	case <-time.After(time.Second):
+		t.Fatalf("no message for %s", p.Username)
+		return CollabMessage{}
+	}
+}
+
+func TestCollab(t *testing.T) {
+	n := db.Note{ID: uuid.New(), Title: "plan", Username: "user1", Text: sql.NullString{String: "world", Valid: true}, Version: 3}
+
+	t.Run("participants exchange edits and cursors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+		expectCollabJoin(mockdb, n, "user2", PermissionEdit)
+
+		owner, state, err := ns.JoinCollab(context.Background(), "user1", n.ID)
+		require.NoError(t, err)
+		require.False(t, owner.ReadOnly)
+		require.Equal(t, int32(3), state.Version)
+		require.Len(t, state.Elements, 5)
+		require.Empty(t, state.Peers)
+
+		editor, state, err := ns.JoinCollab(context.Background(), "user2", n.ID)
+		require.NoError(t, err)
+		require.False(t, editor.ReadOnly)
+		require.Len(t, state.Peers, 1)
+		require.Equal(t, owner.Site, state.Peers[0].Site)
+
+		joined := nextMessage(t, owner)
+		require.Equal(t, CollabPresence, joined.Type)
+		require.Equal(t, "user2", joined.Username)
+
+		ops := typeAtStart(editor.Site, 5, "hello ")
+		require.NoError(t, editor.Update(ops))
+		update := nextMessage(t, owner)
+		require.Equal(t, CollabUpdate, update.Type)
+		require.Equal(t, ops, update.Ops)
+		require.Equal(t, "hello world", owner.session.doc.Text())
+
+		cursor := Cursor{Anchor: ops[0].ID, Head: ops[0].ID}
+		require.NoError(t, owner.SetCursor(cursor))
+		moved := nextMessage(t, editor)
+		require.Equal(t, CollabPresence, moved.Type)
+		require.Equal(t, &cursor, moved.Cursor)
+
+		t.Run("returns ErrInvalidCollabUpdate", func(t *testing.T) {
+			// inserts have to carry the site of the participant
+			require.ErrorIs(t, editor.Update(typeAtStart(owner.Site, 20, "x")), ErrInvalidCollabUpdate)
+			require.ErrorIs(t, editor.Update([]crdt.Op{{Type: crdt.OpDelete, ID: crdt.ID{Seq: 99, Site: "x"}}}), ErrInvalidCollabUpdate)
+			require.ErrorIs(t, owner.SetCursor(Cursor{Anchor: crdt.ID{Seq: 99, Site: "x"}}), ErrInvalidCollabUpdate)
+			require.Empty(t, owner.C)
+		})
+
+		// the last one to leave ends the session after saving
+		mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: n.ID, Username: "user1"}).Times(1).Return(n, nil)
+		mockdb.EXPECT().GetNoteAccess(gomock.Any(), gomock.Any()).Times(1).Return(db.GetNoteAccessRow{Owner: "user1", Permission: "owner"}, nil)
+		mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.UpdateNoteParams) (int32, error) {
+				require.Equal(t, "hello world", arg.Text.String)
+				require.Equal(t, "plan", arg.Title.String)
+				require.Equal(t, int32(3), arg.Version)
+				return 4, nil
+			})
+		mockdb.EXPECT().CreateNoteRevision(gomock.Any(), n.ID).Times(1).Return(int32(2), nil)
+
+		editor.Leave()
+		editor.Leave()
+		left := nextMessage(t, owner)
+		require.Equal(t, CollabLeave, left.Type)
+		owner.Leave()
+
+		require.NoError(t, ns.WaitCollab(context.Background()))
+		require.Empty(t, ns.collab.sessions)
+		require.ErrorIs(t, owner.Update(nil), ErrCollabClosed)
+	})
+
+	t.Run("users the note is shared with for reading can't edit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+		expectCollabJoin(mockdb, n, "user2", PermissionRead)
+
+		owner, _, err := ns.JoinCollab(context.Background(), "user1", n.ID)
+		require.NoError(t, err)
+		reader, _, err := ns.JoinCollab(context.Background(), "user2", n.ID)
+		require.NoError(t, err)
+		require.True(t, reader.ReadOnly)
+
+		require.ErrorIs(t, reader.Update(typeAtStart(reader.Site, 5, "x")), ErrForbidden)
+
+		// nothing was edited, so nothing is saved
+		mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(n, nil)
+		reader.Leave()
+		owner.Leave()
+		require.NoError(t, ns.WaitCollab(context.Background()))
+	})
+
+	t.Run("returns ErrNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(db.Note{}, sql.ErrNoRows)
+		mockdb.EXPECT().GetSharedNote(gomock.Any(), gomock.Any()).Times(1).Return(db.GetSharedNoteRow{}, sql.ErrNoRows)
+
+		_, _, err := ns.JoinCollab(context.Background(), "user2", n.ID)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestCollabSave(t *testing.T) {
+	n := db.Note{ID: uuid.New(), Title: "plan", Username: "user1", Text: sql.NullString{String: "world", Valid: true}, Version: 3}
+	getArgs := &db.GetNoteParams{ID: n.ID, Username: n.Username}
+
+	// Start a session of n with one participant
+	join := func(t *testing.T) (*service, *mockdb.MockQuerier, *CollabPeer) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+		expectCollabJoin(mockdb, n, "", "")
+
+		peer, _, err := ns.JoinCollab(context.Background(), n.Username, n.ID)
+		require.NoError(t, err)
+
+		return ns, mockdb, peer
+	}
+
+	t.Run("merges changes made outside of the session", func(t *testing.T) {
+		ns, mockdb, peer := join(t)
+
+		require.NoError(t, peer.Update(typeAtStart(peer.Site, 5, "hello ")))
+
+		// the note got a title and an exclamation mark through the API meanwhile
+		changed := n
+		changed.Title, changed.Text.String, changed.Version = "greeting", "world!", 4
+		mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(1).Return(changed, nil)
+		mockdb.EXPECT().GetNoteAccess(gomock.Any(), gomock.Any()).Times(1).Return(db.GetNoteAccessRow{Owner: "user1", Permission: "owner"}, nil)
+		mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.UpdateNoteParams) (int32, error) {
+				require.Equal(t, "hello world!", arg.Text.String)
+				require.Equal(t, "greeting", arg.Title.String)
+				require.Equal(t, int32(4), arg.Version)
+				return 5, nil
+			})
+
+		peer.session.save(false)
+
+		merged := nextMessage(t, peer)
+		require.Equal(t, CollabUpdate, merged.Type)
+		require.Equal(t, collabServerSite, merged.Site)
+		saved := nextMessage(t, peer)
+		require.Equal(t, CollabSaved, saved.Type)
+		require.Equal(t, int32(5), saved.Version)
+
+		// saved already, leaving only checks for outside changes and stores the revision the save skipped
+		mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(1).Return(db.Note{ID: n.ID, Username: n.Username, Version: 5, Text: sql.NullString{String: "hello world!", Valid: true}}, nil)
+		mockdb.EXPECT().CreateNoteRevision(gomock.Any(), n.ID).Times(1).Return(int32(2), nil)
+		peer.Leave()
+		require.NoError(t, ns.WaitCollab(context.Background()))
+	})
+
+	t.Run("saves in between add a bounded number of revisions", func(t *testing.T) {
+		ns, mockdb, peer := join(t)
+
+		const ticks = 60
+		version := n.Version
+		current := func(_ context.Context, _ *db.GetNoteParams) (db.Note, error) {
+			saved := n
+			saved.Version = version
+			return saved, nil
+		}
+		update := func(_ context.Context, arg *db.UpdateNoteParams) (int32, error) {
+			require.Equal(t, version, arg.Version)
+			version++
+			return version, nil
+		}
+
+		mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(ticks + 1).DoAndReturn(current)
+		mockdb.EXPECT().GetNoteAccess(gomock.Any(), gomock.Any()).Times(ticks).Return(db.GetNoteAccessRow{Owner: "user1", Permission: "owner"}, nil)
+		mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(ticks).DoAndReturn(update)
+		// one revision after collabRevisionInterval and one when the session ends
+		mockdb.EXPECT().CreateNoteRevision(gomock.Any(), n.ID).Times(2).Return(int32(2), nil)
+
+		for i := 0; i < ticks; i++ {
+			require.NoError(t, peer.Update(typeAtStart(peer.Site, uint64(i)*10, "x")))
+			if i == ticks/2 {
+				peer.session.mu.Lock()
+				peer.session.revised = time.Now().Add(-collabRevisionInterval)
+				peer.session.mu.Unlock()
+			}
+
+			peer.session.save(false)
+			require.Equal(t, CollabSaved, nextMessage(t, peer).Type)
+		}
+
+		peer.Leave()
+		require.NoError(t, ns.WaitCollab(context.Background()))
+		require.Equal(t, n.Version+ticks, version)
+	})
+
+	t.Run("a failed save is reported and retried", func(t *testing.T) {
+		ns, mockdb, peer := join(t)
+
+		require.NoError(t, peer.Update(typeAtStart(peer.Site, 5, "hello ")))
+
+		mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(1).Return(db.Note{}, sql.ErrConnDone)
+		peer.session.save(false)
+		failed := nextMessage(t, peer)
+		require.Equal(t, CollabError, failed.Type)
+		require.ErrorIs(t, failed.Err, ErrDBInternal)
+
+		mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(1).Return(n, nil)
+		mockdb.EXPECT().GetNoteAccess(gomock.Any(), gomock.Any()).Times(1).Return(db.GetNoteAccessRow{Owner: "user1", Permission: "owner"}, nil)
+		mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).Return(int32(4), nil)
+		mockdb.EXPECT().CreateNoteRevision(gomock.Any(), n.ID).Times(1).Return(int32(2), nil)
+		peer.Leave()
+		require.NoError(t, ns.WaitCollab(context.Background()))
+	})
+
+	t.Run("the final save merges a version mismatch and saves again", func(t *testing.T) {
+		ns, mockdb, peer := join(t)
+
+		require.NoError(t, peer.Update(typeAtStart(peer.Site, 5, "hello ")))
+
+		// an exclamation mark is added through the API while the session ends
+		changed := n
+		changed.Text.String, changed.Version = "world!", 4
+		mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(1).Return(n, nil)
+		mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(2).Return(changed, nil)
+		mockdb.EXPECT().GetNoteAccess(gomock.Any(), gomock.Any()).Times(2).Return(db.GetNoteAccessRow{Owner: "user1", Permission: "owner"}, nil)
+		mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).Return(int32(0), sql.ErrNoRows)
+		mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.UpdateNoteParams) (int32, error) {
+				require.Equal(t, "hello world!", arg.Text.String)
+				require.Equal(t, int32(4), arg.Version)
+				return 5, nil
+			})
+		mockdb.EXPECT().CreateNoteRevision(gomock.Any(), n.ID).Times(1).Return(int32(2), nil)
+
+		peer.Leave()
+		require.NoError(t, ns.WaitCollab(context.Background()))
+	})
+
+	t.Run("a final save failing every attempt is logged", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		var logs bytes.Buffer
+		l := zerolog.New(&logs)
+		ns := NewService(mockdb, WithLogger(&l))
+		expectCollabJoin(mockdb, n, "", "")
+
+		peer, _, err := ns.JoinCollab(context.Background(), n.Username, n.ID)
+		require.NoError(t, err)
+		require.NoError(t, peer.Update(typeAtStart(peer.Site, 5, "hello ")))
+
+		// every attempt finds the note changed again by the time it writes
+		version := n.Version
+		mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(2 * collabFinalSaveAttempts).
+			DoAndReturn(func(_ context.Context, _ *db.GetNoteParams) (db.Note, error) {
+				version++
+				changed := n
+				changed.Version = version
+				return changed, nil
+			})
+		mockdb.EXPECT().GetNoteAccess(gomock.Any(), gomock.Any()).Times(collabFinalSaveAttempts).Return(db.GetNoteAccessRow{Owner: "user1", Permission: "owner"}, nil)
+		mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(collabFinalSaveAttempts).Return(int32(0), sql.ErrNoRows)
+
+		peer.Leave()
+		require.NoError(t, ns.WaitCollab(context.Background()))
+		require.Contains(t, logs.String(), "its last edits are lost")
+	})
+
+	t.Run("a deleted note ends the session", func(t *testing.T) {
+		ns, mockdb, peer := join(t)
+
+		// the last save finds the note gone as well
+		mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(2).Return(db.Note{}, sql.ErrNoRows)
+		peer.session.save(false)
+
+		closed := nextMessage(t, peer)
+		require.Equal(t, CollabClosed, closed.Type)
+		require.ErrorIs(t, closed.Err, ErrNotFound)
+		_, ok := <-peer.C
+		require.False(t, ok)
+
+		require.NoError(t, ns.WaitCollab(context.Background()))
+	})
+}
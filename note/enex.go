@@ -0,0 +1,323 @@
+package note
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Note of an Evernote export
+type enexNote struct {
+	Title   string   `xml:"title"`
+	Content string   `xml:"content"`
+	Tags    []string `xml:"tag"`
+}
+
+// Report whether r starts like an Evernote export
+func looksLikeENEX(r io.ReaderAt) bool {
+	head := make([]byte, 1024)
+	n, _ := r.ReadAt(head, 0)
+
+	return bytes.Contains(head[:n], []byte("<en-export"))
+}
+
+// Read the notes of an Evernote export one at a time
+func readENEX(r io.Reader, fn func(importItem) error) error {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	seen := false
+	for i := 1; ; {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return ErrInvalidImport
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "en-export":
+			seen = true
+			continue
+		case "note":
+		default:
+			continue
+		}
+		if !seen {
+			return ErrInvalidImport
+		}
+
+		item := importItem{source: fmt.Sprintf("note %d", i)}
+		i++
+
+		var en enexNote
+		if err := d.DecodeElement(&en, &start); err != nil {
+			return ErrInvalidImport
+		}
+
+		item.title = en.Title
+		item.tags = en.Tags
+		if len(en.Content) > maxImportNoteSize {
+			item.err = ErrImportNoteTooLarge
+		} else if item.text, err = enmlToMarkdown(en.Content); err != nil {
+			item.err = ErrInvalidImport
+		}
+		if item.title == "" {
+			item.title = titleFromText(item.text)
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	if !seen {
+		return ErrInvalidImport
+	}
+
+	return nil
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// Convert the ENML content of an Evernote note to Markdown
+func enmlToMarkdown(enml string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(enml))
+	if err != nil {
+		return "", err
+	}
+
+	var c enmlConverter
+	c.walk(doc)
+
+	lines := strings.Split(c.b.String(), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	md := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+
+	return strings.TrimSpace(md), nil
+}
+
+// Writes Markdown while walking an HTML tree
+type enmlConverter struct {
+	b     strings.Builder
+	lists []int // per open list: 0 for bullets, otherwise the next number
+	pre   bool
+}
+
+func (c *enmlConverter) atLineStart() bool {
+	s := c.b.String()
+	return len(s) == 0 || strings.HasSuffix(s, "\n")
+}
+
+// Start a new line unless already at one
+func (c *enmlConverter) line() {
+	if !c.atLineStart() {
+		c.b.WriteString("\n")
+	}
+}
+
+// Leave a blank line before the next block
+func (c *enmlConverter) block() {
+	s := c.b.String()
+	if len(s) == 0 || strings.HasSuffix(s, "\n\n") {
+		return
+	}
+	c.line()
+	c.b.WriteString("\n")
+}
+
+func (c *enmlConverter) text(s string) {
+	if c.pre {
+		c.b.WriteString(s)
+		return
+	}
+
+	// collapse whitespace like a browser does
+	collapsed := strings.Join(strings.Fields(s), " ")
+	if collapsed == "" {
+		if s != "" && !c.atLineStart() && !strings.HasSuffix(c.b.String(), " ") {
+			c.b.WriteString(" ")
+		}
+		return
+	}
+	if isSpace(s[0]) && !c.atLineStart() && !strings.HasSuffix(c.b.String(), " ") {
+		c.b.WriteString(" ")
+	}
+	c.b.WriteString(collapsed)
+	if isSpace(s[len(s)-1]) {
+		c.b.WriteString(" ")
+	}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func (c *enmlConverter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child)
+	}
+}
+
+func (c *enmlConverter) wrap(n *html.Node, mark string) {
+	c.b.WriteString(mark)
+	c.children(n)
+	c.b.WriteString(mark)
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func (c *enmlConverter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		c.children(n)
+		return
+	}
+
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.block()
+		c.b.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		c.children(n)
+		c.block()
+	case "p":
+		c.block()
+		c.children(n)
+		c.block()
+	case "div":
+		// Evernote writes a div per line
+		c.line()
+		c.children(n)
+		c.line()
+	case "br":
+		c.b.WriteString("\n")
+	case "hr":
+		c.block()
+		c.b.WriteString("---")
+		c.block()
+	case "ul", "ol":
+		next := 0
+		if n.Data == "ol" {
+			next = 1
+		}
+		if len(c.lists) == 0 {
+			c.block()
+		}
+		c.lists = append(c.lists, next)
+		c.children(n)
+		c.lists = c.lists[:len(c.lists)-1]
+		if len(c.lists) == 0 {
+			c.block()
+		}
+	case "li":
+		c.line()
+		depth := len(c.lists)
+		if depth == 0 {
+			depth = 1
+			c.lists = append(c.lists, 0)
+			defer func() { c.lists = c.lists[:0] }()
+		}
+		c.b.WriteString(strings.Repeat("  ", depth-1))
+		if next := c.lists[depth-1]; next > 0 {
+			fmt.Fprintf(&c.b, "%d. ", next)
+			c.lists[depth-1]++
+		} else {
+			c.b.WriteString("- ")
+		}
+		c.children(n)
+		c.line()
+	case "en-todo":
+		mark := "[ ] "
+		if attr(n, "checked") == "true" {
+			mark = "[x] "
+		}
+		if c.atLineStart() {
+			mark = "- " + mark
+		}
+		c.b.WriteString(mark)
+		c.children(n)
+	case "b", "strong":
+		c.wrap(n, "**")
+	case "i", "em":
+		c.wrap(n, "*")
+	case "s", "strike", "del":
+		c.wrap(n, "~~")
+	case "code":
+		if c.pre {
+			c.children(n)
+			return
+		}
+		c.wrap(n, "`")
+	case "pre":
+		c.block()
+		c.b.WriteString("```\n")
+		c.pre = true
+		c.children(n)
+		c.pre = false
+		c.line()
+		c.b.WriteString("```")
+		c.block()
+	case "a":
+		href := attr(n, "href")
+		if href == "" {
+			c.children(n)
+			return
+		}
+		c.b.WriteString("[")
+		c.children(n)
+		fmt.Fprintf(&c.b, "](%s)", href)
+	case "blockquote":
+		var inner enmlConverter
+		inner.children(n)
+		c.block()
+		for _, l := range strings.Split(strings.TrimSpace(inner.b.String()), "\n") {
+			c.b.WriteString(strings.TrimRight("> "+l, " ") + "\n")
+		}
+		c.block()
+	case "tr":
+		c.line()
+		first := true
+		for cell := n.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode {
+				continue
+			}
+			if !first {
+				c.b.WriteString(" | ")
+			}
+			first = false
+			c.children(cell)
+		}
+		c.line()
+	case "en-media", "en-crypt", "img", "script", "style", "head":
+		// attachments and encrypted text are not imported
+	default:
+		c.children(n)
+	}
+}
@@ -0,0 +1,106 @@
+package note
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnmlToMarkdown(t *testing.T) {
+	testCases := []struct {
+		name string
+		enml string
+		want string
+	}{
+		{
+			name: "lines and formatting",
+			enml: `<en-note><div>Hello <b>bold</b> and <i>italic</i></div><div>second   line</div><div><br/></div><div>after a blank</div></en-note>`,
+			want: "Hello **bold** and *italic*\nsecond line\n\nafter a blank",
+		},
+		{
+			name: "headings and links",
+			enml: `<en-note><h2>Links</h2><div><a href="https://example.com">site</a></div></en-note>`,
+			want: "## Links\n\n[site](https://example.com)",
+		},
+		{
+			name: "lists",
+			enml: `<en-note><ul><li>one</li><li>two<ol><li>a</li><li>b</li></ol></li></ul></en-note>`,
+			want: "- one\n- two\n  1. a\n  2. b",
+		},
+		{
+			name: "todos",
+			enml: `<en-note><div><en-todo checked="true"/>milk</div><div><en-todo checked="false"/>bread</div></en-note>`,
+			want: "- [x] milk\n- [ ] bread",
+		},
+		{
+			name: "code block keeps whitespace",
+			enml: "<en-note><pre>if x {\n    y()\n}</pre></en-note>",
+			want: "```\nif x {\n    y()\n}\n```",
+		},
+		{
+			name: "quote",
+			enml: `<en-note><blockquote><div>quoted</div><div>text</div></blockquote></en-note>`,
+			want: "> quoted\n> text",
+		},
+		{
+			name: "attachments are left out",
+			enml: `<en-note><div>see <en-media type="image/png" hash="abc"/></div></en-note>`,
+			want: "see",
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := enmlToMarkdown(tc.enml)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+const testENEX = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20240301T100000Z" application="Evernote" version="10">
+  <note>
+    <title>Groceries</title>
+    <created>20240101T120000Z</created>
+    <tag>home</tag>
+    <tag>Shopping</tag>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd"><en-note><div><en-todo checked="true"/>milk</div></en-note>]]></content>
+  </note>
+  <note>
+    <title></title>
+    <content><![CDATA[<en-note><div>First line becomes the title</div><div>body &amp; more</div></en-note>]]></content>
+  </note>
+</en-export>`
+
+func TestReadENEX(t *testing.T) {
+	var items []importItem
+	err := readENEX(strings.NewReader(testENEX), func(item importItem) error {
+		items = append(items, item)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	require.Equal(t, "Groceries", items[0].title)
+	require.Equal(t, []string{"home", "Shopping"}, items[0].tags)
+	require.Equal(t, "- [x] milk", items[0].text)
+	require.NoError(t, items[0].err)
+
+	require.Equal(t, "First line becomes the title", items[1].title)
+	require.Equal(t, "First line becomes the title\nbody & more", items[1].text)
+
+	t.Run("returns ErrInvalidImport - not an export", func(t *testing.T) {
+		err := readENEX(strings.NewReader(`<notes><note><title>x</title></note></notes>`), func(importItem) error { return nil })
+		require.ErrorIs(t, err, ErrInvalidImport)
+	})
+
+	t.Run("returns ErrInvalidImport - not XML", func(t *testing.T) {
+		err := readENEX(strings.NewReader(`<en-export><note><title>x</note>`), func(importItem) error { return nil })
+		require.ErrorIs(t, err, ErrInvalidImport)
+	})
+}
@@ -0,0 +1,48 @@
+package note
+
+import (
+	"context"
+	"time"
+
+	"github.com/alekslesik/online-note-z/lib/events"
+	"github.com/google/uuid"
+)
+
+// Delivers note events to the subscribers of each user, events.Bus and events.PGRelay implement it
+type EventBroker interface {
+	Publish(ctx context.Context, e events.Event)
+	Subscribe(username string) *events.Subscription
+}
+
+// Publish note changes to broker instead of an in-process bus, e.g. to share them between replicas
+func WithEvents(broker EventBroker) Option {
+	return func(s *service) {
+		s.events = broker
+	}
+}
+
+// Tell the subscribers of every user in usernames about a committed change of a note
+func (s *service) publish(ctx context.Context, typ events.Type, noteID uuid.UUID, version int32, usernames ...string) {
+	at := time.Now()
+
+	for i, username := range usernames {
+		// a grantee editing their own share is also the owner's event
+		if i > 0 && username == usernames[0] {
+			continue
+		}
+
+		s.events.Publish(ctx, events.Event{
+			ID:       uuid.New(),
+			Type:     typ,
+			NoteID:   noteID,
+			Username: username,
+			Version:  version,
+			At:       at,
+		})
+	}
+}
+
+// Receive the note events of the user until the subscription is closed
+func (s *service) SubscribeEvents(username string) *events.Subscription {
+	return s.events.Subscribe(username)
+}
@@ -0,0 +1,93 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/events"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoteEvents(t *testing.T) {
+	const owner = "user1"
+	id := uuid.New()
+
+	t.Run("creating a note publishes note.created", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+		sub := ns.SubscribeEvents(owner)
+		defer sub.Close()
+
+		mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).Return(id, nil)
+		mockdb.EXPECT().CreateNoteRevision(gomock.Any(), id).Times(1).Return(int32(1), nil)
+
+		_, err := ns.CreateNote(context.Background(), "title", owner, "text", nil, uuid.NullUUID{})
+		require.NoError(t, err)
+
+		e := <-sub.C
+		require.Equal(t, events.NoteCreated, e.Type)
+		require.Equal(t, id, e.NoteID)
+		require.Equal(t, owner, e.Username)
+		require.Equal(t, int32(1), e.Version)
+	})
+
+	t.Run("an edit by a grantee is published to the owner and the grantee", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		bus := events.NewBus(4)
+		ns := NewService(mockdb, WithEvents(bus))
+		ownerSub := bus.Subscribe(owner)
+		granteeSub := bus.Subscribe("user2")
+
+		mockdb.EXPECT().GetNoteAccess(gomock.Any(), gomock.Any()).Times(1).Return(db.GetNoteAccessRow{Owner: owner, Permission: "edit"}, nil)
+		mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).Return(int32(7), nil)
+		mockdb.EXPECT().CreateNoteRevision(gomock.Any(), id).Times(1).Return(int32(2), nil)
+
+		_, err := ns.UpdateNote(context.Background(), "user2", id, "title", "text", true, nil, 6)
+		require.NoError(t, err)
+
+		for _, sub := range []*events.Subscription{ownerSub, granteeSub} {
+			e := <-sub.C
+			require.Equal(t, events.NoteUpdated, e.Type)
+			require.Equal(t, int32(7), e.Version)
+		}
+	})
+
+	t.Run("deleting a note publishes note.deleted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+		sub := ns.SubscribeEvents(owner)
+		defer sub.Close()
+
+		mockdb.EXPECT().GetNoteAccess(gomock.Any(), gomock.Any()).Times(1).Return(db.GetNoteAccessRow{Owner: owner, Permission: "owner"}, nil)
+		mockdb.EXPECT().TrashNote(gomock.Any(), gomock.Any()).Times(1).Return(id, nil)
+
+		_, err := ns.DeleteNote(context.Background(), owner, id, 0)
+		require.NoError(t, err)
+
+		e := <-sub.C
+		require.Equal(t, events.NoteDeleted, e.Type)
+		require.Equal(t, id, e.NoteID)
+	})
+
+	t.Run("a failed change publishes nothing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+		sub := ns.SubscribeEvents(owner)
+		defer sub.Close()
+
+		mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrConnDone)
+
+		_, err := ns.CreateNote(context.Background(), "title", owner, "text", nil, uuid.NullUUID{})
+		require.ErrorIs(t, err, ErrDBInternal)
+		require.Empty(t, sub.C)
+	})
+}
@@ -0,0 +1,282 @@
+package note
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// How many notes are read per query while exporting
+	exportBatchSize = 200
+
+	exportFormatVersion = 1
+	manifestName        = "manifest.json"
+	maxExportNameLength = 100
+)
+
+// YAML front matter of an exported note
+type frontMatter struct {
+	ID       uuid.UUID `yaml:"id"`
+	Title    string    `yaml:"title"`
+	Notebook string    `yaml:"notebook,omitempty"`
+	Tags     []string  `yaml:"tags"`
+	Created  time.Time `yaml:"created"`
+	Updated  time.Time `yaml:"updated"`
+}
+
+// Index of the archive, written last as manifest.json
+type exportManifest struct {
+	Version    int                      `json:"version"`
+	ExportedAt time.Time                `json:"exportedAt"`
+	Username   string                   `json:"username"`
+	Notebooks  []exportManifestNotebook `json:"notebooks"`
+	Notes      []exportManifestNote     `json:"notes"`
+}
+
+type exportManifestNotebook struct {
+	ID       uuid.UUID  `json:"id"`
+	Name     string     `json:"name"`
+	ParentID *uuid.UUID `json:"parentId,omitempty"`
+	Path     string     `json:"path"`
+}
+
+type exportManifestNote struct {
+	ID         uuid.UUID  `json:"id"`
+	Title      string     `json:"title"`
+	Path       string     `json:"path"`
+	NotebookID *uuid.UUID `json:"notebookId,omitempty"`
+	Tags       []string   `json:"tags"`
+	Version    int32      `json:"version"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// Make a notebook name or note title usable as a file name on any OS
+func exportName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r), strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		default:
+			return r
+		}
+	}, name)
+
+	if utf8.RuneCountInString(name) > maxExportNameLength {
+		name = string([]rune(name)[:maxExportNameLength])
+	}
+
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return "untitled"
+	}
+
+	return name
+}
+
+// Hands out archive paths, case-insensitively unique since the archive may be unpacked on Windows or macOS
+type exportPaths map[string]bool
+
+func (p exportPaths) claim(dir string, name string, ext string) string {
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s (%d)", name, i)
+		}
+
+		full := path.Join(dir, candidate+ext)
+		if !p[strings.ToLower(full)] {
+			p[strings.ToLower(full)] = true
+			return full
+		}
+	}
+}
+
+// Give every notebook a directory, children are nested in their parents
+func notebookDirs(notebooks []db.Notebook, paths exportPaths) map[uuid.UUID]string {
+	children := make(map[uuid.UUID][]db.Notebook)
+	for _, nb := range notebooks {
+		children[nb.ParentID.UUID] = append(children[nb.ParentID.UUID], nb)
+	}
+	for _, c := range children {
+		sort.Slice(c, func(i, j int) bool {
+			if c[i].Name != c[j].Name {
+				return c[i].Name < c[j].Name
+			}
+			return c[i].ID.String() < c[j].ID.String()
+		})
+	}
+
+	dirs := make(map[uuid.UUID]string, len(notebooks))
+
+	var walk func(parent uuid.UUID, dir string)
+	walk = func(parent uuid.UUID, dir string) {
+		for _, nb := range children[parent] {
+			// the tree can't have cycles, but never loop on bad data
+			if _, seen := dirs[nb.ID]; seen {
+				continue
+			}
+			dirs[nb.ID] = paths.claim(dir, exportName(nb.Name), "")
+			walk(nb.ID, dirs[nb.ID])
+		}
+	}
+	walk(uuid.Nil, "")
+
+	return dirs
+}
+
+// Format a note as Markdown with YAML front matter
+func exportNote(n Note, notebookDir string) ([]byte, error) {
+	tags := n.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	fm, err := yaml.Marshal(frontMatter{
+		ID:       n.ID,
+		Title:    n.Title,
+		Notebook: notebookDir,
+		Tags:     tags,
+		Created:  n.CreatedAt.UTC(),
+		Updated:  n.UpdatedAt.UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(fm)
+	buf.WriteString("---\n\n")
+	buf.WriteString(n.Text.String)
+	if n.Text.String != "" && !strings.HasSuffix(n.Text.String, "\n") {
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Write every note of the user to w as a ZIP of Markdown files arranged by notebook, plus a manifest.
+// Note bodies are fetched and written in batches of exportBatchSize, only the manifest and the used paths are kept for all notes.
+// The archive is incomplete if an error is returned, the caller must not close anything on w.
+func (s *service) ExportNotes(ctx context.Context, username string, w io.Writer) error {
+	notebooks, err := s.q.ListNotebooks(ctx, username)
+	if err != nil {
+		return ErrDBInternal
+	}
+
+	paths := exportPaths{strings.ToLower(manifestName): true}
+	dirs := notebookDirs(notebooks, paths)
+
+	manifest := exportManifest{
+		Version:    exportFormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Username:   username,
+		Notebooks:  make([]exportManifestNotebook, 0, len(notebooks)),
+		Notes:      []exportManifestNote{},
+	}
+	for _, nb := range notebooks {
+		entry := exportManifestNotebook{ID: nb.ID, Name: nb.Name, Path: dirs[nb.ID]}
+		if nb.ParentID.Valid {
+			parentID := nb.ParentID.UUID
+			entry.ParentID = &parentID
+		}
+		manifest.Notebooks = append(manifest.Notebooks, entry)
+	}
+
+	zw := zip.NewWriter(w)
+
+	params := &db.ListNotesParams{
+		Username:      username,
+		Tags:          []string{},
+		MinTagMatches: 1,
+		SortBy:        SortByCreatedAt,
+		MaxResults:    exportBatchSize,
+	}
+	for {
+		page, err := s.q.ListNotes(ctx, params)
+		if err != nil {
+			return ErrDBInternal
+		}
+
+		notes, err := s.withTags(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		for _, n := range notes {
+			dir := ""
+			if n.NotebookID.Valid {
+				dir = dirs[n.NotebookID.UUID]
+			}
+
+			content, err := exportNote(n, dir)
+			if err != nil {
+				return fmt.Errorf("could not export note %v! %v", n.ID, err)
+			}
+
+			name := paths.claim(dir, exportName(n.Title), ".md")
+			f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: n.UpdatedAt})
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write(content); err != nil {
+				return err
+			}
+
+			entry := exportManifestNote{
+				ID:        n.ID,
+				Title:     n.Title,
+				Path:      name,
+				Tags:      n.Tags,
+				Version:   n.Version,
+				CreatedAt: n.CreatedAt.UTC(),
+				UpdatedAt: n.UpdatedAt.UTC(),
+			}
+			if entry.Tags == nil {
+				entry.Tags = []string{}
+			}
+			if n.NotebookID.Valid {
+				notebookID := n.NotebookID.UUID
+				entry.NotebookID = &notebookID
+			}
+			manifest.Notes = append(manifest.Notes, entry)
+		}
+
+		if len(page) < exportBatchSize {
+			break
+		}
+
+		last := page[len(page)-1]
+		params.HasCursor = true
+		params.CursorTime = last.CreatedAt
+		params.CursorID = last.ID
+	}
+
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: manifestName, Method: zip.Deflate, Modified: manifest.ExportedAt})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,181 @@
+package note
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportName(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "Shopping list", want: "Shopping list"},
+		{name: "path separators", in: "a/b\\c", want: "a_b_c"},
+		{name: "reserved characters", in: `what? "now": <x>|*`, want: "what_ _now__ _x___"},
+		{name: "control characters", in: "a\tb\nc", want: "a_b_c"},
+		{name: "dots and spaces", in: " ..hidden. ", want: "hidden"},
+		{name: "empty", in: "...", want: "untitled"},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, exportName(tc.in))
+		})
+	}
+}
+
+// Read every file of a ZIP archive
+func readZip(t *testing.T, b []byte) map[string]string {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+
+	files := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+
+	return files
+}
+
+func TestExportNotes(t *testing.T) {
+	const username = "user1"
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	work := db.Notebook{ID: uuid.New(), Username: username, Name: "Work"}
+	projects := db.Notebook{ID: uuid.New(), Username: username, Name: "Projects/2024", ParentID: uuid.NullUUID{UUID: work.ID, Valid: true}}
+
+	inbox := db.Note{ID: uuid.New(), Title: "Inbox", Username: username, Text: sql.NullString{String: "# Inbox\n\n- [ ] call", Valid: true}, CreatedAt: created, UpdatedAt: created, Version: 2}
+	plan := db.Note{ID: uuid.New(), Title: "Plan: Q1", Username: username, Text: sql.NullString{String: "plan", Valid: true}, NotebookID: uuid.NullUUID{UUID: projects.ID, Valid: true}, CreatedAt: created, UpdatedAt: created, Version: 1}
+
+	// a full first batch makes the export ask for the next one
+	batch := make([]db.Note, 0, exportBatchSize)
+	batch = append(batch, inbox, plan)
+	for i := len(batch); i < exportBatchSize; i++ {
+		batch = append(batch, db.Note{ID: uuid.New(), Title: fmt.Sprintf("note %d", i), Username: username, CreatedAt: created.Add(time.Duration(i) * time.Second)})
+	}
+	// same title as a note of the first batch, after making it a file name
+	clash := db.Note{ID: uuid.New(), Title: "Inbox", Username: username, CreatedAt: created.Add(time.Hour)}
+
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := NewService(mockdb)
+
+	mockdb.EXPECT().ListNotebooks(gomock.Any(), username).Times(1).Return([]db.Notebook{work, projects}, nil)
+	gomock.InOrder(
+		mockdb.EXPECT().ListNotes(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.ListNotesParams) ([]db.Note, error) {
+				require.False(t, arg.HasCursor)
+				require.Equal(t, int32(exportBatchSize), arg.MaxResults)
+				return batch, nil
+			}),
+		mockdb.EXPECT().ListNotes(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.ListNotesParams) ([]db.Note, error) {
+				last := batch[len(batch)-1]
+				require.True(t, arg.HasCursor)
+				require.Equal(t, last.ID, arg.CursorID)
+				require.Equal(t, last.CreatedAt, arg.CursorTime)
+				return []db.Note{clash}, nil
+			}),
+	)
+	mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), gomock.Any()).Times(2).
+		Return([]db.GetTagsOfNotesRow{{NoteID: inbox.ID, Name: "todo"}, {NoteID: inbox.ID, Name: "home"}}, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, ns.ExportNotes(context.Background(), username, &buf))
+
+	files := readZip(t, buf.Bytes())
+	require.Len(t, files, exportBatchSize+2)
+
+	require.Equal(t, fmt.Sprintf(`---
+id: %s
+title: Inbox
+tags:
+    - todo
+    - home
+created: 2024-03-01T10:00:00Z
+updated: 2024-03-01T10:00:00Z
+---
+
+# Inbox
+
+- [ ] call
+`, inbox.ID), files["Inbox.md"])
+	require.Contains(t, files, "Inbox (2).md")
+	require.Contains(t, files["Work/Projects_2024/Plan_ Q1.md"], "notebook: Work/Projects_2024\n")
+
+	var manifest exportManifest
+	require.NoError(t, json.Unmarshal([]byte(files[manifestName]), &manifest))
+	require.Equal(t, exportFormatVersion, manifest.Version)
+	require.Equal(t, username, manifest.Username)
+	require.Len(t, manifest.Notebooks, 2)
+	require.Len(t, manifest.Notes, exportBatchSize+1)
+	require.Equal(t, "Work/Projects_2024/Plan_ Q1.md", manifest.Notes[1].Path)
+	require.Equal(t, projects.ID, *manifest.Notes[1].NotebookID)
+	require.Equal(t, "Inbox (2).md", manifest.Notes[exportBatchSize].Path)
+}
+
+func TestExportNotesErrors(t *testing.T) {
+	const username = "user1"
+
+	t.Run("returns ErrDBInternal - notebooks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().ListNotebooks(gomock.Any(), username).Times(1).Return(nil, sql.ErrConnDone)
+
+		var buf bytes.Buffer
+		require.ErrorIs(t, ns.ExportNotes(context.Background(), username, &buf), ErrDBInternal)
+		require.Zero(t, buf.Len())
+	})
+
+	t.Run("returns ErrDBInternal - notes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().ListNotebooks(gomock.Any(), username).Times(1).Return([]db.Notebook{}, nil)
+		mockdb.EXPECT().ListNotes(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+
+		var buf bytes.Buffer
+		require.ErrorIs(t, ns.ExportNotes(context.Background(), username, &buf), ErrDBInternal)
+	})
+
+	t.Run("exporting no notes OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().ListNotebooks(gomock.Any(), username).Times(1).Return([]db.Notebook{}, nil)
+		mockdb.EXPECT().ListNotes(gomock.Any(), gomock.Any()).Times(1).Return([]db.Note{}, nil)
+
+		var buf bytes.Buffer
+		require.NoError(t, ns.ExportNotes(context.Background(), username, &buf))
+
+		files := readZip(t, buf.Bytes())
+		require.Len(t, files, 1)
+		require.Contains(t, files[manifestName], `"notes": []`)
+	})
+}
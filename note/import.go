@@ -0,0 +1,460 @@
+package note
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ImportAuto     = ""
+	ImportMarkdown = "markdown"
+	ImportENEX     = "enex"
+	ImportKeep     = "keep"
+
+	// How many files a ZIP may hold, and how large one note may be once unpacked
+	maxImportFiles    = 10000
+	maxImportNoteSize = 5 << 20
+
+	// Titles shorter than that are rejected by the API
+	minTitleLength   = 4
+	maxTitleSuffix   = 100
+	untitledTitle    = "Untitled"
+	shortTitleSuffix = " (imported)"
+)
+
+var (
+	ErrInvalidImport      = errors.New("import file is not a Markdown ZIP, an ENEX file or a Google Keep Takeout archive")
+	ErrImportTooLarge     = errors.New("import file has too many files")
+	ErrImportNoteTooLarge = errors.New("imported note is larger than 5 MiB")
+	ErrInvalidFrontMatter = errors.New("front matter is not valid YAML")
+)
+
+// Note read from an import file, err is set when it couldn't be read
+type importItem struct {
+	source   string
+	title    string
+	text     string
+	tags     []string
+	notebook []string // names from the top notebook down
+	err      error
+}
+
+// Outcome of one imported item, ID is set on success and Err on failure
+type ImportResult struct {
+	Source string
+	Title  string
+	ID     uuid.UUID
+	Err    error
+}
+
+type ImportReport struct {
+	Imported int
+	Failed   int
+	Results  []ImportResult
+}
+
+// Import notes from a Markdown ZIP, an Evernote ENEX file or a Google Keep Takeout archive.
+// ImportAuto detects the format. Every item is imported on its own and reported,
+// titles taken by other notes get a " (2)", " (3)"... suffix.
+func (s *service) ImportNotes(ctx context.Context, username string, format string, r io.ReaderAt, size int64) (ImportReport, error) {
+	var zr *zip.Reader
+	if format != ImportENEX {
+		var err error
+		zr, err = zip.NewReader(r, size)
+		switch {
+		case err == nil:
+			if len(zr.File) > maxImportFiles {
+				return ImportReport{}, ErrImportTooLarge
+			}
+		case format != ImportAuto || !looksLikeENEX(r):
+			return ImportReport{}, ErrInvalidImport
+		default:
+			format = ImportENEX
+		}
+	}
+
+	if format == ImportAuto {
+		format = ImportMarkdown
+		if isKeepTakeout(zr) {
+			format = ImportKeep
+		}
+	}
+
+	im := &importer{s: s, username: username, report: ImportReport{Results: []ImportResult{}}}
+
+	var err error
+	switch format {
+	case ImportMarkdown:
+		err = readMarkdownZip(zr, im.add(ctx))
+	case ImportKeep:
+		err = readKeepTakeout(zr, im.add(ctx))
+	case ImportENEX:
+		err = readENEX(io.NewSectionReader(r, 0, size), im.add(ctx))
+	default:
+		return ImportReport{}, ErrInvalidImport
+	}
+	if err != nil {
+		return im.report, err
+	}
+
+	return im.report, nil
+}
+
+// Creates the notes of an import and keeps track of the notebooks
+type importer struct {
+	s         *service
+	username  string
+	notebooks map[string]uuid.UUID // path of names -> ID, loaded on first use
+	report    ImportReport
+}
+
+func (im *importer) add(ctx context.Context) func(importItem) error {
+	return func(item importItem) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result := ImportResult{Source: item.source, Title: item.title}
+
+		if item.err == nil {
+			result.ID, result.Title, item.err = im.create(ctx, item)
+		}
+
+		if item.err != nil {
+			// the whole import is stopped by a broken DB, not by a bad item
+			if errors.Is(item.err, ErrDBInternal) {
+				return item.err
+			}
+			result.Err = item.err
+			im.report.Failed++
+		} else {
+			im.report.Imported++
+		}
+		im.report.Results = append(im.report.Results, result)
+
+		return nil
+	}
+}
+
+func (im *importer) create(ctx context.Context, item importItem) (uuid.UUID, string, error) {
+	notebookID, err := im.notebook(ctx, item.notebook)
+	if err != nil {
+		return uuid.Nil, item.title, err
+	}
+
+	title := importTitle(item.title)
+	for i := 1; i <= maxTitleSuffix; i++ {
+		candidate := title
+		if i > 1 {
+			candidate = fmt.Sprintf("%s (%d)", title, i)
+		}
+
+		id, err := im.s.CreateNote(ctx, candidate, im.username, item.text, item.tags, notebookID)
+		if !errors.Is(err, ErrAlreadyExists) {
+			return id, candidate, err
+		}
+	}
+
+	return uuid.Nil, title, ErrAlreadyExists
+}
+
+// Find or create the notebook at the given path
+func (im *importer) notebook(ctx context.Context, names []string) (uuid.NullUUID, error) {
+	if len(names) == 0 {
+		return uuid.NullUUID{}, nil
+	}
+
+	if im.notebooks == nil {
+		existing, err := im.s.ListNotebooks(ctx, im.username)
+		if err != nil {
+			return uuid.NullUUID{}, err
+		}
+		im.notebooks = notebookPaths(existing)
+	}
+
+	parent := uuid.NullUUID{}
+	for i := range names {
+		key := notebookPathKey(names[:i+1])
+		if id, ok := im.notebooks[key]; ok {
+			parent = uuid.NullUUID{UUID: id, Valid: true}
+			continue
+		}
+
+		nb, err := im.s.CreateNotebook(ctx, im.username, names[i], parent)
+		if err != nil {
+			return uuid.NullUUID{}, err
+		}
+		im.notebooks[key] = nb.ID
+		parent = uuid.NullUUID{UUID: nb.ID, Valid: true}
+	}
+
+	return parent, nil
+}
+
+func notebookPathKey(names []string) string {
+	return strings.Join(names, "\x00")
+}
+
+// Map the path of names of every notebook to its ID
+func notebookPaths(notebooks []db.Notebook) map[string]uuid.UUID {
+	byID := make(map[uuid.UUID]db.Notebook, len(notebooks))
+	for _, nb := range notebooks {
+		byID[nb.ID] = nb
+	}
+
+	paths := make(map[string]uuid.UUID, len(notebooks))
+	for _, nb := range notebooks {
+		names := []string{nb.Name}
+		for p := nb.ParentID; p.Valid && len(names) <= len(notebooks); p = byID[p.UUID].ParentID {
+			names = append([]string{byID[p.UUID].Name}, names...)
+		}
+		paths[notebookPathKey(names)] = nb.ID
+	}
+
+	return paths
+}
+
+// Make an imported title acceptable to the API
+func importTitle(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return untitledTitle
+	}
+	if utf8.RuneCountInString(title) < minTitleLength {
+		return title + shortTitleSuffix
+	}
+
+	return title
+}
+
+// Title from the first non-empty line of a text
+func titleFromText(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#-*>[] "))
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > 80 {
+			line = string([]rune(line)[:80])
+		}
+		return line
+	}
+
+	return ""
+}
+
+// Split names of a notebook path, empty segments are dropped
+func splitNotebookPath(p string) []string {
+	var names []string
+	for _, name := range strings.Split(p, "/") {
+		if name = strings.TrimSpace(name); name != "" && name != "." {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Read a ZIP entry, refusing ones that unpack to more than maxImportNoteSize
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxImportNoteSize {
+		return nil, ErrImportNoteTooLarge
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, ErrInvalidImport
+	}
+	defer rc.Close()
+
+	// the size in the header may lie
+	b, err := io.ReadAll(io.LimitReader(rc, maxImportNoteSize+1))
+	if err != nil {
+		return nil, ErrInvalidImport
+	}
+	if len(b) > maxImportNoteSize {
+		return nil, ErrImportNoteTooLarge
+	}
+
+	return b, nil
+}
+
+// Skip directories and files added by archivers
+func skipZipFile(f *zip.File) bool {
+	name := f.Name
+	return f.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".")
+}
+
+// Front matter as written by the export
+type importFrontMatter struct {
+	Title    string   `yaml:"title"`
+	Tags     []string `yaml:"tags"`
+	Notebook string   `yaml:"notebook"`
+}
+
+// Split the YAML front matter off a Markdown file
+func parseFrontMatter(content string) (importFrontMatter, string, error) {
+	var fm importFrontMatter
+
+	content = strings.TrimPrefix(content, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return fm, content, nil
+	}
+
+	rest := content[len("---\n"):]
+	end := strings.Index(rest, "\n---\n")
+	switch {
+	case strings.HasPrefix(rest, "---\n"):
+		return fm, strings.TrimPrefix(rest[len("---\n"):], "\n"), nil
+	case end < 0 && strings.HasSuffix(rest, "\n---"):
+		end = len(rest) - len("\n---")
+	case end < 0:
+		return fm, content, nil
+	}
+
+	err := yaml.Unmarshal([]byte(rest[:end]), &fm)
+	if err != nil {
+		return fm, "", ErrInvalidFrontMatter
+	}
+
+	body := ""
+	if end+len("\n---\n") <= len(rest) {
+		body = rest[end+len("\n---\n"):]
+	}
+
+	return fm, strings.TrimPrefix(body, "\n"), nil
+}
+
+// Read a ZIP of Markdown files, directories become notebooks unless the front matter names one
+func readMarkdownZip(zr *zip.Reader, fn func(importItem) error) error {
+	for _, f := range zr.File {
+		if skipZipFile(f) || f.Name == manifestName {
+			continue
+		}
+
+		ext := strings.ToLower(path.Ext(f.Name))
+		if ext != ".md" && ext != ".markdown" && ext != ".txt" {
+			continue
+		}
+
+		item := importItem{source: f.Name, title: strings.TrimSuffix(path.Base(f.Name), path.Ext(f.Name))}
+
+		content, err := readZipFile(f)
+		if err != nil {
+			item.err = err
+		} else if !utf8.Valid(content) {
+			item.err = ErrInvalidImport
+		} else {
+			var fm importFrontMatter
+			fm, item.text, item.err = parseFrontMatter(string(content))
+
+			if fm.Title != "" {
+				item.title = fm.Title
+			}
+			item.tags = fm.Tags
+			item.notebook = splitNotebookPath(path.Dir(f.Name))
+			if fm.Notebook != "" {
+				item.notebook = splitNotebookPath(fm.Notebook)
+			}
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Note of a Google Keep Takeout archive
+type keepNote struct {
+	Title       string `json:"title"`
+	TextContent string `json:"textContent"`
+	ListContent []struct {
+		Text      string `json:"text"`
+		IsChecked bool   `json:"isChecked"`
+	} `json:"listContent"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	IsTrashed bool `json:"isTrashed"`
+}
+
+func isKeepNote(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".json") && (strings.HasPrefix(name, "Keep/") || strings.Contains(name, "/Keep/"))
+}
+
+func isKeepTakeout(zr *zip.Reader) bool {
+	for _, f := range zr.File {
+		if isKeepNote(f.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Read the notes of a Google Keep Takeout archive, trashed notes are left out
+func readKeepTakeout(zr *zip.Reader, fn func(importItem) error) error {
+	for _, f := range zr.File {
+		if skipZipFile(f) || !isKeepNote(f.Name) {
+			continue
+		}
+
+		item := importItem{source: f.Name}
+
+		var kn keepNote
+		content, err := readZipFile(f)
+		if err == nil && json.Unmarshal(content, &kn) != nil {
+			err = ErrInvalidImport
+		}
+
+		switch {
+		case err != nil:
+			item.err = err
+		case kn.IsTrashed:
+			continue
+		default:
+			item.text = kn.TextContent
+			if len(kn.ListContent) > 0 {
+				var buf bytes.Buffer
+				for _, li := range kn.ListContent {
+					mark := " "
+					if li.IsChecked {
+						mark = "x"
+					}
+					fmt.Fprintf(&buf, "- [%s] %s\n", mark, li.Text)
+				}
+				item.text = buf.String()
+			}
+
+			item.title = kn.Title
+			if item.title == "" {
+				item.title = titleFromText(item.text)
+			}
+
+			for _, l := range kn.Labels {
+				item.tags = append(item.tags, l.Name)
+			}
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
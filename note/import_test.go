@@ -0,0 +1,259 @@
+package note
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Build a ZIP archive from names and contents
+func buildZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for name, content := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		wantFM   importFrontMatter
+		wantBody string
+		wantErr  error
+	}{
+		{
+			name:     "no front matter",
+			content:  "# Title\n\ntext",
+			wantBody: "# Title\n\ntext",
+		},
+		{
+			name:     "exported note",
+			content:  "---\nid: 6f1c\ntitle: 'Plan: Q1'\nnotebook: Work/Projects\ntags:\n    - a\n    - b\n---\n\nbody\n",
+			wantFM:   importFrontMatter{Title: "Plan: Q1", Tags: []string{"a", "b"}, Notebook: "Work/Projects"},
+			wantBody: "body\n",
+		},
+		{
+			name:     "windows line endings",
+			content:  "---\r\ntitle: x\r\n---\r\nbody",
+			wantFM:   importFrontMatter{Title: "x"},
+			wantBody: "body",
+		},
+		{
+			name:     "front matter only",
+			content:  "---\ntitle: x\n---",
+			wantFM:   importFrontMatter{Title: "x"},
+			wantBody: "",
+		},
+		{
+			name:     "unterminated is text",
+			content:  "---\nnot front matter",
+			wantBody: "---\nnot front matter",
+		},
+		{
+			name:    "returns ErrInvalidFrontMatter",
+			content: "---\ntags: [a\n---\nbody",
+			wantErr: ErrInvalidFrontMatter,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fm, body, err := parseFrontMatter(tc.content)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantFM, fm)
+			require.Equal(t, tc.wantBody, body)
+		})
+	}
+}
+
+func TestReadMarkdownZip(t *testing.T) {
+	b := buildZip(t, map[string]string{
+		"manifest.json":            `{}`,
+		"Inbox.md":                 "plain",
+		"Work/Plan.md":             "---\ntitle: The plan\ntags: [q1]\n---\n\nsteps",
+		"Work/Moved.md":            "---\nnotebook: Archive/2023\n---\nold",
+		"Work/picture.png":         "\x89PNG",
+		"__MACOSX/Work/._Plan.md":  "junk",
+		"Broken.md":                "---\ntags: [a\n---\n",
+		"Notes/Binary.txt":         "\xff\xfe",
+		"Notes/Markdown.markdown":  "long extension",
+		"Notes/.hidden/Secret.md":  "still imported, only the file name is checked",
+		"Notes/.DS_Store":          "junk",
+		"Notes/Sub Notes/Child.md": "child",
+	})
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+
+	items := make(map[string]importItem)
+	err = readMarkdownZip(zr, func(item importItem) error {
+		items[item.source] = item
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 8)
+
+	require.Equal(t, importItem{source: "Inbox.md", title: "Inbox", text: "plain"}, items["Inbox.md"])
+	require.Equal(t, importItem{source: "Work/Plan.md", title: "The plan", text: "steps", tags: []string{"q1"}, notebook: []string{"Work"}}, items["Work/Plan.md"])
+	require.Equal(t, []string{"Archive", "2023"}, items["Work/Moved.md"].notebook)
+	require.Equal(t, []string{"Notes", "Sub Notes"}, items["Notes/Sub Notes/Child.md"].notebook)
+	require.ErrorIs(t, items["Broken.md"].err, ErrInvalidFrontMatter)
+	require.ErrorIs(t, items["Notes/Binary.txt"].err, ErrInvalidImport)
+	require.Contains(t, items, "Notes/Markdown.markdown")
+}
+
+func TestReadKeepTakeout(t *testing.T) {
+	b := buildZip(t, map[string]string{
+		"Takeout/Keep/Groceries.json":  `{"title":"Groceries","listContent":[{"text":"milk","isChecked":true},{"text":"bread","isChecked":false}],"labels":[{"name":"Home"}]}`,
+		"Takeout/Keep/Untitled.json":   `{"title":"","textContent":"Call the bank\nabout the card"}`,
+		"Takeout/Keep/Trashed.json":    `{"title":"Gone","textContent":"x","isTrashed":true}`,
+		"Takeout/Keep/Broken.json":     `{"title":`,
+		"Takeout/Keep/Groceries.html":  `<html></html>`,
+		"Takeout/archive_browser.html": `<html></html>`,
+	})
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+	require.True(t, isKeepTakeout(zr))
+
+	items := make(map[string]importItem)
+	err = readKeepTakeout(zr, func(item importItem) error {
+		items[item.source] = item
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	groceries := items["Takeout/Keep/Groceries.json"]
+	require.Equal(t, "Groceries", groceries.title)
+	require.Equal(t, "- [x] milk\n- [ ] bread\n", groceries.text)
+	require.Equal(t, []string{"Home"}, groceries.tags)
+
+	require.Equal(t, "Call the bank", items["Takeout/Keep/Untitled.json"].title)
+	require.ErrorIs(t, items["Takeout/Keep/Broken.json"].err, ErrInvalidImport)
+}
+
+func TestImportTitle(t *testing.T) {
+	require.Equal(t, "Untitled", importTitle("  "))
+	require.Equal(t, "AI (imported)", importTitle("AI"))
+	require.Equal(t, "Weekly plan", importTitle(" Weekly \n plan "))
+}
+
+func TestImportNotes(t *testing.T) {
+	const username = "user1"
+	work := db.Notebook{ID: uuid.New(), Username: username, Name: "Work"}
+
+	t.Run("importing markdown with title collisions and notebooks OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		b := buildZip(t, map[string]string{
+			"Work/Projects/Plan.md": "steps",
+		})
+
+		projectsID := uuid.New()
+		mockdb.EXPECT().ListNotebooks(gomock.Any(), username).Times(1).Return([]db.Notebook{work}, nil)
+		mockdb.EXPECT().GetNotebook(gomock.Any(), gomock.Any()).AnyTimes().Return(db.Notebook{}, nil)
+		mockdb.EXPECT().CreateNotebook(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.CreateNotebookParams) (db.Notebook, error) {
+				require.Equal(t, "Projects", arg.Name)
+				require.Equal(t, uuid.NullUUID{UUID: work.ID, Valid: true}, arg.ParentID)
+				return db.Notebook{ID: projectsID, Name: arg.Name, ParentID: arg.ParentID}, nil
+			})
+		gomock.InOrder(
+			mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).
+				DoAndReturn(func(_ context.Context, arg *db.CreateNoteParams) (uuid.UUID, error) {
+					require.Equal(t, "Plan", arg.Title)
+					return uuid.Nil, uniqueViolation()
+				}),
+			mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).
+				DoAndReturn(func(_ context.Context, arg *db.CreateNoteParams) (uuid.UUID, error) {
+					require.Equal(t, "Plan (2)", arg.Title)
+					require.Equal(t, uuid.NullUUID{UUID: projectsID, Valid: true}, arg.NotebookID)
+					return arg.ID, nil
+				}),
+		)
+		mockdb.EXPECT().FindTitleConflict(gomock.Any(), gomock.Any()).Times(1).Return(uuid.New(), nil)
+		mockdb.EXPECT().CreateNoteRevision(gomock.Any(), gomock.Any()).Times(1).Return(int32(1), nil)
+
+		report, err := ns.ImportNotes(context.Background(), username, ImportAuto, bytes.NewReader(b), int64(len(b)))
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Imported)
+		require.Zero(t, report.Failed)
+		require.Equal(t, "Plan (2)", report.Results[0].Title)
+		require.NotEqual(t, uuid.Nil, report.Results[0].ID)
+	})
+
+	t.Run("importing ENEX reports failed items", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		enex := `<en-export>
+  <note><title>Good note</title><content><![CDATA[<en-note>text</en-note>]]></content></note>
+  <note><title>Bad tag</title><tag>` + "a\tb" + `</tag><content><![CDATA[<en-note>text</en-note>]]></content></note>
+</en-export>`
+
+		mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.CreateNoteParams) (uuid.UUID, error) {
+				require.Equal(t, "Good note", arg.Title)
+				return arg.ID, nil
+			})
+		mockdb.EXPECT().CreateNoteRevision(gomock.Any(), gomock.Any()).Times(1).Return(int32(1), nil)
+
+		r := bytes.NewReader([]byte(enex))
+		report, err := ns.ImportNotes(context.Background(), username, ImportAuto, r, r.Size())
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Imported)
+		require.Equal(t, 1, report.Failed)
+		require.Equal(t, "Bad tag", report.Results[1].Title)
+		require.ErrorIs(t, report.Results[1].Err, ErrInvalidTag)
+	})
+
+	t.Run("returns ErrDBInternal and the report so far", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		b := buildZip(t, map[string]string{"Inbox.md": "text"})
+		mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrConnDone)
+
+		_, err := ns.ImportNotes(context.Background(), username, ImportMarkdown, bytes.NewReader(b), int64(len(b)))
+		require.ErrorIs(t, err, ErrDBInternal)
+	})
+
+	t.Run("returns ErrInvalidImport", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		r := bytes.NewReader([]byte("just some text"))
+		_, err := ns.ImportNotes(context.Background(), username, ImportAuto, r, r.Size())
+		require.ErrorIs(t, err, ErrInvalidImport)
+
+		_, err = ns.ImportNotes(context.Background(), username, ImportKeep, r, r.Size())
+		require.ErrorIs(t, err, ErrInvalidImport)
+	})
+}
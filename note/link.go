@@ -0,0 +1,164 @@
+package note
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/password"
+	"github.com/google/uuid"
+)
+
+// Random bytes in a link token, enough that tokens can't be guessed
+const linkTokenSize = 32
+
+// Create an unguessable link token and the hash it is stored under
+func newLinkToken() (string, string, error) {
+	b := make([]byte, linkTokenSize)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", fmt.Errorf("could not generate a random link token! %v", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, hashLinkToken(token), nil
+}
+
+// The tokens are random, a plain hash is enough to keep a DB leak from exposing them
+func hashLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Start of the stored token hash, enough to find the link in the DB and safe to log unlike the token
+func LinkTokenHashPrefix(token string) string {
+	return hashLinkToken(token)[:12]
+}
+
+// Public link to a note of owner, returned with its token which isn't stored and can't be shown again.
+// An empty password leaves the link open, a zero expiresAt never expires.
+func (s *service) CreateLink(ctx context.Context, owner string, noteID uuid.UUID, pass string, expiresAt time.Time) (db.NoteLink, string, error) {
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return db.NoteLink{}, "", ErrInvalidLinkExpiry
+	}
+
+	var passwordHash sql.NullString
+	if pass != "" {
+		hash, err := password.Hash(pass)
+		if errors.Is(err, password.ErrTooShort) {
+			return db.NoteLink{}, "", ErrInvalidLinkPassword
+		}
+		if err != nil {
+			return db.NoteLink{}, "", err
+		}
+		passwordHash = sql.NullString{String: hash, Valid: true}
+	}
+
+	token, tokenHash, err := newLinkToken()
+	if err != nil {
+		return db.NoteLink{}, "", err
+	}
+
+	_, err = s.q.GetNote(ctx, &db.GetNoteParams{ID: noteID, Username: owner})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return db.NoteLink{}, "", ErrNotFound
+	case err != nil:
+		return db.NoteLink{}, "", ErrDBInternal
+	}
+
+	link, err := s.q.CreateNoteLink(ctx, &db.CreateNoteLinkParams{
+		ID:           uuid.New(),
+		NoteID:       noteID,
+		TokenHash:    tokenHash,
+		PasswordHash: passwordHash,
+		ExpiresAt:    sql.NullTime{Time: expiresAt, Valid: !expiresAt.IsZero()},
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		return db.NoteLink{}, "", ErrDBInternal
+	}
+
+	return link, token, nil
+}
+
+// Return the public links of a note of owner, expired ones included
+func (s *service) ListLinks(ctx context.Context, owner string, noteID uuid.UUID) ([]db.NoteLink, error) {
+	_, err := s.q.GetNote(ctx, &db.GetNoteParams{ID: noteID, Username: owner})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, ErrDBInternal
+	}
+
+	links, err := s.q.ListNoteLinks(ctx, &db.ListNoteLinksParams{NoteID: noteID, Username: owner})
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	return links, nil
+}
+
+// Delete a public link, the token stops working at once
+func (s *service) RevokeLink(ctx context.Context, owner string, noteID uuid.UUID, linkID uuid.UUID) error {
+	_, err := s.q.DeleteNoteLink(ctx, &db.DeleteNoteLinkParams{ID: linkID, NoteID: noteID, Username: owner})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrLinkNotFound
+	case err != nil:
+		return ErrDBInternal
+	default:
+		return nil
+	}
+}
+
+// Open the note behind a public link and render it, counting the view.
+// The password is only checked when the link has one.
+func (s *service) OpenLink(ctx context.Context, token string, pass string) (Note, string, error) {
+	row, err := s.q.GetLinkedNote(ctx, hashLinkToken(token))
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return Note{}, "", ErrLinkNotFound
+	case err != nil:
+		return Note{}, "", ErrDBInternal
+	}
+
+	link := row.NoteLink
+	if link.ExpiresAt.Valid && !link.ExpiresAt.Time.After(time.Now()) {
+		return Note{}, "", ErrLinkExpired
+	}
+
+	if link.PasswordHash.Valid {
+		if pass == "" {
+			return Note{}, "", ErrLinkPasswordRequired
+		}
+		if password.Validate(link.PasswordHash.String, pass) != nil {
+			return Note{}, "", ErrLinkPasswordIncorrect
+		}
+	}
+
+	html, err := s.render(row.Note)
+	if err != nil {
+		return Note{}, "", err
+	}
+
+	err = s.q.CountLinkView(ctx, link.ID)
+	if err != nil {
+		return Note{}, "", ErrDBInternal
+	}
+
+	return Note{Note: row.Note}, html, nil
+}
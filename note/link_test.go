@@ -0,0 +1,171 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/password"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateLink(t *testing.T) {
+	const owner = "user1"
+	noteID := uuid.New()
+
+	t.Run("creating link with password and expiry OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		expiresAt := time.Now().Add(time.Hour)
+		mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: noteID, Username: owner}).Times(1).Return(db.Note{ID: noteID}, nil)
+
+		var stored *db.CreateNoteLinkParams
+		mockdb.EXPECT().CreateNoteLink(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.CreateNoteLinkParams) (db.NoteLink, error) {
+				stored = arg
+				return db.NoteLink{ID: arg.ID, NoteID: arg.NoteID, TokenHash: arg.TokenHash, PasswordHash: arg.PasswordHash, ExpiresAt: arg.ExpiresAt}, nil
+			})
+
+		link, token, err := ns.CreateLink(context.Background(), owner, noteID, "secret", expiresAt)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+		require.Equal(t, hashLinkToken(token), stored.TokenHash)
+		require.NotEqual(t, token, stored.TokenHash)
+		require.NoError(t, password.Validate(stored.PasswordHash.String, "secret"))
+		require.True(t, link.ExpiresAt.Time.Equal(expiresAt))
+	})
+
+	t.Run("returns ErrInvalidLinkExpiry", func(t *testing.T) {
+		ns := NewService(mockdb.NewMockQuerier(gomock.NewController(t)))
+		_, _, err := ns.CreateLink(context.Background(), owner, noteID, "", time.Now().Add(-time.Minute))
+		require.ErrorIs(t, err, ErrInvalidLinkExpiry)
+	})
+
+	t.Run("returns ErrInvalidLinkPassword", func(t *testing.T) {
+		ns := NewService(mockdb.NewMockQuerier(gomock.NewController(t)))
+		_, _, err := ns.CreateLink(context.Background(), owner, noteID, "abc", time.Time{})
+		require.ErrorIs(t, err, ErrInvalidLinkPassword)
+	})
+
+	t.Run("returns ErrNotFound - note of another user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(db.Note{}, sql.ErrNoRows)
+		_, _, err := ns.CreateLink(context.Background(), owner, noteID, "", time.Time{})
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestOpenLink(t *testing.T) {
+	const token = "token"
+	linkID := uuid.New()
+	n := db.Note{ID: uuid.New(), Title: "Plan", Text: sql.NullString{String: "**bold**", Valid: true}, Version: 2}
+
+	hash, err := password.Hash("secret")
+	require.NoError(t, err)
+	protected := db.NoteLink{ID: linkID, PasswordHash: sql.NullString{String: hash, Valid: true}}
+
+	testCases := []struct {
+		name        string
+		password    string
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "opening link OK - view counted",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetLinkedNote(gomock.Any(), hashLinkToken(token)).Times(1).Return(db.GetLinkedNoteRow{NoteLink: db.NoteLink{ID: linkID}, Note: n}, nil)
+				mockdb.EXPECT().CountLinkView(gomock.Any(), linkID).Times(1).Return(nil)
+			},
+		},
+		{
+			name:     "opening protected link OK",
+			password: "secret",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetLinkedNote(gomock.Any(), gomock.Any()).Times(1).Return(db.GetLinkedNoteRow{NoteLink: protected, Note: n}, nil)
+				mockdb.EXPECT().CountLinkView(gomock.Any(), linkID).Times(1).Return(nil)
+			},
+		},
+		{
+			name: "returns ErrLinkPasswordRequired",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetLinkedNote(gomock.Any(), gomock.Any()).Times(1).Return(db.GetLinkedNoteRow{NoteLink: protected, Note: n}, nil)
+			},
+			wantErr: ErrLinkPasswordRequired,
+		},
+		{
+			name:     "returns ErrLinkPasswordIncorrect",
+			password: "guess1",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetLinkedNote(gomock.Any(), gomock.Any()).Times(1).Return(db.GetLinkedNoteRow{NoteLink: protected, Note: n}, nil)
+			},
+			wantErr: ErrLinkPasswordIncorrect,
+		},
+		{
+			name: "returns ErrLinkExpired",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				expired := db.NoteLink{ID: linkID, ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}}
+				mockdb.EXPECT().GetLinkedNote(gomock.Any(), gomock.Any()).Times(1).Return(db.GetLinkedNoteRow{NoteLink: expired, Note: n}, nil)
+			},
+			wantErr: ErrLinkExpired,
+		},
+		{
+			name: "returns ErrLinkNotFound - revoked or note trashed",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetLinkedNote(gomock.Any(), gomock.Any()).Times(1).Return(db.GetLinkedNoteRow{}, sql.ErrNoRows)
+			},
+			wantErr: ErrLinkNotFound,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			got, html, err := ns.OpenLink(context.Background(), token, tc.password)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "Plan", got.Title)
+			require.Equal(t, "<p><strong>bold</strong></p>\n", html)
+		})
+	}
+}
+
+func TestLinkTokenHashPrefix(t *testing.T) {
+	token, hash, err := newLinkToken()
+	require.NoError(t, err)
+
+	prefix := LinkTokenHashPrefix(token)
+	require.True(t, strings.HasPrefix(hash, prefix))
+	require.Less(t, len(prefix), len(hash))
+}
+
+func TestRevokeLink(t *testing.T) {
+	const owner = "user1"
+	noteID, linkID := uuid.New(), uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := NewService(mockdb)
+
+	mockdb.EXPECT().DeleteNoteLink(gomock.Any(), &db.DeleteNoteLinkParams{ID: linkID, NoteID: noteID, Username: owner}).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+	require.ErrorIs(t, ns.RevokeLink(context.Background(), owner, noteID, linkID), ErrLinkNotFound)
+}
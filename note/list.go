@@ -0,0 +1,177 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/google/uuid"
+)
+
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByTitle     = "title"
+
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// Filters, sorting and the page to return. Zero times are no filter, the ranges are [from, to).
+type ListOptions struct {
+	Limit        int32
+	Cursor       string
+	SortBy       string
+	Descending   bool
+	CreatedFrom  time.Time
+	CreatedTo    time.Time
+	UpdatedFrom  time.Time
+	UpdatedTo    time.Time
+	Tags         []string
+	MatchAllTags bool
+}
+
+// One page of notes. NextCursor is empty on the last page, Total counts all notes matching the filters.
+type NotePage struct {
+	Notes      []Note
+	NextCursor string
+	Total      int64
+}
+
+// Position after the last note of a page. The sort order is part of it,
+// so a cursor can't be reused with a different order.
+type cursor struct {
+	SortBy     string    `json:"s"`
+	Descending bool      `json:"d"`
+	Time       time.Time `json:"t,omitempty"`
+	Title      string    `json:"n,omitempty"`
+	ID         uuid.UUID `json:"i"`
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func cursorAfter(n db.Note, sortBy string, descending bool) cursor {
+	c := cursor{SortBy: sortBy, Descending: descending, ID: n.ID}
+
+	switch sortBy {
+	case SortByUpdatedAt:
+		c.Time = n.UpdatedAt
+	case SortByTitle:
+		c.Title = n.Title
+	default:
+		c.Time = n.CreatedAt
+	}
+
+	return c
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// Return a page of the user's notes using keyset pagination
+func (s *service) ListNotes(ctx context.Context, username string, opts ListOptions) (NotePage, error) {
+	if opts.SortBy == "" {
+		opts.SortBy = SortByCreatedAt
+	}
+	if opts.SortBy != SortByCreatedAt && opts.SortBy != SortByUpdatedAt && opts.SortBy != SortByTitle {
+		return NotePage{}, ErrInvalidSort
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultListLimit
+	}
+	if opts.Limit > MaxListLimit {
+		opts.Limit = MaxListLimit
+	}
+
+	tags, err := normalizeTags(opts.Tags)
+	if err != nil {
+		return NotePage{}, err
+	}
+
+	minTagMatches := int32(1)
+	if opts.MatchAllTags {
+		minTagMatches = int32(len(tags))
+	}
+
+	params := &db.ListNotesParams{
+		Username:      username,
+		CreatedFrom:   nullTime(opts.CreatedFrom),
+		CreatedTo:     nullTime(opts.CreatedTo),
+		UpdatedFrom:   nullTime(opts.UpdatedFrom),
+		UpdatedTo:     nullTime(opts.UpdatedTo),
+		Tags:          tags,
+		MinTagMatches: minTagMatches,
+		SortBy:        opts.SortBy,
+		Descending:    opts.Descending,
+		// one extra row tells if there is a next page
+		MaxResults: opts.Limit + 1,
+	}
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return NotePage{}, err
+		}
+		if c.SortBy != opts.SortBy || c.Descending != opts.Descending {
+			return NotePage{}, ErrInvalidCursor
+		}
+
+		params.HasCursor = true
+		params.CursorTime = c.Time
+		params.CursorTitle = c.Title
+		params.CursorID = c.ID
+	}
+
+	notes, err := s.q.ListNotes(ctx, params)
+	if err != nil {
+		return NotePage{}, ErrDBInternal
+	}
+
+	total, err := s.q.CountNotes(ctx, &db.CountNotesParams{
+		Username:      username,
+		CreatedFrom:   params.CreatedFrom,
+		CreatedTo:     params.CreatedTo,
+		UpdatedFrom:   params.UpdatedFrom,
+		UpdatedTo:     params.UpdatedTo,
+		Tags:          tags,
+		MinTagMatches: minTagMatches,
+	})
+	if err != nil {
+		return NotePage{}, ErrDBInternal
+	}
+
+	var next string
+	if len(notes) > int(opts.Limit) {
+		notes = notes[:opts.Limit]
+		next = encodeCursor(cursorAfter(notes[len(notes)-1], opts.SortBy, opts.Descending))
+	}
+
+	tagged, err := s.withTags(ctx, notes)
+	if err != nil {
+		return NotePage{}, err
+	}
+
+	return NotePage{Notes: tagged, NextCursor: next, Total: total}, nil
+}
@@ -0,0 +1,117 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListNotes(t *testing.T) {
+	const username = "user1"
+	now := time.Now().Truncate(time.Microsecond).UTC()
+	notes := []db.Note{
+		{ID: uuid.New(), Title: "a", UpdatedAt: now},
+		{ID: uuid.New(), Title: "b", UpdatedAt: now.Add(-time.Minute)},
+		{ID: uuid.New(), Title: "c", UpdatedAt: now.Add(-time.Hour)},
+	}
+
+	t.Run("default options", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().ListNotes(gomock.Any(), &db.ListNotesParams{
+			Username:      username,
+			Tags:          []string{},
+			MinTagMatches: 1,
+			SortBy:        SortByCreatedAt,
+			MaxResults:    DefaultListLimit + 1,
+		}).Times(1).Return(notes, nil)
+		mockdb.EXPECT().CountNotes(gomock.Any(), gomock.Any()).Times(1).Return(int64(3), nil)
+		mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), gomock.Any()).Times(1).Return([]db.GetTagsOfNotesRow{}, nil)
+
+		page, err := ns.ListNotes(context.Background(), username, ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, page.Notes, 3)
+		require.Empty(t, page.NextCursor)
+		require.Equal(t, int64(3), page.Total)
+	})
+
+	t.Run("next cursor continues after the last note", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		opts := ListOptions{Limit: 2, SortBy: SortByUpdatedAt, Descending: true, Tags: []string{"Work", "home"}, MatchAllTags: true}
+
+		mockdb.EXPECT().ListNotes(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.ListNotesParams) ([]db.Note, error) {
+				require.False(t, arg.HasCursor)
+				require.Equal(t, int32(3), arg.MaxResults)
+				require.Equal(t, []string{"home", "work"}, arg.Tags)
+				require.Equal(t, int32(2), arg.MinTagMatches)
+				return notes, nil
+			})
+		mockdb.EXPECT().CountNotes(gomock.Any(), gomock.Any()).Times(2).Return(int64(3), nil)
+		mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), gomock.Any()).Times(2).Return([]db.GetTagsOfNotesRow{}, nil)
+
+		page, err := ns.ListNotes(context.Background(), username, opts)
+		require.NoError(t, err)
+		require.Len(t, page.Notes, 2)
+		require.NotEmpty(t, page.NextCursor)
+
+		mockdb.EXPECT().ListNotes(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.ListNotesParams) ([]db.Note, error) {
+				require.True(t, arg.HasCursor)
+				require.Equal(t, notes[1].ID, arg.CursorID)
+				require.True(t, notes[1].UpdatedAt.Equal(arg.CursorTime))
+				return notes[2:], nil
+			})
+
+		opts.Cursor = page.NextCursor
+		page, err = ns.ListNotes(context.Background(), username, opts)
+		require.NoError(t, err)
+		require.Len(t, page.Notes, 1)
+		require.Empty(t, page.NextCursor)
+	})
+
+	t.Run("returns ErrInvalidCursor - different sort order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		c := encodeCursor(cursor{SortBy: SortByTitle, ID: uuid.New(), Title: "a"})
+		_, err := ns.ListNotes(context.Background(), username, ListOptions{Cursor: c, SortBy: SortByTitle, Descending: true})
+		require.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = ns.ListNotes(context.Background(), username, ListOptions{Cursor: "%%%"})
+		require.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("returns ErrInvalidSort", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		_, err := ns.ListNotes(context.Background(), username, ListOptions{SortBy: "text"})
+		require.ErrorIs(t, err, ErrInvalidSort)
+	})
+
+	t.Run("returns ErrDBInternal", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().ListNotes(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+
+		_, err := ns.ListNotes(context.Background(), username, ListOptions{Limit: 1000})
+		require.ErrorIs(t, err, ErrDBInternal)
+	})
+}
@@ -1 +1,817 @@
-package mock
\ No newline at end of file
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/alekslesik/online-note-z/server/http (interfaces: NoteService)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	time "time"
+
+	sqlc "github.com/alekslesik/online-note-z/db/sqlc"
+	events "github.com/alekslesik/online-note-z/lib/events"
+	note "github.com/alekslesik/online-note-z/note"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockNoteService is a mock of NoteService interface.
+type MockNoteService struct {
+	ctrl     *gomock.Controller
+	recorder *MockNoteServiceMockRecorder
+}
+
+// MockNoteServiceMockRecorder is the mock recorder for MockNoteService.
+type MockNoteServiceMockRecorder struct {
+	mock *MockNoteService
+}
+
+// NewMockNoteService creates a new mock instance.
+func NewMockNoteService(ctrl *gomock.Controller) *MockNoteService {
+	mock := &MockNoteService{ctrl: ctrl}
+	mock.recorder = &MockNoteServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNoteService) EXPECT() *MockNoteServiceMockRecorder {
+	return m.recorder
+}
+
+// AddAttachment mocks base method.
+func (m *MockNoteService) AddAttachment(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3, arg4 string, arg5 int64, arg6 io.Reader) (sqlc.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAttachment", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].(sqlc.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAttachment indicates an expected call of AddAttachment.
+func (mr *MockNoteServiceMockRecorder) AddAttachment(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAttachment", reflect.TypeOf((*MockNoteService)(nil).AddAttachment), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
+// CreateLink mocks base method.
+func (m *MockNoteService) CreateLink(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3 string, arg4 time.Time) (sqlc.NoteLink, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLink", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(sqlc.NoteLink)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateLink indicates an expected call of CreateLink.
+func (mr *MockNoteServiceMockRecorder) CreateLink(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLink", reflect.TypeOf((*MockNoteService)(nil).CreateLink), arg0, arg1, arg2, arg3, arg4)
+}
+
+// CreateNote mocks base method.
+func (m *MockNoteService) CreateNote(arg0 context.Context, arg1, arg2, arg3 string, arg4 []string, arg5 uuid.NullUUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNote", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNote indicates an expected call of CreateNote.
+func (mr *MockNoteServiceMockRecorder) CreateNote(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNote", reflect.TypeOf((*MockNoteService)(nil).CreateNote), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// CreateNotebook mocks base method.
+func (m *MockNoteService) CreateNotebook(arg0 context.Context, arg1, arg2 string, arg3 uuid.NullUUID) (sqlc.Notebook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNotebook", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(sqlc.Notebook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNotebook indicates an expected call of CreateNotebook.
+func (mr *MockNoteServiceMockRecorder) CreateNotebook(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotebook", reflect.TypeOf((*MockNoteService)(nil).CreateNotebook), arg0, arg1, arg2, arg3)
+}
+
+// CreateSession mocks base method.
+func (m *MockNoteService) CreateSession(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 time.Time) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockNoteServiceMockRecorder) CreateSession(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockNoteService)(nil).CreateSession), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// DeleteAttachment mocks base method.
+func (m *MockNoteService) DeleteAttachment(arg0 context.Context, arg1 string, arg2, arg3 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttachment", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttachment indicates an expected call of DeleteAttachment.
+func (mr *MockNoteServiceMockRecorder) DeleteAttachment(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttachment", reflect.TypeOf((*MockNoteService)(nil).DeleteAttachment), arg0, arg1, arg2, arg3)
+}
+
+// DeleteNote mocks base method.
+func (m *MockNoteService) DeleteNote(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3 int32) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNote", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteNote indicates an expected call of DeleteNote.
+func (mr *MockNoteServiceMockRecorder) DeleteNote(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNote", reflect.TypeOf((*MockNoteService)(nil).DeleteNote), arg0, arg1, arg2, arg3)
+}
+
+// DeleteNotebook mocks base method.
+func (m *MockNoteService) DeleteNotebook(arg0 context.Context, arg1 string, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotebook", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNotebook indicates an expected call of DeleteNotebook.
+func (mr *MockNoteServiceMockRecorder) DeleteNotebook(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotebook", reflect.TypeOf((*MockNoteService)(nil).DeleteNotebook), arg0, arg1, arg2)
+}
+
+// DiffRevisions mocks base method.
+func (m *MockNoteService) DiffRevisions(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3, arg4 int32) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffRevisions", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffRevisions indicates an expected call of DiffRevisions.
+func (mr *MockNoteServiceMockRecorder) DiffRevisions(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffRevisions", reflect.TypeOf((*MockNoteService)(nil).DiffRevisions), arg0, arg1, arg2, arg3, arg4)
+}
+
+// EmptyTrash mocks base method.
+func (m *MockNoteService) EmptyTrash(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmptyTrash", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EmptyTrash indicates an expected call of EmptyTrash.
+func (mr *MockNoteServiceMockRecorder) EmptyTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmptyTrash", reflect.TypeOf((*MockNoteService)(nil).EmptyTrash), arg0, arg1)
+}
+
+// ExportNotes mocks base method.
+func (m *MockNoteService) ExportNotes(arg0 context.Context, arg1 string, arg2 io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportNotes", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportNotes indicates an expected call of ExportNotes.
+func (mr *MockNoteServiceMockRecorder) ExportNotes(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportNotes", reflect.TypeOf((*MockNoteService)(nil).ExportNotes), arg0, arg1, arg2)
+}
+
+// GetAllNotesFromUser mocks base method.
+func (m *MockNoteService) GetAllNotesFromUser(arg0 context.Context, arg1 string) ([]note.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllNotesFromUser", arg0, arg1)
+	ret0, _ := ret[0].([]note.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllNotesFromUser indicates an expected call of GetAllNotesFromUser.
+func (mr *MockNoteServiceMockRecorder) GetAllNotesFromUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllNotesFromUser", reflect.TypeOf((*MockNoteService)(nil).GetAllNotesFromUser), arg0, arg1)
+}
+
+// GetNote mocks base method.
+func (m *MockNoteService) GetNote(arg0 context.Context, arg1 string, arg2 uuid.UUID) (note.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNote", arg0, arg1, arg2)
+	ret0, _ := ret[0].(note.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNote indicates an expected call of GetNote.
+func (mr *MockNoteServiceMockRecorder) GetNote(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNote", reflect.TypeOf((*MockNoteService)(nil).GetNote), arg0, arg1, arg2)
+}
+
+// GetNotebookTree mocks base method.
+func (m *MockNoteService) GetNotebookTree(arg0 context.Context, arg1 string, arg2 uuid.UUID) (*note.NotebookTree, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotebookTree", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*note.NotebookTree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotebookTree indicates an expected call of GetNotebookTree.
+func (mr *MockNoteServiceMockRecorder) GetNotebookTree(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotebookTree", reflect.TypeOf((*MockNoteService)(nil).GetNotebookTree), arg0, arg1, arg2)
+}
+
+// GetRevision mocks base method.
+func (m *MockNoteService) GetRevision(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3 int32) (sqlc.NoteRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(sqlc.NoteRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockNoteServiceMockRecorder) GetRevision(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockNoteService)(nil).GetRevision), arg0, arg1, arg2, arg3)
+}
+
+// GetSettings mocks base method.
+func (m *MockNoteService) GetSettings(arg0 context.Context, arg1 string) (note.Settings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettings", arg0, arg1)
+	ret0, _ := ret[0].(note.Settings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettings indicates an expected call of GetSettings.
+func (mr *MockNoteServiceMockRecorder) GetSettings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettings", reflect.TypeOf((*MockNoteService)(nil).GetSettings), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockNoteService) GetUser(arg0 context.Context, arg1 string) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockNoteServiceMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockNoteService)(nil).GetUser), arg0, arg1)
+}
+
+// ImportNotes mocks base method.
+func (m *MockNoteService) ImportNotes(arg0 context.Context, arg1, arg2 string, arg3 io.ReaderAt, arg4 int64) (note.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportNotes", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(note.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportNotes indicates an expected call of ImportNotes.
+func (mr *MockNoteServiceMockRecorder) ImportNotes(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportNotes", reflect.TypeOf((*MockNoteService)(nil).ImportNotes), arg0, arg1, arg2, arg3, arg4)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockNoteService) IsTokenRevoked(arg0 context.Context, arg1 ...uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "IsTokenRevoked", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockNoteServiceMockRecorder) IsTokenRevoked(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockNoteService)(nil).IsTokenRevoked), varargs...)
+}
+
+// JoinCollab mocks base method.
+func (m *MockNoteService) JoinCollab(arg0 context.Context, arg1 string, arg2 uuid.UUID) (*note.CollabPeer, note.CollabState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JoinCollab", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*note.CollabPeer)
+	ret1, _ := ret[1].(note.CollabState)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// JoinCollab indicates an expected call of JoinCollab.
+func (mr *MockNoteServiceMockRecorder) JoinCollab(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JoinCollab", reflect.TypeOf((*MockNoteService)(nil).JoinCollab), arg0, arg1, arg2)
+}
+
+// ListAttachments mocks base method.
+func (m *MockNoteService) ListAttachments(arg0 context.Context, arg1 string, arg2 uuid.UUID) ([]sqlc.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAttachments", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]sqlc.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAttachments indicates an expected call of ListAttachments.
+func (mr *MockNoteServiceMockRecorder) ListAttachments(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAttachments", reflect.TypeOf((*MockNoteService)(nil).ListAttachments), arg0, arg1, arg2)
+}
+
+// ListLinks mocks base method.
+func (m *MockNoteService) ListLinks(arg0 context.Context, arg1 string, arg2 uuid.UUID) ([]sqlc.NoteLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLinks", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]sqlc.NoteLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLinks indicates an expected call of ListLinks.
+func (mr *MockNoteServiceMockRecorder) ListLinks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLinks", reflect.TypeOf((*MockNoteService)(nil).ListLinks), arg0, arg1, arg2)
+}
+
+// ListNotebooks mocks base method.
+func (m *MockNoteService) ListNotebooks(arg0 context.Context, arg1 string) ([]sqlc.Notebook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotebooks", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Notebook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotebooks indicates an expected call of ListNotebooks.
+func (mr *MockNoteServiceMockRecorder) ListNotebooks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotebooks", reflect.TypeOf((*MockNoteService)(nil).ListNotebooks), arg0, arg1)
+}
+
+// ListNotes mocks base method.
+func (m *MockNoteService) ListNotes(arg0 context.Context, arg1 string, arg2 note.ListOptions) (note.NotePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotes", arg0, arg1, arg2)
+	ret0, _ := ret[0].(note.NotePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotes indicates an expected call of ListNotes.
+func (mr *MockNoteServiceMockRecorder) ListNotes(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotes", reflect.TypeOf((*MockNoteService)(nil).ListNotes), arg0, arg1, arg2)
+}
+
+// ListRevisions mocks base method.
+func (m *MockNoteService) ListRevisions(arg0 context.Context, arg1 string, arg2 uuid.UUID) ([]sqlc.ListNoteRevisionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]sqlc.ListNoteRevisionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockNoteServiceMockRecorder) ListRevisions(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockNoteService)(nil).ListRevisions), arg0, arg1, arg2)
+}
+
+// ListSessions mocks base method.
+func (m *MockNoteService) ListSessions(arg0 context.Context, arg1 string) ([]sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockNoteServiceMockRecorder) ListSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockNoteService)(nil).ListSessions), arg0, arg1)
+}
+
+// ListSharedNotes mocks base method.
+func (m *MockNoteService) ListSharedNotes(arg0 context.Context, arg1 string) ([]note.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSharedNotes", arg0, arg1)
+	ret0, _ := ret[0].([]note.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSharedNotes indicates an expected call of ListSharedNotes.
+func (mr *MockNoteServiceMockRecorder) ListSharedNotes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSharedNotes", reflect.TypeOf((*MockNoteService)(nil).ListSharedNotes), arg0, arg1)
+}
+
+// ListShares mocks base method.
+func (m *MockNoteService) ListShares(arg0 context.Context, arg1 string, arg2 uuid.UUID) ([]sqlc.NoteShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShares", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]sqlc.NoteShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShares indicates an expected call of ListShares.
+func (mr *MockNoteServiceMockRecorder) ListShares(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShares", reflect.TypeOf((*MockNoteService)(nil).ListShares), arg0, arg1, arg2)
+}
+
+// ListTags mocks base method.
+func (m *MockNoteService) ListTags(arg0 context.Context, arg1 string) ([]sqlc.ListTagsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTags", arg0, arg1)
+	ret0, _ := ret[0].([]sqlc.ListTagsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTags indicates an expected call of ListTags.
+func (mr *MockNoteServiceMockRecorder) ListTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTags", reflect.TypeOf((*MockNoteService)(nil).ListTags), arg0, arg1)
+}
+
+// ListTrash mocks base method.
+func (m *MockNoteService) ListTrash(arg0 context.Context, arg1 string) ([]note.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrash", arg0, arg1)
+	ret0, _ := ret[0].([]note.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockNoteServiceMockRecorder) ListTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockNoteService)(nil).ListTrash), arg0, arg1)
+}
+
+// MergeTag mocks base method.
+func (m *MockNoteService) MergeTag(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeTag", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeTag indicates an expected call of MergeTag.
+func (mr *MockNoteServiceMockRecorder) MergeTag(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeTag", reflect.TypeOf((*MockNoteService)(nil).MergeTag), arg0, arg1, arg2, arg3)
+}
+
+// MoveNote mocks base method.
+func (m *MockNoteService) MoveNote(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3 uuid.NullUUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveNote", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveNote indicates an expected call of MoveNote.
+func (mr *MockNoteServiceMockRecorder) MoveNote(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveNote", reflect.TypeOf((*MockNoteService)(nil).MoveNote), arg0, arg1, arg2, arg3)
+}
+
+// OpenAttachment mocks base method.
+func (m *MockNoteService) OpenAttachment(arg0 context.Context, arg1 string, arg2, arg3 uuid.UUID) (sqlc.Attachment, io.ReadSeekCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenAttachment", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(sqlc.Attachment)
+	ret1, _ := ret[1].(io.ReadSeekCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenAttachment indicates an expected call of OpenAttachment.
+func (mr *MockNoteServiceMockRecorder) OpenAttachment(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenAttachment", reflect.TypeOf((*MockNoteService)(nil).OpenAttachment), arg0, arg1, arg2, arg3)
+}
+
+// OpenLink mocks base method.
+func (m *MockNoteService) OpenLink(arg0 context.Context, arg1, arg2 string) (note.Note, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(note.Note)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenLink indicates an expected call of OpenLink.
+func (mr *MockNoteServiceMockRecorder) OpenLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenLink", reflect.TypeOf((*MockNoteService)(nil).OpenLink), arg0, arg1, arg2)
+}
+
+// RegisterUser mocks base method.
+func (m *MockNoteService) RegisterUser(arg0 context.Context, arg1 *sqlc.RegisterUserParams) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterUser", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterUser indicates an expected call of RegisterUser.
+func (mr *MockNoteServiceMockRecorder) RegisterUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockNoteService)(nil).RegisterUser), arg0, arg1)
+}
+
+// RenameTag mocks base method.
+func (m *MockNoteService) RenameTag(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameTag", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameTag indicates an expected call of RenameTag.
+func (mr *MockNoteServiceMockRecorder) RenameTag(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameTag", reflect.TypeOf((*MockNoteService)(nil).RenameTag), arg0, arg1, arg2, arg3)
+}
+
+// RenderNote mocks base method.
+func (m *MockNoteService) RenderNote(arg0 context.Context, arg1 string, arg2 uuid.UUID) (note.Note, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderNote", arg0, arg1, arg2)
+	ret0, _ := ret[0].(note.Note)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RenderNote indicates an expected call of RenderNote.
+func (mr *MockNoteServiceMockRecorder) RenderNote(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderNote", reflect.TypeOf((*MockNoteService)(nil).RenderNote), arg0, arg1, arg2)
+}
+
+// RestoreNote mocks base method.
+func (m *MockNoteService) RestoreNote(arg0 context.Context, arg1 string, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreNote", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreNote indicates an expected call of RestoreNote.
+func (mr *MockNoteServiceMockRecorder) RestoreNote(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreNote", reflect.TypeOf((*MockNoteService)(nil).RestoreNote), arg0, arg1, arg2)
+}
+
+// RestoreRevision mocks base method.
+func (m *MockNoteService) RestoreRevision(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3 int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRevision", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreRevision indicates an expected call of RestoreRevision.
+func (mr *MockNoteServiceMockRecorder) RestoreRevision(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRevision", reflect.TypeOf((*MockNoteService)(nil).RestoreRevision), arg0, arg1, arg2, arg3)
+}
+
+// RevokeLink mocks base method.
+func (m *MockNoteService) RevokeLink(arg0 context.Context, arg1 string, arg2, arg3 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeLink", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeLink indicates an expected call of RevokeLink.
+func (mr *MockNoteServiceMockRecorder) RevokeLink(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeLink", reflect.TypeOf((*MockNoteService)(nil).RevokeLink), arg0, arg1, arg2, arg3)
+}
+
+// RevokeSession mocks base method.
+func (m *MockNoteService) RevokeSession(arg0 context.Context, arg1 string, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockNoteServiceMockRecorder) RevokeSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockNoteService)(nil).RevokeSession), arg0, arg1, arg2)
+}
+
+// RevokeSessionByRefreshToken mocks base method.
+func (m *MockNoteService) RevokeSessionByRefreshToken(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSessionByRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSessionByRefreshToken indicates an expected call of RevokeSessionByRefreshToken.
+func (mr *MockNoteServiceMockRecorder) RevokeSessionByRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSessionByRefreshToken", reflect.TypeOf((*MockNoteService)(nil).RevokeSessionByRefreshToken), arg0, arg1)
+}
+
+// RevokeShare mocks base method.
+func (m *MockNoteService) RevokeShare(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeShare", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeShare indicates an expected call of RevokeShare.
+func (mr *MockNoteServiceMockRecorder) RevokeShare(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeShare", reflect.TypeOf((*MockNoteService)(nil).RevokeShare), arg0, arg1, arg2, arg3)
+}
+
+// RevokeToken mocks base method.
+func (m *MockNoteService) RevokeToken(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockNoteServiceMockRecorder) RevokeToken(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockNoteService)(nil).RevokeToken), arg0, arg1, arg2, arg3)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockNoteService) RotateRefreshToken(arg0 context.Context, arg1, arg2 string, arg3 time.Time) (string, uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(uuid.UUID)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockNoteServiceMockRecorder) RotateRefreshToken(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockNoteService)(nil).RotateRefreshToken), arg0, arg1, arg2, arg3)
+}
+
+// Search mocks base method.
+func (m *MockNoteService) Search(arg0 context.Context, arg1, arg2 string, arg3 int32) ([]sqlc.SearchNotesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]sqlc.SearchNotesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockNoteServiceMockRecorder) Search(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockNoteService)(nil).Search), arg0, arg1, arg2, arg3)
+}
+
+// ShareNote mocks base method.
+func (m *MockNoteService) ShareNote(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3 string, arg4 note.Permission) (sqlc.NoteShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShareNote", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(sqlc.NoteShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShareNote indicates an expected call of ShareNote.
+func (mr *MockNoteServiceMockRecorder) ShareNote(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShareNote", reflect.TypeOf((*MockNoteService)(nil).ShareNote), arg0, arg1, arg2, arg3, arg4)
+}
+
+// SubscribeEvents mocks base method.
+func (m *MockNoteService) SubscribeEvents(arg0 string) *events.Subscription {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeEvents", arg0)
+	ret0, _ := ret[0].(*events.Subscription)
+	return ret0
+}
+
+// SubscribeEvents indicates an expected call of SubscribeEvents.
+func (mr *MockNoteServiceMockRecorder) SubscribeEvents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeEvents", reflect.TypeOf((*MockNoteService)(nil).SubscribeEvents), arg0)
+}
+
+// Sync mocks base method.
+func (m *MockNoteService) Sync(arg0 context.Context, arg1, arg2 string, arg3 []note.SyncChange) (note.SyncResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sync", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(note.SyncResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sync indicates an expected call of Sync.
+func (mr *MockNoteServiceMockRecorder) Sync(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockNoteService)(nil).Sync), arg0, arg1, arg2, arg3)
+}
+
+// UpdateNote mocks base method.
+func (m *MockNoteService) UpdateNote(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3, arg4 string, arg5 bool, arg6 []string, arg7 int32) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNote", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateNote indicates an expected call of UpdateNote.
+func (mr *MockNoteServiceMockRecorder) UpdateNote(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNote", reflect.TypeOf((*MockNoteService)(nil).UpdateNote), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+}
+
+// UpdateNotebook mocks base method.
+func (m *MockNoteService) UpdateNotebook(arg0 context.Context, arg1 string, arg2 uuid.UUID, arg3 string, arg4 uuid.NullUUID) (sqlc.Notebook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNotebook", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(sqlc.Notebook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateNotebook indicates an expected call of UpdateNotebook.
+func (mr *MockNoteServiceMockRecorder) UpdateNotebook(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNotebook", reflect.TypeOf((*MockNoteService)(nil).UpdateNotebook), arg0, arg1, arg2, arg3, arg4)
+}
+
+// UpdateSettings mocks base method.
+func (m *MockNoteService) UpdateSettings(arg0 context.Context, arg1 string, arg2 note.Settings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockNoteServiceMockRecorder) UpdateSettings(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockNoteService)(nil).UpdateSettings), arg0, arg1, arg2)
+}
@@ -4,27 +4,227 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
-	db "github.com/adykaaa/online-notes/db/sqlc"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/events"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/rs/zerolog"
 )
 
 var (
-	ErrAlreadyExists     = errors.New("note already exists")
+	ErrAlreadyExists     = errors.New("note with that title already exists in the notebook")
 	ErrDBInternal        = errors.New("internal DB error during operation")
 	ErrNotFound          = errors.New("requested note is not found")
 	ErrUserAlreadyExists = errors.New("note already exists")
 	ErrUserNotFound      = errors.New("requested note is not found")
+
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid")
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+
+	ErrSessionNotFound = errors.New("requested session is not found")
+
+	ErrInvalidTag       = errors.New("tag is empty, too long or contains control characters")
+	ErrTagNotFound      = errors.New("requested tag is not found")
+	ErrTagAlreadyExists = errors.New("tag already exists")
+
+	ErrInvalidNotebookName   = errors.New("notebook name is empty or too long")
+	ErrNotebookNotFound      = errors.New("requested notebook is not found")
+	ErrNotebookAlreadyExists = errors.New("notebook with that name already exists in the parent")
+	ErrNotebookCycle         = errors.New("notebook can't be moved into itself or its children")
+
+	ErrInvalidSort   = errors.New("notes can only be sorted by created_at, updated_at or title")
+	ErrInvalidCursor = errors.New("cursor is malformed or doesn't match the sort order")
+
+	ErrRevisionNotFound = errors.New("requested note revision is not found")
+
+	ErrVersionMismatch = errors.New("note has been changed since it was read")
+
+	ErrInvalidAttachment  = errors.New("attachment file name is empty or too long")
+	ErrAttachmentNotFound = errors.New("requested attachment is not found")
+	ErrQuotaExceeded      = errors.New("attachment storage quota exceeded")
+	ErrStorageInternal    = errors.New("internal blob storage error during operation")
+
+	ErrRenderInternal = errors.New("internal error while rendering the note")
+
+	ErrDuplicateTitles = errors.New("notebooks contain notes with the same title")
+
+	ErrInvalidPermission = errors.New("share permission must be read or edit")
+	ErrShareWithOwner    = errors.New("note can't be shared with its owner")
+	ErrShareNotFound     = errors.New("requested note share is not found")
+	ErrForbidden         = errors.New("note share doesn't allow the operation")
+
+	ErrInvalidLinkExpiry     = errors.New("link expiry must be in the future")
+	ErrInvalidLinkPassword   = errors.New("link password is too short")
+	ErrLinkNotFound          = errors.New("requested note link is not found")
+	ErrLinkExpired           = errors.New("note link has expired")
+	ErrLinkPasswordRequired  = errors.New("note link is protected by a password")
+	ErrLinkPasswordIncorrect = errors.New("note link password is incorrect")
+
+	ErrInvalidCollabUpdate = errors.New("co-editing update is malformed or refers to unknown text")
+	ErrCollabTooLarge      = errors.New("note is too large to edit together")
+	ErrCollabClosed        = errors.New("co-editing session has ended")
+
+	ErrNoteIDTaken        = errors.New("a note with that ID already exists")
+	ErrInvalidSyncToken   = errors.New("sync token is malformed")
+	ErrInvalidSyncChange  = errors.New("sync change must be an upsert or a delete")
+	ErrTooManySyncChanges = errors.New("too many changes in one sync")
 )
 
+// Returned when the expected version of a note is outdated, carries the current server copy
+type VersionMismatchError struct {
+	Current Note
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("%v, current version is %d", ErrVersionMismatch, e.Current.Version)
+}
+
+func (e *VersionMismatchError) Is(target error) bool {
+	return target == ErrVersionMismatch
+}
+
+// Returned when another note of the user in the same notebook has the title, carries that note's ID
+type TitleConflictError struct {
+	ID uuid.UUID
+}
+
+func (e *TitleConflictError) Error() string {
+	return fmt.Sprintf("%v, conflicting note is %v", ErrAlreadyExists, e.ID)
+}
+
+func (e *TitleConflictError) Is(target error) bool {
+	return target == ErrAlreadyExists
+}
+
+// Note together with its tag names
+type Note struct {
+	db.Note
+	Tags []string
+	// Set on notes shared with the user, empty on their own notes
+	Permission Permission
+}
+
+const defaultRevisionLimit = 50
+
 type service struct {
-	q db.Querier
+	q               db.Querier
+	revoked         *revocationCache
+	revisionLimit   int
+	blobs           BlobStore
+	attachmentQuota int64
+	rendered        *renderCache
+	events          EventBroker
+	collab          *collabHub
+	log             *zerolog.Logger
+}
+
+type Option func(*service)
+
+// Keep at most n revisions per note, 0 keeps every revision
+func WithRevisionLimit(n int) Option {
+	return func(s *service) {
+		s.revisionLimit = n
+	}
 }
 
-func NewService(q db.Querier) *service {
-	return &service{q}
+// Store attachment contents in bs, attachments are rejected without a store
+func WithBlobStore(bs BlobStore) Option {
+	return func(s *service) {
+		s.blobs = bs
+	}
+}
+
+// Limit the total size of attachments per user to quota bytes, 0 means unlimited
+func WithAttachmentQuota(quota int64) Option {
+	return func(s *service) {
+		s.attachmentQuota = quota
+	}
+}
+
+// Log failures of background work, like the last save of a co-editing session. Nothing is logged by default.
+func WithLogger(l *zerolog.Logger) Option {
+	return func(s *service) {
+		s.log = l
+	}
+}
+
+func NewService(q db.Querier, opts ...Option) *service {
+	nop := zerolog.Nop()
+	s := &service{
+		q:             q,
+		revoked:       newRevocationCache(defaultRevocationCacheTTL),
+		revisionLimit: defaultRevisionLimit,
+		rendered:      newRenderCache(defaultRenderCacheSize),
+		events:        events.NewBus(events.DefaultBufferSize),
+		collab:        newCollabHub(),
+		log:           &nop,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Implemented by the DB returned from db.NewSQL
+type txRunner interface {
+	ExecTx(ctx context.Context, fn func(q db.Querier) error) error
+}
+
+// Run fn in a transaction when the querier supports it, otherwise run it directly
+func (s *service) execTx(ctx context.Context, fn func(q db.Querier) error) error {
+	if tx, ok := s.q.(txRunner); ok {
+		return tx.ExecTx(ctx, fn)
+	}
+
+	return fn(s.q)
+}
+
+// Report whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation"
+}
+
+// Name of the constraint a Postgres error is about, empty for other errors
+func violatedConstraint(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint
+	}
+
+	return ""
+}
+
+// Find the note that holds the title a write collided on, the note being written is excluded.
+// Called after the failed transaction, falls back to ErrAlreadyExists when the holder can't be found.
+func (s *service) titleConflict(ctx context.Context, username string, excludeID uuid.UUID, title string, notebookID uuid.NullUUID) error {
+	id, err := s.q.FindTitleConflict(ctx, &db.FindTitleConflictParams{
+		Username:   username,
+		Title:      title,
+		NotebookID: notebookID,
+		ExcludeID:  excludeID,
+	})
+	if err != nil {
+		return ErrAlreadyExists
+	}
+
+	return &TitleConflictError{ID: id}
+}
+
+// Like titleConflict, for a note that stays in its notebook
+func (s *service) noteTitleConflict(ctx context.Context, username string, id uuid.UUID, title string) error {
+	n, err := s.q.GetNote(ctx, &db.GetNoteParams{ID: id, Username: username})
+	if err != nil {
+		return ErrAlreadyExists
+	}
+
+	return s.titleConflict(ctx, username, id, title, n.NotebookID)
 }
 
 // Register user
@@ -32,10 +232,9 @@ func (s *service) RegisterUser(ctx context.Context, args *db.RegisterUserParams)
 	uname, err := s.q.RegisterUser(ctx, args)
 
 	switch {
+	case isUniqueViolation(err):
+		return "", ErrUserAlreadyExists
 	case err != nil:
-		if err.(*pq.Error).Code.Name() == "unique_violation" {
-			return "", ErrUserAlreadyExists
-		}
 		return "", ErrDBInternal
 	default:
 		return uname, nil
@@ -56,68 +255,257 @@ func (s *service) GetUser(ctx context.Context, username string) (db.User, error)
 	}
 }
 
-// Create node
-func (s *service) CreateNote(ctx context.Context, title string, username string, text string) (uuid.UUID, error) {
-	reID, err := s.q.CreateNote(ctx, &db.CreateNoteParams{
-		ID:        uuid.New(),
-		Title:     title,
-		Username:  username,
-		Text:      sql.NullString{String: text, Valid: true},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+// Create node with the given tags, optionally inside one of the user's notebooks
+func (s *service) CreateNote(ctx context.Context, title string, username string, text string, tags []string, notebookID uuid.NullUUID) (uuid.UUID, error) {
+	return s.createNote(ctx, uuid.New(), title, username, text, tags, notebookID)
+}
+
+// Like CreateNote, with an ID picked by the client. Returns ErrNoteIDTaken when a note has it already.
+func (s *service) createNote(ctx context.Context, id uuid.UUID, title string, username string, text string, tags []string, notebookID uuid.NullUUID) (uuid.UUID, error) {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var reID uuid.UUID
+	err = s.execTx(ctx, func(q db.Querier) error {
+		err := checkNotebook(ctx, q, username, notebookID)
+		if err != nil {
+			return err
+		}
+
+		reID, err = q.CreateNote(ctx, &db.CreateNoteParams{
+			ID:         id,
+			Title:      title,
+			Username:   username,
+			Text:       sql.NullString{String: text, Valid: true},
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+			NotebookID: notebookID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(tags) > 0 {
+			err = setNoteTags(ctx, q, username, reID, tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		return s.addRevision(ctx, q, reID)
 	})
 
 	switch {
+	case errors.Is(err, ErrNotebookNotFound):
+		return uuid.Nil, err
+	case isUniqueViolation(err) && violatedConstraint(err) == "notes_pkey":
+		return uuid.Nil, ErrNoteIDTaken
+	case isUniqueViolation(err):
+		return uuid.Nil, s.titleConflict(ctx, username, uuid.Nil, title, notebookID)
 	case err != nil:
-		if err.(*pq.Error).Code.Name() == "unique_violation" {
-			return uuid.Nil, ErrAlreadyExists
-		}
 		return uuid.Nil, ErrDBInternal
 	default:
+		s.publish(ctx, events.NoteCreated, reID, 1, username)
 		return reID, nil
 	}
 }
 
 // Return all user's notes
-func (s *service) GetAllNotesFromUser(ctx context.Context, username string) ([]db.Note, error) {
+func (s *service) GetAllNotesFromUser(ctx context.Context, username string) ([]Note, error) {
 	notes, err := s.q.GetAllNotesFromUser(ctx, username)
-
 	if err != nil {
 		return nil, ErrDBInternal
 	}
 
-	return notes, nil
+	return s.withTags(ctx, notes)
 }
 
-// Delete node
-func (s *service) DeleteNote(ctx context.Context, reqID uuid.UUID) (uuid.UUID, error) {
-	id, err := s.q.DeleteNote(ctx, reqID)
+// Return one note of the user with its tags
+func (s *service) GetNote(ctx context.Context, username string, id uuid.UUID) (Note, error) {
+	n, err := s.q.GetNote(ctx, &db.GetNoteParams{ID: id, Username: username})
+
+	// not the owner, the note may be shared with the user
+	var perm Permission
+	if errors.Is(err, sql.ErrNoRows) {
+		var shared db.GetSharedNoteRow
+		shared, err = s.q.GetSharedNote(ctx, &db.GetSharedNoteParams{ID: id, Username: username})
+		n, perm = shared.Note, Permission(shared.Permission)
+	}
 
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
+		return Note{}, ErrNotFound
+	case err != nil:
+		return Note{}, ErrDBInternal
+	}
+
+	tagged, err := s.withTags(ctx, []db.Note{n})
+	if err != nil {
+		return Note{}, err
+	}
+	tagged[0].Permission = perm
+
+	return tagged[0], nil
+}
+
+// Find out why a versioned write touched no rows: the note is gone or someone else changed it first
+func versionConflict(ctx context.Context, q db.Querier, username string, id uuid.UUID, version int32) error {
+	if version == 0 {
+		return sql.ErrNoRows
+	}
+
+	current, err := q.GetNote(ctx, &db.GetNoteParams{ID: id, Username: username})
+	if err != nil {
+		return err
+	}
+
+	return &VersionMismatchError{Current: Note{Note: current}}
+}
+
+// Load the tags of the server copy carried by a version mismatch
+func (s *service) withCurrentTags(ctx context.Context, err error) error {
+	var mismatch *VersionMismatchError
+	if !errors.As(err, &mismatch) {
+		return err
+	}
+
+	tagged, tagErr := s.withTags(ctx, []db.Note{mismatch.Current.Note})
+	if tagErr == nil {
+		mismatch.Current = tagged[0]
+	}
+
+	return mismatch
+}
+
+// Move note to the trash, only its owner may. Version 0 skips the version check.
+func (s *service) DeleteNote(ctx context.Context, username string, reqID uuid.UUID, version int32) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.execTx(ctx, func(q db.Querier) error {
+		_, err := noteOwner(ctx, q, username, reqID, permissionOwner)
+		if err != nil {
+			return err
+		}
+
+		id, err = q.TrashNote(ctx, &db.TrashNoteParams{
+			ID:        reqID,
+			Username:  username,
+			Version:   version,
+			DeletedAt: time.Now(),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return versionConflict(ctx, q, username, reqID, version)
+		}
+
+		return err
+	})
+
+	switch {
+	case errors.Is(err, ErrVersionMismatch):
+		return uuid.Nil, s.withCurrentTags(ctx, err)
+	case errors.Is(err, ErrForbidden):
 		return uuid.Nil, err
+	case errors.Is(err, sql.ErrNoRows):
+		return uuid.Nil, ErrNotFound
 	case err != nil:
 		return uuid.Nil, ErrDBInternal
 	default:
+		s.publish(ctx, events.NoteDeleted, id, 0, username)
 		return id, nil
 	}
 }
 
-// Update note
-func (s *service) UpdateNote(ctx context.Context, reqID uuid.UUID, title string, text string, isTextValid bool) (uuid.UUID, error) {
-	id, err := s.q.UpdateNote(ctx, &db.UpdateNoteParams{
-		ID: reqID,
-		Title: sql.NullString{String: title, Valid: true},
-		Text: sql.NullString{String: text, Valid: isTextValid},
-		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+// Update note of the user or one shared with them for editing, and return its new version.
+// Tags are replaced unless they are nil. Version 0 skips the version check.
+func (s *service) UpdateNote(ctx context.Context, username string, reqID uuid.UUID, title string, text string, isTextValid bool, tags []string, version int32) (int32, error) {
+	return s.updateNote(ctx, username, reqID, title, text, isTextValid, tags, version, updateOptions{revise: true})
+}
+
+// What updateNote does besides writing the title, text and tags
+type updateOptions struct {
+	// store the new version as a revision
+	revise bool
+	// move the note there as well, only its owner may. Nil leaves it in its notebook.
+	notebookID *uuid.NullUUID
+}
+
+// Like UpdateNote, with the extras of opts in the same transaction
+func (s *service) updateNote(ctx context.Context, username string, reqID uuid.UUID, title string, text string, isTextValid bool, tags []string, version int32, opts updateOptions) (int32, error) {
+	var err error
+	if tags != nil {
+		if tags, err = normalizeTags(tags); err != nil {
+			return 0, err
+		}
+	}
+
+	var (
+		newVersion int32
+		owner      string
+	)
+	err = s.execTx(ctx, func(q db.Querier) error {
+		var err error
+		owner, err = noteOwner(ctx, q, username, reqID, PermissionEdit)
+		if err != nil {
+			return err
+		}
+
+		// a grantee edits the note in the name of its owner
+		newVersion, err = q.UpdateNote(ctx, &db.UpdateNoteParams{
+			ID:        reqID,
+			Username:  owner,
+			Title:     sql.NullString{String: title, Valid: true},
+			Text:      sql.NullString{String: text, Valid: isTextValid},
+			UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			Version:   version,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return versionConflict(ctx, q, owner, reqID, version)
+		}
+		if err != nil {
+			return err
+		}
+
+		if tags != nil {
+			err = setNoteTags(ctx, q, owner, reqID, tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		if opts.notebookID != nil {
+			moved, err := moveNoteIfElsewhere(ctx, q, username, owner, reqID, *opts.notebookID)
+			if err != nil {
+				return err
+			}
+			if moved {
+				// the move is a version of its own
+				newVersion++
+			}
+		}
+
+		if !opts.revise {
+			return nil
+		}
+
+		return s.addRevision(ctx, q, reqID)
 	})
 
 	switch {
+	case errors.Is(err, ErrVersionMismatch):
+		return 0, s.withCurrentTags(ctx, err)
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrNotebookNotFound):
+		return 0, err
+	case isUniqueViolation(err) && opts.notebookID != nil:
+		return 0, s.titleConflict(ctx, owner, reqID, title, *opts.notebookID)
+	case isUniqueViolation(err):
+		return 0, s.noteTitleConflict(ctx, owner, reqID, title)
 	case errors.Is(err, sql.ErrNoRows):
-		return uuid.Nil, ErrNotFound
+		return 0, ErrNotFound
 	case err != nil:
-		return uuid.Nil, ErrDBInternal
+		return 0, ErrDBInternal
 	default:
-		return id, nil
+		s.publish(ctx, events.NoteUpdated, reqID, newVersion, owner, username)
+		return newVersion, nil
 	}
 }
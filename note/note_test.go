@@ -3,10 +3,11 @@ package note
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"testing"
 
-	mockdb "github.com/adykaaa/online-notes/db/mock"
-	db "github.com/adykaaa/online-notes/db/sqlc"
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -194,6 +195,45 @@ func TestCreateNote(t *testing.T) {
 	}
 }
 
+func TestCreateNoteTitleConflict(t *testing.T) {
+	const username = "user1"
+	notebookID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	conflictID := uuid.New()
+
+	t.Run("returns the conflicting note", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().GetNotebook(gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{ID: notebookID.UUID}, nil)
+		mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, uniqueViolation())
+		mockdb.EXPECT().FindTitleConflict(gomock.Any(), &db.FindTitleConflictParams{
+			Username:   username,
+			Title:      "title",
+			NotebookID: notebookID,
+		}).Times(1).Return(conflictID, nil)
+
+		_, err := ns.CreateNote(context.Background(), "title", username, "text", nil, notebookID)
+		require.ErrorIs(t, err, ErrAlreadyExists)
+
+		var conflict *TitleConflictError
+		require.ErrorAs(t, err, &conflict)
+		require.Equal(t, conflictID, conflict.ID)
+	})
+
+	t.Run("returns ErrAlreadyExists - conflicting note not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, uniqueViolation())
+		mockdb.EXPECT().FindTitleConflict(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+
+		_, err := ns.CreateNote(context.Background(), "title", username, "text", nil, uuid.NullUUID{})
+		require.Equal(t, ErrAlreadyExists, err)
+	})
+}
+
 func TestGetAllNotesFromUser(t *testing.T) {
 	const username = "user1"
 
@@ -239,8 +279,29 @@ func TestGetAllNotesFromUser(t *testing.T) {
 	}
 }
 
+// Matches TrashNoteParams ignoring the DeletedAt timestamp set by the service
+type trashNoteMatcher db.TrashNoteParams
+
+func (m *trashNoteMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(*db.TrashNoteParams)
+	if !ok {
+		return false
+	}
+
+	return arg.ID == m.ID && arg.Username == m.Username && arg.Version == m.Version && !arg.DeletedAt.IsZero()
+}
+
+func (m *trashNoteMatcher) String() string {
+	return fmt.Sprintf("ID: %v, Username: %s, Version: %d", m.ID, m.Username, m.Version)
+}
+
 func TestDeleteNote(t *testing.T) {
+	const username = "user1"
 	id := uuid.New()
+	args := &db.TrashNoteParams{ID: id, Username: username, Version: 3}
+	current := db.Note{ID: id, Title: "title", Username: username, Version: 5}
+	accessArgs := &db.GetNoteAccessParams{ID: id, Username: username}
+	owner := db.GetNoteAccessRow{Owner: username, Permission: "owner"}
 
 	testCases := []struct {
 		name              string
@@ -250,20 +311,72 @@ func TestDeleteNote(t *testing.T) {
 		{
 			name: "deleting note OK",
 			mockdbDeleteNote: func(mockdb *mockdb.MockQuerier) {
-				mockdb.EXPECT().DeleteNote(gomock.Any(), id).Times(1).Return(id, nil)
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().TrashNote(gomock.Any(), (*trashNoteMatcher)(args)).Times(1).Return(id, nil)
 			},
 			checkReturnValues: func(t *testing.T, retID uuid.UUID, err error) {
 				require.Equal(t, id, retID)
 				require.Nil(t, err)
 			},
 		},
+		{
+			name: "deleting other user's note returns ErrNotFound",
+			mockdbDeleteNote: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(db.GetNoteAccessRow{}, sql.ErrNoRows)
+			},
+			checkReturnValues: func(t *testing.T, retID uuid.UUID, err error) {
+				require.Equal(t, uuid.Nil, retID)
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "deleting note shared for editing returns ErrForbidden",
+			mockdbDeleteNote: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(db.GetNoteAccessRow{Owner: "user2", Permission: "edit"}, nil)
+			},
+			checkReturnValues: func(t *testing.T, retID uuid.UUID, err error) {
+				require.Equal(t, uuid.Nil, retID)
+				require.ErrorIs(t, err, ErrForbidden)
+			},
+		},
+		{
+			name: "deleting note trashed in the meantime returns ErrNotFound",
+			mockdbDeleteNote: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().TrashNote(gomock.Any(), (*trashNoteMatcher)(args)).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+				mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: id, Username: username}).Times(1).Return(db.Note{}, sql.ErrNoRows)
+			},
+			checkReturnValues: func(t *testing.T, retID uuid.UUID, err error) {
+				require.Equal(t, uuid.Nil, retID)
+				require.ErrorIs(t, err, ErrNotFound)
+			},
+		},
+		{
+			name: "deleting outdated version returns the current note",
+			mockdbDeleteNote: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().TrashNote(gomock.Any(), (*trashNoteMatcher)(args)).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+				mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: id, Username: username}).Times(1).Return(current, nil)
+				mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), []uuid.UUID{id}).Times(1).Return([]db.GetTagsOfNotesRow{{NoteID: id, Name: "go"}}, nil)
+			},
+			checkReturnValues: func(t *testing.T, retID uuid.UUID, err error) {
+				require.Equal(t, uuid.Nil, retID)
+				require.ErrorIs(t, err, ErrVersionMismatch)
+
+				var mismatch *VersionMismatchError
+				require.ErrorAs(t, err, &mismatch)
+				require.Equal(t, int32(5), mismatch.Current.Version)
+				require.Equal(t, []string{"go"}, mismatch.Current.Tags)
+			},
+		},
 		{
 			name: "deleting note returns ErrDBInternal",
 			mockdbDeleteNote: func(mockdb *mockdb.MockQuerier) {
-				mockdb.EXPECT().DeleteNote(gomock.Any(), id).Times(1).Return(uuid.Nil, ErrDBInternal)
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().TrashNote(gomock.Any(), (*trashNoteMatcher)(args)).Times(1).Return(uuid.Nil, sql.ErrConnDone)
 			},
 			checkReturnValues: func(t *testing.T, retID uuid.UUID, err error) {
-				require.Equal(t, retID, uuid.Nil)
+				require.Equal(t, uuid.Nil, retID)
 				require.ErrorIs(t, err, ErrDBInternal)
 			},
 		},
@@ -277,51 +390,117 @@ func TestDeleteNote(t *testing.T) {
 			ns := NewService(mockdb)
 
 			tc.mockdbDeleteNote(mockdb)
-			id, err := ns.q.DeleteNote(context.Background(), id)
-			tc.checkReturnValues(t, id, err)
+			retID, err := ns.DeleteNote(context.Background(), username, id, args.Version)
+			tc.checkReturnValues(t, retID, err)
 		})
 	}
 }
 
+// Matches UpdateNoteParams ignoring the UpdatedAt timestamp set by the service
+type updateNoteMatcher db.UpdateNoteParams
+
+func (m *updateNoteMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(*db.UpdateNoteParams)
+	if !ok {
+		return false
+	}
+
+	return arg.ID == m.ID && arg.Username == m.Username && arg.Title == m.Title && arg.Text == m.Text && arg.Version == m.Version && arg.UpdatedAt.Valid
+}
+
+func (m *updateNoteMatcher) String() string {
+	return fmt.Sprintf("ID: %v, Username: %s, Title: %s", m.ID, m.Username, m.Title.String)
+}
+
 func TestUpdateNote(t *testing.T) {
 	args := db.UpdateNoteParams{
-		ID:    uuid.New(),
-		Title: sql.NullString{String: "testtitle1", Valid: true},
-		Text:  sql.NullString{String: "testtext", Valid: true},
+		ID:       uuid.New(),
+		Username: "user1",
+		Title:    sql.NullString{String: "testtitle1", Valid: true},
+		Text:     sql.NullString{String: "testtext", Valid: true},
+		Version:  3,
 	}
+	getArgs := &db.GetNoteParams{ID: args.ID, Username: args.Username}
+	accessArgs := &db.GetNoteAccessParams{ID: args.ID, Username: args.Username}
+	owner := db.GetNoteAccessRow{Owner: args.Username, Permission: "owner"}
 
 	testCases := []struct {
 		name              string
 		mockdbUpdateNote  func(mockdb *mockdb.MockQuerier, args *db.UpdateNoteParams)
-		checkReturnValues func(t *testing.T, args *db.UpdateNoteParams, id uuid.UUID, err error)
+		checkReturnValues func(t *testing.T, version int32, err error)
 	}{
 		{
 			name: "updating note OK",
 			mockdbUpdateNote: func(mockdb *mockdb.MockQuerier, args *db.UpdateNoteParams) {
-				mockdb.EXPECT().UpdateNote(gomock.Any(), args).Times(1).Return(args.ID, nil)
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().UpdateNote(gomock.Any(), (*updateNoteMatcher)(args)).Times(1).Return(int32(4), nil)
+				mockdb.EXPECT().CreateNoteRevision(gomock.Any(), args.ID).Times(1).Return(int32(2), nil)
 			},
-			checkReturnValues: func(t *testing.T, args *db.UpdateNoteParams, id uuid.UUID, err error) {
-				require.Equal(t, args.ID, id)
+			checkReturnValues: func(t *testing.T, version int32, err error) {
+				require.Equal(t, int32(4), version)
 				require.Nil(t, err)
 			},
 		},
 		{
-			name: "updating note returns ErrNotFound",
+			name: "updating other user's note returns ErrNotFound",
 			mockdbUpdateNote: func(mockdb *mockdb.MockQuerier, args *db.UpdateNoteParams) {
-				mockdb.EXPECT().UpdateNote(gomock.Any(), args).Times(1).Return(uuid.Nil, ErrNotFound)
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(db.GetNoteAccessRow{}, sql.ErrNoRows)
 			},
-			checkReturnValues: func(t *testing.T, args *db.UpdateNoteParams, id uuid.UUID, err error) {
-				require.Equal(t, uuid.Nil, id)
+			checkReturnValues: func(t *testing.T, version int32, err error) {
+				require.Zero(t, version)
 				require.ErrorIs(t, err, ErrNotFound)
 			},
 		},
+		{
+			name: "updating note shared for editing OK - written as the owner",
+			mockdbUpdateNote: func(mockdb *mockdb.MockQuerier, args *db.UpdateNoteParams) {
+				asOwner := *args
+				asOwner.Username = "owner1"
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(db.GetNoteAccessRow{Owner: "owner1", Permission: "edit"}, nil)
+				mockdb.EXPECT().UpdateNote(gomock.Any(), (*updateNoteMatcher)(&asOwner)).Times(1).Return(int32(4), nil)
+				mockdb.EXPECT().CreateNoteRevision(gomock.Any(), args.ID).Times(1).Return(int32(2), nil)
+			},
+			checkReturnValues: func(t *testing.T, version int32, err error) {
+				require.Equal(t, int32(4), version)
+				require.Nil(t, err)
+			},
+		},
+		{
+			name: "updating note shared for reading returns ErrForbidden",
+			mockdbUpdateNote: func(mockdb *mockdb.MockQuerier, args *db.UpdateNoteParams) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(db.GetNoteAccessRow{Owner: "owner1", Permission: "read"}, nil)
+			},
+			checkReturnValues: func(t *testing.T, version int32, err error) {
+				require.Zero(t, version)
+				require.ErrorIs(t, err, ErrForbidden)
+			},
+		},
+		{
+			name: "updating outdated version returns the current note",
+			mockdbUpdateNote: func(mockdb *mockdb.MockQuerier, args *db.UpdateNoteParams) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().UpdateNote(gomock.Any(), (*updateNoteMatcher)(args)).Times(1).Return(int32(0), sql.ErrNoRows)
+				mockdb.EXPECT().GetNote(gomock.Any(), getArgs).Times(1).Return(db.Note{ID: args.ID, Title: "theirs", Version: 7}, nil)
+				mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), []uuid.UUID{args.ID}).Times(1).Return([]db.GetTagsOfNotesRow{}, nil)
+			},
+			checkReturnValues: func(t *testing.T, version int32, err error) {
+				require.Zero(t, version)
+
+				var mismatch *VersionMismatchError
+				require.ErrorAs(t, err, &mismatch)
+				require.ErrorIs(t, err, ErrVersionMismatch)
+				require.Equal(t, "theirs", mismatch.Current.Title)
+				require.Equal(t, int32(7), mismatch.Current.Version)
+			},
+		},
 		{
 			name: "updating note returns ErrDBInternal",
 			mockdbUpdateNote: func(mockdb *mockdb.MockQuerier, args *db.UpdateNoteParams) {
-				mockdb.EXPECT().UpdateNote(gomock.Any(), args).Times(1).Return(uuid.Nil, ErrDBInternal)
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().UpdateNote(gomock.Any(), (*updateNoteMatcher)(args)).Times(1).Return(int32(0), sql.ErrConnDone)
 			},
-			checkReturnValues: func(t *testing.T, args *db.UpdateNoteParams, id uuid.UUID, err error) {
-				require.Equal(t, uuid.Nil, id)
+			checkReturnValues: func(t *testing.T, version int32, err error) {
+				require.Zero(t, version)
 				require.ErrorIs(t, err, ErrDBInternal)
 			},
 		},
@@ -335,8 +514,8 @@ func TestUpdateNote(t *testing.T) {
 			ns := NewService(mockdb)
 
 			tc.mockdbUpdateNote(mockdb, &args)
-			id, err := ns.q.UpdateNote(context.Background(), &args)
-			tc.checkReturnValues(t, &args, id, err)
+			version, err := ns.UpdateNote(context.Background(), args.Username, args.ID, args.Title.String, args.Text.String, true, nil, args.Version)
+			tc.checkReturnValues(t, version, err)
 		})
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,308 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/events"
+	"github.com/google/uuid"
+)
+
+const maxNotebookNameLength = 100
+
+// Notebook with everything below it
+type NotebookTree struct {
+	db.Notebook
+	Notebooks []*NotebookTree
+	Notes     []Note
+}
+
+func normalizeNotebookName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNotebookNameLength {
+		return "", ErrInvalidNotebookName
+	}
+
+	return name, nil
+}
+
+// Make sure the notebook, if any, belongs to the user
+func checkNotebook(ctx context.Context, q db.Querier, username string, notebookID uuid.NullUUID) error {
+	if !notebookID.Valid {
+		return nil
+	}
+
+	_, err := q.GetNotebook(ctx, &db.GetNotebookParams{ID: notebookID.UUID, Username: username})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotebookNotFound
+	}
+
+	return err
+}
+
+// Map errors of notebook writes
+func notebookError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotebookNotFound), errors.Is(err, ErrNotebookCycle):
+		return err
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotebookNotFound
+	case isUniqueViolation(err):
+		return ErrNotebookAlreadyExists
+	default:
+		return ErrDBInternal
+	}
+}
+
+// Create notebook, at the top level when parentID is null
+func (s *service) CreateNotebook(ctx context.Context, username string, name string, parentID uuid.NullUUID) (db.Notebook, error) {
+	name, err := normalizeNotebookName(name)
+	if err != nil {
+		return db.Notebook{}, err
+	}
+
+	var notebook db.Notebook
+	err = s.execTx(ctx, func(q db.Querier) error {
+		err := checkNotebook(ctx, q, username, parentID)
+		if err != nil {
+			return err
+		}
+
+		notebook, err = q.CreateNotebook(ctx, &db.CreateNotebookParams{
+			ID:        uuid.New(),
+			Username:  username,
+			ParentID:  parentID,
+			Name:      name,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+		return err
+	})
+	if err != nil {
+		return db.Notebook{}, notebookError(err)
+	}
+
+	return notebook, nil
+}
+
+// Return all notebooks of a user, the client builds the hierarchy from ParentID
+func (s *service) ListNotebooks(ctx context.Context, username string) ([]db.Notebook, error) {
+	notebooks, err := s.q.ListNotebooks(ctx, username)
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	return notebooks, nil
+}
+
+// Rename a notebook and/or move it under another parent
+func (s *service) UpdateNotebook(ctx context.Context, username string, id uuid.UUID, name string, parentID uuid.NullUUID) (db.Notebook, error) {
+	name, err := normalizeNotebookName(name)
+	if err != nil {
+		return db.Notebook{}, err
+	}
+
+	var notebook db.Notebook
+	err = s.execTx(ctx, func(q db.Querier) error {
+		err := checkNotebook(ctx, q, username, parentID)
+		if err != nil {
+			return err
+		}
+
+		if parentID.Valid {
+			cycle, err := q.IsNotebookInSubtree(ctx, &db.IsNotebookInSubtreeParams{RootID: id, ID: parentID.UUID})
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return ErrNotebookCycle
+			}
+		}
+
+		notebook, err = q.UpdateNotebook(ctx, &db.UpdateNotebookParams{
+			ID:        id,
+			Username:  username,
+			Name:      name,
+			ParentID:  parentID,
+			UpdatedAt: time.Now(),
+		})
+		return err
+	})
+	if err != nil {
+		return db.Notebook{}, notebookError(err)
+	}
+
+	return notebook, nil
+}
+
+// Delete a notebook with its children. Their notes are kept without a notebook, a note titled like one
+// already without a notebook fails it with TitleConflictError.
+func (s *service) DeleteNotebook(ctx context.Context, username string, id uuid.UUID) error {
+	_, err := s.q.DeleteNotebook(ctx, &db.DeleteNotebookParams{ID: id, Username: username})
+	switch {
+	case isUniqueViolation(err):
+		// the notes left without a notebook clash with a title outside of it
+		return s.deleteNotebookConflict(ctx, username, id)
+	case err != nil:
+		return notebookError(err)
+	}
+
+	return nil
+}
+
+// Find the note whose title blocks the notes of a notebook from being left without a notebook
+func (s *service) deleteNotebookConflict(ctx context.Context, username string, id uuid.UUID) error {
+	rows, err := s.q.GetNotebookTree(ctx, &db.GetNotebookTreeParams{ID: id, Username: username})
+	if err != nil {
+		return ErrAlreadyExists
+	}
+
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+
+	notes, err := s.q.GetNotesInNotebooks(ctx, &db.GetNotesInNotebooksParams{Username: username, NotebookIds: ids})
+	if err != nil {
+		return ErrAlreadyExists
+	}
+
+	titles := make(map[string]uuid.UUID, len(notes))
+	for _, n := range notes {
+		if !n.UniqueTitle {
+			continue
+		}
+
+		// two notes of the notebook may clash with each other as well
+		if other, ok := titles[n.Title]; ok {
+			return &TitleConflictError{ID: other}
+		}
+		titles[n.Title] = n.ID
+
+		conflict, err := s.q.FindTitleConflict(ctx, &db.FindTitleConflictParams{Username: username, Title: n.Title})
+		if err == nil {
+			return &TitleConflictError{ID: conflict}
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return ErrAlreadyExists
+		}
+	}
+
+	return ErrAlreadyExists
+}
+
+// Return a notebook with all notebooks and notes below it
+func (s *service) GetNotebookTree(ctx context.Context, username string, id uuid.UUID) (*NotebookTree, error) {
+	rows, err := s.q.GetNotebookTree(ctx, &db.GetNotebookTreeParams{ID: id, Username: username})
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotebookNotFound
+	}
+
+	nodes := make(map[uuid.UUID]*NotebookTree, len(rows))
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		nodes[row.ID] = &NotebookTree{Notebook: db.Notebook(row), Notebooks: []*NotebookTree{}, Notes: []Note{}}
+		ids = append(ids, row.ID)
+	}
+
+	// rows are sorted by name, so children keep that order
+	for _, row := range rows {
+		if row.ID != id && row.ParentID.Valid {
+			parent := nodes[row.ParentID.UUID]
+			parent.Notebooks = append(parent.Notebooks, nodes[row.ID])
+		}
+	}
+
+	notes, err := s.q.GetNotesInNotebooks(ctx, &db.GetNotesInNotebooksParams{Username: username, NotebookIds: ids})
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	tagged, err := s.withTags(ctx, notes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range tagged {
+		node := nodes[n.NotebookID.UUID]
+		node.Notes = append(node.Notes, n)
+	}
+
+	return nodes[id], nil
+}
+
+// Move a note of owner into notebookID unless it is there already, in the transaction of another write.
+// Notebooks are the owner's, so a grantee may not move the note.
+func moveNoteIfElsewhere(ctx context.Context, q db.Querier, username string, owner string, noteID uuid.UUID, notebookID uuid.NullUUID) (bool, error) {
+	n, err := q.GetNote(ctx, &db.GetNoteParams{ID: noteID, Username: owner})
+	if err != nil {
+		return false, err
+	}
+	if n.NotebookID == notebookID {
+		return false, nil
+	}
+	if username != owner {
+		return false, ErrForbidden
+	}
+
+	err = checkNotebook(ctx, q, owner, notebookID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = q.MoveNote(ctx, &db.MoveNoteParams{
+		ID:         noteID,
+		Username:   owner,
+		NotebookID: notebookID,
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Move a note into a notebook, or out of any notebook when notebookID is null
+func (s *service) MoveNote(ctx context.Context, username string, noteID uuid.UUID, notebookID uuid.NullUUID) error {
+	err := s.execTx(ctx, func(q db.Querier) error {
+		err := checkNotebook(ctx, q, username, notebookID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.MoveNote(ctx, &db.MoveNoteParams{
+			ID:         noteID,
+			Username:   username,
+			NotebookID: notebookID,
+			UpdatedAt:  time.Now(),
+		})
+		return err
+	})
+
+	switch {
+	case errors.Is(err, ErrNotebookNotFound):
+		return err
+	case isUniqueViolation(err):
+		n, err := s.q.GetNote(ctx, &db.GetNoteParams{ID: noteID, Username: username})
+		if err != nil {
+			return ErrAlreadyExists
+		}
+		return s.titleConflict(ctx, username, noteID, n.Title, notebookID)
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case err != nil:
+		return ErrDBInternal
+	default:
+		s.publish(ctx, events.NoteUpdated, noteID, 0, username)
+		return nil
+	}
+}
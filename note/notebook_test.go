@@ -0,0 +1,315 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateNotebook(t *testing.T) {
+	const username = "user1"
+	parent := uuid.New()
+
+	testCases := []struct {
+		name        string
+		nbName      string
+		parentID    uuid.NullUUID
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:     "creating nested notebook OK",
+			nbName:   " Recipes ",
+			parentID: uuid.NullUUID{UUID: parent, Valid: true},
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNotebook(gomock.Any(), &db.GetNotebookParams{ID: parent, Username: username}).Times(1).Return(db.Notebook{ID: parent}, nil)
+				mockdb.EXPECT().CreateNotebook(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.CreateNotebookParams) (db.Notebook, error) {
+						require.Equal(t, "Recipes", arg.Name)
+						return db.Notebook{ID: arg.ID, Name: arg.Name, ParentID: arg.ParentID}, nil
+					})
+			},
+		},
+		{
+			name:     "returns ErrNotebookNotFound - parent of another user",
+			nbName:   "Recipes",
+			parentID: uuid.NullUUID{UUID: parent, Valid: true},
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNotebook(gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{}, sql.ErrNoRows)
+				mockdb.EXPECT().CreateNotebook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: ErrNotebookNotFound,
+		},
+		{
+			name:   "returns ErrInvalidNotebookName",
+			nbName: "  ",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+			},
+			wantErr: ErrInvalidNotebookName,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			_, err := ns.CreateNotebook(context.Background(), username, tc.nbName, tc.parentID)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateNotebook(t *testing.T) {
+	const username = "user1"
+	id := uuid.New()
+	child := uuid.New()
+
+	t.Run("returns ErrNotebookCycle - moving into own child", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().GetNotebook(gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{ID: child}, nil)
+		mockdb.EXPECT().IsNotebookInSubtree(gomock.Any(), &db.IsNotebookInSubtreeParams{RootID: id, ID: child}).Times(1).Return(true, nil)
+		mockdb.EXPECT().UpdateNotebook(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := ns.UpdateNotebook(context.Background(), username, id, "name", uuid.NullUUID{UUID: child, Valid: true})
+		require.ErrorIs(t, err, ErrNotebookCycle)
+	})
+
+	t.Run("moving to the top level OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().UpdateNotebook(gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{ID: id}, nil)
+
+		notebook, err := ns.UpdateNotebook(context.Background(), username, id, "name", uuid.NullUUID{})
+		require.NoError(t, err)
+		require.Equal(t, id, notebook.ID)
+	})
+
+	t.Run("returns ErrNotebookAlreadyExists", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().UpdateNotebook(gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{}, uniqueViolation())
+
+		_, err := ns.UpdateNotebook(context.Background(), username, id, "name", uuid.NullUUID{})
+		require.ErrorIs(t, err, ErrNotebookAlreadyExists)
+	})
+}
+
+func TestDeleteNotebook(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+	child := uuid.New()
+	outside := uuid.New()
+	first := uuid.New()
+
+	testCases := []struct {
+		name        string
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+		conflictID  uuid.UUID
+	}{
+		{
+			name: "deleting notebook OK",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeleteNotebook(gomock.Any(), &db.DeleteNotebookParams{ID: id, Username: username}).Times(1).Return(id, nil)
+			},
+		},
+		{
+			name: "returns ErrNotebookNotFound",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeleteNotebook(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			wantErr: ErrNotebookNotFound,
+		},
+		{
+			name: "returns TitleConflictError - title taken by a note without a notebook",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeleteNotebook(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, uniqueViolation())
+				mockdb.EXPECT().GetNotebookTree(gomock.Any(), &db.GetNotebookTreeParams{ID: id, Username: username}).Times(1).
+					Return([]db.GetNotebookTreeRow{{ID: id}, {ID: child, ParentID: uuid.NullUUID{UUID: id, Valid: true}}}, nil)
+				mockdb.EXPECT().GetNotesInNotebooks(gomock.Any(), &db.GetNotesInNotebooksParams{Username: username, NotebookIds: []uuid.UUID{id, child}}).Times(1).
+					Return([]db.Note{
+						{ID: uuid.New(), Title: "free", UniqueTitle: true},
+						{ID: uuid.New(), Title: "taken", UniqueTitle: true},
+					}, nil)
+				mockdb.EXPECT().FindTitleConflict(gomock.Any(), &db.FindTitleConflictParams{Username: username, Title: "free"}).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+				mockdb.EXPECT().FindTitleConflict(gomock.Any(), &db.FindTitleConflictParams{Username: username, Title: "taken"}).Times(1).Return(outside, nil)
+			},
+			wantErr:    ErrAlreadyExists,
+			conflictID: outside,
+		},
+		{
+			name: "returns TitleConflictError - notes of two notebooks share a title",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeleteNotebook(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, uniqueViolation())
+				mockdb.EXPECT().GetNotebookTree(gomock.Any(), gomock.Any()).Times(1).
+					Return([]db.GetNotebookTreeRow{{ID: id}, {ID: child, ParentID: uuid.NullUUID{UUID: id, Valid: true}}}, nil)
+				mockdb.EXPECT().GetNotesInNotebooks(gomock.Any(), gomock.Any()).Times(1).
+					Return([]db.Note{
+						{ID: first, Title: "same", UniqueTitle: true, NotebookID: uuid.NullUUID{UUID: id, Valid: true}},
+						{ID: uuid.New(), Title: "same", UniqueTitle: true, NotebookID: uuid.NullUUID{UUID: child, Valid: true}},
+					}, nil)
+				mockdb.EXPECT().FindTitleConflict(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			wantErr:    ErrAlreadyExists,
+			conflictID: first,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			err := ns.DeleteNotebook(context.Background(), username, id)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, tc.wantErr)
+			if tc.conflictID != uuid.Nil {
+				var conflict *TitleConflictError
+				require.ErrorAs(t, err, &conflict)
+				require.Equal(t, tc.conflictID, conflict.ID)
+			}
+		})
+	}
+}
+
+func TestGetNotebookTree(t *testing.T) {
+	const username = "user1"
+	root := uuid.New()
+	child := uuid.New()
+	grandchild := uuid.New()
+	n1 := db.Note{ID: uuid.New(), NotebookID: uuid.NullUUID{UUID: root, Valid: true}}
+	n2 := db.Note{ID: uuid.New(), NotebookID: uuid.NullUUID{UUID: grandchild, Valid: true}}
+
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := NewService(mockdb)
+
+	mockdb.EXPECT().GetNotebookTree(gomock.Any(), &db.GetNotebookTreeParams{ID: root, Username: username}).Times(1).Return([]db.GetNotebookTreeRow{
+		{ID: child, ParentID: uuid.NullUUID{UUID: root, Valid: true}, Name: "a"},
+		{ID: grandchild, ParentID: uuid.NullUUID{UUID: child, Valid: true}, Name: "b"},
+		{ID: root, Name: "c"},
+	}, nil)
+	mockdb.EXPECT().GetNotesInNotebooks(gomock.Any(), gomock.Any()).Times(1).Return([]db.Note{n1, n2}, nil)
+	mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), gomock.Any()).Times(1).Return([]db.GetTagsOfNotesRow{}, nil)
+
+	tree, err := ns.GetNotebookTree(context.Background(), username, root)
+	require.NoError(t, err)
+	require.Equal(t, root, tree.ID)
+	require.Len(t, tree.Notes, 1)
+	require.Equal(t, n1.ID, tree.Notes[0].ID)
+	require.Len(t, tree.Notebooks, 1)
+	require.Equal(t, child, tree.Notebooks[0].ID)
+	require.Empty(t, tree.Notebooks[0].Notes)
+	require.Len(t, tree.Notebooks[0].Notebooks, 1)
+	require.Equal(t, n2.ID, tree.Notebooks[0].Notebooks[0].Notes[0].ID)
+
+	t.Run("returns ErrNotebookNotFound", func(t *testing.T) {
+		mockdb.EXPECT().GetNotebookTree(gomock.Any(), gomock.Any()).Times(1).Return([]db.GetNotebookTreeRow{}, nil)
+
+		_, err := ns.GetNotebookTree(context.Background(), "otheruser", root)
+		require.ErrorIs(t, err, ErrNotebookNotFound)
+	})
+}
+
+func TestMoveNote(t *testing.T) {
+	const username = "user1"
+	noteID := uuid.New()
+	notebookID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+
+	testCases := []struct {
+		name        string
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "moving note OK",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNotebook(gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{ID: notebookID.UUID}, nil)
+				mockdb.EXPECT().MoveNote(gomock.Any(), gomock.Any()).Times(1).Return(noteID, nil)
+			},
+		},
+		{
+			name: "returns ErrNotebookNotFound",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNotebook(gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{}, sql.ErrNoRows)
+			},
+			wantErr: ErrNotebookNotFound,
+		},
+		{
+			name: "returns ErrNotFound - note of another user",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNotebook(gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{ID: notebookID.UUID}, nil)
+				mockdb.EXPECT().MoveNote(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "returns ErrAlreadyExists - title taken in the target notebook",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNotebook(gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{ID: notebookID.UUID}, nil)
+				mockdb.EXPECT().MoveNote(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, uniqueViolation())
+				mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(db.Note{ID: noteID, Title: "title"}, nil)
+				mockdb.EXPECT().FindTitleConflict(gomock.Any(), &db.FindTitleConflictParams{
+					Username:   username,
+					Title:      "title",
+					NotebookID: notebookID,
+					ExcludeID:  noteID,
+				}).Times(1).Return(uuid.New(), nil)
+			},
+			wantErr: ErrAlreadyExists,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			err := ns.MoveNote(context.Background(), username, noteID, notebookID)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func uniqueViolation() error {
+	return &pq.Error{Code: "23505"}
+}
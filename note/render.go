@@ -0,0 +1,116 @@
+package note
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/markdown"
+	"github.com/google/uuid"
+)
+
+// How many rendered notes are kept in memory
+const defaultRenderCacheSize = 1024
+
+// Rendered HTML of a note version
+type renderEntry struct {
+	id uuid.UUID
+	// a note purged and created again with the same ID starts over from version 1
+	createdAt time.Time
+	version   int32
+	html      string
+}
+
+func (e *renderEntry) renders(n db.Note) bool {
+	return e.version == n.Version && e.createdAt.Equal(n.CreatedAt)
+}
+
+// Least recently used cache of rendered notes, one version per note.
+// A new version replaces the old one, so edits never serve stale HTML.
+type renderCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front is the most recently used
+	entries map[uuid.UUID]*list.Element
+}
+
+func newRenderCache(size int) *renderCache {
+	return &renderCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[uuid.UUID]*list.Element),
+	}
+}
+
+func (c *renderCache) get(n db.Note) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[n.ID]
+	if !ok || !el.Value.(*renderEntry).renders(n) {
+		return "", false
+	}
+
+	c.order.MoveToFront(el)
+	return el.Value.(*renderEntry).html, true
+}
+
+func (c *renderCache) put(n db.Note, html string) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[n.ID]; ok {
+		e := el.Value.(*renderEntry)
+		// a slow render of an older version must not replace a newer one
+		if e.createdAt.Equal(n.CreatedAt) && e.version > n.Version {
+			return
+		}
+		e.createdAt, e.version, e.html = n.CreatedAt, n.Version, html
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[n.ID] = c.order.PushFront(&renderEntry{id: n.ID, createdAt: n.CreatedAt, version: n.Version, html: html})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*renderEntry).id)
+	}
+}
+
+// Return a note together with its text rendered from Markdown to sanitized HTML
+func (s *service) RenderNote(ctx context.Context, username string, id uuid.UUID) (Note, string, error) {
+	n, err := s.GetNote(ctx, username, id)
+	if err != nil {
+		return Note{}, "", err
+	}
+
+	html, err := s.render(n.Note)
+	if err != nil {
+		return Note{}, "", err
+	}
+
+	return n, html, nil
+}
+
+// Render the text of a note, going through the cache
+func (s *service) render(n db.Note) (string, error) {
+	if html, ok := s.rendered.get(n); ok {
+		return html, nil
+	}
+
+	html, err := markdown.Render(n.Text.String)
+	if err != nil {
+		return "", ErrRenderInternal
+	}
+	s.rendered.put(n, html)
+
+	return html, nil
+}
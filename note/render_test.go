@@ -0,0 +1,100 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderNote(t *testing.T) {
+	const username = "user1"
+	id := uuid.New()
+	args := &db.GetNoteParams{ID: id, Username: username}
+	text := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+
+	t.Run("rendering is cached per version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, nil)
+		gomock.InOrder(
+			mockdb.EXPECT().GetNote(gomock.Any(), args).Times(1).Return(db.Note{ID: id, Text: text("# v1"), Version: 1}, nil),
+			// same version, the text is not rendered again
+			mockdb.EXPECT().GetNote(gomock.Any(), args).Times(1).Return(db.Note{ID: id, Text: text("# changed"), Version: 1}, nil),
+			mockdb.EXPECT().GetNote(gomock.Any(), args).Times(1).Return(db.Note{ID: id, Text: text("# v2"), Version: 2}, nil),
+		)
+
+		_, html, err := ns.RenderNote(context.Background(), username, id)
+		require.NoError(t, err)
+		require.Equal(t, "<h1>v1</h1>\n", html)
+
+		_, html, err = ns.RenderNote(context.Background(), username, id)
+		require.NoError(t, err)
+		require.Equal(t, "<h1>v1</h1>\n", html)
+
+		_, html, err = ns.RenderNote(context.Background(), username, id)
+		require.NoError(t, err)
+		require.Equal(t, "<h1>v2</h1>\n", html)
+	})
+
+	t.Run("returns ErrNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().GetNote(gomock.Any(), args).Times(1).Return(db.Note{}, sql.ErrNoRows)
+		mockdb.EXPECT().GetSharedNote(gomock.Any(), gomock.Any()).Times(1).Return(db.GetSharedNoteRow{}, sql.ErrNoRows)
+
+		_, _, err := ns.RenderNote(context.Background(), username, id)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestRenderCache(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	version := func(id uuid.UUID, v int32) db.Note { return db.Note{ID: id, CreatedAt: created, Version: v} }
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	cache := newRenderCache(2)
+
+	cache.put(version(a, 1), "a1")
+	cache.put(version(b, 1), "b1")
+
+	// a becomes the most recently used, so b is evicted by c
+	_, ok := cache.get(version(a, 1))
+	require.True(t, ok)
+	cache.put(version(c, 1), "c1")
+
+	_, ok = cache.get(version(b, 1))
+	require.False(t, ok)
+	html, ok := cache.get(version(c, 1))
+	require.True(t, ok)
+	require.Equal(t, "c1", html)
+
+	// an older version never replaces a newer one
+	cache.put(version(a, 3), "a3")
+	cache.put(version(a, 2), "a2")
+	_, ok = cache.get(version(a, 2))
+	require.False(t, ok)
+	html, ok = cache.get(version(a, 3))
+	require.True(t, ok)
+	require.Equal(t, "a3", html)
+
+	// c purged and created again with the same ID starts over from version 1
+	recreated := db.Note{ID: c, CreatedAt: created.Add(time.Hour), Version: 1}
+	_, ok = cache.get(recreated)
+	require.False(t, ok)
+	cache.put(recreated, "c1 again")
+	html, ok = cache.get(recreated)
+	require.True(t, ok)
+	require.Equal(t, "c1 again", html)
+	_, ok = cache.get(version(c, 1))
+	require.False(t, ok)
+}
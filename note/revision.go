@@ -0,0 +1,145 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/events"
+	"github.com/google/uuid"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Snapshot the current state of a note as a new revision and drop the ones over the limit
+func (s *service) addRevision(ctx context.Context, q db.Querier, noteID uuid.UUID) error {
+	rev, err := q.CreateNoteRevision(ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	if s.revisionLimit <= 0 || int(rev) <= s.revisionLimit {
+		return nil
+	}
+
+	return q.PruneNoteRevisions(ctx, &db.PruneNoteRevisionsParams{
+		NoteID:   noteID,
+		Revision: rev - int32(s.revisionLimit),
+	})
+}
+
+// Return the revisions of a note, newest first
+func (s *service) ListRevisions(ctx context.Context, username string, noteID uuid.UUID) ([]db.ListNoteRevisionsRow, error) {
+	revs, err := s.q.ListNoteRevisions(ctx, &db.ListNoteRevisionsParams{NoteID: noteID, Username: username})
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	// every note has at least one revision, so nothing means it's not the user's note
+	if len(revs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return revs, nil
+}
+
+// Return a single revision of a note. Revision 0 is the latest one.
+func (s *service) GetRevision(ctx context.Context, username string, noteID uuid.UUID, rev int32) (db.NoteRevision, error) {
+	var revision db.NoteRevision
+	var err error
+
+	if rev <= 0 {
+		revision, err = s.q.GetLatestNoteRevision(ctx, &db.GetLatestNoteRevisionParams{NoteID: noteID, Username: username})
+	} else {
+		revision, err = s.q.GetNoteRevision(ctx, &db.GetNoteRevisionParams{NoteID: noteID, Username: username, Revision: rev})
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return db.NoteRevision{}, ErrRevisionNotFound
+	case err != nil:
+		return db.NoteRevision{}, ErrDBInternal
+	default:
+		return revision, nil
+	}
+}
+
+// Unified diff of the title and text between two revisions. Revision 0 is the latest one.
+func (s *service) DiffRevisions(ctx context.Context, username string, noteID uuid.UUID, from int32, to int32) (string, error) {
+	a, err := s.GetRevision(ctx, username, noteID, from)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := s.GetRevision(ctx, username, noteID, to)
+	if err != nil {
+		return "", err
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(revisionContent(a)),
+		B:        difflib.SplitLines(revisionContent(b)),
+		FromFile: fmt.Sprintf("revision %d", a.Revision),
+		FromDate: a.CreatedAt.Format(time.RFC3339),
+		ToFile:   fmt.Sprintf("revision %d", b.Revision),
+		ToDate:   b.CreatedAt.Format(time.RFC3339),
+		Context:  3,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return diff, nil
+}
+
+// Title on the first line, so renames show up in the diff too
+func revisionContent(r db.NoteRevision) string {
+	return r.Title + "\n\n" + r.Text.String
+}
+
+// Make an old revision the current state of the note. The restore is itself a new revision.
+func (s *service) RestoreRevision(ctx context.Context, username string, noteID uuid.UUID, rev int32) error {
+	var (
+		title   string
+		version int32
+	)
+	err := s.execTx(ctx, func(q db.Querier) error {
+		revision, err := q.GetNoteRevision(ctx, &db.GetNoteRevisionParams{NoteID: noteID, Username: username, Revision: rev})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRevisionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		title = revision.Title
+
+		version, err = q.UpdateNote(ctx, &db.UpdateNoteParams{
+			ID:        noteID,
+			Username:  username,
+			Title:     sql.NullString{String: revision.Title, Valid: true},
+			Text:      sql.NullString{String: revision.Text.String, Valid: true},
+			UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.addRevision(ctx, q, noteID)
+	})
+
+	switch {
+	case errors.Is(err, ErrRevisionNotFound):
+		return err
+	case isUniqueViolation(err):
+		return s.noteTitleConflict(ctx, username, noteID, title)
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case err != nil:
+		return ErrDBInternal
+	default:
+		s.publish(ctx, events.NoteUpdated, noteID, version, username)
+		return nil
+	}
+}
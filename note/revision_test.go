@@ -0,0 +1,172 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRevision(t *testing.T) {
+	noteID := uuid.New()
+
+	testCases := []struct {
+		name        string
+		limit       int
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+	}{
+		{
+			name:  "below the limit nothing is pruned",
+			limit: 5,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().CreateNoteRevision(gomock.Any(), noteID).Times(1).Return(int32(5), nil)
+				mockdb.EXPECT().PruneNoteRevisions(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:  "revisions over the limit are pruned",
+			limit: 5,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().CreateNoteRevision(gomock.Any(), noteID).Times(1).Return(int32(8), nil)
+				mockdb.EXPECT().PruneNoteRevisions(gomock.Any(), &db.PruneNoteRevisionsParams{NoteID: noteID, Revision: 3}).Times(1).Return(nil)
+			},
+		},
+		{
+			name:  "no limit keeps everything",
+			limit: 0,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().CreateNoteRevision(gomock.Any(), noteID).Times(1).Return(int32(1000), nil)
+				mockdb.EXPECT().PruneNoteRevisions(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb, WithRevisionLimit(tc.limit))
+
+			tc.mockdbCalls(mockdb)
+			require.NoError(t, ns.addRevision(context.Background(), mockdb, noteID))
+		})
+	}
+}
+
+func TestListRevisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := NewService(mockdb)
+
+	mockdb.EXPECT().ListNoteRevisions(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListNoteRevisionsRow{}, nil)
+
+	_, err := ns.ListRevisions(context.Background(), "otheruser", uuid.New())
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDiffRevisions(t *testing.T) {
+	const username = "user1"
+	noteID := uuid.New()
+	created := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := NewService(mockdb)
+
+	mockdb.EXPECT().GetNoteRevision(gomock.Any(), &db.GetNoteRevisionParams{NoteID: noteID, Username: username, Revision: 1}).Times(1).
+		Return(db.NoteRevision{NoteID: noteID, Revision: 1, Title: "list", Text: sql.NullString{String: "milk\neggs", Valid: true}, CreatedAt: created}, nil)
+	mockdb.EXPECT().GetLatestNoteRevision(gomock.Any(), &db.GetLatestNoteRevisionParams{NoteID: noteID, Username: username}).Times(1).
+		Return(db.NoteRevision{NoteID: noteID, Revision: 2, Title: "list", Text: sql.NullString{String: "milk\nbread", Valid: true}, CreatedAt: created}, nil)
+
+	diff, err := ns.DiffRevisions(context.Background(), username, noteID, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, "--- revision 1\t2023-05-01T12:00:00Z\n"+
+		"+++ revision 2\t2023-05-01T12:00:00Z\n"+
+		"@@ -1,4 +1,4 @@\n"+
+		" list\n"+
+		" \n"+
+		" milk\n"+
+		"-eggs\n"+
+		"+bread\n", diff)
+
+	t.Run("returns ErrRevisionNotFound", func(t *testing.T) {
+		mockdb.EXPECT().GetNoteRevision(gomock.Any(), gomock.Any()).Times(1).Return(db.NoteRevision{}, sql.ErrNoRows)
+
+		_, err := ns.DiffRevisions(context.Background(), username, noteID, 7, 0)
+		require.ErrorIs(t, err, ErrRevisionNotFound)
+	})
+}
+
+func TestRestoreRevision(t *testing.T) {
+	const username = "user1"
+	noteID := uuid.New()
+	revision := db.NoteRevision{NoteID: noteID, Revision: 1, Title: "old title", Text: sql.NullString{String: "old text", Valid: true}}
+
+	testCases := []struct {
+		name        string
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "restoring revision OK",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteRevision(gomock.Any(), gomock.Any()).Times(1).Return(revision, nil)
+				mockdb.EXPECT().UpdateNote(gomock.Any(), (*updateNoteMatcher)(&db.UpdateNoteParams{
+					ID:       noteID,
+					Username: username,
+					Title:    sql.NullString{String: "old title", Valid: true},
+					Text:     sql.NullString{String: "old text", Valid: true},
+				})).Times(1).Return(int32(4), nil)
+				mockdb.EXPECT().CreateNoteRevision(gomock.Any(), noteID).Times(1).Return(int32(3), nil)
+			},
+		},
+		{
+			name: "returns ErrRevisionNotFound",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteRevision(gomock.Any(), gomock.Any()).Times(1).Return(db.NoteRevision{}, sql.ErrNoRows)
+			},
+			wantErr: ErrRevisionNotFound,
+		},
+		{
+			name: "returns ErrAlreadyExists - title taken in the meantime",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteRevision(gomock.Any(), gomock.Any()).Times(1).Return(revision, nil)
+				mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).Return(int32(0), uniqueViolation())
+				mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(db.Note{ID: noteID}, nil)
+				mockdb.EXPECT().FindTitleConflict(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.FindTitleConflictParams) (uuid.UUID, error) {
+						require.Equal(t, revision.Title, arg.Title)
+						require.Equal(t, noteID, arg.ExcludeID)
+						return uuid.New(), nil
+					})
+			},
+			wantErr: ErrAlreadyExists,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			err := ns.RestoreRevision(context.Background(), username, noteID, 1)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
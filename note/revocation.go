@@ -0,0 +1,84 @@
+package note
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How long an ID is trusted to be not revoked before asking the DB again.
+// Bounds how long a revocation made by another replica can go unnoticed.
+const defaultRevocationCacheTTL = 30 * time.Second
+
+// In-memory cache in front of the revoked_tokens table
+type revocationCache struct {
+	mu        sync.RWMutex
+	revoked   map[uuid.UUID]time.Time // id -> expiry of the revoked token
+	checked   map[uuid.UUID]time.Time // id -> time it was found not revoked
+	ttl       time.Duration
+	lastPrune time.Time
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		revoked: make(map[uuid.UUID]time.Time),
+		checked: make(map[uuid.UUID]time.Time),
+		ttl:     ttl,
+	}
+}
+
+// Report whether id is known to be revoked, and whether the cache knows the answer at all
+func (c *revocationCache) lookup(id uuid.UUID, now time.Time) (revoked bool, known bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if expiresAt, ok := c.revoked[id]; ok && now.Before(expiresAt) {
+		return true, true
+	}
+
+	if checkedAt, ok := c.checked[id]; ok && now.Sub(checkedAt) < c.ttl {
+		return false, true
+	}
+
+	return false, false
+}
+
+func (c *revocationCache) revoke(id uuid.UUID, expiresAt time.Time, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.revoked[id] = expiresAt
+	delete(c.checked, id)
+	c.prune(now)
+}
+
+func (c *revocationCache) markValid(ids []uuid.UUID, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range ids {
+		c.checked[id] = now
+	}
+	c.prune(now)
+}
+
+// Drop stale entries, at most once per TTL. Must be called with the lock held.
+func (c *revocationCache) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < c.ttl {
+		return
+	}
+	c.lastPrune = now
+
+	for id, expiresAt := range c.revoked {
+		if !now.Before(expiresAt) {
+			delete(c.revoked, id)
+		}
+	}
+
+	for id, checkedAt := range c.checked {
+		if now.Sub(checkedAt) >= c.ttl {
+			delete(c.checked, id)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+var ErrInvalidSearchQuery = errors.New("search query has no searchable terms")
+
+// Search the notes of a user, best matches first.
+// Words are ANDed together, "quoted words" must appear as a phrase and a trailing * makes a prefix match.
+func (s *service) Search(ctx context.Context, username string, query string, limit int32) ([]db.SearchNotesRow, error) {
+	tsquery := buildTSQuery(query)
+	if tsquery == "" {
+		return nil, ErrInvalidSearchQuery
+	}
+
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	notes, err := s.q.SearchNotes(ctx, &db.SearchNotesParams{
+		Query:      tsquery,
+		Username:   username,
+		MaxResults: limit,
+	})
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	return notes, nil
+}
+
+// Turn user input into a to_tsquery expression.
+// Only letters and digits make it into the lexemes, so the input can't inject tsquery operators.
+func buildTSQuery(input string) string {
+	var parts []string
+
+	for i, chunk := range strings.Split(input, `"`) {
+		// odd chunks are between quotes
+		if i%2 == 1 {
+			if p := phrase(chunk); p != "" {
+				parts = append(parts, "("+p+")")
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(chunk) {
+			words := splitWords(field)
+			for j, w := range words {
+				// a trailing * applies to the last word of the field, e.g. note-ta*
+				prefix := j == len(words)-1 && strings.HasSuffix(field, "*")
+				parts = append(parts, lexeme(w, prefix))
+			}
+		}
+	}
+
+	return strings.Join(parts, " & ")
+}
+
+// Words that must follow each other
+func phrase(s string) string {
+	words := splitWords(s)
+	lexemes := make([]string, 0, len(words))
+	for _, w := range words {
+		lexemes = append(lexemes, lexeme(w, false))
+	}
+
+	return strings.Join(lexemes, " <-> ")
+}
+
+func lexeme(word string, prefix bool) string {
+	if prefix {
+		return "'" + word + "':*"
+	}
+	return "'" + word + "'"
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,110 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTSQuery(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "single word", input: "Groceries", want: "'groceries'"},
+		{name: "words are ANDed", input: "buy milk", want: "'buy' & 'milk'"},
+		{name: "prefix", input: "gro*", want: "'gro':*"},
+		{name: "phrase", input: `"shopping list" today`, want: "('shopping' <-> 'list') & 'today'"},
+		{name: "prefix applies to the last word", input: "note-ta*", want: "'note' & 'ta':*"},
+		{name: "operators are dropped", input: "a & !b | c:1 ')", want: "'a' & 'b' & 'c' & '1'"},
+		{name: "unterminated quote is a phrase", input: `"big cat`, want: "('big' <-> 'cat')"},
+		{name: "nothing searchable", input: `!! "" *`, want: ""},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, buildTSQuery(tc.input))
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	const username = "user1"
+
+	testCases := []struct {
+		name              string
+		query             string
+		limit             int32
+		mockdbCalls       func(mockdb *mockdb.MockQuerier)
+		checkReturnValues func(t *testing.T, notes []db.SearchNotesRow, err error)
+	}{
+		{
+			name:  "search OK with default limit",
+			query: "milk",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().SearchNotes(gomock.Any(), &db.SearchNotesParams{Query: "'milk'", Username: username, MaxResults: DefaultSearchLimit}).
+					Times(1).Return([]db.SearchNotesRow{{Title: "Groceries"}}, nil)
+			},
+			checkReturnValues: func(t *testing.T, notes []db.SearchNotesRow, err error) {
+				require.NoError(t, err)
+				require.Len(t, notes, 1)
+			},
+		},
+		{
+			name:  "limit is capped",
+			query: "milk",
+			limit: 1000,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().SearchNotes(gomock.Any(), &db.SearchNotesParams{Query: "'milk'", Username: username, MaxResults: MaxSearchLimit}).
+					Times(1).Return([]db.SearchNotesRow{}, nil)
+			},
+			checkReturnValues: func(t *testing.T, notes []db.SearchNotesRow, err error) {
+				require.NoError(t, err)
+				require.Empty(t, notes)
+			},
+		},
+		{
+			name:  "returns ErrInvalidSearchQuery",
+			query: "&&",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().SearchNotes(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkReturnValues: func(t *testing.T, notes []db.SearchNotesRow, err error) {
+				require.ErrorIs(t, err, ErrInvalidSearchQuery)
+			},
+		},
+		{
+			name:  "returns ErrDBInternal",
+			query: "milk",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().SearchNotes(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkReturnValues: func(t *testing.T, notes []db.SearchNotesRow, err error) {
+				require.ErrorIs(t, err, ErrDBInternal)
+				require.Nil(t, notes)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			notes, err := ns.Search(context.Background(), username, tc.query, tc.limit)
+			tc.checkReturnValues(t, notes, err)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/google/uuid"
+)
+
+// Start a new session at login and store the hash of its first refresh token.
+// The session ID doubles as the refresh token family.
+func (s *service) CreateSession(ctx context.Context, username string, userAgent string, clientIP string, refreshHash string, expiresAt time.Time) (uuid.UUID, error) {
+	now := time.Now()
+	sessionID := uuid.New()
+
+	err := s.execTx(ctx, func(q db.Querier) error {
+		_, err := q.CreateSession(ctx, &db.CreateSessionParams{
+			ID:         sessionID,
+			Username:   username,
+			UserAgent:  userAgent,
+			ClientIp:   clientIP,
+			CreatedAt:  now,
+			LastSeenAt: now,
+			ExpiresAt:  expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateRefreshToken(ctx, &db.CreateRefreshTokenParams{
+			ID:        uuid.New(),
+			FamilyID:  sessionID,
+			Username:  username,
+			TokenHash: refreshHash,
+			CreatedAt: now,
+			ExpiresAt: expiresAt,
+		})
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, ErrDBInternal
+	}
+
+	return sessionID, nil
+}
+
+// Return the sessions of the user that are neither revoked nor expired
+func (s *service) ListSessions(ctx context.Context, username string) ([]db.Session, error) {
+	sessions, err := s.q.ListActiveSessions(ctx, &db.ListActiveSessionsParams{
+		Username:  username,
+		ExpiresAt: time.Now(),
+	})
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	return sessions, nil
+}
+
+// Revoke a session of the user together with its refresh tokens and access tokens
+func (s *service) RevokeSession(ctx context.Context, username string, sessionID uuid.UUID) error {
+	now := time.Now()
+	var session db.Session
+
+	err := s.execTx(ctx, func(q db.Querier) error {
+		var err error
+		session, err = revokeSession(ctx, q, username, sessionID, now)
+		return err
+	})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrSessionNotFound
+	case err != nil:
+		return ErrDBInternal
+	}
+
+	s.revoked.revoke(session.ID, session.ExpiresAt, now)
+	return nil
+}
+
+// Revoke the session a refresh token belongs to, used on logout when the access token is gone
+func (s *service) RevokeSessionByRefreshToken(ctx context.Context, refreshHash string) error {
+	token, err := s.q.GetRefreshTokenByHash(ctx, refreshHash)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrSessionNotFound
+	case err != nil:
+		return ErrDBInternal
+	}
+
+	return s.RevokeSession(ctx, token.Username, token.FamilyID)
+}
+
+// Revoke a single access token until it expires
+func (s *service) RevokeToken(ctx context.Context, username string, tokenID uuid.UUID, expiresAt time.Time) error {
+	now := time.Now()
+
+	err := s.q.CreateRevokedToken(ctx, &db.CreateRevokedTokenParams{
+		ID:        tokenID,
+		Username:  username,
+		RevokedAt: now,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return ErrDBInternal
+	}
+
+	s.revoked.revoke(tokenID, expiresAt, now)
+	return nil
+}
+
+// Report whether any of the token or session IDs has been revoked
+func (s *service) IsTokenRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error) {
+	now := time.Now()
+	unknown := make([]uuid.UUID, 0, len(ids))
+
+	for _, id := range ids {
+		revoked, known := s.revoked.lookup(id, now)
+		if revoked {
+			return true, nil
+		}
+		if !known {
+			unknown = append(unknown, id)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return false, nil
+	}
+
+	rows, err := s.q.GetRevokedTokens(ctx, &db.GetRevokedTokensParams{
+		Ids: unknown,
+		Now: now,
+	})
+	if err != nil {
+		return false, ErrDBInternal
+	}
+
+	for _, r := range rows {
+		s.revoked.revoke(r.ID, r.ExpiresAt, now)
+	}
+
+	if len(rows) > 0 {
+		return true, nil
+	}
+
+	s.revoked.markValid(unknown, now)
+	return false, nil
+}
+
+// Mark the session revoked, kill its refresh tokens and put its ID on the revocation list
+func revokeSession(ctx context.Context, q db.Querier, username string, sessionID uuid.UUID, now time.Time) (db.Session, error) {
+	session, err := q.RevokeSession(ctx, &db.RevokeSessionParams{
+		ID:       sessionID,
+		Username: username,
+		Now:      now,
+	})
+	if err != nil {
+		return db.Session{}, err
+	}
+
+	err = q.RevokeRefreshTokenFamily(ctx, &db.RevokeRefreshTokenFamilyParams{
+		FamilyID:  sessionID,
+		RevokedAt: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return db.Session{}, err
+	}
+
+	err = q.CreateRevokedToken(ctx, &db.CreateRevokedTokenParams{
+		ID:        sessionID,
+		Username:  username,
+		RevokedAt: now,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		return db.Session{}, err
+	}
+
+	return session, nil
+}
@@ -0,0 +1,135 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTokenRevoked(t *testing.T) {
+	tokenID := uuid.New()
+	sessionID := uuid.New()
+
+	t.Run("revoked token is found in DB and cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().GetRevokedTokens(gomock.Any(), gomock.Any()).Times(1).
+			Return([]db.GetRevokedTokensRow{{ID: sessionID, ExpiresAt: time.Now().Add(time.Hour)}}, nil)
+
+		for i := 0; i < 2; i++ {
+			revoked, err := ns.IsTokenRevoked(context.Background(), tokenID, sessionID)
+			require.NoError(t, err)
+			require.True(t, revoked)
+		}
+	})
+
+	t.Run("valid token is cached for the TTL", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().GetRevokedTokens(gomock.Any(), gomock.Any()).Times(1).Return([]db.GetRevokedTokensRow{}, nil)
+
+		for i := 0; i < 2; i++ {
+			revoked, err := ns.IsTokenRevoked(context.Background(), tokenID, sessionID)
+			require.NoError(t, err)
+			require.False(t, revoked)
+		}
+	})
+
+	t.Run("local revocation is visible without DB lookup", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().CreateRevokedToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		err := ns.RevokeToken(context.Background(), "user1", tokenID, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		revoked, err := ns.IsTokenRevoked(context.Background(), tokenID, sessionID)
+		require.NoError(t, err)
+		require.True(t, revoked)
+	})
+
+	t.Run("returns ErrDBInternal", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().GetRevokedTokens(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+
+		revoked, err := ns.IsTokenRevoked(context.Background(), tokenID)
+		require.ErrorIs(t, err, ErrDBInternal)
+		require.False(t, revoked)
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	const username = "user1"
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name              string
+		mockdbCalls       func(mockdb *mockdb.MockQuerier)
+		checkReturnValues func(t *testing.T, ns *service, err error)
+	}{
+		{
+			name: "revoking session OK",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.Session{ID: sessionID, Username: username, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+				mockdb.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				mockdb.EXPECT().CreateRevokedToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			checkReturnValues: func(t *testing.T, ns *service, err error) {
+				require.NoError(t, err)
+
+				revoked, known := ns.revoked.lookup(sessionID, time.Now())
+				require.True(t, known)
+				require.True(t, revoked)
+			},
+		},
+		{
+			name: "session of other user returns ErrSessionNotFound",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkReturnValues: func(t *testing.T, ns *service, err error) {
+				require.ErrorIs(t, err, ErrSessionNotFound)
+			},
+		},
+		{
+			name: "revoking session returns ErrDBInternal",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkReturnValues: func(t *testing.T, ns *service, err error) {
+				require.ErrorIs(t, err, ErrDBInternal)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			err := ns.RevokeSession(context.Background(), username, sessionID)
+			tc.checkReturnValues(t, ns, err)
+		})
+	}
+}
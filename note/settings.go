@@ -0,0 +1,58 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+)
+
+// Per-user preferences
+type Settings struct {
+	// Notes in the same notebook may share a title
+	AllowDuplicateTitles bool
+}
+
+// Return the settings of the user
+func (s *service) GetSettings(ctx context.Context, username string) (Settings, error) {
+	allow, err := s.q.GetUserSettings(ctx, username)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return Settings{}, ErrUserNotFound
+	case err != nil:
+		return Settings{}, ErrDBInternal
+	default:
+		return Settings{AllowDuplicateTitles: allow}, nil
+	}
+}
+
+// Replace the settings of the user. Titles can only be made unique again
+// once no notebook holds two active notes with the same title.
+func (s *service) UpdateSettings(ctx context.Context, username string, settings Settings) error {
+	err := s.execTx(ctx, func(q db.Querier) error {
+		err := q.SetAllowDuplicateTitles(ctx, &db.SetAllowDuplicateTitlesParams{
+			Username:             username,
+			AllowDuplicateTitles: settings.AllowDuplicateTitles,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the title index only covers notes flagged as unique
+		return q.SetNotesUniqueTitle(ctx, &db.SetNotesUniqueTitleParams{
+			Username:    username,
+			UniqueTitle: !settings.AllowDuplicateTitles,
+		})
+	})
+
+	switch {
+	case isUniqueViolation(err):
+		return ErrDuplicateTitles
+	case err != nil:
+		return ErrDBInternal
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSettings(t *testing.T) {
+	const username = "user1"
+
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := NewService(mockdb)
+
+	mockdb.EXPECT().GetUserSettings(gomock.Any(), username).Times(1).Return(true, nil)
+	settings, err := ns.GetSettings(context.Background(), username)
+	require.NoError(t, err)
+	require.Equal(t, Settings{AllowDuplicateTitles: true}, settings)
+
+	mockdb.EXPECT().GetUserSettings(gomock.Any(), username).Times(1).Return(false, sql.ErrNoRows)
+	_, err = ns.GetSettings(context.Background(), username)
+	require.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestUpdateSettings(t *testing.T) {
+	const username = "user1"
+
+	testCases := []struct {
+		name        string
+		settings    Settings
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:     "allowing duplicate titles OK",
+			settings: Settings{AllowDuplicateTitles: true},
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().SetAllowDuplicateTitles(gomock.Any(), &db.SetAllowDuplicateTitlesParams{Username: username, AllowDuplicateTitles: true}).Times(1).Return(nil)
+				mockdb.EXPECT().SetNotesUniqueTitle(gomock.Any(), &db.SetNotesUniqueTitleParams{Username: username, UniqueTitle: false}).Times(1).Return(nil)
+			},
+		},
+		{
+			name:     "returns ErrDuplicateTitles - notebooks hold duplicates",
+			settings: Settings{AllowDuplicateTitles: false},
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().SetAllowDuplicateTitles(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				mockdb.EXPECT().SetNotesUniqueTitle(gomock.Any(), &db.SetNotesUniqueTitleParams{Username: username, UniqueTitle: true}).Times(1).Return(uniqueViolation())
+			},
+			wantErr: ErrDuplicateTitles,
+		},
+		{
+			name: "returns ErrDBInternal",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().SetAllowDuplicateTitles(gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+			},
+			wantErr: ErrDBInternal,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			err := ns.UpdateSettings(context.Background(), username, tc.settings)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/google/uuid"
+)
+
+// What a user may do with a note shared with them
+type Permission string
+
+const (
+	PermissionRead Permission = "read"
+	PermissionEdit Permission = "edit"
+
+	// never stored, the owner may do anything with their note
+	permissionOwner Permission = "owner"
+)
+
+func (p Permission) level() int {
+	switch p {
+	case PermissionRead:
+		return 1
+	case PermissionEdit:
+		return 2
+	case permissionOwner:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Report whether p covers everything want does
+func (p Permission) allows(want Permission) bool {
+	return p.level() > 0 && p.level() >= want.level()
+}
+
+// Find the owner of a note the user acts on with perm. Returns sql.ErrNoRows
+// when the note isn't visible to the user and ErrForbidden when their share is too weak.
+func noteOwner(ctx context.Context, q db.Querier, username string, id uuid.UUID, perm Permission) (string, error) {
+	access, err := q.GetNoteAccess(ctx, &db.GetNoteAccessParams{ID: id, Username: username})
+	if err != nil {
+		return "", err
+	}
+
+	if !Permission(access.Permission).allows(perm) {
+		return "", ErrForbidden
+	}
+
+	return access.Owner, nil
+}
+
+// Grant another user perm on a note of owner, an existing share gets the new permission
+func (s *service) ShareNote(ctx context.Context, owner string, noteID uuid.UUID, username string, perm Permission) (db.NoteShare, error) {
+	if perm != PermissionRead && perm != PermissionEdit {
+		return db.NoteShare{}, ErrInvalidPermission
+	}
+	if username == owner {
+		return db.NoteShare{}, ErrShareWithOwner
+	}
+
+	var share db.NoteShare
+	err := s.execTx(ctx, func(q db.Querier) error {
+		_, err := q.GetNote(ctx, &db.GetNoteParams{ID: noteID, Username: owner})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = q.GetUser(ctx, username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		share, err = q.UpsertNoteShare(ctx, &db.UpsertNoteShareParams{
+			NoteID:     noteID,
+			Username:   username,
+			Permission: string(perm),
+			CreatedAt:  time.Now(),
+		})
+		return err
+	})
+
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrUserNotFound):
+		return db.NoteShare{}, err
+	case err != nil:
+		return db.NoteShare{}, ErrDBInternal
+	default:
+		return share, nil
+	}
+}
+
+// Return who a note of owner is shared with
+func (s *service) ListShares(ctx context.Context, owner string, noteID uuid.UUID) ([]db.NoteShare, error) {
+	_, err := s.q.GetNote(ctx, &db.GetNoteParams{ID: noteID, Username: owner})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, ErrDBInternal
+	}
+
+	shares, err := s.q.ListNoteShares(ctx, &db.ListNoteSharesParams{NoteID: noteID, Username: owner})
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	return shares, nil
+}
+
+// Stop sharing a note with grantee. Done by the owner, or by the grantee to leave the note.
+func (s *service) RevokeShare(ctx context.Context, username string, noteID uuid.UUID, grantee string) error {
+	_, err := s.q.DeleteNoteShare(ctx, &db.DeleteNoteShareParams{NoteID: noteID, Grantee: grantee, Username: username})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrShareNotFound
+	case err != nil:
+		return ErrDBInternal
+	default:
+		return nil
+	}
+}
+
+// Return the notes other users have shared with the user, most recently updated first
+func (s *service) ListSharedNotes(ctx context.Context, username string) ([]Note, error) {
+	rows, err := s.q.ListSharedNotes(ctx, username)
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	notes := make([]db.Note, 0, len(rows))
+	for _, row := range rows {
+		notes = append(notes, row.Note)
+	}
+
+	tagged, err := s.withTags(ctx, notes)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tagged {
+		tagged[i].Permission = Permission(rows[i].Permission)
+	}
+
+	return tagged, nil
+}
@@ -0,0 +1,158 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionAllows(t *testing.T) {
+	require.True(t, PermissionEdit.allows(PermissionRead))
+	require.True(t, permissionOwner.allows(PermissionEdit))
+	require.False(t, PermissionRead.allows(PermissionEdit))
+	require.False(t, PermissionEdit.allows(permissionOwner))
+	require.False(t, Permission("admin").allows(PermissionRead))
+}
+
+func TestShareNote(t *testing.T) {
+	const owner = "user1"
+	noteID := uuid.New()
+
+	testCases := []struct {
+		name        string
+		username    string
+		perm        Permission
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name:     "sharing note OK",
+			username: "user2",
+			perm:     PermissionEdit,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: noteID, Username: owner}).Times(1).Return(db.Note{ID: noteID}, nil)
+				mockdb.EXPECT().GetUser(gomock.Any(), "user2").Times(1).Return(db.User{Username: "user2"}, nil)
+				mockdb.EXPECT().UpsertNoteShare(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.UpsertNoteShareParams) (db.NoteShare, error) {
+						require.Equal(t, "edit", arg.Permission)
+						return db.NoteShare{NoteID: arg.NoteID, Username: arg.Username, Permission: arg.Permission}, nil
+					})
+			},
+		},
+		{
+			name:        "returns ErrInvalidPermission",
+			username:    "user2",
+			perm:        "owner",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {},
+			wantErr:     ErrInvalidPermission,
+		},
+		{
+			name:        "returns ErrShareWithOwner",
+			username:    owner,
+			perm:        PermissionRead,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {},
+			wantErr:     ErrShareWithOwner,
+		},
+		{
+			name:     "returns ErrNotFound - note of another user",
+			username: "user2",
+			perm:     PermissionRead,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(db.Note{}, sql.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:     "returns ErrUserNotFound",
+			username: "nobody",
+			perm:     PermissionRead,
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).Times(1).Return(db.Note{ID: noteID}, nil)
+				mockdb.EXPECT().GetUser(gomock.Any(), "nobody").Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			share, err := ns.ShareNote(context.Background(), owner, noteID, tc.username, tc.perm)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.username, share.Username)
+		})
+	}
+}
+
+func TestRevokeShare(t *testing.T) {
+	const username = "user1"
+	noteID := uuid.New()
+	args := &db.DeleteNoteShareParams{NoteID: noteID, Grantee: "user2", Username: username}
+
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := NewService(mockdb)
+
+	mockdb.EXPECT().DeleteNoteShare(gomock.Any(), args).Times(1).Return("user2", nil)
+	require.NoError(t, ns.RevokeShare(context.Background(), username, noteID, "user2"))
+
+	mockdb.EXPECT().DeleteNoteShare(gomock.Any(), args).Times(1).Return("", sql.ErrNoRows)
+	require.ErrorIs(t, ns.RevokeShare(context.Background(), username, noteID, "user2"), ErrShareNotFound)
+}
+
+func TestGetSharedNote(t *testing.T) {
+	const username = "user2"
+	id := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := NewService(mockdb)
+
+	mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: id, Username: username}).Times(1).Return(db.Note{}, sql.ErrNoRows)
+	mockdb.EXPECT().GetSharedNote(gomock.Any(), &db.GetSharedNoteParams{ID: id, Username: username}).Times(1).
+		Return(db.GetSharedNoteRow{Note: db.Note{ID: id, Username: "user1"}, Permission: "read"}, nil)
+	mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), []uuid.UUID{id}).Times(1).Return([]db.GetTagsOfNotesRow{{NoteID: id, Name: "work"}}, nil)
+
+	n, err := ns.GetNote(context.Background(), username, id)
+	require.NoError(t, err)
+	require.Equal(t, "user1", n.Username)
+	require.Equal(t, PermissionRead, n.Permission)
+	require.Equal(t, []string{"work"}, n.Tags)
+}
+
+func TestListSharedNotes(t *testing.T) {
+	const username = "user2"
+	first, second := uuid.New(), uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := NewService(mockdb)
+
+	mockdb.EXPECT().ListSharedNotes(gomock.Any(), username).Times(1).Return([]db.ListSharedNotesRow{
+		{Note: db.Note{ID: first}, Permission: "edit"},
+		{Note: db.Note{ID: second}, Permission: "read"},
+	}, nil)
+	mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), []uuid.UUID{first, second}).Times(1).Return([]db.GetTagsOfNotesRow{}, nil)
+
+	notes, err := ns.ListSharedNotes(context.Background(), username)
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	require.Equal(t, PermissionEdit, notes[0].Permission)
+	require.Equal(t, PermissionRead, notes[1].Permission)
+}
@@ -0,0 +1,246 @@
+package note
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/google/uuid"
+)
+
+const (
+	// server changes per sync, the client asks again while HasMore is set
+	maxSyncChanges = 500
+	// client changes per sync
+	MaxSyncClientChanges = 500
+)
+
+type SyncOp string
+
+const (
+	SyncUpsert SyncOp = "upsert"
+	SyncDelete SyncOp = "delete"
+)
+
+type SyncStatus string
+
+const (
+	SyncApplied SyncStatus = "applied"
+	// the note changed on the server since BaseVersion, the client has to merge
+	SyncConflict SyncStatus = "conflict"
+	// the change can't be applied as it is, e.g. an invalid tag or a taken title
+	SyncRejected SyncStatus = "rejected"
+)
+
+// Change a client made while offline. An upsert with BaseVersion 0 creates the note with
+// the client's ID, otherwise BaseVersion is the version of the note the change was made to.
+// An upsert carries the whole note, an empty Text clears it and a null NotebookID takes it out of its notebook.
+type SyncChange struct {
+	ID          uuid.UUID
+	Op          SyncOp
+	Title       string
+	Text        string
+	Tags        []string
+	NotebookID  uuid.NullUUID
+	BaseVersion int32
+}
+
+// Outcome of one client change. Current is the server copy on a conflict, nil when the note
+// was deleted on the server.
+type SyncResult struct {
+	ID      uuid.UUID
+	Status  SyncStatus
+	Version int32
+	Current *Note
+	Err     error
+}
+
+// Change on the server since the client's token. Note is nil on deletes.
+type SyncServerChange struct {
+	ID   uuid.UUID
+	Op   SyncOp
+	Note *Note
+}
+
+type SyncResponse struct {
+	Results []SyncResult
+	Changes []SyncServerChange
+	// to send with the next sync
+	Token   string
+	HasMore bool
+}
+
+// Position in the change log of the user
+type syncToken struct {
+	Seq int64 `json:"q"`
+}
+
+func encodeSyncToken(t syncToken) string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// An empty token is the start of the log
+func decodeSyncToken(s string) (syncToken, error) {
+	var t syncToken
+	if s == "" {
+		return t, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, ErrInvalidSyncToken
+	}
+	if err := json.Unmarshal(b, &t); err != nil || t.Seq < 0 {
+		return t, ErrInvalidSyncToken
+	}
+
+	return t, nil
+}
+
+// Apply the changes of an offline client, then return what changed on the server since token,
+// the client's own changes included. Each client change is applied on its own, with the version
+// check of UpdateNote and DeleteNote detecting conflicts.
+func (s *service) Sync(ctx context.Context, username string, token string, changes []SyncChange) (SyncResponse, error) {
+	if len(changes) > MaxSyncClientChanges {
+		return SyncResponse{}, ErrTooManySyncChanges
+	}
+
+	since, err := decodeSyncToken(token)
+	if err != nil {
+		return SyncResponse{}, err
+	}
+
+	resp := SyncResponse{Results: make([]SyncResult, 0, len(changes))}
+	for _, c := range changes {
+		res, err := s.applySyncChange(ctx, username, c)
+		if err != nil {
+			return SyncResponse{}, err
+		}
+		resp.Results = append(resp.Results, res)
+	}
+
+	logged, err := s.q.ListNoteChanges(ctx, &db.ListNoteChangesParams{
+		Username: username,
+		Seq:      since.Seq,
+		Limit:    maxSyncChanges + 1,
+	})
+	if err != nil {
+		return SyncResponse{}, ErrDBInternal
+	}
+
+	if len(logged) > maxSyncChanges {
+		logged = logged[:maxSyncChanges]
+		resp.HasMore = true
+	}
+
+	resp.Token = token
+	if len(logged) > 0 {
+		resp.Token = encodeSyncToken(syncToken{Seq: logged[len(logged)-1].Seq})
+	}
+
+	resp.Changes, err = s.syncChanges(ctx, username, logged)
+	if err != nil {
+		return SyncResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// Apply one client change. Only failures of the DB are returned as errors,
+// anything else is the outcome of the change.
+func (s *service) applySyncChange(ctx context.Context, username string, c SyncChange) (SyncResult, error) {
+	res := SyncResult{ID: c.ID, Status: SyncApplied}
+
+	var err error
+	switch {
+	case c.Op == SyncUpsert && c.BaseVersion == 0:
+		_, err = s.createNote(ctx, c.ID, c.Title, username, c.Text, c.Tags, c.NotebookID)
+		res.Version = 1
+	case c.Op == SyncUpsert:
+		tags := c.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		// the change carries the whole note, an empty text clears it and a null notebook takes it out of one
+		res.Version, err = s.updateNote(ctx, username, c.ID, c.Title, c.Text, true, tags, c.BaseVersion,
+			updateOptions{revise: true, notebookID: &c.NotebookID})
+	case c.Op == SyncDelete:
+		_, err = s.DeleteNote(ctx, username, c.ID, c.BaseVersion)
+		if errors.Is(err, ErrNotFound) {
+			// deleted on both sides
+			err = nil
+		}
+	default:
+		err = ErrInvalidSyncChange
+	}
+
+	var mismatch *VersionMismatchError
+	switch {
+	case err == nil:
+		return res, nil
+	case errors.As(err, &mismatch):
+		res.Status, res.Current = SyncConflict, &mismatch.Current
+	case errors.Is(err, ErrNotFound):
+		// edited offline, deleted on the server
+		res.Status = SyncConflict
+	case errors.Is(err, ErrNoteIDTaken):
+		// a retry of a create that went through, or an ID of somebody else
+		n, getErr := s.GetNote(ctx, username, c.ID)
+		if getErr != nil {
+			res.Status = SyncRejected
+			break
+		}
+		res.Status, res.Current = SyncConflict, &n
+	case errors.Is(err, ErrDBInternal):
+		return SyncResult{}, err
+	default:
+		res.Status = SyncRejected
+	}
+
+	res.Version = 0
+	res.Err = err
+
+	return res, nil
+}
+
+// Load the current state of the logged notes, the ones gone or in the trash are deletes
+func (s *service) syncChanges(ctx context.Context, username string, logged []db.ListNoteChangesRow) ([]SyncServerChange, error) {
+	changes := make([]SyncServerChange, 0, len(logged))
+	if len(logged) == 0 {
+		return changes, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(logged))
+	for _, l := range logged {
+		ids = append(ids, l.NoteID)
+	}
+
+	notes, err := s.q.GetNotesByIDs(ctx, &db.GetNotesByIDsParams{Username: username, Ids: ids})
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	tagged, err := s.withTags(ctx, notes)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*Note, len(tagged))
+	for i := range tagged {
+		byID[tagged[i].ID] = &tagged[i]
+	}
+
+	// in log order, so a client that stops midway has applied a prefix
+	for _, id := range ids {
+		if n, ok := byID[id]; ok {
+			changes = append(changes, SyncServerChange{ID: id, Op: SyncUpsert, Note: n})
+		} else {
+			changes = append(changes, SyncServerChange{ID: id, Op: SyncDelete})
+		}
+	}
+
+	return changes, nil
+}
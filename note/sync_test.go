@@ -0,0 +1,315 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncToken(t *testing.T) {
+	tok, err := decodeSyncToken(encodeSyncToken(syncToken{Seq: 42}))
+	require.NoError(t, err)
+	require.Equal(t, int64(42), tok.Seq)
+
+	tok, err = decodeSyncToken("")
+	require.NoError(t, err)
+	require.Zero(t, tok.Seq)
+
+	for _, bad := range []string{"!!", "bm90LWpzb24", encodeSyncToken(syncToken{Seq: -1})} {
+		_, err = decodeSyncToken(bad)
+		require.ErrorIs(t, err, ErrInvalidSyncToken)
+	}
+}
+
+func TestSync(t *testing.T) {
+	const username = "user1"
+	id := uuid.New()
+	otherID := uuid.New()
+	notebookID := uuid.New()
+	owner := db.GetNoteAccessRow{Owner: username, Permission: "owner"}
+	accessArgs := &db.GetNoteAccessParams{ID: id, Username: username}
+	since := encodeSyncToken(syncToken{Seq: 10})
+
+	// no server changes since the token
+	expectNoChanges := func(mockdb *mockdb.MockQuerier) {
+		mockdb.EXPECT().ListNoteChanges(gomock.Any(), &db.ListNoteChangesParams{Username: username, Seq: 10, Limit: maxSyncChanges + 1}).
+			Times(1).Return([]db.ListNoteChangesRow{}, nil)
+	}
+
+	testCases := []struct {
+		name              string
+		token             string
+		changes           []SyncChange
+		mockdbSync        func(mockdb *mockdb.MockQuerier)
+		checkReturnValues func(t *testing.T, resp SyncResponse, err error)
+	}{
+		{
+			name:  "creating note with the client's ID OK",
+			token: since,
+			changes: []SyncChange{
+				{ID: id, Op: SyncUpsert, Title: "title", Text: "text"},
+			},
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+					func(_ context.Context, arg *db.CreateNoteParams) (uuid.UUID, error) {
+						require.Equal(t, id, arg.ID)
+						return arg.ID, nil
+					})
+				mockdb.EXPECT().CreateNoteRevision(gomock.Any(), id).Times(1).Return(int32(1), nil)
+				expectNoChanges(mockdb)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []SyncResult{{ID: id, Status: SyncApplied, Version: 1}}, resp.Results)
+				require.Empty(t, resp.Changes)
+				// nothing new, the client keeps its place
+				require.Equal(t, since, resp.Token)
+			},
+		},
+		{
+			name:  "creating note with a taken ID returns a conflict",
+			token: since,
+			changes: []SyncChange{
+				{ID: id, Op: SyncUpsert, Title: "title"},
+			},
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, &pq.Error{Code: "23505", Constraint: "notes_pkey"})
+				mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: id, Username: username}).Times(1).Return(db.Note{ID: id, Title: "title", Version: 2}, nil)
+				mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), []uuid.UUID{id}).Times(1).Return([]db.GetTagsOfNotesRow{}, nil)
+				expectNoChanges(mockdb)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, resp.Results, 1)
+				require.Equal(t, SyncConflict, resp.Results[0].Status)
+				require.ErrorIs(t, resp.Results[0].Err, ErrNoteIDTaken)
+				require.Equal(t, int32(2), resp.Results[0].Current.Version)
+			},
+		},
+		{
+			name:  "updating outdated version returns a conflict with the server copy",
+			token: since,
+			changes: []SyncChange{
+				{ID: id, Op: SyncUpsert, Title: "mine", Text: "text", BaseVersion: 3},
+			},
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).Return(int32(0), sql.ErrNoRows)
+				mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: id, Username: username}).Times(1).Return(db.Note{ID: id, Title: "theirs", Version: 5}, nil)
+				mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), []uuid.UUID{id}).Times(1).Return([]db.GetTagsOfNotesRow{}, nil)
+				expectNoChanges(mockdb)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, resp.Results, 1)
+				require.Equal(t, SyncConflict, resp.Results[0].Status)
+				require.Zero(t, resp.Results[0].Version)
+				require.ErrorIs(t, resp.Results[0].Err, ErrVersionMismatch)
+				require.Equal(t, "theirs", resp.Results[0].Current.Title)
+			},
+		},
+		{
+			name:  "updating note moved to another notebook offline moves it",
+			token: since,
+			changes: []SyncChange{
+				{ID: id, Op: SyncUpsert, Title: "mine", Text: "text", NotebookID: uuid.NullUUID{UUID: notebookID, Valid: true}, BaseVersion: 3},
+			},
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).Return(int32(4), nil)
+				mockdb.EXPECT().ClearNoteTags(gomock.Any(), id).Times(1).Return(nil)
+				mockdb.EXPECT().DeleteUnusedTags(gomock.Any(), username).Times(1).Return(nil)
+				mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: id, Username: username}).Times(1).Return(db.Note{ID: id, Version: 4}, nil)
+				mockdb.EXPECT().GetNotebook(gomock.Any(), &db.GetNotebookParams{ID: notebookID, Username: username}).Times(1).Return(db.Notebook{ID: notebookID}, nil)
+				mockdb.EXPECT().MoveNote(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.MoveNoteParams) (uuid.UUID, error) {
+						require.Equal(t, uuid.NullUUID{UUID: notebookID, Valid: true}, arg.NotebookID)
+						return id, nil
+					})
+				mockdb.EXPECT().CreateNoteRevision(gomock.Any(), id).Times(1).Return(int32(3), nil)
+				expectNoChanges(mockdb)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				// the update and the move are a version each
+				require.Equal(t, []SyncResult{{ID: id, Status: SyncApplied, Version: 5}}, resp.Results)
+			},
+		},
+		{
+			name:  "updating note with an empty text clears it",
+			token: since,
+			changes: []SyncChange{
+				{ID: id, Op: SyncUpsert, Title: "mine", BaseVersion: 3},
+			},
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(owner, nil)
+				mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.UpdateNoteParams) (int32, error) {
+						require.Equal(t, sql.NullString{String: "", Valid: true}, arg.Text)
+						return 4, nil
+					})
+				mockdb.EXPECT().ClearNoteTags(gomock.Any(), id).Times(1).Return(nil)
+				mockdb.EXPECT().DeleteUnusedTags(gomock.Any(), username).Times(1).Return(nil)
+				// already out of any notebook, nothing to move
+				mockdb.EXPECT().GetNote(gomock.Any(), &db.GetNoteParams{ID: id, Username: username}).Times(1).Return(db.Note{ID: id, Version: 4}, nil)
+				mockdb.EXPECT().MoveNote(gomock.Any(), gomock.Any()).Times(0)
+				mockdb.EXPECT().CreateNoteRevision(gomock.Any(), id).Times(1).Return(int32(3), nil)
+				expectNoChanges(mockdb)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []SyncResult{{ID: id, Status: SyncApplied, Version: 4}}, resp.Results)
+			},
+		},
+		{
+			name:  "updating note deleted on the server returns a conflict",
+			token: since,
+			changes: []SyncChange{
+				{ID: id, Op: SyncUpsert, Title: "mine", BaseVersion: 3},
+			},
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(db.GetNoteAccessRow{}, sql.ErrNoRows)
+				expectNoChanges(mockdb)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, SyncConflict, resp.Results[0].Status)
+				require.Nil(t, resp.Results[0].Current)
+				require.ErrorIs(t, resp.Results[0].Err, ErrNotFound)
+			},
+		},
+		{
+			name:  "deleting note deleted on the server OK",
+			token: since,
+			changes: []SyncChange{
+				{ID: id, Op: SyncDelete, BaseVersion: 3},
+			},
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(db.GetNoteAccessRow{}, sql.ErrNoRows)
+				expectNoChanges(mockdb)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []SyncResult{{ID: id, Status: SyncApplied}}, resp.Results)
+			},
+		},
+		{
+			name:  "updating note shared for reading is rejected",
+			token: since,
+			changes: []SyncChange{
+				{ID: id, Op: SyncUpsert, Title: "mine", BaseVersion: 3},
+			},
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(db.GetNoteAccessRow{Owner: "user2", Permission: "read"}, nil)
+				expectNoChanges(mockdb)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, SyncRejected, resp.Results[0].Status)
+				require.ErrorIs(t, resp.Results[0].Err, ErrForbidden)
+			},
+		},
+		{
+			name:  "returns server changes with tombstones in log order",
+			token: since,
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().ListNoteChanges(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListNoteChangesRow{
+					{NoteID: otherID, Seq: 11},
+					{NoteID: id, Seq: 14},
+				}, nil)
+				mockdb.EXPECT().GetNotesByIDs(gomock.Any(), &db.GetNotesByIDsParams{Username: username, Ids: []uuid.UUID{otherID, id}}).
+					Times(1).Return([]db.Note{{ID: id, Title: "title", Version: 4}}, nil)
+				mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), []uuid.UUID{id}).Times(1).Return([]db.GetTagsOfNotesRow{
+					{NoteID: id, Name: "work"},
+				}, nil)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, resp.Changes, 2)
+				require.Equal(t, SyncServerChange{ID: otherID, Op: SyncDelete}, resp.Changes[0])
+				require.Equal(t, SyncUpsert, resp.Changes[1].Op)
+				require.Equal(t, []string{"work"}, resp.Changes[1].Note.Tags)
+				require.Equal(t, encodeSyncToken(syncToken{Seq: 14}), resp.Token)
+				require.False(t, resp.HasMore)
+			},
+		},
+		{
+			name: "returns the first page of changes of a full sync",
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				rows := make([]db.ListNoteChangesRow, maxSyncChanges+1)
+				for i := range rows {
+					rows[i] = db.ListNoteChangesRow{NoteID: uuid.New(), Seq: int64(i + 1)}
+				}
+				mockdb.EXPECT().ListNoteChanges(gomock.Any(), &db.ListNoteChangesParams{Username: username, Limit: maxSyncChanges + 1}).
+					Times(1).Return(rows, nil)
+				mockdb.EXPECT().GetNotesByIDs(gomock.Any(), gomock.Any()).Times(1).Return([]db.Note{}, nil)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, resp.Changes, maxSyncChanges)
+				require.True(t, resp.HasMore)
+				require.Equal(t, encodeSyncToken(syncToken{Seq: maxSyncChanges}), resp.Token)
+			},
+		},
+		{
+			name:       "invalid token returns ErrInvalidSyncToken",
+			token:      "!!",
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.ErrorIs(t, err, ErrInvalidSyncToken)
+			},
+		},
+		{
+			name:       "too many client changes returns ErrTooManySyncChanges",
+			changes:    make([]SyncChange, MaxSyncClientChanges+1),
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.ErrorIs(t, err, ErrTooManySyncChanges)
+			},
+		},
+		{
+			name:  "failing change log returns ErrDBInternal",
+			token: since,
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().ListNoteChanges(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.ErrorIs(t, err, ErrDBInternal)
+			},
+		},
+		{
+			name:  "failing write aborts the sync with ErrDBInternal",
+			token: since,
+			changes: []SyncChange{
+				{ID: id, Op: SyncDelete, BaseVersion: 3},
+			},
+			mockdbSync: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetNoteAccess(gomock.Any(), accessArgs).Times(1).Return(db.GetNoteAccessRow{}, sql.ErrConnDone)
+			},
+			checkReturnValues: func(t *testing.T, resp SyncResponse, err error) {
+				require.ErrorIs(t, err, ErrDBInternal)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbSync(mockdb)
+			resp, err := ns.Sync(context.Background(), username, tc.token, tc.changes)
+			tc.checkReturnValues(t, resp, err)
+		})
+	}
+}
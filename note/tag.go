@@ -0,0 +1,226 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/events"
+	"github.com/google/uuid"
+)
+
+const maxTagLength = 50
+
+// Trim and lowercase tags, drop duplicates and sort them
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || utf8.RuneCountInString(tag) > maxTagLength || strings.IndexFunc(tag, unicode.IsControl) >= 0 {
+		return "", ErrInvalidTag
+	}
+
+	return tag, nil
+}
+
+// Replace the tags of a note. Tags no note uses anymore are removed.
+func setNoteTags(ctx context.Context, q db.Querier, username string, noteID uuid.UUID, tags []string) error {
+	err := q.ClearNoteTags(ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) > 0 {
+		ids := make([]uuid.UUID, len(tags))
+		for i := range ids {
+			ids[i] = uuid.New()
+		}
+
+		err = q.CreateTags(ctx, &db.CreateTagsParams{Ids: ids, Username: username, Names: tags})
+		if err != nil {
+			return err
+		}
+
+		err = q.AddNoteTags(ctx, &db.AddNoteTagsParams{NoteID: noteID, Username: username, Names: tags})
+		if err != nil {
+			return err
+		}
+	}
+
+	return q.DeleteUnusedTags(ctx, username)
+}
+
+// Load the tags of all notes with a single query
+func (s *service) withTags(ctx context.Context, notes []db.Note) ([]Note, error) {
+	tagged := make([]Note, 0, len(notes))
+	if len(notes) == 0 {
+		return tagged, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(notes))
+	for _, n := range notes {
+		ids = append(ids, n.ID)
+	}
+
+	rows, err := s.q.GetTagsOfNotes(ctx, ids)
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	tags := make(map[uuid.UUID][]string, len(notes))
+	for _, row := range rows {
+		tags[row.NoteID] = append(tags[row.NoteID], row.Name)
+	}
+
+	for _, n := range notes {
+		noteTags := tags[n.ID]
+		if noteTags == nil {
+			noteTags = []string{}
+		}
+		tagged = append(tagged, Note{Note: n, Tags: noteTags})
+	}
+
+	return tagged, nil
+}
+
+// Return the tags of a user with the number of notes using them
+func (s *service) ListTags(ctx context.Context, username string) ([]db.ListTagsRow, error) {
+	tags, err := s.q.ListTags(ctx, username)
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	return tags, nil
+}
+
+// Bump the version of the notes tagged with tagID, their tags are about to change
+func bumpTaggedNotes(ctx context.Context, q db.Querier, tagID uuid.UUID) ([]db.BumpTaggedNotesRow, error) {
+	return q.BumpTaggedNotes(ctx, &db.BumpTaggedNotesParams{TagID: tagID, UpdatedAt: time.Now()})
+}
+
+// Rename a tag on all notes of the user
+func (s *service) RenameTag(ctx context.Context, username string, name string, newName string) error {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return err
+	}
+	newName, err = normalizeTag(newName)
+	if err != nil {
+		return err
+	}
+
+	var bumped []db.BumpTaggedNotesRow
+	err = s.execTx(ctx, func(q db.Querier) error {
+		id, err := q.RenameTag(ctx, &db.RenameTagParams{
+			Username: username,
+			Name:     name,
+			NewName:  newName,
+		})
+		if err != nil {
+			return err
+		}
+
+		bumped, err = bumpTaggedNotes(ctx, q, id)
+		return err
+	})
+
+	switch {
+	case isUniqueViolation(err):
+		return ErrTagAlreadyExists
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrTagNotFound
+	case err != nil:
+		return ErrDBInternal
+	default:
+		s.publishBumped(ctx, bumped, username)
+		return nil
+	}
+}
+
+// Tell about the notes whose tags changed
+func (s *service) publishBumped(ctx context.Context, bumped []db.BumpTaggedNotesRow, username string) {
+	for _, n := range bumped {
+		s.publish(ctx, events.NoteUpdated, n.ID, n.Version, username)
+	}
+}
+
+// Move every note tagged with name over to into and remove name.
+// If into doesn't exist yet the tag is simply renamed.
+func (s *service) MergeTag(ctx context.Context, username string, name string, into string) error {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return err
+	}
+	into, err = normalizeTag(into)
+	if err != nil {
+		return err
+	}
+	if name == into {
+		return nil
+	}
+
+	var bumped []db.BumpTaggedNotesRow
+	err = s.execTx(ctx, func(q db.Querier) error {
+		from, err := q.GetTag(ctx, &db.GetTagParams{Username: username, Name: name})
+		if err != nil {
+			return err
+		}
+
+		// every note tagged with name changes, whether it had into already or not
+		bumped, err = bumpTaggedNotes(ctx, q, from.ID)
+		if err != nil {
+			return err
+		}
+
+		to, err := q.GetTag(ctx, &db.GetTagParams{Username: username, Name: into})
+		if errors.Is(err, sql.ErrNoRows) {
+			_, err = q.RenameTag(ctx, &db.RenameTagParams{Username: username, Name: name, NewName: into})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		err = q.MoveNoteTags(ctx, &db.MoveNoteTagsParams{FromTagID: from.ID, ToTagID: to.ID})
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteTag(ctx, from.ID)
+	})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrTagNotFound
+	case err != nil:
+		return ErrDBInternal
+	default:
+		s.publishBumped(ctx, bumped, username)
+		return nil
+	}
+}
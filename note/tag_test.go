@@ -0,0 +1,214 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/events"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{" Work", "urgent", "work ", "Ärger"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"urgent", "work", "ärger"}, tags)
+
+	_, err = normalizeTags([]string{"ok", "  "})
+	require.ErrorIs(t, err, ErrInvalidTag)
+
+	_, err = normalizeTags([]string{strings.Repeat("a", maxTagLength+1)})
+	require.ErrorIs(t, err, ErrInvalidTag)
+
+	_, err = normalizeTags([]string{"new\nline"})
+	require.ErrorIs(t, err, ErrInvalidTag)
+}
+
+func TestCreateNoteWithTags(t *testing.T) {
+	const username = "user1"
+
+	t.Run("tags are set on the new note", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+		id := uuid.New()
+
+		gomock.InOrder(
+			mockdb.EXPECT().CreateNote(gomock.Any(), gomock.Any()).Times(1).Return(id, nil),
+			mockdb.EXPECT().ClearNoteTags(gomock.Any(), id).Times(1).Return(nil),
+			mockdb.EXPECT().CreateTags(gomock.Any(), gomock.Any()).Times(1).Return(nil),
+			mockdb.EXPECT().AddNoteTags(gomock.Any(), &db.AddNoteTagsParams{NoteID: id, Username: username, Names: []string{"home", "work"}}).Times(1).Return(nil),
+			mockdb.EXPECT().DeleteUnusedTags(gomock.Any(), username).Times(1).Return(nil),
+			mockdb.EXPECT().CreateNoteRevision(gomock.Any(), id).Times(1).Return(int32(1), nil),
+		)
+
+		retID, err := ns.CreateNote(context.Background(), "title", username, "text", []string{"Work", "home"}, uuid.NullUUID{})
+		require.NoError(t, err)
+		require.Equal(t, id, retID)
+	})
+
+	t.Run("invalid tags are rejected before touching the DB", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		_, err := ns.CreateNote(context.Background(), "title", username, "text", []string{""}, uuid.NullUUID{})
+		require.ErrorIs(t, err, ErrInvalidTag)
+	})
+}
+
+func TestRenameTag(t *testing.T) {
+	const username = "user1"
+	tagID := uuid.New()
+	tagged := []db.BumpTaggedNotesRow{{ID: uuid.New(), Version: 4}, {ID: uuid.New(), Version: 2}}
+
+	testCases := []struct {
+		name        string
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+		wantUpdated []db.BumpTaggedNotesRow
+	}{
+		{
+			name: "renaming tag OK - tagged notes get a new version",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RenameTag(gomock.Any(), &db.RenameTagParams{Username: username, Name: "work", NewName: "job"}).Times(1).Return(tagID, nil)
+				mockdb.EXPECT().BumpTaggedNotes(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.BumpTaggedNotesParams) ([]db.BumpTaggedNotesRow, error) {
+						require.Equal(t, tagID, arg.TagID)
+						require.False(t, arg.UpdatedAt.IsZero())
+						return tagged, nil
+					})
+			},
+			wantUpdated: tagged,
+		},
+		{
+			name: "returns ErrTagNotFound",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RenameTag(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			wantErr: ErrTagNotFound,
+		},
+		{
+			name: "returns ErrDBInternal",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RenameTag(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrConnDone)
+			},
+			wantErr: ErrDBInternal,
+		},
+		{
+			name: "returns ErrDBInternal - bumping versions fails",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RenameTag(gomock.Any(), gomock.Any()).Times(1).Return(tagID, nil)
+				mockdb.EXPECT().BumpTaggedNotes(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			wantErr: ErrDBInternal,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			sub := ns.SubscribeEvents(username)
+			defer sub.Close()
+
+			tc.mockdbCalls(mockdb)
+			err := ns.RenameTag(context.Background(), username, "Work", "job")
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+			requireNotesUpdated(t, sub, tc.wantUpdated)
+		})
+	}
+}
+
+func TestMergeTag(t *testing.T) {
+	const username = "user1"
+	from := db.Tag{ID: uuid.New(), Username: username, Name: "work"}
+	to := db.Tag{ID: uuid.New(), Username: username, Name: "job"}
+	tagged := []db.BumpTaggedNotesRow{{ID: uuid.New(), Version: 3}}
+
+	testCases := []struct {
+		name        string
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+		wantUpdated []db.BumpTaggedNotesRow
+	}{
+		{
+			name: "merging into existing tag OK",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetTag(gomock.Any(), &db.GetTagParams{Username: username, Name: "work"}).Times(1).Return(from, nil)
+				mockdb.EXPECT().BumpTaggedNotes(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.BumpTaggedNotesParams) ([]db.BumpTaggedNotesRow, error) {
+						require.Equal(t, from.ID, arg.TagID)
+						return tagged, nil
+					})
+				mockdb.EXPECT().GetTag(gomock.Any(), &db.GetTagParams{Username: username, Name: "job"}).Times(1).Return(to, nil)
+				mockdb.EXPECT().MoveNoteTags(gomock.Any(), &db.MoveNoteTagsParams{FromTagID: from.ID, ToTagID: to.ID}).Times(1).Return(nil)
+				mockdb.EXPECT().DeleteTag(gomock.Any(), from.ID).Times(1).Return(nil)
+			},
+			wantUpdated: tagged,
+		},
+		{
+			name: "merging into missing tag renames it",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetTag(gomock.Any(), &db.GetTagParams{Username: username, Name: "work"}).Times(1).Return(from, nil)
+				mockdb.EXPECT().BumpTaggedNotes(gomock.Any(), gomock.Any()).Times(1).Return(tagged, nil)
+				mockdb.EXPECT().GetTag(gomock.Any(), &db.GetTagParams{Username: username, Name: "job"}).Times(1).Return(db.Tag{}, sql.ErrNoRows)
+				mockdb.EXPECT().RenameTag(gomock.Any(), &db.RenameTagParams{Username: username, Name: "work", NewName: "job"}).Times(1).Return(from.ID, nil)
+			},
+			wantUpdated: tagged,
+		},
+		{
+			name: "returns ErrTagNotFound",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().GetTag(gomock.Any(), gomock.Any()).Times(1).Return(db.Tag{}, sql.ErrNoRows)
+			},
+			wantErr: ErrTagNotFound,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			sub := ns.SubscribeEvents(username)
+			defer sub.Close()
+
+			tc.mockdbCalls(mockdb)
+			err := ns.MergeTag(context.Background(), username, "work", "job")
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+			requireNotesUpdated(t, sub, tc.wantUpdated)
+		})
+	}
+}
+
+// Check note.updated was published for each of the notes and nothing else
+func requireNotesUpdated(t *testing.T, sub *events.Subscription, notes []db.BumpTaggedNotesRow) {
+	for _, n := range notes {
+		e := <-sub.C
+		require.Equal(t, events.NoteUpdated, e.Type)
+		require.Equal(t, n.ID, e.NoteID)
+		require.Equal(t, n.Version, e.Version)
+	}
+	require.Empty(t, sub.C)
+}
@@ -0,0 +1,79 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/google/uuid"
+)
+
+// Exchange a refresh token for a new one of the same session and return its owner and session.
+// Presenting an already rotated token revokes the whole session, since one of the two
+// holders of the token must be an attacker.
+func (s *service) RotateRefreshToken(ctx context.Context, oldHash string, newHash string, expiresAt time.Time) (string, uuid.UUID, error) {
+	now := time.Now()
+	var old db.RefreshToken
+
+	err := s.execTx(ctx, func(q db.Querier) error {
+		var err error
+
+		// only one concurrent request can revoke an active token, the others fall through to reuse detection
+		old, err = q.RevokeActiveRefreshToken(ctx, &db.RevokeActiveRefreshTokenParams{
+			TokenHash: oldHash,
+			Now:       now,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateRefreshToken(ctx, &db.CreateRefreshTokenParams{
+			ID:        uuid.New(),
+			FamilyID:  old.FamilyID,
+			Username:  old.Username,
+			TokenHash: newHash,
+			CreatedAt: now,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.TouchSession(ctx, &db.TouchSessionParams{
+			ID:         old.FamilyID,
+			LastSeenAt: now,
+			ExpiresAt:  expiresAt,
+		})
+	})
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "", uuid.Nil, s.refreshTokenRejected(ctx, oldHash, now)
+	case err != nil:
+		return "", uuid.Nil, ErrDBInternal
+	}
+
+	return old.Username, old.FamilyID, nil
+}
+
+// Find out why a refresh token could not be rotated, revoking its session on reuse
+func (s *service) refreshTokenRejected(ctx context.Context, tokenHash string, now time.Time) error {
+	token, err := s.q.GetRefreshTokenByHash(ctx, tokenHash)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrRefreshTokenInvalid
+	case err != nil:
+		return ErrDBInternal
+	case token.RevokedAt.Valid:
+		err = s.RevokeSession(ctx, token.Username, token.FamilyID)
+		if err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+		return ErrRefreshTokenReused
+	default:
+		return ErrRefreshTokenExpired
+	}
+}
@@ -0,0 +1,118 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	const (
+		username = "user1"
+		oldHash  = "oldhash"
+		newHash  = "newhash"
+	)
+	familyID := uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name              string
+		mockdbCalls       func(mockdb *mockdb.MockQuerier)
+		checkReturnValues func(t *testing.T, username string, sessionID uuid.UUID, err error)
+	}{
+		{
+			name: "rotation OK - new token keeps the family",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RevokeActiveRefreshToken(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.RefreshToken{FamilyID: familyID, Username: username, TokenHash: oldHash}, nil)
+				mockdb.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.CreateRefreshTokenParams) (uuid.UUID, error) {
+						require.Equal(t, familyID, arg.FamilyID)
+						require.Equal(t, username, arg.Username)
+						require.Equal(t, newHash, arg.TokenHash)
+						return arg.ID, nil
+					})
+				mockdb.EXPECT().TouchSession(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			checkReturnValues: func(t *testing.T, uname string, sessionID uuid.UUID, err error) {
+				require.NoError(t, err)
+				require.Equal(t, username, uname)
+				require.Equal(t, familyID, sessionID)
+			},
+		},
+		{
+			name: "unknown token returns ErrRefreshTokenInvalid",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RevokeActiveRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(db.RefreshToken{}, sql.ErrNoRows)
+				mockdb.EXPECT().GetRefreshTokenByHash(gomock.Any(), oldHash).Times(1).Return(db.RefreshToken{}, sql.ErrNoRows)
+			},
+			checkReturnValues: func(t *testing.T, uname string, sessionID uuid.UUID, err error) {
+				require.ErrorIs(t, err, ErrRefreshTokenInvalid)
+				require.Empty(t, uname)
+			},
+		},
+		{
+			name: "expired token returns ErrRefreshTokenExpired",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RevokeActiveRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(db.RefreshToken{}, sql.ErrNoRows)
+				mockdb.EXPECT().GetRefreshTokenByHash(gomock.Any(), oldHash).Times(1).
+					Return(db.RefreshToken{FamilyID: familyID, ExpiresAt: time.Now().Add(-time.Hour)}, nil)
+			},
+			checkReturnValues: func(t *testing.T, uname string, sessionID uuid.UUID, err error) {
+				require.ErrorIs(t, err, ErrRefreshTokenExpired)
+				require.Empty(t, uname)
+			},
+		},
+		{
+			name: "replayed token revokes the session and the family",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RevokeActiveRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(db.RefreshToken{}, sql.ErrNoRows)
+				mockdb.EXPECT().GetRefreshTokenByHash(gomock.Any(), oldHash).Times(1).
+					Return(db.RefreshToken{FamilyID: familyID, Username: username, RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}, nil)
+				mockdb.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.Session{ID: familyID, Username: username, ExpiresAt: expiresAt}, nil)
+				mockdb.EXPECT().CreateRevokedToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				mockdb.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg *db.RevokeRefreshTokenFamilyParams) error {
+						require.Equal(t, familyID, arg.FamilyID)
+						return nil
+					})
+			},
+			checkReturnValues: func(t *testing.T, uname string, sessionID uuid.UUID, err error) {
+				require.ErrorIs(t, err, ErrRefreshTokenReused)
+				require.Empty(t, uname)
+			},
+		},
+		{
+			name: "rotation returns ErrDBInternal",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RevokeActiveRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(db.RefreshToken{}, sql.ErrConnDone)
+			},
+			checkReturnValues: func(t *testing.T, uname string, sessionID uuid.UUID, err error) {
+				require.ErrorIs(t, err, ErrDBInternal)
+				require.Empty(t, uname)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			uname, sessionID, err := ns.RotateRefreshToken(context.Background(), oldHash, newHash, expiresAt)
+			tc.checkReturnValues(t, uname, sessionID, err)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/events"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// How many trashed notes are purged per statement, keeps the row locks short
+const purgeBatchSize = 500
+
+// Return the trashed notes of the user, most recently deleted first
+func (s *service) ListTrash(ctx context.Context, username string) ([]Note, error) {
+	notes, err := s.q.ListTrash(ctx, username)
+	if err != nil {
+		return nil, ErrDBInternal
+	}
+
+	return s.withTags(ctx, notes)
+}
+
+// Move a trashed note back to the notes
+func (s *service) RestoreNote(ctx context.Context, username string, id uuid.UUID) error {
+	_, err := s.q.RestoreNote(ctx, &db.RestoreNoteParams{ID: id, Username: username})
+
+	switch {
+	case isUniqueViolation(err):
+		// another note took the title while this one was in the trash
+		n, err := s.q.GetTrashedNote(ctx, &db.GetTrashedNoteParams{ID: id, Username: username})
+		if err != nil {
+			return ErrAlreadyExists
+		}
+		return s.titleConflict(ctx, username, id, n.Title, n.NotebookID)
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case err != nil:
+		return ErrDBInternal
+	default:
+		s.publish(ctx, events.NoteCreated, id, 0, username)
+		return nil
+	}
+}
+
+// Permanently delete every trashed note of the user, return how many were deleted
+func (s *service) EmptyTrash(ctx context.Context, username string) (int64, error) {
+	var (
+		n    int64
+		keys []string
+	)
+	err := s.execTx(ctx, func(q db.Querier) error {
+		var err error
+		keys, err = q.DeleteTrashedAttachments(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		n, err = q.EmptyTrash(ctx, username)
+		return err
+	})
+	if err != nil {
+		return 0, ErrDBInternal
+	}
+
+	// the notes are gone either way, a leftover blob only wastes space
+	s.deleteBlobs(ctx, keys)
+
+	return n, nil
+}
+
+// Permanently delete the notes of all users trashed before the given time.
+// ErrStorageInternal means the notes are purged but some attachment contents are left over.
+func (s *service) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	var total int64
+
+	keys, err := s.q.DeletePurgeableAttachments(ctx, before)
+	if err != nil {
+		return 0, ErrDBInternal
+	}
+	blobErr := s.deleteBlobs(ctx, keys)
+
+	for {
+		n, err := s.q.PurgeTrash(ctx, &db.PurgeTrashParams{
+			DeletedBefore: before,
+			MaxRows:       purgeBatchSize,
+		})
+		if err != nil {
+			return total, ErrDBInternal
+		}
+
+		total += n
+		if n < purgeBatchSize {
+			break
+		}
+	}
+
+	if blobErr != nil {
+		return total, ErrStorageInternal
+	}
+
+	return total, nil
+}
+
+// Purge notes kept in the trash longer than retention every interval, until ctx is done.
+// A zero retention keeps trashed notes forever.
+func (s *service) RunTrashPurger(ctx context.Context, retention time.Duration, interval time.Duration, l *zerolog.Logger) {
+	if retention <= 0 || interval <= 0 {
+		l.Info().Msg("trash purging is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.PurgeTrash(ctx, time.Now().Add(-retention))
+		switch {
+		case err != nil && ctx.Err() == nil:
+			l.Error().Err(err).Msgf("could not purge the trash. %v", err)
+		case n > 0:
+			l.Info().Msgf("purged %d notes from the trash", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package note
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreNote(t *testing.T) {
+	const username = "user1"
+	id := uuid.New()
+	args := &db.RestoreNoteParams{ID: id, Username: username}
+
+	testCases := []struct {
+		name        string
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		wantErr     error
+	}{
+		{
+			name: "restoring note OK",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RestoreNote(gomock.Any(), args).Times(1).Return(id, nil)
+			},
+		},
+		{
+			name: "returns ErrNotFound - note is not in the trash",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RestoreNote(gomock.Any(), args).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "returns ErrAlreadyExists - title taken while in the trash",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RestoreNote(gomock.Any(), args).Times(1).Return(uuid.Nil, uniqueViolation())
+				mockdb.EXPECT().GetTrashedNote(gomock.Any(), &db.GetTrashedNoteParams{ID: id, Username: username}).Times(1).
+					Return(db.Note{ID: id, Title: "title"}, nil)
+				mockdb.EXPECT().FindTitleConflict(gomock.Any(), &db.FindTitleConflictParams{Username: username, Title: "title", ExcludeID: id}).Times(1).
+					Return(uuid.New(), nil)
+			},
+			wantErr: ErrAlreadyExists,
+		},
+		{
+			name: "returns ErrDBInternal",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().RestoreNote(gomock.Any(), args).Times(1).Return(uuid.Nil, sql.ErrConnDone)
+			},
+			wantErr: ErrDBInternal,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+
+			tc.mockdbCalls(mockdb)
+			err := ns.RestoreNote(context.Background(), username, id)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	before := time.Now().Add(-time.Hour)
+	args := &db.PurgeTrashParams{DeletedBefore: before, MaxRows: purgeBatchSize}
+
+	testCases := []struct {
+		name        string
+		mockdbCalls func(mockdb *mockdb.MockQuerier)
+		blobs       *memBlobStore
+		wantPurged  int64
+		wantBlobs   int
+		wantErr     error
+	}{
+		{
+			name:  "purging in batches OK",
+			blobs: newMemBlobStore("user1/note/a", "user1/note/b", "user2/note/c"),
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeletePurgeableAttachments(gomock.Any(), before).Times(1).Return([]string{"user1/note/a", "user1/note/b"}, nil)
+				gomock.InOrder(
+					mockdb.EXPECT().PurgeTrash(gomock.Any(), args).Times(2).Return(int64(purgeBatchSize), nil),
+					mockdb.EXPECT().PurgeTrash(gomock.Any(), args).Times(1).Return(int64(7), nil),
+				)
+			},
+			wantPurged: 2*purgeBatchSize + 7,
+			wantBlobs:  1,
+		},
+		{
+			name: "purging empty trash OK",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeletePurgeableAttachments(gomock.Any(), before).Times(1).Return([]string{}, nil)
+				mockdb.EXPECT().PurgeTrash(gomock.Any(), args).Times(1).Return(int64(0), nil)
+			},
+		},
+		{
+			name: "returns ErrDBInternal with the count purged so far",
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeletePurgeableAttachments(gomock.Any(), before).Times(1).Return([]string{}, nil)
+				gomock.InOrder(
+					mockdb.EXPECT().PurgeTrash(gomock.Any(), args).Times(1).Return(int64(purgeBatchSize), nil),
+					mockdb.EXPECT().PurgeTrash(gomock.Any(), args).Times(1).Return(int64(0), sql.ErrConnDone),
+				)
+			},
+			wantPurged: purgeBatchSize,
+			wantErr:    ErrDBInternal,
+		},
+		{
+			name:  "returns ErrStorageInternal - notes purged, blobs left over",
+			blobs: &memBlobStore{objects: map[string][]byte{}, deleteErr: errors.New("connection refused")},
+			mockdbCalls: func(mockdb *mockdb.MockQuerier) {
+				mockdb.EXPECT().DeletePurgeableAttachments(gomock.Any(), before).Times(1).Return([]string{"user1/note/a"}, nil)
+				mockdb.EXPECT().PurgeTrash(gomock.Any(), args).Times(1).Return(int64(1), nil)
+			},
+			wantPurged: 1,
+			wantErr:    ErrStorageInternal,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockdb := mockdb.NewMockQuerier(ctrl)
+			ns := NewService(mockdb)
+			if tc.blobs != nil {
+				ns = NewService(mockdb, WithBlobStore(tc.blobs))
+			}
+
+			tc.mockdbCalls(mockdb)
+			n, err := ns.PurgeTrash(context.Background(), before)
+			require.Equal(t, tc.wantPurged, n)
+			if tc.blobs != nil && tc.blobs.deleteErr == nil {
+				require.Len(t, tc.blobs.objects, tc.wantBlobs)
+			}
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunTrashPurger(t *testing.T) {
+	l := zerolog.Nop()
+
+	t.Run("disabled purger returns at once", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().PurgeTrash(gomock.Any(), gomock.Any()).Times(0)
+		ns.RunTrashPurger(context.Background(), 0, time.Hour, &l)
+	})
+
+	t.Run("purges on start and stops when cancelled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		retention := 24 * time.Hour
+
+		mockdb.EXPECT().DeletePurgeableAttachments(gomock.Any(), gomock.Any()).Times(1).Return([]string{}, nil)
+		mockdb.EXPECT().PurgeTrash(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg *db.PurgeTrashParams) (int64, error) {
+				require.WithinDuration(t, time.Now().Add(-retention), arg.DeletedBefore, time.Minute)
+				cancel()
+				return 3, nil
+			})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			ns.RunTrashPurger(ctx, retention, time.Hour, &l)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("purger did not stop after the context was cancelled")
+		}
+	})
+}
+
+func TestEmptyTrash(t *testing.T) {
+	const username = "user1"
+
+	t.Run("emptying trash deletes attachment contents", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		blobs := newMemBlobStore("user1/note/a", "user1/other/b")
+		ns := NewService(mockdb, WithBlobStore(blobs))
+
+		mockdb.EXPECT().DeleteTrashedAttachments(gomock.Any(), username).Times(1).Return([]string{"user1/note/a"}, nil)
+		mockdb.EXPECT().EmptyTrash(gomock.Any(), username).Times(1).Return(int64(2), nil)
+
+		n, err := ns.EmptyTrash(context.Background(), username)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), n)
+		require.Len(t, blobs.objects, 1)
+		require.Contains(t, blobs.objects, "user1/other/b")
+	})
+
+	t.Run("returns ErrDBInternal", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockdb := mockdb.NewMockQuerier(ctrl)
+		ns := NewService(mockdb)
+
+		mockdb.EXPECT().DeleteTrashedAttachments(gomock.Any(), username).Times(1).Return(nil, sql.ErrConnDone)
+
+		_, err := ns.EmptyTrash(context.Background(), username)
+		require.ErrorIs(t, err, ErrDBInternal)
+	})
+}
@@ -0,0 +1,162 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/alekslesik/online-note-z/server/grpc/pb"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Methods that can be called without an access token
+var publicMethods = map[string]bool{
+	pb.Notes_Register_FullMethodName: true,
+	pb.Notes_Login_FullMethodName:    true,
+	// the access token has likely expired by then
+	pb.Notes_RefreshToken_FullMethodName: true,
+}
+
+// Log every call with its code and duration, a panicking handler fails the call instead of the server
+func LoggingUnaryInterceptor(l *zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		start := time.Now()
+		defer func() {
+			if p := recover(); p != nil {
+				l.Error().Msgf("gRPC call %s panicked. %v\n%s", info.FullMethod, p, debug.Stack())
+				err = status.Error(codes.Internal, "internal error")
+			}
+			logCall(l, info.FullMethod, start, err)
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+func LoggingStreamInterceptor(l *zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		defer func() {
+			if p := recover(); p != nil {
+				l.Error().Msgf("gRPC call %s panicked. %v\n%s", info.FullMethod, p, debug.Stack())
+				err = status.Error(codes.Internal, "internal error")
+			}
+			logCall(l, info.FullMethod, start, err)
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+func logCall(l *zerolog.Logger, method string, start time.Time, err error) {
+	l.Info().
+		Str("method", method).
+		Str("code", status.Code(err).String()).
+		Dur("duration", time.Since(start)).
+		Msg("gRPC call")
+}
+
+// Authenticate the calls like auth.AuthMiddleware does the HTTP requests, the access token
+// comes in the "authorization" metadata as "Bearer <token>" instead of a cookie.
+func AuthUnaryInterceptor(t auth.TokenManager, rc auth.RevocationChecker, l *zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, t, rc, l)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func AuthStreamInterceptor(t auth.TokenManager, rc auth.RevocationChecker, l *zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if publicMethods[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), t, rc, l)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// Server stream carrying the verified payload in its context
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// Verify the access token of the call and store its payload in the returned context
+func authenticate(ctx context.Context, t auth.TokenManager, rc auth.RevocationChecker, l *zerolog.Logger) (context.Context, error) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		l.Error().Msg("authorization metadata is not set!")
+		return nil, status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	payload, err := t.VerifyToken(token)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrTokenInvalid):
+			l.Error().Err(err).Msgf("PASETO is invalid!")
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		case errors.Is(err, auth.ErrTokenExpired):
+			l.Error().Err(err).Msgf("PASETO is expired!")
+			return nil, status.Error(codes.Unauthenticated, "expired token")
+		default:
+			l.Error().Err(err).Msgf("PASETO could not be verified!")
+			return nil, status.Error(codes.Unauthenticated, "missing token")
+		}
+	}
+
+	revoked, err := rc.IsTokenRevoked(ctx, payload.ID, payload.SessionID)
+	if err != nil {
+		l.Error().Err(err).Msgf("could not check if PASETO is revoked!")
+		return nil, status.Error(codes.Internal, "could not verify token")
+	}
+	if revoked {
+		l.Info().Msgf("PASETO %v of user %s is revoked!", payload.ID, payload.Username)
+		return nil, status.Error(codes.Unauthenticated, "revoked token")
+	}
+
+	return auth.NewContext(ctx, payload), nil
+}
+
+// Take the token out of the "authorization: Bearer <token>" metadata
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
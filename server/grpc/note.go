@@ -0,0 +1,266 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/alekslesik/online-note-z/note"
+	"github.com/alekslesik/online-note-z/server/grpc/pb"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toNoteMessage(n note.Note) *pb.Note {
+	msg := &pb.Note{
+		Id:         n.ID.String(),
+		Title:      n.Title,
+		User:       n.Username,
+		Text:       n.Text.String,
+		Tags:       n.Tags,
+		Version:    n.Version,
+		CreatedAt:  timestamppb.New(n.CreatedAt),
+		UpdatedAt:  timestamppb.New(n.UpdatedAt),
+		Permission: string(n.Permission),
+	}
+	if n.NotebookID.Valid {
+		msg.NotebookId = n.NotebookID.UUID.String()
+	}
+
+	return msg
+}
+
+// Map an error of the note service to the status of the call
+func noteStatus(err error) error {
+	switch {
+	case errors.Is(err, note.ErrNotFound), errors.Is(err, note.ErrNotebookNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, note.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "the note is shared with you for reading only")
+	case errors.Is(err, note.ErrAlreadyExists), errors.Is(err, note.ErrNoteIDTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, note.ErrDuplicateTitles):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, note.ErrVersionMismatch):
+		// the message carries the current version
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, note.ErrInvalidTag), errors.Is(err, note.ErrInvalidSort), errors.Is(err, note.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// User verified by the auth interceptor
+func authUsername(ctx context.Context) (string, error) {
+	payload, ok := auth.PayloadFromContext(ctx)
+	if !ok || payload.Username == "" {
+		return "", status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	return payload.Username, nil
+}
+
+func parseID(id string, field string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "%s must be a UUID", field)
+	}
+
+	return parsed, nil
+}
+
+// Empty is no notebook
+func parseNotebookID(id string) (uuid.NullUUID, error) {
+	if id == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	parsed, err := parseID(id, "notebook_id")
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: parsed, Valid: true}, nil
+}
+
+// Same rule as the title of the HTTP API
+func validateTitle(title string) error {
+	if validator.New().Var(title, "required,min=4") != nil {
+		return status.Error(codes.InvalidArgument, "title of a note must be at least 4 characters long")
+	}
+
+	return nil
+}
+
+// Writes have to name the version they were made to, the note service treats 0 as no check
+func validateVersion(version int32) error {
+	if version <= 0 {
+		return status.Error(codes.InvalidArgument, "version of the note is required")
+	}
+
+	return nil
+}
+
+func (n *notesServer) CreateNote(ctx context.Context, req *pb.CreateNoteRequest) (*pb.CreateNoteResponse, error) {
+	username, err := authUsername(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateTitle(req.GetTitle()); err != nil {
+		return nil, err
+	}
+
+	notebookID, err := parseNotebookID(req.GetNotebookId())
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := n.s.CreateNote(ctx, req.GetTitle(), username, req.GetText(), req.GetTags(), notebookID)
+	if err != nil {
+		n.l.Info().Err(err).Msgf("Note creation failed for user %s. %v", username, err)
+		return nil, noteStatus(err)
+	}
+
+	n.l.Info().Msgf("Note with ID %v has been created for user: %s", id, username)
+	return &pb.CreateNoteResponse{Id: id.String()}, nil
+}
+
+func (n *notesServer) GetNote(ctx context.Context, req *pb.GetNoteRequest) (*pb.Note, error) {
+	username, err := authUsername(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(req.GetId(), "id")
+	if err != nil {
+		return nil, err
+	}
+
+	nt, err := n.s.GetNote(ctx, username, id)
+	if err != nil {
+		n.l.Info().Err(err).Msgf("Could not get Note %v of user %s. %v", id, username, err)
+		return nil, noteStatus(err)
+	}
+
+	return toNoteMessage(nt), nil
+}
+
+func (n *notesServer) UpdateNote(ctx context.Context, req *pb.UpdateNoteRequest) (*pb.UpdateNoteResponse, error) {
+	username, err := authUsername(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(req.GetId(), "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateTitle(req.GetTitle()); err != nil {
+		return nil, err
+	}
+
+	if err := validateVersion(req.GetVersion()); err != nil {
+		return nil, err
+	}
+
+	// tags are left alone when the field is missing, an empty list removes them
+	var tags []string
+	if req.GetTags() != nil {
+		tags = append([]string{}, req.GetTags().GetNames()...)
+	}
+
+	version, err := n.s.UpdateNote(ctx, username, id, req.GetTitle(), req.GetText(), req.GetText() != "", tags, req.GetVersion())
+	if err != nil {
+		n.l.Info().Err(err).Msgf("Could not update Note %v of user %s. %v", id, username, err)
+		return nil, noteStatus(err)
+	}
+
+	n.l.Info().Msgf("Note %v has been updated to version %d", id, version)
+	return &pb.UpdateNoteResponse{Version: version}, nil
+}
+
+func (n *notesServer) DeleteNote(ctx context.Context, req *pb.DeleteNoteRequest) (*pb.DeleteNoteResponse, error) {
+	username, err := authUsername(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(req.GetId(), "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateVersion(req.GetVersion()); err != nil {
+		return nil, err
+	}
+
+	_, err = n.s.DeleteNote(ctx, username, id, req.GetVersion())
+	if err != nil {
+		n.l.Info().Err(err).Msgf("Could not delete Note %v of user %s. %v", id, username, err)
+		return nil, noteStatus(err)
+	}
+
+	n.l.Info().Msgf("Note %v has been moved to the trash", id)
+	return &pb.DeleteNoteResponse{}, nil
+}
+
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
+
+// Stream the notes a page at a time, so a large listing doesn't have to fit in memory
+func (n *notesServer) ListNotes(req *pb.ListNotesRequest, stream pb.Notes_ListNotesServer) error {
+	ctx := stream.Context()
+
+	username, err := authUsername(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := note.ListOptions{
+		Limit:        note.MaxListLimit,
+		SortBy:       req.GetSortBy(),
+		Descending:   req.GetDescending(),
+		CreatedFrom:  timeOf(req.GetCreatedFrom()),
+		CreatedTo:    timeOf(req.GetCreatedTo()),
+		UpdatedFrom:  timeOf(req.GetUpdatedFrom()),
+		UpdatedTo:    timeOf(req.GetUpdatedTo()),
+		Tags:         req.GetTags(),
+		MatchAllTags: req.GetMatchAllTags(),
+	}
+
+	sent := 0
+	for {
+		page, err := n.s.ListNotes(ctx, username, opts)
+		if err != nil {
+			n.l.Info().Err(err).Msgf("Could not list the notes of user %s. %v", username, err)
+			return noteStatus(err)
+		}
+
+		for _, nt := range page.Notes {
+			if err := stream.Send(toNoteMessage(nt)); err != nil {
+				return err
+			}
+			sent++
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	n.l.Info().Msgf("Streamed %d notes of user %s", sent, username)
+	return nil
+}
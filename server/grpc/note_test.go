@@ -0,0 +1,294 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	"github.com/alekslesik/online-note-z/server/grpc/pb"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateNote(t *testing.T) {
+	id := uuid.New()
+	notebookID := uuid.New()
+
+	testCases := []struct {
+		name      string
+		req       *pb.CreateNoteRequest
+		mockSvc   func(svc *mocksvc.MockNoteService)
+		checkCode codes.Code
+	}{
+		{
+			name: "creating note OK",
+			req:  &pb.CreateNoteRequest{Title: "title", Text: "text", Tags: []string{"work"}, NotebookId: notebookID.String()},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().CreateNote(gomock.Any(), "title", username, "text", []string{"work"}, uuid.NullUUID{UUID: notebookID, Valid: true}).
+					Times(1).Return(id, nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name:      "returns invalid argument - short title",
+			req:       &pb.CreateNoteRequest{Title: "t"},
+			mockSvc:   func(svc *mocksvc.MockNoteService) {},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name:      "returns invalid argument - malformed notebook ID",
+			req:       &pb.CreateNoteRequest{Title: "title", NotebookId: "nope"},
+			mockSvc:   func(svc *mocksvc.MockNoteService) {},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name: "returns already exists - title taken",
+			req:  &pb.CreateNoteRequest{Title: "title"},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().CreateNote(gomock.Any(), "title", username, "", nil, uuid.NullUUID{}).
+					Times(1).Return(uuid.Nil, &note.TitleConflictError{ID: uuid.New()})
+			},
+			checkCode: codes.AlreadyExists,
+		},
+		{
+			name: "returns internal",
+			req:  &pb.CreateNoteRequest{Title: "title"},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().CreateNote(gomock.Any(), "title", username, "", nil, uuid.NullUUID{}).Times(1).Return(uuid.Nil, note.ErrDBInternal)
+			},
+			checkCode: codes.Internal,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc := mocksvc.NewMockNoteService(ctrl)
+			expectNotRevoked(svc)
+			tc.mockSvc(svc)
+
+			client := newTestClient(t, svc, &auth.MockTokenManager{Username: username})
+			resp, err := client.CreateNote(withToken(context.Background()), tc.req)
+			require.Equal(t, tc.checkCode, status.Code(err))
+			if err == nil {
+				require.Equal(t, id.String(), resp.Id)
+			}
+		})
+	}
+}
+
+func TestUpdateNote(t *testing.T) {
+	id := uuid.New()
+
+	testCases := []struct {
+		name      string
+		req       *pb.UpdateNoteRequest
+		mockSvc   func(svc *mocksvc.MockNoteService)
+		checkCode codes.Code
+	}{
+		{
+			name: "updating note OK - tags left alone",
+			req:  &pb.UpdateNoteRequest{Id: id.String(), Title: "title", Text: "text", Version: 3},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().UpdateNote(gomock.Any(), username, id, "title", "text", true, nil, int32(3)).Times(1).Return(int32(4), nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name: "updating note OK - empty tags clear them",
+			req:  &pb.UpdateNoteRequest{Id: id.String(), Title: "title", Tags: &pb.Tags{}, Version: 3},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().UpdateNote(gomock.Any(), username, id, "title", "", false, []string{}, int32(3)).Times(1).Return(int32(4), nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name: "returns aborted - outdated version",
+			req:  &pb.UpdateNoteRequest{Id: id.String(), Title: "title", Version: 3},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().UpdateNote(gomock.Any(), username, id, "title", "", false, nil, int32(3)).Times(1).
+					Return(int32(0), &note.VersionMismatchError{Current: note.Note{Note: db.Note{Version: 7}}})
+			},
+			checkCode: codes.Aborted,
+		},
+		{
+			name: "returns permission denied - shared for reading",
+			req:  &pb.UpdateNoteRequest{Id: id.String(), Title: "title", Version: 3},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().UpdateNote(gomock.Any(), username, id, "title", "", false, nil, int32(3)).Times(1).Return(int32(0), note.ErrForbidden)
+			},
+			checkCode: codes.PermissionDenied,
+		},
+		{
+			name:      "returns invalid argument - malformed ID",
+			req:       &pb.UpdateNoteRequest{Id: "nope", Title: "title", Version: 3},
+			mockSvc:   func(svc *mocksvc.MockNoteService) {},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name:      "returns invalid argument - missing version",
+			req:       &pb.UpdateNoteRequest{Id: id.String(), Title: "title", Text: "text"},
+			mockSvc:   func(svc *mocksvc.MockNoteService) {},
+			checkCode: codes.InvalidArgument,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc := mocksvc.NewMockNoteService(ctrl)
+			expectNotRevoked(svc)
+			tc.mockSvc(svc)
+
+			client := newTestClient(t, svc, &auth.MockTokenManager{Username: username})
+			resp, err := client.UpdateNote(withToken(context.Background()), tc.req)
+			require.Equal(t, tc.checkCode, status.Code(err))
+			if err == nil {
+				require.Equal(t, int32(4), resp.Version)
+			}
+		})
+	}
+}
+
+func TestGetAndDeleteNote(t *testing.T) {
+	id := uuid.New()
+	notebookID := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	svc := mocksvc.NewMockNoteService(ctrl)
+	expectNotRevoked(svc)
+	client := newTestClient(t, svc, &auth.MockTokenManager{Username: username})
+	ctx := withToken(context.Background())
+
+	t.Run("getting note OK", func(t *testing.T) {
+		svc.EXPECT().GetNote(gomock.Any(), username, id).Times(1).Return(note.Note{
+			Note: db.Note{ID: id, Title: "title", Username: username, Version: 2, NotebookID: uuid.NullUUID{UUID: notebookID, Valid: true}},
+			Tags: []string{"work"},
+		}, nil)
+
+		n, err := client.GetNote(ctx, &pb.GetNoteRequest{Id: id.String()})
+		require.NoError(t, err)
+		require.Equal(t, "title", n.Title)
+		require.Equal(t, []string{"work"}, n.Tags)
+		require.Equal(t, notebookID.String(), n.NotebookId)
+		require.Equal(t, int32(2), n.Version)
+	})
+
+	t.Run("getting note returns not found", func(t *testing.T) {
+		svc.EXPECT().GetNote(gomock.Any(), username, id).Times(1).Return(note.Note{}, note.ErrNotFound)
+
+		_, err := client.GetNote(ctx, &pb.GetNoteRequest{Id: id.String()})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("deleting note OK", func(t *testing.T) {
+		svc.EXPECT().DeleteNote(gomock.Any(), username, id, int32(2)).Times(1).Return(id, nil)
+
+		_, err := client.DeleteNote(ctx, &pb.DeleteNoteRequest{Id: id.String(), Version: 2})
+		require.NoError(t, err)
+	})
+
+	t.Run("deleting note returns aborted - outdated version", func(t *testing.T) {
+		svc.EXPECT().DeleteNote(gomock.Any(), username, id, int32(1)).Times(1).Return(uuid.Nil, &note.VersionMismatchError{})
+
+		_, err := client.DeleteNote(ctx, &pb.DeleteNoteRequest{Id: id.String(), Version: 1})
+		require.Equal(t, codes.Aborted, status.Code(err))
+	})
+
+	t.Run("deleting note returns invalid argument - missing version", func(t *testing.T) {
+		svc.EXPECT().DeleteNote(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := client.DeleteNote(ctx, &pb.DeleteNoteRequest{Id: id.String()})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestListNotes(t *testing.T) {
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("streams every page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc := mocksvc.NewMockNoteService(ctrl)
+		expectNotRevoked(svc)
+
+		opts := note.ListOptions{Limit: note.MaxListLimit, SortBy: note.SortByTitle, Tags: []string{"work"}}
+		gomock.InOrder(
+			svc.EXPECT().ListNotes(gomock.Any(), username, opts).Times(1).Return(note.NotePage{
+				Notes:      []note.Note{{Note: db.Note{ID: first}}, {Note: db.Note{ID: second}}},
+				NextCursor: "next",
+			}, nil),
+			svc.EXPECT().ListNotes(gomock.Any(), username, gomock.Any()).Times(1).DoAndReturn(
+				func(_ context.Context, _ string, opts note.ListOptions) (note.NotePage, error) {
+					require.Equal(t, "next", opts.Cursor)
+					return note.NotePage{Notes: []note.Note{{Note: db.Note{ID: third}}}}, nil
+				}),
+		)
+
+		client := newTestClient(t, svc, &auth.MockTokenManager{Username: username})
+		stream, err := client.ListNotes(withToken(context.Background()), &pb.ListNotesRequest{SortBy: note.SortByTitle, Tags: []string{"work"}})
+		require.NoError(t, err)
+
+		var ids []string
+		for {
+			n, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			ids = append(ids, n.Id)
+		}
+		require.Equal(t, []string{first.String(), second.String(), third.String()}, ids)
+	})
+
+	t.Run("returns invalid argument - unknown sort", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc := mocksvc.NewMockNoteService(ctrl)
+		expectNotRevoked(svc)
+		svc.EXPECT().ListNotes(gomock.Any(), username, gomock.Any()).Times(1).Return(note.NotePage{}, note.ErrInvalidSort)
+
+		client := newTestClient(t, svc, &auth.MockTokenManager{Username: username})
+		stream, err := client.ListNotes(withToken(context.Background()), &pb.ListNotesRequest{SortBy: "size"})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestNoteStatus(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		checkCode codes.Code
+	}{
+		{name: "not found", err: note.ErrNotFound, checkCode: codes.NotFound},
+		{name: "forbidden", err: note.ErrForbidden, checkCode: codes.PermissionDenied},
+		{name: "title taken", err: &note.TitleConflictError{ID: uuid.New()}, checkCode: codes.AlreadyExists},
+		{name: "note ID taken", err: fmt.Errorf("create: %w", note.ErrNoteIDTaken), checkCode: codes.AlreadyExists},
+		{name: "duplicate titles", err: note.ErrDuplicateTitles, checkCode: codes.FailedPrecondition},
+		{name: "version mismatch", err: note.ErrVersionMismatch, checkCode: codes.Aborted},
+		{name: "invalid tag", err: note.ErrInvalidTag, checkCode: codes.InvalidArgument},
+		{name: "unknown error", err: errors.New("db down"), checkCode: codes.Internal},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.checkCode, status.Code(noteStatus(tc.err)))
+		})
+	}
+}
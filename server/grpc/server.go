@@ -0,0 +1,150 @@
+// Package grpcserver serves the notes API over gRPC, next to the HTTP API and backed by the same service.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	"github.com/alekslesik/online-note-z/server/grpc/pb"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+const defaultShutdownTimeout = 10 * time.Second
+
+var ErrEmptyAddress = errors.New("server address cannot be empty")
+
+// Operations of the note service the gRPC API exposes
+type NoteService interface {
+	RegisterUser(ctx context.Context, args *db.RegisterUserParams) (string, error)
+	GetUser(ctx context.Context, username string) (db.User, error)
+	CreateSession(ctx context.Context, username string, userAgent string, clientIP string, refreshHash string, expiresAt time.Time) (uuid.UUID, error)
+	IsTokenRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error)
+	RotateRefreshToken(ctx context.Context, oldHash string, newHash string, expiresAt time.Time) (string, uuid.UUID, error)
+	RevokeSession(ctx context.Context, username string, sessionID uuid.UUID) error
+	RevokeToken(ctx context.Context, username string, tokenID uuid.UUID, expiresAt time.Time) error
+	CreateNote(ctx context.Context, title string, username string, text string, tags []string, notebookID uuid.NullUUID) (uuid.UUID, error)
+	GetNote(ctx context.Context, username string, id uuid.UUID) (note.Note, error)
+	UpdateNote(ctx context.Context, username string, reqID uuid.UUID, title string, text string, isTextValid bool, tags []string, version int32) (int32, error)
+	DeleteNote(ctx context.Context, username string, id uuid.UUID, version int32) (uuid.UUID, error)
+	ListNotes(ctx context.Context, username string, opts note.ListOptions) (note.NotePage, error)
+}
+
+type Server struct {
+	server          *grpc.Server
+	address         string
+	logger          *zerolog.Logger
+	shutdownTimeout time.Duration
+}
+
+// Create new gRPC server on its own address. In-flight calls get shutdownTimeout to finish once the server is stopped.
+func NewGRPC(s NoteService, symmetricKey string, tokenDuration time.Duration, refreshDuration time.Duration, address string, shutdownTimeout time.Duration, l *zerolog.Logger) (*Server, error) {
+	if address == "" {
+		l.Error().Msg("gRPC server address is empty")
+		return nil, ErrEmptyAddress
+	}
+
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
+	pm, err := auth.NewPasetoManager(symmetricKey)
+	if err != nil {
+		l.Err(err).Msgf("could not create a new PasetoCreator. %v", err)
+		return nil, err
+	}
+
+	return &Server{
+		server:          newServer(s, pm, tokenDuration, refreshDuration, l),
+		address:         address,
+		logger:          l,
+		shutdownTimeout: shutdownTimeout,
+	}, nil
+}
+
+// Register the notes API with the logging and auth interceptors
+func newServer(s NoteService, t auth.TokenManager, tokenDuration time.Duration, refreshDuration time.Duration, l *zerolog.Logger) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(LoggingUnaryInterceptor(l), AuthUnaryInterceptor(t, s, l)),
+		grpc.ChainStreamInterceptor(LoggingStreamInterceptor(l), AuthStreamInterceptor(t, s, l)),
+	)
+
+	pb.RegisterNotesServer(srv, &notesServer{
+		s:               s,
+		tokens:          t,
+		tokenDuration:   tokenDuration,
+		refreshDuration: refreshDuration,
+		l:               l,
+	})
+
+	return srv
+}
+
+// Listen on the server address and block until ctx is done, SIGINT/SIGTERM is received
+// or the listener fails. In-flight calls are drained before returning.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.address)
+	if err != nil {
+		s.logger.Error().Err(err).Msgf("could not listen on %s. %v", s.address, err)
+		return err
+	}
+
+	return s.serve(ctx, ln)
+}
+
+func (s *Server) serve(ctx context.Context, ln net.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	notify := make(chan error, 1)
+	go func() {
+		s.logger.Info().Msgf("gRPC server is listening on %s", ln.Addr())
+		notify <- s.server.Serve(ln)
+		close(notify)
+	}()
+
+	select {
+	case err := <-notify:
+		s.logger.Error().Err(err).Msgf("error during gRPC server connection %v", err)
+		return err
+	case <-ctx.Done():
+		s.logger.Info().Msgf("gRPC server run interrupted. %v", context.Cause(ctx))
+	}
+
+	return s.Shutdown()
+}
+
+// Stop accepting new calls and wait for in-flight ones until the shutdown timeout.
+// Calls still running after the timeout are canceled.
+func (s *Server) Shutdown() error {
+	s.logger.Info().Msgf("shutting down the gRPC server, draining calls for at most %v", s.shutdownTimeout)
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	timer := time.NewTimer(s.shutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		s.logger.Info().Msg("gRPC server stopped gracefully")
+		return nil
+	case <-timer.C:
+		s.server.Stop()
+		err := context.DeadlineExceeded
+		s.logger.Error().Err(err).Msgf("could not drain in-flight gRPC calls in time. %v", err)
+		return err
+	}
+}
@@ -0,0 +1,394 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/password"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	"github.com/alekslesik/online-note-z/server/grpc/pb"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const username = "testuser1"
+
+// Serve the API on an in-memory listener and return a client of it
+func newTestClient(t *testing.T, s NoteService, tm auth.TokenManager) pb.NotesClient {
+	t.Helper()
+
+	l := zerolog.New(io.Discard)
+	lis := bufconn.Listen(1 << 20)
+	srv := newServer(s, tm, time.Minute, time.Hour, &l)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewNotesClient(conn)
+}
+
+func withToken(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer testtoken")
+}
+
+func expectNotRevoked(svc *mocksvc.MockNoteService) {
+	svc.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	id := uuid.New()
+
+	testCases := []struct {
+		name      string
+		ctx       context.Context
+		tm        *auth.MockTokenManager
+		mockSvc   func(svc *mocksvc.MockNoteService)
+		checkCode codes.Code
+	}{
+		{
+			name: "auth OK",
+			ctx:  withToken(context.Background()),
+			tm:   &auth.MockTokenManager{Username: username},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				expectNotRevoked(svc)
+				svc.EXPECT().GetNote(gomock.Any(), username, id).Times(1).Return(note.Note{Note: db.Note{ID: id, Title: "title"}}, nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name:      "returns unauthenticated - missing token",
+			ctx:       context.Background(),
+			tm:        &auth.MockTokenManager{Username: username},
+			mockSvc:   func(svc *mocksvc.MockNoteService) {},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name:      "returns unauthenticated - not a bearer token",
+			ctx:       metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic dXNlcg=="),
+			tm:        &auth.MockTokenManager{Username: username},
+			mockSvc:   func(svc *mocksvc.MockNoteService) {},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name:      "returns unauthenticated - expired token",
+			ctx:       withToken(context.Background()),
+			tm:        &auth.MockTokenManager{ReturnExpiredToken: true},
+			mockSvc:   func(svc *mocksvc.MockNoteService) {},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name: "returns unauthenticated - revoked token",
+			ctx:  withToken(context.Background()),
+			tm:   &auth.MockTokenManager{Username: username},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+			},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name: "returns internal - revocation store failure",
+			ctx:  withToken(context.Background()),
+			tm:   &auth.MockTokenManager{Username: username},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(false, note.ErrDBInternal)
+			},
+			checkCode: codes.Internal,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvc(svc)
+
+			client := newTestClient(t, svc, tc.tm)
+			_, err := client.GetNote(tc.ctx, &pb.GetNoteRequest{Id: id.String()})
+			require.Equal(t, tc.checkCode, status.Code(err))
+		})
+	}
+
+	t.Run("streams are authenticated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc := mocksvc.NewMockNoteService(ctrl)
+
+		client := newTestClient(t, svc, &auth.MockTokenManager{Username: username})
+		stream, err := client.ListNotes(context.Background(), &pb.ListNotesRequest{})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestRegister(t *testing.T) {
+	testCases := []struct {
+		name      string
+		req       *pb.RegisterRequest
+		mockSvc   func(svc *mocksvc.MockNoteService)
+		checkCode codes.Code
+	}{
+		{
+			name: "registering user OK",
+			req:  &pb.RegisterRequest{Username: username, Password: "password1", Email: "user1@example.com"},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().RegisterUser(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+					func(_ context.Context, args *db.RegisterUserParams) (string, error) {
+						require.Equal(t, username, args.Username)
+						require.NoError(t, password.Validate(args.Password, "password1"))
+						return args.Username, nil
+					})
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name:      "returns invalid argument - malformed email",
+			req:       &pb.RegisterRequest{Username: username, Password: "password1", Email: "nope"},
+			mockSvc:   func(svc *mocksvc.MockNoteService) {},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name: "returns already exists - username taken",
+			req:  &pb.RegisterRequest{Username: username, Password: "password1", Email: "user1@example.com"},
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().RegisterUser(gomock.Any(), gomock.Any()).Times(1).Return("", note.ErrUserAlreadyExists)
+			},
+			checkCode: codes.AlreadyExists,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvc(svc)
+
+			client := newTestClient(t, svc, &auth.MockTokenManager{})
+			_, err := client.Register(context.Background(), tc.req)
+			require.Equal(t, tc.checkCode, status.Code(err))
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	hashed, err := password.Hash("password1")
+	require.NoError(t, err)
+	user := db.User{Username: username, Password: hashed}
+	sessionID := uuid.New()
+
+	t.Run("login OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc := mocksvc.NewMockNoteService(ctrl)
+		svc.EXPECT().GetUser(gomock.Any(), username).Times(1).Return(user, nil)
+		svc.EXPECT().CreateSession(gomock.Any(), username, gomock.Any(), gomock.Any(), "hashed-testrefreshtoken", gomock.Any()).Times(1).Return(sessionID, nil)
+
+		client := newTestClient(t, svc, &auth.MockTokenManager{})
+		resp, err := client.Login(context.Background(), &pb.LoginRequest{Username: username, Password: "password1"})
+		require.NoError(t, err)
+		require.Equal(t, "testtoken", resp.AccessToken)
+		require.Equal(t, "testrefreshtoken", resp.RefreshToken)
+		require.Equal(t, sessionID.String(), resp.SessionId)
+		require.True(t, resp.RefreshTokenExpiresAt.AsTime().After(resp.AccessTokenExpiresAt.AsTime()))
+	})
+
+	t.Run("returns unauthenticated - wrong password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc := mocksvc.NewMockNoteService(ctrl)
+		svc.EXPECT().GetUser(gomock.Any(), username).Times(1).Return(user, nil)
+
+		client := newTestClient(t, svc, &auth.MockTokenManager{})
+		_, err := client.Login(context.Background(), &pb.LoginRequest{Username: username, Password: "password2"})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("returns not found - unknown user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc := mocksvc.NewMockNoteService(ctrl)
+		svc.EXPECT().GetUser(gomock.Any(), "nobody").Times(1).Return(db.User{}, note.ErrUserNotFound)
+
+		client := newTestClient(t, svc, &auth.MockTokenManager{})
+		_, err := client.Login(context.Background(), &pb.LoginRequest{Username: "nobody", Password: "password1"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name      string
+		token     string
+		mockSvc   func(svc *mocksvc.MockNoteService)
+		checkCode codes.Code
+	}{
+		{
+			name:  "refresh OK",
+			token: "oldrefreshtoken",
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().RotateRefreshToken(gomock.Any(), "hashed-oldrefreshtoken", "hashed-testrefreshtoken", gomock.Any()).Times(1).Return(username, sessionID, nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name:      "returns invalid argument - missing token",
+			mockSvc:   func(svc *mocksvc.MockNoteService) {},
+			checkCode: codes.InvalidArgument,
+		},
+		{
+			name:  "returns unauthenticated - reused token",
+			token: "oldrefreshtoken",
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().RotateRefreshToken(gomock.Any(), "hashed-oldrefreshtoken", gomock.Any(), gomock.Any()).Times(1).Return("", uuid.Nil, note.ErrRefreshTokenReused)
+			},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name:  "returns unauthenticated - expired token",
+			token: "oldrefreshtoken",
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().RotateRefreshToken(gomock.Any(), "hashed-oldrefreshtoken", gomock.Any(), gomock.Any()).Times(1).Return("", uuid.Nil, note.ErrRefreshTokenExpired)
+			},
+			checkCode: codes.Unauthenticated,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvc(svc)
+
+			// no access token, it is expired when the client refreshes
+			client := newTestClient(t, svc, &auth.MockTokenManager{})
+			resp, err := client.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: tc.token})
+			require.Equal(t, tc.checkCode, status.Code(err))
+			if tc.checkCode == codes.OK {
+				require.Equal(t, "testtoken", resp.AccessToken)
+				require.Equal(t, "testrefreshtoken", resp.RefreshToken)
+				require.Equal(t, sessionID.String(), resp.SessionId)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mockSvc   func(svc *mocksvc.MockNoteService)
+		checkCode codes.Code
+	}{
+		{
+			name: "logout OK",
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().RevokeToken(gomock.Any(), username, gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				svc.EXPECT().RevokeSession(gomock.Any(), username, gomock.Any()).Times(1).Return(nil)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name: "logout OK - session already gone",
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().RevokeToken(gomock.Any(), username, gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				svc.EXPECT().RevokeSession(gomock.Any(), username, gomock.Any()).Times(1).Return(note.ErrSessionNotFound)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name: "returns internal - revoke fails",
+			mockSvc: func(svc *mocksvc.MockNoteService) {
+				svc.EXPECT().RevokeToken(gomock.Any(), username, gomock.Any(), gomock.Any()).Times(1).Return(errors.New("db down"))
+			},
+			checkCode: codes.Internal,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc := mocksvc.NewMockNoteService(ctrl)
+			expectNotRevoked(svc)
+			tc.mockSvc(svc)
+
+			client := newTestClient(t, svc, &auth.MockTokenManager{Username: username})
+			_, err := client.Logout(withToken(context.Background()), &pb.LogoutRequest{})
+			require.Equal(t, tc.checkCode, status.Code(err))
+		})
+	}
+
+	t.Run("returns unauthenticated - missing token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc := mocksvc.NewMockNoteService(ctrl)
+
+		client := newTestClient(t, svc, &auth.MockTokenManager{Username: username})
+		_, err := client.Logout(context.Background(), &pb.LogoutRequest{})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	l := zerolog.New(io.Discard)
+	s := &Server{
+		server:          newServer(nil, &auth.MockTokenManager{}, time.Minute, time.Hour, &l),
+		logger:          &l,
+		shutdownTimeout: time.Second,
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.serve(ctx, ln) }()
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+}
+
+func TestNewGRPC(t *testing.T) {
+	l := zerolog.New(io.Discard)
+
+	_, err := NewGRPC(nil, "yuIXHzQp2ouQbZ9T6keVPqsT24V28Zkd", time.Minute, time.Hour, "", 0, &l)
+	require.ErrorIs(t, err, ErrEmptyAddress)
+
+	_, err = NewGRPC(nil, "short", time.Minute, time.Hour, ":0", 0, &l)
+	require.ErrorIs(t, err, auth.ErrInvalidSymmetricKeySize)
+
+	s, err := NewGRPC(nil, "yuIXHzQp2ouQbZ9T6keVPqsT24V28Zkd", time.Minute, time.Hour, ":0", 0, &l)
+	require.NoError(t, err)
+	require.Equal(t, defaultShutdownTimeout, s.shutdownTimeout)
+}
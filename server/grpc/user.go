@@ -0,0 +1,205 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/password"
+	"github.com/alekslesik/online-note-z/note"
+	"github.com/alekslesik/online-note-z/server/grpc/pb"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Implements pb.NotesServer on top of the note service
+type notesServer struct {
+	pb.UnimplementedNotesServer
+
+	s               NoteService
+	tokens          auth.TokenManager
+	tokenDuration   time.Duration
+	refreshDuration time.Duration
+	l               *zerolog.Logger
+}
+
+func (n *notesServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	// same rules as POST /register
+	user := models.User{Username: req.GetUsername(), Password: req.GetPassword(), Email: req.GetEmail()}
+	err := validator.New().Struct(&user)
+	if err != nil {
+		n.l.Info().Msgf("error during User struct validation %v", err)
+		return nil, status.Error(codes.InvalidArgument, "wrongly formatted or missing User parameter")
+	}
+
+	hashedPw, err := password.Hash(user.Password)
+	switch {
+	case errors.Is(err, password.ErrTooShort):
+		return nil, status.Error(codes.InvalidArgument, "password is too short")
+	case err != nil:
+		n.l.Error().Err(err).Msgf("error during password hashing %v", err)
+		return nil, status.Error(codes.Internal, "internal error during password hashing")
+	}
+
+	uname, err := n.s.RegisterUser(ctx, &db.RegisterUserParams{
+		Username: user.Username,
+		Password: hashedPw,
+		Email:    user.Email,
+	})
+	switch {
+	case errors.Is(err, note.ErrUserAlreadyExists):
+		n.l.Info().Msgf("registration failed, username or email already in use for user %s", user.Username)
+		return nil, status.Error(codes.AlreadyExists, "username or email already in use")
+	case err != nil:
+		n.l.Error().Err(err).Msgf("Error during User registration! %v", err)
+		return nil, status.Error(codes.Internal, "internal error during user registration")
+	}
+
+	n.l.Info().Msgf("User registration for %s was successful!", uname)
+	return &pb.RegisterResponse{Username: uname}, nil
+}
+
+// Start a session like POST /login, the tokens are returned instead of set as cookies
+func (n *notesServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	user, err := n.s.GetUser(ctx, req.GetUsername())
+	switch {
+	case errors.Is(err, note.ErrUserNotFound):
+		n.l.Info().Msgf("user: %s is not found", req.GetUsername())
+		return nil, status.Error(codes.NotFound, "user is not found")
+	case err != nil:
+		n.l.Error().Err(err).Msgf("Error during user lookup! %v", err)
+		return nil, status.Error(codes.Internal, "internal error during user lookup")
+	}
+
+	err = password.Validate(user.Password, req.GetPassword())
+	if err != nil {
+		n.l.Info().Msgf("Wrong password was provided for user %s", req.GetUsername())
+		return nil, status.Error(codes.Unauthenticated, "wrong password was provided")
+	}
+
+	refreshToken, refreshHash, err := n.tokens.CreateRefreshToken()
+	if err != nil {
+		n.l.Error().Err(err).Msgf("Could not create refresh token for user. %v", err)
+		return nil, status.Error(codes.Internal, "internal server error while creating the token")
+	}
+
+	refreshExpiresAt := time.Now().Add(n.refreshDuration)
+	sessionID, err := n.s.CreateSession(ctx, user.Username, userAgent(ctx), clientIP(ctx), refreshHash, refreshExpiresAt)
+	if err != nil {
+		n.l.Error().Err(err).Msgf("Could not create session for user. %v", err)
+		return nil, status.Error(codes.Internal, "internal server error while creating the token")
+	}
+
+	accessToken, payload, err := n.tokens.CreateToken(user.Username, sessionID, n.tokenDuration)
+	if err != nil {
+		n.l.Error().Err(err).Msgf("Could not create PASETO for user. %v", err)
+		return nil, status.Error(codes.Internal, "internal server error while creating the token")
+	}
+
+	n.l.Info().Msgf("User login for %s was successful!", user.Username)
+	return &pb.LoginResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(payload.ExpiresAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(refreshExpiresAt),
+		SessionId:             sessionID.String(),
+	}, nil
+}
+
+// Rotate the refresh token like POST /token/refresh, a reused token revokes its whole family
+func (n *notesServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.LoginResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is missing")
+	}
+
+	newRefreshToken, newRefreshHash, err := n.tokens.CreateRefreshToken()
+	if err != nil {
+		n.l.Error().Err(err).Msgf("Could not create refresh token. %v", err)
+		return nil, status.Error(codes.Internal, "internal server error while creating the token")
+	}
+
+	refreshExpiresAt := time.Now().Add(n.refreshDuration)
+	username, sessionID, err := n.s.RotateRefreshToken(ctx, n.tokens.HashRefreshToken(req.GetRefreshToken()), newRefreshHash, refreshExpiresAt)
+	switch {
+	case errors.Is(err, note.ErrRefreshTokenReused):
+		n.l.Warn().Msg("refresh token reuse detected, token family is revoked")
+		return nil, status.Error(codes.Unauthenticated, "refresh token has already been used, please log in again")
+	case errors.Is(err, note.ErrRefreshTokenInvalid), errors.Is(err, note.ErrRefreshTokenExpired):
+		n.l.Info().Err(err).Msgf("refresh token rejected. %v", err)
+		return nil, status.Error(codes.Unauthenticated, "refresh token is invalid or expired")
+	case err != nil:
+		n.l.Error().Err(err).Msgf("Error during refresh token rotation! %v", err)
+		return nil, status.Error(codes.Internal, "internal error during token refresh")
+	}
+
+	accessToken, payload, err := n.tokens.CreateToken(username, sessionID, n.tokenDuration)
+	if err != nil {
+		n.l.Error().Err(err).Msgf("Could not create PASETO for user. %v", err)
+		return nil, status.Error(codes.Internal, "internal server error while creating the token")
+	}
+
+	n.l.Info().Msgf("Token refresh for %s was successful!", username)
+	return &pb.LoginResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(payload.ExpiresAt),
+		RefreshToken:          newRefreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(refreshExpiresAt),
+		SessionId:             sessionID.String(),
+	}, nil
+}
+
+// Revoke the access token and its session like POST /logout
+func (n *notesServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	payload, ok := auth.PayloadFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	err := n.s.RevokeToken(ctx, payload.Username, payload.ID, payload.ExpiresAt)
+	if err != nil {
+		n.l.Error().Err(err).Msgf("Could not revoke PASETO %v. %v", payload.ID, err)
+		return nil, status.Error(codes.Internal, "internal error during logout")
+	}
+
+	err = n.s.RevokeSession(ctx, payload.Username, payload.SessionID)
+	if err != nil && !errors.Is(err, note.ErrSessionNotFound) {
+		n.l.Error().Err(err).Msgf("Could not revoke session %v. %v", payload.SessionID, err)
+		return nil, status.Error(codes.Internal, "internal error during logout")
+	}
+
+	n.l.Info().Msgf("User logout for %s was successful!", payload.Username)
+	return &pb.LogoutResponse{}, nil
+}
+
+func userAgent(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("user-agent"); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// Client address without the port
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
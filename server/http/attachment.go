@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	maxAttachmentSize = 25 << 20
+	// parts of the form above this are spooled to temporary files
+	maxMultipartMemory = 8 << 20
+	// uploads and downloads may outlast the server read/write timeouts
+	attachmentTransferTimeout = 5 * time.Minute
+)
+
+func toAttachmentModel(a db.Attachment) models.Attachment {
+	return models.Attachment{
+		ID:          a.ID,
+		NoteID:      a.NoteID,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// Get the attachment ID from the URL, write 400 if it's not a UUID
+func attachmentIDParam(w http.ResponseWriter, r *http.Request, l *zerolog.Logger) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		l.Info().Msgf("Could not convert attachment ID to UUID.")
+		httplib.JSON(w, httplib.Msg{"error": "could not convert attachment id to uuid"}, http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// Give a slow transfer more time than the server timeouts allow
+func extendDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(attachmentTransferTimeout)
+	rc.SetReadDeadline(deadline)
+	rc.SetWriteDeadline(deadline)
+}
+
+// Use the declared type of the upload, then the extension, then sniff the content
+func detectContentType(declared string, filename string, f io.ReadSeeker) string {
+	if declared != "" && declared != "application/octet-stream" {
+		return declared
+	}
+
+	if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+		return byExt
+	}
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	f.Seek(0, io.SeekStart)
+
+	return http.DetectContentType(head[:n])
+}
+
+// Map attachment errors of the note service to responses
+func writeAttachmentError(w http.ResponseWriter, l *zerolog.Logger, noteID uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, note.ErrNotFound):
+		l.Info().Msgf("Note %v is not found!", noteID)
+		httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+	case errors.Is(err, note.ErrAttachmentNotFound):
+		l.Info().Msgf("Attachment of note %v is not found!", noteID)
+		httplib.JSON(w, httplib.Msg{"error": "attachment is not found"}, http.StatusNotFound)
+	case errors.Is(err, note.ErrInvalidAttachment):
+		l.Info().Msgf("Invalid attachment for note %v", noteID)
+		httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
+	case errors.Is(err, note.ErrQuotaExceeded):
+		l.Info().Msgf("Attachment quota exceeded while uploading to note %v", noteID)
+		httplib.JSON(w, httplib.Msg{"error": "attachment storage quota exceeded"}, http.StatusRequestEntityTooLarge)
+	default:
+		l.Error().Err(err).Msgf("Attachment operation on note %v failed. %v", noteID, err)
+		httplib.JSON(w, httplib.Msg{"error": "internal error during attachment operation"}, http.StatusInternalServerError)
+	}
+}
+
+// POST /notes/{id}/attachments, multipart form with a "file" field
+func AddAttachment(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, _, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		extendDeadlines(w)
+		ctx, cancelTransfer := context.WithTimeout(r.Context(), attachmentTransferTimeout)
+		defer cancelTransfer()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		// leave some room for the multipart framing
+		r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+1<<20)
+		err := r.ParseMultipartForm(maxMultipartMemory)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				l.Info().Msgf("Attachment for note %v is too large", noteID)
+				httplib.JSON(w, httplib.Msg{"error": "attachments must be at most 25 MiB"}, http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			l.Info().Msgf("Could not parse the attachment upload. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "expected a multipart form with a file field"}, http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			l.Info().Msgf("Attachment upload has no file field. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "expected a multipart form with a file field"}, http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+
+		if header.Size > maxAttachmentSize {
+			l.Info().Msgf("Attachment for note %v is too large", noteID)
+			httplib.JSON(w, httplib.Msg{"error": "attachments must be at most 25 MiB"}, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		contentType := detectContentType(header.Header.Get("Content-Type"), header.Filename, f)
+
+		a, err := s.AddAttachment(ctx, username, noteID, header.Filename, contentType, header.Size, f)
+		if err != nil {
+			writeAttachmentError(w, l, noteID, err)
+			return
+		}
+
+		l.Info().Msgf("Attachment %v has been added to note %v", a.ID, noteID)
+		httplib.JSON(w, toAttachmentModel(a), http.StatusCreated)
+	}
+}
+
+// GET /notes/{id}/attachments
+func ListAttachments(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		attachments, err := s.ListAttachments(ctx, username, noteID)
+		if err != nil {
+			writeAttachmentError(w, l, noteID, err)
+			return
+		}
+
+		resp := make([]models.Attachment, 0, len(attachments))
+		for _, a := range attachments {
+			resp = append(resp, toAttachmentModel(a))
+		}
+
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
+
+// GET /notes/{id}/attachments/{attachmentID}, supports Range requests
+func GetAttachment(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, _, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		extendDeadlines(w)
+		ctx, cancelTransfer := context.WithTimeout(r.Context(), attachmentTransferTimeout)
+		defer cancelTransfer()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		id, ok := attachmentIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		a, content, err := s.OpenAttachment(ctx, username, noteID, id)
+		if err != nil {
+			writeAttachmentError(w, l, noteID, err)
+			return
+		}
+		defer content.Close()
+
+		// never let the browser render an upload as a page of this site
+		w.Header().Set("Content-Type", a.ContentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+
+		http.ServeContent(w, r, a.Filename, a.CreatedAt, content)
+	}
+}
+
+// DELETE /notes/{id}/attachments/{attachmentID}
+func DeleteAttachment(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		id, ok := attachmentIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		err := s.DeleteAttachment(ctx, username, noteID, id)
+		if err != nil {
+			writeAttachmentError(w, l, noteID, err)
+			return
+		}
+
+		l.Info().Msgf("Attachment %v of note %v has been deleted", id, noteID)
+		httplib.JSON(w, httplib.Msg{"success": "attachment deleted"}, http.StatusOK)
+	}
+}
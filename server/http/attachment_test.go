@@ -0,0 +1,350 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+// Build a multipart body with a single file field
+func multipartFile(t *testing.T, field string, filename string, content string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+
+	fw, err := mw.CreateFormFile(field, filename)
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	return body, mw.FormDataContentType()
+}
+
+func TestAddAttachment(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		field         string
+		filename      string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "adding attachment OK",
+			field:    "file",
+			filename: "notes.md",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().AddAttachment(gomock.Any(), username, noteID, "notes.md", "text/markdown; charset=utf-8", int64(7), gomock.Any()).Times(1).
+					Return(db.Attachment{ID: uuid.New(), NoteID: noteID, Filename: "notes.md", Size: 7}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, rec.Code)
+
+				var a models.Attachment
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&a))
+				require.Equal(t, "notes.md", a.Filename)
+				require.Equal(t, int64(7), a.Size)
+			},
+		},
+		{
+			name:     "sniffs the content type of unknown extensions",
+			field:    "file",
+			filename: "blob",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().AddAttachment(gomock.Any(), username, noteID, "blob", "text/plain; charset=utf-8", int64(7), gomock.Any()).Times(1).
+					Return(db.Attachment{ID: uuid.New(), NoteID: noteID}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, rec.Code)
+			},
+		},
+		{
+			name:     "returns request entity too large - quota exceeded",
+			field:    "file",
+			filename: "notes.md",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().AddAttachment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+					Return(db.Attachment{}, note.ErrQuotaExceeded)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			},
+		},
+		{
+			name:     "returns not found - note of another user",
+			field:    "file",
+			filename: "notes.md",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().AddAttachment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+					Return(db.Attachment{}, note.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:     "returns bad request - no file field",
+			field:    "upload",
+			filename: "notes.md",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().AddAttachment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			body, contentType := multipartFile(t, tc.field, tc.filename, "# hello")
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/notes/"+noteID.String()+"/attachments", body)
+			req.Header.Set("Content-Type", contentType)
+			req = withURLParam(withAuthUser(req, username), "id", noteID.String())
+
+			handler := AddAttachment(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestAddAttachmentTooLarge(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().AddAttachment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	body, contentType := multipartFile(t, "file", "big.bin", strings.Repeat("x", maxAttachmentSize+2<<20))
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/notes/"+noteID.String()+"/attachments", body)
+	req.Header.Set("Content-Type", contentType)
+	req = withURLParam(withAuthUser(req, username), "id", noteID.String())
+
+	AddAttachment(mocksvc)(rec, req)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestListAttachments(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "listing attachments OK",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListAttachments(gomock.Any(), username, noteID).Times(1).
+					Return([]db.Attachment{{ID: uuid.New(), NoteID: noteID, Filename: "a.png"}, {ID: uuid.New(), NoteID: noteID, Filename: "b.pdf"}}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var attachments []models.Attachment
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&attachments))
+				require.Len(t, attachments, 2)
+				require.Equal(t, "b.pdf", attachments[1].Filename)
+			},
+		},
+		{
+			name: "returns not found - note of another user",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListAttachments(gomock.Any(), username, noteID).Times(1).Return(nil, note.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/notes/"+noteID.String()+"/attachments", nil)
+			req = withURLParam(withAuthUser(req, username), "id", noteID.String())
+
+			handler := ListAttachments(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestGetAttachment(t *testing.T) {
+	const username = "testuser1"
+	noteID, id := uuid.New(), uuid.New()
+	a := db.Attachment{ID: id, NoteID: noteID, Filename: "report.pdf", ContentType: "application/pdf", Size: 10, CreatedAt: time.Now()}
+
+	testCases := []struct {
+		name          string
+		rangeHeader   string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "downloading attachment OK",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenAttachment(gomock.Any(), username, noteID, id).Times(1).
+					Return(a, nopSeekCloser{strings.NewReader("0123456789")}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+				require.Equal(t, `attachment; filename=report.pdf`, rec.Header().Get("Content-Disposition"))
+				require.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+				require.Equal(t, "0123456789", rec.Body.String())
+			},
+		},
+		{
+			name:        "downloading a range OK",
+			rangeHeader: "bytes=2-4",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenAttachment(gomock.Any(), username, noteID, id).Times(1).
+					Return(a, nopSeekCloser{strings.NewReader("0123456789")}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPartialContent, rec.Code)
+				require.Equal(t, "bytes 2-4/10", rec.Header().Get("Content-Range"))
+				require.Equal(t, "234", rec.Body.String())
+			},
+		},
+		{
+			name:        "returns range not satisfiable",
+			rangeHeader: "bytes=20-",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenAttachment(gomock.Any(), username, noteID, id).Times(1).
+					Return(a, nopSeekCloser{strings.NewReader("0123456789")}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+			},
+		},
+		{
+			name: "returns not found",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenAttachment(gomock.Any(), username, noteID, id).Times(1).Return(db.Attachment{}, nil, note.ErrAttachmentNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/notes/"+noteID.String()+"/attachments/"+id.String(), nil)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			req = withURLParams(withAuthUser(req, username), map[string]string{"id": noteID.String(), "attachmentID": id.String()})
+
+			handler := GetAttachment(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	const username = "testuser1"
+	noteID, id := uuid.New(), uuid.New()
+
+	testCases := []struct {
+		name          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "deleting attachment OK",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteAttachment(gomock.Any(), username, noteID, id).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "returns not found",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteAttachment(gomock.Any(), username, noteID, id).Times(1).Return(note.ErrAttachmentNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "returns internal server error - storage error",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteAttachment(gomock.Any(), username, noteID, id).Times(1).Return(note.ErrStorageInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/notes/"+noteID.String()+"/attachments/"+id.String(), nil)
+			req = withURLParams(withAuthUser(req, username), map[string]string{"id": noteID.String(), "attachmentID": id.String()})
+
+			handler := DeleteAttachment(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
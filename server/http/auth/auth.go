@@ -1,21 +1,30 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
 // Authenticator is an interface for authenticating a user.
 type TokenManager interface {
-	CreateToken(username string, duration time.Duration) (string, *PasetoPayload, error)
+	CreateToken(username string, sessionID uuid.UUID, duration time.Duration) (string, *PasetoPayload, error)
 	VerifyToken(token string) (*PasetoPayload, error)
+	CreateRefreshToken() (string, string, error)
+	HashRefreshToken(token string) string
+}
+
+// RevocationChecker tells whether a token or the session it belongs to has been revoked.
+type RevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error)
 }
 
 // Middleware for authenticating a user.
-func AuthMiddleware(t TokenManager, l *zerolog.Logger) func(http.Handler) http.Handler {
+func AuthMiddleware(t TokenManager, rc RevocationChecker, l *zerolog.Logger) func(http.Handler) http.Handler {
 	f := func(h http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			tokenCookie, err := r.Cookie("paseto")
@@ -37,11 +46,29 @@ func AuthMiddleware(t TokenManager, l *zerolog.Logger) func(http.Handler) http.H
 					http.Error(w, "expired token", http.StatusUnauthorized)
 					return
 				}
+				l.Error().Err(err).Msgf("PASETO could not be verified!")
+				http.Error(w, "missing token", http.StatusUnauthorized)
+				return
+			}
+
+			revoked, err := rc.IsTokenRevoked(r.Context(), payload.ID, payload.SessionID)
+			if err != nil {
+				l.Error().Err(err).Msgf("could not check if PASETO is revoked!")
+				http.Error(w, "could not verify token", http.StatusInternalServerError)
+				return
+			}
+			if revoked {
+				l.Info().Msgf("PASETO %v of user %s is revoked!", payload.ID, payload.Username)
+				http.Error(w, "revoked token", http.StatusUnauthorized)
+				return
 			}
+
 			l.Info().Msgf("User %s is authorized! tokenID: %v, issuedAt: %v, expiresAt: %v", payload.Username, payload.ID, payload.IssuedAt, payload.ExpiresAt)
-			h.ServeHTTP(w, r)
+
+			// make the verified user available to the handlers
+			h.ServeHTTP(w, r.WithContext(NewContext(r.Context(), payload)))
 		}
 		return http.HandlerFunc(fn)
 	}
 	return f
-}
\ No newline at end of file
+}
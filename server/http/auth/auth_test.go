@@ -1,14 +1,16 @@
 package auth
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
-	httplib "github.com/adykaaa/online-notes/lib/http"
+	httplib "github.com/alekslesik/online-note-z/lib/http"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 )
@@ -72,7 +74,7 @@ func TestAuthMiddleware(t *testing.T) {
 			tm := tc.newMockTokenMgr()
 
 			r := chi.NewRouter()
-			r.Use(AuthMiddleware(tm, &l))
+			r.Use(AuthMiddleware(tm, &MockRevocationChecker{}, &l))
 
 			r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
 				httplib.JSON(w, "msg from test handler", http.StatusOK)
@@ -89,3 +91,91 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddlewareSetsPayload(t *testing.T) {
+	l := zerolog.New(io.Discard)
+	tm := &MockTokenManager{Username: "testuser1"}
+
+	r := chi.NewRouter()
+	r.Use(AuthMiddleware(tm, &MockRevocationChecker{}, &l))
+
+	var payload *PasetoPayload
+	var ok bool
+	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		payload, ok = PayloadFromContext(r.Context())
+		httplib.JSON(w, "msg from test handler", http.StatusOK)
+	})
+
+	t.Run("payload is stored in request context", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.AddCookie(&http.Cookie{Name: "paseto", Value: "test", Expires: time.Now().Add(30 * time.Minute)})
+
+		r.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.True(t, ok)
+		require.Equal(t, "testuser1", payload.Username)
+	})
+
+	t.Run("returns unauthorized - missing cookie", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+
+		r.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestAuthMiddlewareRevocation(t *testing.T) {
+	l := zerolog.New(io.Discard)
+
+	testCases := []struct {
+		name          string
+		rc            *MockRevocationChecker
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "auth OK - not revoked",
+			rc:   &MockRevocationChecker{},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "returns unauthorized - revoked token",
+			rc:   &MockRevocationChecker{Revoked: map[uuid.UUID]bool{uuid.Nil: true}},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "returns internal server error - revocation store failure",
+			rc:   &MockRevocationChecker{ReturnError: errors.New("db is down")},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(AuthMiddleware(&MockTokenManager{}, tc.rc, &l))
+
+			r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+				httplib.JSON(w, "msg from test handler", http.StatusOK)
+			})
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.AddCookie(&http.Cookie{Name: "paseto", Value: "test", Expires: time.Now().Add(30 * time.Minute)})
+
+			r.ServeHTTP(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package auth
+
+import "context"
+
+// Unexported key type, so no other package can overwrite the payload in the context
+type payloadCtxKey struct{}
+
+// Return a copy of ctx carrying the verified PASETO payload
+func NewContext(ctx context.Context, payload *PasetoPayload) context.Context {
+	return context.WithValue(ctx, payloadCtxKey{}, payload)
+}
+
+// Return the verified PASETO payload stored by AuthMiddleware
+func PayloadFromContext(ctx context.Context) (*PasetoPayload, bool) {
+	payload, ok := ctx.Value(payloadCtxKey{}).(*PasetoPayload)
+	return payload, ok && payload != nil
+}
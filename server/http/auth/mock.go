@@ -1,14 +1,20 @@
 package auth
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type MockTokenManager struct {
 	ReturnInvalidToken bool
 	ReturnExpiredToken bool
+	Username           string
 }
 
-func (m *MockTokenManager) CreateToken(username string, duration time.Duration) (string, *PasetoPayload, error) {
-	return "testtoken", &PasetoPayload{}, nil
+func (m *MockTokenManager) CreateToken(username string, sessionID uuid.UUID, duration time.Duration) (string, *PasetoPayload, error) {
+	return "testtoken", &PasetoPayload{Username: username, SessionID: sessionID, ExpiresAt: time.Now().Add(duration)}, nil
 }
 
 func (m *MockTokenManager) VerifyToken(token string) (*PasetoPayload, error) {
@@ -20,5 +26,32 @@ func (m *MockTokenManager) VerifyToken(token string) (*PasetoPayload, error) {
 		return nil, ErrTokenInvalid
 	}
 
-	return &PasetoPayload{}, nil
-}
\ No newline at end of file
+	return &PasetoPayload{Username: m.Username}, nil
+}
+
+func (m *MockTokenManager) CreateRefreshToken() (string, string, error) {
+	return "testrefreshtoken", m.HashRefreshToken("testrefreshtoken"), nil
+}
+
+func (m *MockTokenManager) HashRefreshToken(token string) string {
+	return "hashed-" + token
+}
+
+type MockRevocationChecker struct {
+	Revoked     map[uuid.UUID]bool
+	ReturnError error
+}
+
+func (m *MockRevocationChecker) IsTokenRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error) {
+	if m.ReturnError != nil {
+		return false, m.ReturnError
+	}
+
+	for _, id := range ids {
+		if m.Revoked[id] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -1,6 +1,11 @@
 package auth
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -18,26 +23,30 @@ var (
 	ErrInvalidSymmetricKeySize = errors.New("the symmetric key size is invalid")
 )
 
+// Number of random bytes in a refresh token
+const refreshTokenSize = 32
+
 type PasetoPayload struct {
 	ID        uuid.UUID `json:"id"`
+	SessionID uuid.UUID `json:"sessionId"`
 	Username  string    `json:"username"`
 	IssuedAt  time.Time `json:"issuedAt"`
 	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 // Create new payload
-func NewPasetoPayload(username string, tokenDuration time.Duration) (*PasetoPayload, error) {
+func NewPasetoPayload(username string, sessionID uuid.UUID, tokenDuration time.Duration) (*PasetoPayload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
-		fmt.Errorf("Could not generate a random token ID! %v", err)
-		return nil, err
+		return nil, fmt.Errorf("could not generate a random token ID! %v", err)
 	}
 
 	payload := &PasetoPayload{
 		ID:        tokenID,
+		SessionID: sessionID,
 		Username:  username,
 		IssuedAt:  time.Now(),
-		ExpiresAt: time.Now().Add(tokenDuration + time.Second),
+		ExpiresAt: time.Now().Add(tokenDuration),
 	}
 
 	return payload, nil
@@ -63,8 +72,8 @@ func NewPasetoManager(symmetricKey string) (*PasetoManager, error) {
 }
 
 // Create new PasetoPayload
-func (c *PasetoManager) CreateToken(username string, duration time.Duration) (string, *PasetoPayload, error) {
-	payload, err := NewPasetoPayload(username, duration)
+func (c *PasetoManager) CreateToken(username string, sessionID uuid.UUID, duration time.Duration) (string, *PasetoPayload, error) {
+	payload, err := NewPasetoPayload(username, sessionID, duration)
 	if err != nil {
 		return "", nil, err
 	}
@@ -93,3 +102,25 @@ func (c *PasetoManager) VerifyToken(token string) (*PasetoPayload, error) {
 
 	return payload, nil
 }
+
+// Create new opaque refresh token and its hash. Only the hash should be stored server-side.
+func (c *PasetoManager) CreateRefreshToken() (string, string, error) {
+	b := make([]byte, refreshTokenSize)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", fmt.Errorf("could not generate a random refresh token! %v", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, c.HashRefreshToken(token), nil
+}
+
+// Hash refresh token with HMAC-SHA256 keyed by the symmetric key
+func (c *PasetoManager) HashRefreshToken(token string) string {
+	mac := hmac.New(sha256.New, c.symmetricKey)
+	mac.Write([]byte(token))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
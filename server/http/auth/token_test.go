@@ -4,7 +4,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/adykaaa/online-notes/lib/random"
+	"github.com/alekslesik/online-note-z/lib/random"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -13,12 +14,13 @@ func TestPasetoManager(t *testing.T) {
 	key := random.NewString(32)
 	uname := random.NewString(15)
 	duration := 1000 * time.Second
+	sessionID := uuid.New()
 	pc, err := NewPasetoManager(key)
 
 	require.NoError(t, err)
 
 	t.Run("tokenCreation and verification OK", func(t *testing.T) {
-		token, payload, err := pc.CreateToken(uname, duration)
+		token, payload, err := pc.CreateToken(uname, sessionID, duration)
 
 		require.NoError(t, err)
 		require.Equal(t, payload.Username, uname)
@@ -27,6 +29,7 @@ func TestPasetoManager(t *testing.T) {
 		payload, err = pc.VerifyToken(token)
 		require.NoError(t, err)
 		require.Equal(t, payload.Username, uname)
+		require.Equal(t, sessionID, payload.SessionID)
 	})
 
 	t.Run("fails because of invalid key length", func(t *testing.T) {
@@ -48,7 +51,7 @@ func TestPasetoManager(t *testing.T) {
 	})
 
 	t.Run("fails with expired token", func(t *testing.T) {
-		token, _, err := pc.CreateToken(uname, 0)
+		token, _, err := pc.CreateToken(uname, sessionID, 0)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 		retToken, err := pc.VerifyToken(token)
@@ -56,4 +59,25 @@ func TestPasetoManager(t *testing.T) {
 		require.Nil(t, retToken)
 	})
 
+	t.Run("refresh token creation OK", func(t *testing.T) {
+		token1, hash1, err := pc.CreateRefreshToken()
+		require.NoError(t, err)
+		require.NotEmpty(t, token1)
+		require.NotEqual(t, token1, hash1)
+		require.Equal(t, hash1, pc.HashRefreshToken(token1))
+
+		token2, hash2, err := pc.CreateRefreshToken()
+		require.NoError(t, err)
+		require.NotEqual(t, token1, token2)
+		require.NotEqual(t, hash1, hash2)
+	})
+
+	t.Run("refresh token hash depends on key", func(t *testing.T) {
+		other, err := NewPasetoManager(random.NewString(32))
+		require.NoError(t, err)
+
+		token, hash, err := pc.CreateRefreshToken()
+		require.NoError(t, err)
+		require.NotEqual(t, hash, other.HashRefreshToken(token))
+	})
 }
@@ -4,13 +4,18 @@ import (
 	"time"
 
 	"github.com/adykaaa/httplog"
-	auth "github.com/adykaaa/online-notes/server/http/auth"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/alekslesik/online-note-z/server/http/openapi"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/rs/zerolog"
 )
 
+// Origins allowed to call the API from a browser
+var allowedOrigins = []string{"http://localhost:3000"}
+
 // Middleware registration
 func registerChiMiddlewares(r *chi.Mux, l *zerolog.Logger) {
 	// Request logger has middleware.Recoverer and RequestID baked into it.
@@ -18,54 +23,118 @@ func registerChiMiddlewares(r *chi.Mux, l *zerolog.Logger) {
 		middleware.Heartbeat("/ping"),
 		middleware.RedirectSlashes,
 		cors.Handler(cors.Options{
-			AllowedOrigins:   []string{"http://localhost:3000"},
+			AllowedOrigins:   allowedOrigins,
 			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Bearer", "Set-Cookie", "X-Powered-By", "X-Content-Type-Options"},
-			ExposedHeaders:   []string{"Link", "Access-Control-Expose-Headers"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Bearer", "Set-Cookie", "X-Powered-By", "X-Content-Type-Options", "If-Match", "If-None-Match"},
+			ExposedHeaders:   []string{"Link", "Access-Control-Expose-Headers", "ETag"},
 			AllowCredentials: true,
 			MaxAge:           300,
 		}))
 }
 
 // Handlers registration
-func registerChiHandlers(r *chi.Mux, s NoteService, t auth.TokenManager, tokenDuration time.Duration, l *zerolog.Logger) {
+func registerChiHandlers(r *chi.Mux, s NoteService, t auth.TokenManager, doc *openapi3.T, tokenDuration time.Duration, refreshDuration time.Duration, l *zerolog.Logger) {
+	r.Get("/openapi.json", openapi.ServeSpec(doc))
+	r.Get("/docs", openapi.ServeDocs("/openapi.json"))
+
 	r.Post("/register", RegisterUser(s))
-	r.Post("/login", LoginUser(s, t, tokenDuration))
-	r.Post("/logout", LogoutUser())
+	r.Post("/login", LoginUser(s, t, tokenDuration, refreshDuration))
+	r.Post("/logout", LogoutUser(s, t))
+	r.Post("/token/refresh", RefreshToken(s, t, tokenDuration, refreshDuration))
+	r.Get("/p/{token}", OpenLink(s))
 
 	// subroutine with other middleware
 	r.Route("/notes", func(r chi.Router) {
-		r.Use(auth.AuthMiddleware(t, l))
+		r.Use(auth.AuthMiddleware(t, s, l))
 		r.Post("/create", CreateNote(s))
 		r.Get("/", GetAllNotesFromUser(s))
+		r.Get("/search", SearchNotes(s))
+		r.Get("/shared", ListSharedNotes(s))
+		r.Get("/{id}", GetNote(s))
 		r.Put("/{id}", UpdateNote(s))
 		r.Delete("/{id}", DeleteNote(s))
+		r.Put("/{id}/notebook", MoveNote(s))
+		r.Get("/{id}/revisions", ListRevisions(s))
+		r.Get("/{id}/revisions/{rev}", GetRevision(s))
+		r.Post("/{id}/revisions/{rev}/restore", RestoreRevision(s))
+		r.Get("/{id}/diff", DiffRevisions(s))
+		r.Post("/{id}/attachments", AddAttachment(s))
+		r.Get("/{id}/attachments", ListAttachments(s))
+		r.Get("/{id}/attachments/{attachmentID}", GetAttachment(s))
+		r.Delete("/{id}/attachments/{attachmentID}", DeleteAttachment(s))
+		r.Post("/{id}/shares", ShareNote(s))
+		r.Get("/{id}/shares", ListShares(s))
+		r.Delete("/{id}/shares/{username}", RevokeShare(s))
+		r.Post("/{id}/links", CreateLink(s))
+		r.Get("/{id}/links", ListLinks(s))
+		r.Delete("/{id}/links/{linkID}", RevokeLink(s))
+		r.Get("/{id}/collab", CollabNote(s))
+	})
+
+	r.With(auth.AuthMiddleware(t, s, l)).Get("/export", ExportNotes(s))
+	r.With(auth.AuthMiddleware(t, s, l)).Post("/import", ImportNotes(s))
+	r.With(auth.AuthMiddleware(t, s, l)).Post("/sync", SyncNotes(s))
+
+	r.Route("/trash", func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(t, s, l))
+		r.Get("/", ListTrash(s))
+		r.Delete("/", EmptyTrash(s))
+		r.Post("/{id}/restore", RestoreNote(s))
+	})
+
+	r.Route("/notebooks", func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(t, s, l))
+		r.Post("/", CreateNotebook(s))
+		r.Get("/", ListNotebooks(s))
+		r.Get("/{id}", GetNotebook(s))
+		r.Put("/{id}", UpdateNotebook(s))
+		r.Delete("/{id}", DeleteNotebook(s))
+	})
+
+	r.Route("/tags", func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(t, s, l))
+		r.Get("/", ListTags(s))
+		r.Put("/{name}", RenameTag(s))
+		r.Post("/{name}/merge", MergeTag(s))
+	})
+
+	r.Route("/events", func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(t, s, l))
+		r.Get("/", StreamEvents(s))
+		r.Get("/ws", EventsWebSocket(s))
+	})
+
+	r.Route("/settings", func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(t, s, l))
+		r.Get("/", GetSettings(s))
+		r.Put("/", UpdateSettings(s))
+	})
+
+	r.Route("/sessions", func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(t, s, l))
+		r.Get("/", ListSessions(s))
+		r.Delete("/{id}", RevokeSession(s))
 	})
 }
 
 // Create new router
-func NewChiRouter(s NoteService, symmetricKey string, tokenDuration time.Duration, l *zerolog.Logger) (*chi.Mux, error) {
+func NewChiRouter(s NoteService, symmetricKey string, tokenDuration time.Duration, refreshDuration time.Duration, l *zerolog.Logger) (*chi.Mux, error) {
 	pm, err := auth.NewPasetoManager(symmetricKey)
 	if err != nil {
 		l.Err(err).Msgf("could not create a new PasetoCreator. %v", err)
 		return nil, err
 	}
 
+	doc, err := openapi.Load()
+	if err != nil {
+		l.Err(err).Msgf("could not load the OpenAPI document. %v", err)
+		return nil, err
+	}
+
 	r := chi.NewRouter()
 
 	registerChiMiddlewares(r, l)
-	registerChiHandlers(r, s, pm, tokenDuration, l)
+	registerChiHandlers(r, s, pm, doc, tokenDuration, refreshDuration, l)
 
 	return r, nil
 }
-
-type Server struct {
-}
-
-func (s *Server) Shutdown() error {
-	return nil
-}
-
-func NewHTTP(r interface{}, address string, logger *zerolog.Logger) (Server, error) {
-	return Server{}, nil
-}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/rs/zerolog"
+	"golang.org/x/net/websocket"
+)
+
+// a paste of a long text arrives as one update
+const collabMaxMessage = 4 << 20
+
+// first message on the socket, carries the whole text
+const collabSync = "sync"
+
+var errUnknownCollabMessage = errors.New("message type must be update or presence")
+
+func toCollabCursor(c *note.Cursor) *models.CollabCursor {
+	if c == nil {
+		return nil
+	}
+
+	return &models.CollabCursor{Anchor: c.Anchor, Head: c.Head}
+}
+
+func toCollabMessage(m note.CollabMessage) models.CollabMessage {
+	msg := models.CollabMessage{
+		Type:     string(m.Type),
+		Site:     m.Site,
+		Username: m.Username,
+		Version:  m.Version,
+		Ops:      m.Ops,
+		Cursor:   toCollabCursor(m.Cursor),
+	}
+	if m.Err != nil {
+		msg.Error = collabError(m.Err)
+	}
+
+	return msg
+}
+
+// Text of an error sent over the socket
+func collabError(err error) string {
+	switch {
+	case errors.Is(err, note.ErrForbidden):
+		return "note is shared with you for reading only"
+	case errors.Is(err, note.ErrNotFound):
+		return "note has been deleted"
+	case errors.Is(err, note.ErrInvalidCollabUpdate),
+		errors.Is(err, note.ErrCollabTooLarge),
+		errors.Is(err, note.ErrCollabClosed),
+		errors.Is(err, errUnknownCollabMessage):
+		return err.Error()
+	default:
+		return "could not save the note"
+	}
+}
+
+// GET /notes/{id}/collab, WebSocket to edit the note together with the users it's shared with.
+// The server sends a sync message with the text, then the updates, cursors and saves of the others.
+// Clients send update messages with their ops and presence messages with their cursor.
+func CollabNote(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+		payload, _ := auth.PayloadFromContext(ctx)
+
+		id, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		peer, state, err := s.JoinCollab(ctx, username, id)
+		switch {
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note with ID %v is not found.", id)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not join the co-editing of note %v. %v", id, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not open the note for editing"}, http.StatusInternalServerError)
+			return
+		}
+		// also frees the seat when the handshake fails
+		defer peer.Leave()
+
+		ws := websocket.Server{
+			Handshake: checkWebSocketOrigin,
+			Handler: func(conn *websocket.Conn) {
+				serveCollab(conn, peer, state, payload.ExpiresAt, l)
+			},
+		}
+		ws.ServeHTTP(w, r)
+	}
+}
+
+func serveCollab(conn *websocket.Conn, peer *note.CollabPeer, state note.CollabState, expiresAt time.Time, l *zerolog.Logger) {
+	defer conn.Close()
+
+	conn.MaxPayloadBytes = collabMaxMessage
+	// the read deadline of the request is still set on the hijacked connection
+	conn.SetReadDeadline(time.Time{})
+
+	// errors are answered by the reader, everything else by the loop below
+	var mu sync.Mutex
+	send := func(m models.CollabMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+		return websocket.JSON.Send(conn, m)
+	}
+
+	first := models.CollabMessage{
+		Type:     collabSync,
+		Site:     peer.Site,
+		ReadOnly: peer.ReadOnly,
+		Version:  state.Version,
+		Elements: state.Elements,
+		Peers:    make([]models.CollabMessage, 0, len(state.Peers)),
+	}
+	for _, p := range state.Peers {
+		first.Peers = append(first.Peers, toCollabMessage(p))
+	}
+	if err := send(first); err != nil {
+		return
+	}
+
+	// a hijacked request's context isn't canceled when the client leaves, reading notices it
+	ctx, cancel := context.WithCancel(conn.Request().Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			var m models.CollabMessage
+			if err := websocket.JSON.Receive(conn, &m); err != nil {
+				return
+			}
+
+			var err error
+			switch {
+			case m.Type == string(note.CollabUpdate):
+				err = peer.Update(m.Ops)
+			case m.Type == string(note.CollabPresence) && m.Cursor != nil:
+				err = peer.SetCursor(note.Cursor{Anchor: m.Cursor.Anchor, Head: m.Cursor.Head})
+			default:
+				err = errUnknownCollabMessage
+			}
+			if err != nil {
+				// the client has to resync when ops of it were refused
+				l.Info().Msgf("Refused a co-editing message of user %s. %v", peer.Username, err)
+				send(models.CollabMessage{Type: string(note.CollabError), Error: collabError(err)})
+			}
+		}
+	}()
+
+	expired := time.NewTimer(time.Until(expiresAt))
+	defer expired.Stop()
+
+	ticker := time.NewTicker(eventsHeartbeat)
+	defer ticker.Stop()
+
+	stopping := serverStopping(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stopping:
+			return
+		case <-expired.C:
+			return
+		case m, ok := <-peer.C:
+			if !ok {
+				return
+			}
+			if m.Type == note.CollabError {
+				l.Error().Err(m.Err).Msgf("Could not save the co-edited note. %v", m.Err)
+			}
+			if err := send(toCollabMessage(m)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			mu.Lock()
+			conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+			conn.PayloadType = websocket.PingFrame
+			_, err := conn.Write(nil)
+			mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/alekslesik/online-note-z/db/mock"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/crdt"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	"github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestCollabNote(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+
+	testCases := []struct {
+		name         string
+		id           string
+		mockSvcCall  func(mocksvc *mocksvc.MockNoteService)
+		expectedCode int
+	}{
+		{
+			name:         "returns bad request - invalid ID",
+			id:           "not-a-uuid",
+			mockSvcCall:  func(mocksvc *mocksvc.MockNoteService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "returns not found",
+			id:   id.String(),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().JoinCollab(gomock.Any(), username, id).Times(1).Return(nil, note.CollabState{}, note.ErrNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name: "returns internal server error",
+			id:   id.String(),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().JoinCollab(gomock.Any(), username, id).Times(1).Return(nil, note.CollabState{}, note.ErrDBInternal)
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/notes/"+tc.id+"/collab", nil)
+			req = withAuthUser(withURLParam(req, "id", tc.id), username)
+			CollabNote(mocksvc).ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestCollabNoteSocket(t *testing.T) {
+	n := db.Note{ID: uuid.New(), Title: "plan", Username: "testuser1", Text: sql.NullString{String: "hi", Valid: true}, Version: 1}
+
+	// a real service, so both sockets share one session
+	ctrl := gomock.NewController(t)
+	mockdb := mockdb.NewMockQuerier(ctrl)
+	ns := note.NewService(mockdb)
+	mockdb.EXPECT().GetNote(gomock.Any(), gomock.Any()).AnyTimes().Return(n, nil)
+	mockdb.EXPECT().GetTagsOfNotes(gomock.Any(), gomock.Any()).AnyTimes().Return([]db.GetTagsOfNotesRow{}, nil)
+
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().JoinCollab(gomock.Any(), n.Username, n.ID).Times(2).DoAndReturn(ns.JoinCollab)
+
+	h := CollabNote(mocksvc)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = withURLParam(r, "id", n.ID.String())
+		h.ServeHTTP(w, withAuthUntil(r, n.Username, time.Hour))
+	}))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	receive := func(conn *websocket.Conn) models.CollabMessage {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var m models.CollabMessage
+		require.NoError(t, websocket.JSON.Receive(conn, &m))
+		return m
+	}
+
+	conn1, err := websocket.Dial(wsURL, "", srv.URL)
+	require.NoError(t, err)
+	defer conn1.Close()
+
+	sync1 := receive(conn1)
+	require.Equal(t, "sync", sync1.Type)
+	require.Len(t, sync1.Elements, 2)
+	require.Empty(t, sync1.Peers)
+
+	conn2, err := websocket.Dial(wsURL, "", srv.URL)
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	sync2 := receive(conn2)
+	require.Len(t, sync2.Peers, 1)
+	require.Equal(t, sync1.Site, sync2.Peers[0].Site)
+	require.Equal(t, "presence", receive(conn1).Type)
+
+	// the second client types "!" at the end
+	op := crdt.Op{Type: crdt.OpInsert, ID: crdt.ID{Seq: 3, Site: sync2.Site}, After: sync2.Elements[1].ID, Value: "!"}
+	require.NoError(t, websocket.JSON.Send(conn2, models.CollabMessage{Type: "update", Ops: []crdt.Op{op}}))
+
+	update := receive(conn1)
+	require.Equal(t, "update", update.Type)
+	require.Equal(t, sync2.Site, update.Site)
+	require.Equal(t, []crdt.Op{op}, update.Ops)
+
+	// refused messages are answered with an error
+	require.NoError(t, websocket.JSON.Send(conn2, models.CollabMessage{Type: "bogus"}))
+	refused := receive(conn2)
+	require.Equal(t, "error", refused.Type)
+	require.NotEmpty(t, refused.Error)
+
+	conn2.Close()
+	require.Equal(t, "leave", receive(conn1).Type)
+
+	// the last one leaving saves the note
+	saved := make(chan string, 1)
+	mockdb.EXPECT().GetNoteAccess(gomock.Any(), gomock.Any()).Times(1).Return(db.GetNoteAccessRow{Owner: n.Username, Permission: "owner"}, nil)
+	mockdb.EXPECT().UpdateNote(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg *db.UpdateNoteParams) (int32, error) {
+			saved <- arg.Text.String
+			return 2, nil
+		})
+	mockdb.EXPECT().CreateNoteRevision(gomock.Any(), n.ID).Times(1).Return(int32(2), nil)
+
+	conn1.Close()
+	select {
+	case text := <-saved:
+		require.Equal(t, "hi!", text)
+	case <-time.After(5 * time.Second):
+		t.Fatal("note was not saved")
+	}
+	require.NoError(t, ns.WaitCollab(context.Background()))
+}
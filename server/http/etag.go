@@ -0,0 +1,84 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	"github.com/rs/zerolog"
+)
+
+var (
+	errMissingIfMatch = errors.New("If-Match header is required")
+	errInvalidETag    = errors.New("If-Match header must be a note ETag")
+)
+
+// Format a note version as a strong ETag
+func noteETag(version int32) string {
+	return strconv.Quote(strconv.FormatInt(int64(version), 10))
+}
+
+// Parse a note version from an ETag, weak ETags are accepted too
+func parseETag(etag string) (int32, error) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+
+	v, err := strconv.Unquote(etag)
+	if err != nil {
+		return 0, errInvalidETag
+	}
+
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || n <= 0 {
+		return 0, errInvalidETag
+	}
+
+	return int32(n), nil
+}
+
+// Read the version expected by the client from If-Match.
+// "*" matches any version and is returned as 0.
+func ifMatchVersion(r *http.Request) (int32, error) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+
+	switch h {
+	case "":
+		return 0, errMissingIfMatch
+	case "*":
+		return 0, nil
+	default:
+		return parseETag(h)
+	}
+}
+
+// Get the expected version from the request, write 428 or 400 if it's missing or malformed
+func expectedVersion(w http.ResponseWriter, r *http.Request, l *zerolog.Logger) (int32, bool) {
+	version, err := ifMatchVersion(r)
+	switch {
+	case errors.Is(err, errMissingIfMatch):
+		l.Info().Msgf("Request to %s has no If-Match header", r.URL.Path)
+		httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusPreconditionRequired)
+		return 0, false
+	case err != nil:
+		l.Info().Msgf("Request to %s has an invalid If-Match header %q", r.URL.Path, r.Header.Get("If-Match"))
+		httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
+		return 0, false
+	}
+
+	return version, true
+}
+
+// Write 412 with the current server copy of the note and its ETag
+func versionMismatch(w http.ResponseWriter, err error, l *zerolog.Logger) {
+	var mismatch *note.VersionMismatchError
+	if !errors.As(err, &mismatch) {
+		httplib.JSON(w, httplib.Msg{"error": note.ErrVersionMismatch.Error()}, http.StatusPreconditionFailed)
+		return
+	}
+
+	l.Info().Msgf("Note %v has been changed, current version is %d", mismatch.Current.ID, mismatch.Current.Version)
+	w.Header().Set("ETag", noteETag(mismatch.Current.Version))
+	httplib.JSON(w, toNoteModel(mismatch.Current), http.StatusPreconditionFailed)
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/alekslesik/online-note-z/lib/events"
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/rs/zerolog"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// keeps proxies from closing an idle stream
+	eventsHeartbeat = 25 * time.Second
+	// how long an EventSource waits before reconnecting
+	eventsRetry = 3 * time.Second
+	// a client that can't take an event in this time is gone
+	eventsWriteTimeout = 10 * time.Second
+	// clients don't send anything but control frames
+	wsMaxPayload = 1024
+)
+
+var errStreamEnded = errors.New("event stream ended")
+
+func toEventModel(e events.Event) models.Event {
+	return models.Event{
+		ID:      e.ID,
+		Type:    string(e.Type),
+		NoteID:  e.NoteID,
+		Version: e.Version,
+		At:      e.At,
+	}
+}
+
+// Pass the events of sub to send until ctx is done, the server stops or the access token expires.
+// heartbeat is called when there was nothing to send for a while. Returns errStreamEnded when
+// the subscription was dropped for falling behind, the client has to refetch its notes then.
+func pumpEvents(ctx context.Context, sub *events.Subscription, expiresAt time.Time, send func(models.Event) error, heartbeat func() error) error {
+	expired := time.NewTimer(time.Until(expiresAt))
+	defer expired.Stop()
+
+	ticker := time.NewTicker(eventsHeartbeat)
+	defer ticker.Stop()
+
+	stopping := serverStopping(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-stopping:
+			return nil
+		case <-expired.C:
+			// the client reconnects with a refreshed token
+			return nil
+		case e, ok := <-sub.C:
+			if !ok {
+				return errStreamEnded
+			}
+			if err := send(toEventModel(e)); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GET /events, streams created/updated/deleted events of the user's notes as server-sent events
+func StreamEvents(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+		payload, _ := auth.PayloadFromContext(ctx)
+
+		// the stream outlives the write timeout of the server
+		rc := http.NewResponseController(w)
+		rc.SetWriteDeadline(time.Time{})
+
+		sub := s.SubscribeEvents(username)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-store")
+		// stop nginx from buffering the stream
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: %d\n\n", eventsRetry.Milliseconds())
+		err := rc.Flush()
+		if err != nil {
+			l.Error().Err(err).Msgf("Could not stream events, the response can't be flushed. %v", err)
+			return
+		}
+
+		err = pumpEvents(r.Context(), sub, payload.ExpiresAt,
+			func(e models.Event) error {
+				data, err := json.Marshal(e)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+				return rc.Flush()
+			},
+			func() error {
+				fmt.Fprint(w, ": heartbeat\n\n")
+				return rc.Flush()
+			})
+
+		switch {
+		case errors.Is(err, errStreamEnded):
+			l.Info().Msgf("Event stream of user %s fell behind and was closed", username)
+		case err != nil:
+			l.Info().Msgf("Event stream of user %s ended. %v", username, err)
+		}
+	}
+}
+
+// Accept browsers on the API's own host or an allowed origin, and clients that send no origin.
+// The socket is authenticated by cookie, so any other site could otherwise open it for the user.
+func checkWebSocketOrigin(config *websocket.Config, r *http.Request) error {
+	if r.Header.Get("Origin") == "" {
+		return nil
+	}
+
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	config.Origin = origin
+
+	if origin.Host == r.Host {
+		return nil
+	}
+	for _, allowed := range allowedOrigins {
+		if u, err := url.Parse(allowed); err == nil && u.Scheme == origin.Scheme && u.Host == origin.Host {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("origin %s is not allowed", origin)
+}
+
+// GET /events/ws, the events of GET /events over a WebSocket, one JSON message per event
+func EventsWebSocket(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+		payload, _ := auth.PayloadFromContext(ctx)
+
+		ws := websocket.Server{
+			Handshake: checkWebSocketOrigin,
+			Handler: func(conn *websocket.Conn) {
+				serveEventsWebSocket(conn, s, username, payload.ExpiresAt, l)
+			},
+		}
+		ws.ServeHTTP(w, r)
+	}
+}
+
+func serveEventsWebSocket(conn *websocket.Conn, s NoteService, username string, expiresAt time.Time, l *zerolog.Logger) {
+	defer conn.Close()
+
+	conn.MaxPayloadBytes = wsMaxPayload
+	// the read deadline of the request is still set on the hijacked connection
+	conn.SetReadDeadline(time.Time{})
+
+	sub := s.SubscribeEvents(username)
+	defer sub.Close()
+
+	// a hijacked request's context isn't canceled when the client leaves, reading notices it
+	ctx, cancel := context.WithCancel(conn.Request().Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		var msg []byte
+		for {
+			if err := websocket.Message.Receive(conn, &msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	err := pumpEvents(ctx, sub, expiresAt,
+		func(e models.Event) error {
+			conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+			return websocket.JSON.Send(conn, e)
+		},
+		func() error {
+			conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+			conn.PayloadType = websocket.PingFrame
+			_, err := conn.Write(nil)
+			return err
+		})
+
+	switch {
+	case errors.Is(err, errStreamEnded):
+		l.Info().Msgf("Event socket of user %s fell behind and was closed", username)
+	case err != nil:
+		l.Info().Msgf("Event socket of user %s ended. %v", username, err)
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alekslesik/online-note-z/lib/events"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// Authenticate the request as username with a token valid for ttl
+func withAuthUntil(r *http.Request, username string, ttl time.Duration) *http.Request {
+	return r.WithContext(auth.NewContext(r.Context(), &auth.PasetoPayload{Username: username, ExpiresAt: time.Now().Add(ttl)}))
+}
+
+func TestStreamEvents(t *testing.T) {
+	const username = "testuser1"
+	e := events.Event{ID: uuid.New(), Type: events.NoteUpdated, NoteID: uuid.New(), Username: username, Version: 2, At: time.Now()}
+
+	t.Run("streaming events OK - ends when the token expires", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mocksvc := mocksvc.NewMockNoteService(ctrl)
+		bus := events.NewBus(4)
+		var sub *events.Subscription
+
+		mocksvc.EXPECT().SubscribeEvents(username).Times(1).DoAndReturn(func(username string) *events.Subscription {
+			sub = bus.Subscribe(username)
+			bus.Publish(context.Background(), e)
+			return sub
+		})
+
+		rec := httptest.NewRecorder()
+		req := withAuthUntil(httptest.NewRequest(http.MethodGet, "/events", nil), username, 200*time.Millisecond)
+		StreamEvents(mocksvc).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+
+		body := rec.Body.String()
+		require.True(t, strings.HasPrefix(body, "retry: 3000\n\n"))
+		require.Contains(t, body, "id: "+e.ID.String()+"\nevent: note.updated\ndata: {")
+		// the subscription is closed with the stream
+		_, ok := <-sub.C
+		require.False(t, ok)
+	})
+
+	t.Run("streaming events ends when the client leaves", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mocksvc := mocksvc.NewMockNoteService(ctrl)
+
+		mocksvc.EXPECT().SubscribeEvents(username).Times(1).Return(events.NewBus(4).Subscribe(username))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		rec := httptest.NewRecorder()
+		req := withAuthUntil(httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx), username, time.Hour)
+		StreamEvents(mocksvc).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("returns unauthorized", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mocksvc := mocksvc.NewMockNoteService(ctrl)
+
+		rec := httptest.NewRecorder()
+		StreamEvents(mocksvc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestEventsWebSocket(t *testing.T) {
+	const username = "testuser1"
+	e := events.Event{ID: uuid.New(), Type: events.NoteCreated, NoteID: uuid.New(), Username: username, Version: 1, At: time.Now()}
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	bus := events.NewBus(4)
+	subscribed := make(chan struct{})
+
+	mocksvc.EXPECT().SubscribeEvents(username).Times(1).DoAndReturn(func(username string) *events.Subscription {
+		defer close(subscribed)
+		return bus.Subscribe(username)
+	})
+
+	h := EventsWebSocket(mocksvc)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, withAuthUntil(r, username, time.Hour))
+	}))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	_, err := websocket.Dial(wsURL, "", "http://evil.example.com")
+	require.Error(t, err)
+
+	conn, err := websocket.Dial(wsURL, "", srv.URL)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	<-subscribed
+	bus.Publish(context.Background(), e)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var got models.Event
+	require.NoError(t, websocket.JSON.Receive(conn, &got))
+	require.Equal(t, e.ID, got.ID)
+	require.Equal(t, "note.created", got.Type)
+	require.Equal(t, e.NoteID, got.NoteID)
+}
+
+func TestCheckWebSocketOrigin(t *testing.T) {
+	testCases := []struct {
+		origin  string
+		wantErr bool
+	}{
+		{origin: ""},
+		{origin: "http://api.example.com"},
+		{origin: "http://localhost:3000"},
+		{origin: "https://localhost:3000", wantErr: true},
+		{origin: "http://evil.example.com", wantErr: true},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.origin, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "http://api.example.com/events/ws", nil)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+
+			err := checkWebSocketOrigin(&websocket.Config{Version: websocket.ProtocolVersionHybi13}, r)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEventModel(t *testing.T) {
+	e := events.Event{ID: uuid.New(), Type: events.NoteDeleted, NoteID: uuid.New(), Username: "testuser1"}
+
+	b, err := json.Marshal(toEventModel(e))
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "testuser1")
+	require.NotContains(t, string(b), "version")
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+)
+
+// an export of many notes may outlast the server write timeout
+const exportTimeout = 10 * time.Minute
+
+// Sends the ZIP headers with the first byte of the archive,
+// so a failure before that can still be answered with an error.
+type exportWriter struct {
+	w        http.ResponseWriter
+	filename string
+	started  bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", "application/zip")
+		e.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.filename))
+		e.w.WriteHeader(http.StatusOK)
+	}
+
+	return e.w.Write(p)
+}
+
+// GET /export, streams a ZIP of all notes as Markdown files
+func ExportNotes(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, _, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		rc := http.NewResponseController(w)
+		rc.SetWriteDeadline(time.Now().Add(exportTimeout))
+		ctx, cancelExport := context.WithTimeout(r.Context(), exportTimeout)
+		defer cancelExport()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		ew := &exportWriter{w: w, filename: fmt.Sprintf("notes-%s.zip", time.Now().UTC().Format("2006-01-02"))}
+		err := s.ExportNotes(ctx, username, ew)
+		if err == nil {
+			return
+		}
+
+		if !ew.started {
+			l.Error().Err(err).Msgf("Could not export the notes of user %s. %v", username, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not export notes"}, http.StatusInternalServerError)
+			return
+		}
+
+		// a truncated archive must not look like a complete download
+		l.Error().Err(err).Msgf("Export of the notes of user %s failed midway. %v", username, err)
+		panic(http.ErrAbortHandler)
+	}
+}
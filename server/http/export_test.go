@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportNotes(t *testing.T) {
+	const username = "testuser1"
+
+	testCases := []struct {
+		name          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		wantPanic     bool
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "exporting notes OK",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ExportNotes(gomock.Any(), username, gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, _ string, w io.Writer) error {
+						_, err := w.Write([]byte("PK\x03\x04"))
+						return err
+					})
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+				require.Regexp(t, `^attachment; filename="notes-\d{4}-\d{2}-\d{2}\.zip"$`, rec.Header().Get("Content-Disposition"))
+				require.Equal(t, "PK\x03\x04", rec.Body.String())
+			},
+		},
+		{
+			name: "returns internal server error - nothing written yet",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ExportNotes(gomock.Any(), username, gomock.Any()).Times(1).Return(note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+				require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			},
+		},
+		{
+			name: "aborts the response - failed midway",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ExportNotes(gomock.Any(), username, gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, _ string, w io.Writer) error {
+						w.Write([]byte("PK\x03\x04"))
+						return errors.New("connection reset")
+					})
+			},
+			wantPanic: true,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := withAuthUser(httptest.NewRequest(http.MethodGet, "/export", nil), username)
+
+			handler := ExportNotes(mocksvc)
+			if tc.wantPanic {
+				require.PanicsWithValue(t, http.ErrAbortHandler, func() { handler(rec, req) })
+				return
+			}
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+)
+
+const (
+	maxImportSize = 100 << 20
+	// creating thousands of notes may outlast the server timeouts
+	importTimeout = 10 * time.Minute
+)
+
+func toImportReportModel(report note.ImportReport) models.ImportReport {
+	resp := models.ImportReport{
+		Imported: report.Imported,
+		Failed:   report.Failed,
+		Items:    make([]models.ImportResult, 0, len(report.Results)),
+	}
+
+	for _, res := range report.Results {
+		item := models.ImportResult{Source: res.Source, Title: res.Title}
+		if res.Err != nil {
+			item.Error = res.Err.Error()
+		} else {
+			id := res.ID
+			item.ID = &id
+		}
+		resp.Items = append(resp.Items, item)
+	}
+
+	return resp
+}
+
+// POST /import, multipart form with a "file" field holding a Markdown ZIP, an ENEX file or a Keep Takeout archive.
+// ?format=markdown|enex|keep skips the detection.
+func ImportNotes(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, _, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		rc := http.NewResponseController(w)
+		deadline := time.Now().Add(importTimeout)
+		rc.SetReadDeadline(deadline)
+		rc.SetWriteDeadline(deadline)
+		ctx, cancelImport := context.WithTimeout(r.Context(), importTimeout)
+		defer cancelImport()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		switch format {
+		case note.ImportAuto, note.ImportMarkdown, note.ImportENEX, note.ImportKeep:
+		default:
+			l.Info().Msgf("Invalid import format %q", format)
+			httplib.JSON(w, httplib.Msg{"error": "format must be markdown, enex or keep"}, http.StatusBadRequest)
+			return
+		}
+
+		// leave some room for the multipart framing
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+1<<20)
+		err := r.ParseMultipartForm(maxMultipartMemory)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				l.Info().Msgf("Import of user %s is too large", username)
+				httplib.JSON(w, httplib.Msg{"error": "imports must be at most 100 MiB"}, http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			l.Info().Msgf("Could not parse the import upload. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "expected a multipart form with a file field"}, http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			l.Info().Msgf("Import upload has no file field. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "expected a multipart form with a file field"}, http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+
+		if header.Size > maxImportSize {
+			l.Info().Msgf("Import of user %s is too large", username)
+			httplib.JSON(w, httplib.Msg{"error": "imports must be at most 100 MiB"}, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		report, err := s.ImportNotes(ctx, username, format, f, header.Size)
+		switch {
+		case errors.Is(err, note.ErrInvalidImport):
+			l.Info().Msgf("Unsupported import file %q of user %s", header.Filename, username)
+			httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
+			return
+		case errors.Is(err, note.ErrImportTooLarge):
+			l.Info().Msgf("Import file %q of user %s has too many files", header.Filename, username)
+			httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			// notes imported before the failure stay, the report tells which
+			l.Error().Err(err).Msgf("Import of user %s failed after %d notes. %v", username, report.Imported, err)
+			resp := toImportReportModel(report)
+			resp.Error = "import stopped by an internal error"
+			httplib.JSON(w, resp, http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("Imported %d notes of user %s, %d failed", report.Imported, username, report.Failed)
+		httplib.JSON(w, toImportReportModel(report), http.StatusOK)
+	}
+}
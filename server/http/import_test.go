@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportNotes(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+
+	testCases := []struct {
+		name          string
+		query         string
+		field         string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "importing notes OK",
+			field: "file",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ImportNotes(gomock.Any(), username, note.ImportAuto, gomock.Any(), int64(len("export"))).Times(1).
+					DoAndReturn(func(_ context.Context, _ string, _ string, r io.ReaderAt, size int64) (note.ImportReport, error) {
+						b := make([]byte, size)
+						_, err := r.ReadAt(b, 0)
+						require.NoError(t, err)
+						require.Equal(t, "export", string(b))
+
+						return note.ImportReport{Imported: 1, Failed: 1, Results: []note.ImportResult{
+							{Source: "a.md", Title: "Note a", ID: id},
+							{Source: "b.md", Title: "Note b", Err: note.ErrInvalidFrontMatter},
+						}}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var report models.ImportReport
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+				require.Equal(t, 1, report.Imported)
+				require.Equal(t, 1, report.Failed)
+				require.Equal(t, id, *report.Items[0].ID)
+				require.Nil(t, report.Items[1].ID)
+				require.Equal(t, note.ErrInvalidFrontMatter.Error(), report.Items[1].Error)
+			},
+		},
+		{
+			name:  "importing with a format OK",
+			query: "?format=enex",
+			field: "file",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ImportNotes(gomock.Any(), username, note.ImportENEX, gomock.Any(), gomock.Any()).Times(1).Return(note.ImportReport{}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:  "returns bad request - unknown format",
+			query: "?format=onenote",
+			field: "file",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ImportNotes(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:  "returns bad request - unsupported file",
+			field: "file",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ImportNotes(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(note.ImportReport{}, note.ErrInvalidImport)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:  "returns bad request - no file field",
+			field: "upload",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ImportNotes(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:  "returns internal server error with the report so far",
+			field: "file",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ImportNotes(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+					Return(note.ImportReport{Imported: 1, Results: []note.ImportResult{{Source: "a.md", Title: "Note a", ID: id}}}, note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+				var report models.ImportReport
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+				require.Equal(t, 1, report.Imported)
+				require.NotEmpty(t, report.Error)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			body, contentType := multipartFile(t, tc.field, "export.zip", "export")
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/import"+tc.query, body)
+			req.Header.Set("Content-Type", contentType)
+			req = withAuthUser(req, username)
+
+			handler := ImportNotes(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"net/http"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Get the link ID from the URL
+func linkIDParam(w http.ResponseWriter, r *http.Request, l *zerolog.Logger) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "linkID"))
+	if err != nil {
+		l.Info().Msgf("Could not convert link ID to UUID.")
+		httplib.JSON(w, httplib.Msg{"error": "could not convert link id to uuid"}, http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func toNoteLinkModel(link db.NoteLink) models.NoteLink {
+	return models.NoteLink{
+		ID:          link.ID,
+		HasPassword: link.PasswordHash.Valid,
+		ExpiresAt:   timePtr(link.ExpiresAt),
+		Views:       link.Views,
+		CreatedAt:   link.CreatedAt,
+	}
+}
+
+// POST /notes/{id}/links
+func CreateLink(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		// both fields are optional, an empty body makes an open link that never expires
+		linkRequest := struct {
+			Password  string     `json:"password"`
+			ExpiresAt *time.Time `json:"expiresAt"`
+		}{}
+
+		err := json.NewDecoder(r.Body).Decode(&linkRequest)
+		if err != nil && !errors.Is(err, io.EOF) {
+			l.Info().Msgf("Invalid link request for Note %v. %v", noteID, err)
+			httplib.JSON(w, httplib.Msg{"error": "wrongly formatted link request"}, http.StatusBadRequest)
+			return
+		}
+
+		var expiresAt time.Time
+		if linkRequest.ExpiresAt != nil {
+			expiresAt = *linkRequest.ExpiresAt
+		}
+
+		link, token, err := s.CreateLink(ctx, username, noteID, linkRequest.Password, expiresAt)
+		switch {
+		case errors.Is(err, note.ErrInvalidLinkExpiry), errors.Is(err, note.ErrInvalidLinkPassword):
+			l.Info().Msgf("Could not create a link to Note %v. %v", noteID, err)
+			httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
+			return
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note %v of user %s is not found!", noteID, username)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not create a link to Note %v. %v", noteID, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not create link"}, http.StatusInternalServerError)
+			return
+		}
+
+		resp := toNoteLinkModel(link)
+		resp.Token = token
+		resp.URL = "/p/" + token
+
+		l.Info().Msgf("Link %v to Note %v has been created", link.ID, noteID)
+		httplib.JSON(w, resp, http.StatusCreated)
+	}
+}
+
+// GET /notes/{id}/links
+func ListLinks(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		links, err := s.ListLinks(ctx, username, noteID)
+		switch {
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note %v of user %s is not found!", noteID, username)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not list the links to Note %v. %v", noteID, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not retrieve note links"}, http.StatusInternalServerError)
+			return
+		}
+
+		resp := make([]models.NoteLink, 0, len(links))
+		for _, link := range links {
+			resp = append(resp, toNoteLinkModel(link))
+		}
+
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
+
+// DELETE /notes/{id}/links/{linkID}
+func RevokeLink(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		linkID, ok := linkIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		err := s.RevokeLink(ctx, username, noteID, linkID)
+		switch {
+		case errors.Is(err, note.ErrLinkNotFound):
+			l.Info().Msgf("Link %v to Note %v is not found", linkID, noteID)
+			httplib.JSON(w, httplib.Msg{"error": "link is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not revoke link %v. %v", linkID, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not revoke link"}, http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("Link %v to Note %v has been revoked", linkID, noteID)
+		httplib.JSON(w, httplib.Msg{"success": "link revoked"}, http.StatusOK)
+	}
+}
+
+// Ask the browser for the link password, the user name of the prompt is ignored
+func linkPasswordChallenge(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="note", charset="UTF-8"`)
+	httplib.JSON(w, httplib.Msg{"error": msg}, http.StatusUnauthorized)
+}
+
+// GET /p/{token}, public. The password of a protected link is sent with HTTP Basic auth.
+func OpenLink(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		// the token is a secret, keep it out of caches and referrers
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("X-Robots-Tag", "noindex")
+
+		_, pass, _ := r.BasicAuth()
+
+		token := chi.URLParam(r, "token")
+		n, body, err := s.OpenLink(ctx, token, pass)
+		switch {
+		case errors.Is(err, note.ErrLinkNotFound):
+			l.Info().Msgf("Request for an unknown note link")
+			httplib.JSON(w, httplib.Msg{"error": "link is not found"}, http.StatusNotFound)
+			return
+		case errors.Is(err, note.ErrLinkExpired):
+			l.Info().Msgf("Request for an expired note link")
+			httplib.JSON(w, httplib.Msg{"error": "link has expired"}, http.StatusGone)
+			return
+		case errors.Is(err, note.ErrLinkPasswordRequired):
+			linkPasswordChallenge(w, "link is protected by a password")
+			return
+		case errors.Is(err, note.ErrLinkPasswordIncorrect):
+			l.Info().Msgf("Wrong password for the note link with token hash %s...", note.LinkTokenHashPrefix(token))
+			linkPasswordChallenge(w, "password is incorrect")
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not open note link. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "could not open link"}, http.StatusInternalServerError)
+			return
+		}
+
+		title := html.EscapeString(n.Title)
+		writeRenderedNote(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>"+title+"</title>\n</head>\n<body>\n<h1>"+title+"</h1>\n"+body+"</body>\n</html>\n")
+	}
+}
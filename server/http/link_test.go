@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateLink(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+	linkID := uuid.New()
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		body          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "creating link OK",
+			body: `{"password": "secret", "expiresAt": "2030-01-02T03:04:05Z"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateLink(gomock.Any(), username, noteID, "secret", expiresAt).Times(1).
+					Return(db.NoteLink{ID: linkID, NoteID: noteID}, "tok", nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, rec.Code)
+
+				var link models.NoteLink
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&link))
+				require.Equal(t, linkID, link.ID)
+				require.Equal(t, "tok", link.Token)
+				require.Equal(t, "/p/tok", link.URL)
+			},
+		},
+		{
+			name: "creating open link without a body OK",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateLink(gomock.Any(), username, noteID, "", time.Time{}).Times(1).Return(db.NoteLink{ID: linkID}, "tok", nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - expiry in the past",
+			body: `{"expiresAt": "2020-01-01T00:00:00Z"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateLink(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(db.NoteLink{}, "", note.ErrInvalidLinkExpiry)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/notes/"+noteID.String()+"/links", strings.NewReader(tc.body))
+			req = withURLParam(withAuthUser(req, username), "id", noteID.String())
+
+			CreateLink(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestOpenLink(t *testing.T) {
+	const token = "tok"
+
+	testCases := []struct {
+		name          string
+		password      string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "opening link OK",
+			password: "secret",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				n := note.Note{Note: db.Note{Title: "<Plan>"}}
+				mocksvc.EXPECT().OpenLink(gomock.Any(), token, "secret").Times(1).Return(n, "<p>steps</p>\n", nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+				require.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+				require.Contains(t, rec.Body.String(), "<title>&lt;Plan&gt;</title>")
+				require.Contains(t, rec.Body.String(), "<p>steps</p>")
+			},
+		},
+		{
+			name: "returns unauthorized - password required",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenLink(gomock.Any(), token, "").Times(1).Return(note.Note{}, "", note.ErrLinkPasswordRequired)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+				require.Contains(t, rec.Header().Get("WWW-Authenticate"), "Basic")
+			},
+		},
+		{
+			name: "returns gone - link expired",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenLink(gomock.Any(), token, "").Times(1).Return(note.Note{}, "", note.ErrLinkExpired)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, rec.Code)
+			},
+		},
+		{
+			name: "returns not found - link revoked",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenLink(gomock.Any(), token, "").Times(1).Return(note.Note{}, "", note.ErrLinkNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/p/"+token, nil)
+			if tc.password != "" {
+				req.SetBasicAuth("", tc.password)
+			}
+			req = withURLParam(req, "token", token)
+
+			OpenLink(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/alekslesik/online-note-z/lib/crdt"
 	"github.com/google/uuid"
 )
 
@@ -13,10 +14,196 @@ type User struct {
 }
 
 type Note struct {
-	ID        uuid.UUID `json:"id"`
-	Title     string    `json:"title" validate:"required,min=4"`
-	User      string    `json:"user" validate:"required"`
+	ID         uuid.UUID  `json:"id"`
+	Title      string     `json:"title" validate:"required,min=4"`
+	User       string     `json:"user"`
+	Text       string     `json:"text"`
+	Tags       []string   `json:"tags"`
+	NotebookID *uuid.UUID `json:"notebookId"`
+	Version    int32      `json:"version"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	DeletedAt  *time.Time `json:"deletedAt,omitempty"`
+	// read or edit on notes shared with the user
+	Permission string `json:"permission,omitempty"`
+}
+
+// Page of the notes listing, next_cursor is empty on the last page
+type NotePage struct {
+	Notes      []Note `json:"notes"`
+	NextCursor string `json:"next_cursor"`
+	Total      int64  `json:"total"`
+}
+
+// Highlights wrap the matched words in <mark></mark>, everything else is HTML escaped
+type SearchResult struct {
+	ID             uuid.UUID `json:"id"`
+	Title          string    `json:"title"`
+	Text           string    `json:"text"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	Rank           float32   `json:"rank"`
+	TitleHighlight string    `json:"titleHighlight"`
+	Snippet        string    `json:"snippet"`
+}
+
+type Tag struct {
+	Name      string `json:"name"`
+	NoteCount int64  `json:"noteCount"`
+}
+
+type Notebook struct {
+	ID        uuid.UUID  `json:"id"`
+	ParentID  *uuid.UUID `json:"parentId"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Notebook with all notebooks and notes below it
+type NotebookTree struct {
+	Notebook
+	Notebooks []NotebookTree `json:"notebooks"`
+	Notes     []Note         `json:"notes"`
+}
+
+type RevisionInfo struct {
+	Revision   int32     `json:"revision"`
+	Title      string    `json:"title"`
+	TextLength int32     `json:"textLength"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type Revision struct {
+	NoteID    uuid.UUID `json:"noteId"`
+	Revision  int32     `json:"revision"`
+	Title     string    `json:"title"`
 	Text      string    `json:"text"`
 	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type Attachment struct {
+	ID          uuid.UUID `json:"id"`
+	NoteID      uuid.UUID `json:"noteId"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	ClientIP   string    `json:"clientIp"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
+// Outcome of one imported file or note, id is set on success and error on failure
+type ImportResult struct {
+	Source string     `json:"source"`
+	Title  string     `json:"title"`
+	ID     *uuid.UUID `json:"id,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// Error is set when the import stopped early, the items imported before stay
+type ImportReport struct {
+	Imported int            `json:"imported"`
+	Failed   int            `json:"failed"`
+	Items    []ImportResult `json:"items"`
+	Error    string         `json:"error,omitempty"`
+}
+
+type Settings struct {
+	AllowDuplicateTitles bool `json:"allowDuplicateTitles"`
+}
+
+type Share struct {
+	Username   string    `json:"username" validate:"required"`
+	Permission string    `json:"permission" validate:"required,oneof=read edit"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Token and url are only returned when the link is created
+type NoteLink struct {
+	ID          uuid.UUID  `json:"id"`
+	Token       string     `json:"token,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	HasPassword bool       `json:"hasPassword"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	Views       int64      `json:"views"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// Change of one of the user's notes, pushed over /events
+type Event struct {
+	ID      uuid.UUID `json:"id"`
+	Type    string    `json:"type"`
+	NoteID  uuid.UUID `json:"noteId"`
+	Version int32     `json:"version,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Message of a note co-editing socket, which fields are set depends on the type
+type CollabMessage struct {
+	Type     string          `json:"type"`
+	Site     string          `json:"site,omitempty"`
+	Username string          `json:"username,omitempty"`
+	ReadOnly bool            `json:"readOnly,omitempty"`
+	Version  int32           `json:"version,omitempty"`
+	Ops      []crdt.Op       `json:"ops,omitempty"`
+	Cursor   *CollabCursor   `json:"cursor,omitempty"`
+	Elements []crdt.Element  `json:"elements,omitempty"`
+	Peers    []CollabMessage `json:"peers,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Zero IDs are the start of the text
+type CollabCursor struct {
+	Anchor crdt.ID `json:"anchor"`
+	Head   crdt.ID `json:"head"`
+}
+
+// Change made by an offline client. A base version of 0 creates the note with the client's ID.
+type SyncChange struct {
+	ID          uuid.UUID  `json:"id" validate:"required"`
+	Op          string     `json:"op" validate:"required,oneof=upsert delete"`
+	Title       string     `json:"title" validate:"required_if=Op upsert,omitempty,min=4"`
+	Text        string     `json:"text"`
+	Tags        []string   `json:"tags"`
+	NotebookID  *uuid.UUID `json:"notebookId"`
+	BaseVersion int32      `json:"baseVersion" validate:"min=0"`
+}
+
+// An empty token fetches every note of the user
+type SyncRequest struct {
+	Token   string       `json:"token"`
+	Changes []SyncChange `json:"changes" validate:"max=500,dive"`
+}
+
+// Outcome of a client change, current is the server copy on a conflict
+type SyncResult struct {
+	ID      uuid.UUID `json:"id"`
+	Status  string    `json:"status"`
+	Version int32     `json:"version,omitempty"`
+	Current *Note     `json:"current,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Change on the server, note is left out on deletes
+type SyncServerChange struct {
+	ID   uuid.UUID `json:"id"`
+	Op   string    `json:"op"`
+	Note *Note     `json:"note,omitempty"`
+}
+
+// Clients sync again with token right away while hasMore is set
+type SyncResponse struct {
+	Token   string             `json:"token"`
+	Results []SyncResult       `json:"results"`
+	Changes []SyncServerChange `json:"changes"`
+	HasMore bool               `json:"hasMore"`
 }
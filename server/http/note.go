@@ -1,21 +1,51 @@
 package server
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"errors"
 	// "flag"
+	"fmt"
 	"net/http"
-	"strings"
+	"net/url"
+	"strconv"
+	"time"
 
 	httplib "github.com/alekslesik/online-note-z/lib/http"
 	"github.com/alekslesik/online-note-z/note"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
 	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 	// "golang.org/x/text/cases"
 )
 
+// Get the authenticated username set by auth.AuthMiddleware, write 401 if it's missing
+func authUsername(w http.ResponseWriter, ctx context.Context, l *zerolog.Logger) (string, bool) {
+	payload, ok := auth.PayloadFromContext(ctx)
+	if !ok || payload.Username == "" {
+		l.Error().Msg("authenticated user is missing from the request context")
+		httplib.JSON(w, httplib.Msg{"error": "user is not authenticated"}, http.StatusUnauthorized)
+		return "", false
+	}
+
+	return payload.Username, true
+}
+
+// Get the note ID from the URL, write 400 if it's not a UUID
+func noteIDParam(w http.ResponseWriter, r *http.Request, l *zerolog.Logger) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		l.Info().Msgf("Could not convert ID to UUID.")
+		httplib.JSON(w, httplib.Msg{"error": "could not convert note id to uuid"}, http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
 // POST /notes/create
 func CreateNote(s NoteService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -23,6 +53,12 @@ func CreateNote(s NoteService) http.HandlerFunc {
 		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
 		defer cancel()
 
+		// the owner is always the authenticated user
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
 		// models.Note instance
 		var noteRequest models.Note
 
@@ -45,12 +81,20 @@ func CreateNote(s NoteService) http.HandlerFunc {
 		}
 
 		// create node in DB
-		retID, err := s.CreateNote(ctx, noteRequest.Title, noteRequest.User, noteRequest.Text)
+		retID, err := s.CreateNote(ctx, noteRequest.Title, username, noteRequest.Text, noteRequest.Tags, nullUUID(noteRequest.NotebookID))
 
 		switch {
+		case errors.Is(err, note.ErrNotebookNotFound):
+			l.Info().Msgf("Note creation failed, notebook %v is not found", noteRequest.NotebookID)
+			httplib.JSON(w, httplib.Msg{"error": "notebook is not found"}, http.StatusNotFound)
+			return
+		case errors.Is(err, note.ErrInvalidTag):
+			l.Info().Msgf("Note creation failed, invalid tags %v", noteRequest.Tags)
+			httplib.JSON(w, httplib.Msg{"error": "tags must be 1-50 characters long"}, http.StatusBadRequest)
+			return
 		case errors.Is(err, note.ErrAlreadyExists):
-			l.Error().Err(err).Msgf("Note creation failed, a note with that title already exists")
-			httplib.JSON(w, httplib.Msg{"error": "a Note with that title already exists! Titles must be unique."}, http.StatusForbidden)
+			l.Info().Msgf("Note creation failed, a note with that title already exists")
+			titleConflict(w, err)
 			return
 		case errors.Is(err, note.ErrDBInternal):
 			l.Error().Err(err).Msgf("Error during Note creation! %v", err)
@@ -59,43 +103,203 @@ func CreateNote(s NoteService) http.HandlerFunc {
 
 		// return successful JSON response to user
 		default:
-			l.Info().Msgf("Note with ID %v has been created for user: %s", retID, noteRequest.User)
+			l.Info().Msgf("Note with ID %v has been created for user: %s", retID, username)
 			httplib.JSON(w, httplib.Msg{"success": "note creation successful!"}, http.StatusCreated)
 		}
 	}
 }
 
-// GET /notes/
+// Write 409 with the ID of the note holding the title, when it is known
+func titleConflict(w http.ResponseWriter, err error) {
+	msg := httplib.Msg{"error": "a note with that title already exists in this notebook"}
+
+	var conflict *note.TitleConflictError
+	if errors.As(err, &conflict) {
+		msg["conflictingId"] = conflict.ID.String()
+	}
+
+	httplib.JSON(w, msg, http.StatusConflict)
+}
+
+// Parse a date filter, either RFC 3339 or a plain date
+func parseTimeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+
+	return time.Parse("2006-01-02", v)
+}
+
+// Read the listing options from the query string
+func listOptions(query url.Values) (note.ListOptions, error) {
+	opts := note.ListOptions{
+		Cursor: query.Get("cursor"),
+		SortBy: query.Get("sort"),
+		Tags:   query["tag"],
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil || n <= 0 {
+			return opts, errors.New("limit must be a positive number")
+		}
+		opts.Limit = int32(n)
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Descending = true
+	default:
+		return opts, errors.New("order must be asc or desc")
+	}
+
+	// notes must have every tag unless match=any is set
+	switch query.Get("match") {
+	case "", "all":
+		opts.MatchAllTags = true
+	case "any":
+	default:
+		return opts, errors.New("match must be any or all")
+	}
+
+	var err error
+	for _, f := range []struct {
+		param string
+		dst   *time.Time
+	}{
+		{"created_from", &opts.CreatedFrom},
+		{"created_to", &opts.CreatedTo},
+		{"updated_from", &opts.UpdatedFrom},
+		{"updated_to", &opts.UpdatedTo},
+	} {
+		*f.dst, err = parseTimeParam(query.Get(f.param))
+		if err != nil {
+			return opts, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", f.param)
+		}
+	}
+
+	return opts, nil
+}
+
+// GET /notes/?limit=&cursor=&sort=&order=&tag=&match=&created_from=&created_to=&updated_from=&updated_to=
 func GetAllNotesFromUser(s NoteService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// take logger and context
 		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
 		defer cancel()
 
-		// get username from request URL
-		username := r.URL.Query().Get("username")
+		// get username of the authenticated user
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
 
-		if username == "" {
-			l.Error().Msgf("error fetching username, the request parameter is empty. %s", username)
-			httplib.JSON(w, httplib.Msg{"error": "user not in request params"}, http.StatusBadRequest)
+		opts, err := listOptions(r.URL.Query())
+		if err != nil {
+			l.Info().Msgf("Invalid notes listing parameters. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
 			return
 		}
 
-		// get all notes from user
-		notes, err := s.GetAllNotesFromUser(ctx, username)
+		page, err := s.ListNotes(ctx, username, opts)
 		switch {
-		case errors.Is(err, note.ErrNotFound):
-			l.Info().Msgf("Requested user has no Notes!. %s", username)
+		case errors.Is(err, note.ErrInvalidTag):
+			l.Info().Msgf("Invalid tag filter %v", opts.Tags)
+			httplib.JSON(w, httplib.Msg{"error": "tags must be 1-50 characters long"}, http.StatusBadRequest)
+			return
+		case errors.Is(err, note.ErrInvalidSort), errors.Is(err, note.ErrInvalidCursor):
+			l.Info().Msgf("Invalid notes listing parameters. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
+			return
 		case errors.Is(err, note.ErrDBInternal):
 			l.Info().Err(err).Msgf("Could not retrieve Notes for user. %v", err)
 			httplib.JSON(w, httplib.Msg{"error": "could not retrieve notes for user"}, http.StatusInternalServerError)
 			return
+		}
+
+		resp := models.NotePage{
+			Notes:      make([]models.Note, 0, len(page.Notes)),
+			NextCursor: page.NextCursor,
+			Total:      page.Total,
+		}
+		for _, n := range page.Notes {
+			resp.Notes = append(resp.Notes, toNoteModel(n))
+		}
 
 		// return successful JSON response to user
-		default:
-			l.Info().Msgf("Retrieving user notes for %s was successful!", username)
-			httplib.JSON(w, notes, http.StatusOK)
+		l.Info().Msgf("Retrieving user notes for %s was successful!", username)
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
+
+// GET /notes/{id}, ?format=html or Accept: text/html returns the text rendered from Markdown
+func GetNote(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		id, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		asHTML, err := wantsHTML(r)
+		if err != nil {
+			l.Info().Msgf("Invalid note format %q", r.URL.Query().Get("format"))
+			httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
+			return
+		}
+
+		var (
+			n    note.Note
+			html string
+		)
+		if asHTML {
+			n, html, err = s.RenderNote(ctx, username, id)
+		} else {
+			n, err = s.GetNote(ctx, username, id)
+		}
+
+		switch {
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note %v of user %s is not found!", id, username)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not retrieve Note %v. %v", id, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not retrieve note"}, http.StatusInternalServerError)
+			return
+		}
+
+		etag := noteETag(n.Version)
+		if asHTML {
+			etag = renderedETag(n.Version)
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Vary", "Accept")
+		w.Header().Set("Cache-Control", "private, no-cache")
+
+		// the client already has this version
+		if etagMatches(r, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		if asHTML {
+			writeRenderedNote(w, html)
+			return
 		}
+
+		httplib.JSON(w, toNoteModel(n), http.StatusOK)
 	}
 }
 
@@ -106,19 +310,36 @@ func DeleteNote(s NoteService) http.HandlerFunc {
 		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
 		defer cancel()
 
-		// parse URL path and take "id" and convert it to UUID
-		reqUUID, err := uuid.Parse(strings.Split(r.URL.Path, "/")[2])
-		if err != nil {
-			l.Info().Msgf("Could not convert ID to UUID.")
-			httplib.JSON(w, httplib.Msg{"error": "could not convert note id to uuid"}, http.StatusBadRequest)
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		reqUUID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		// the client must prove it has seen the latest version
+		version, ok := expectedVersion(w, r, l)
+		if !ok {
 			return
 		}
 
 		// delete note
-		id, err := s.DeleteNote(ctx, reqUUID)
+		id, err := s.DeleteNote(ctx, username, reqUUID, version)
 		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			l.Info().Msg("User has no notes to delete from!")
+		case errors.Is(err, note.ErrVersionMismatch):
+			versionMismatch(w, err, l)
+			return
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note %v of user %s is not found!", reqUUID, username)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+			return
+		case errors.Is(err, note.ErrForbidden):
+			l.Info().Msgf("User %s may not delete the shared Note %v", username, reqUUID)
+			httplib.JSON(w, httplib.Msg{"error": "only the owner can delete the note"}, http.StatusForbidden)
+			return
 		case errors.Is(err, note.ErrDBInternal):
 			l.Info().Err(err).Msgf("Could not delete Note %v from the DB!", reqUUID)
 			httplib.JSON(w, httplib.Msg{"error": "could not delete note from DB"}, http.StatusInternalServerError)
@@ -126,8 +347,8 @@ func DeleteNote(s NoteService) http.HandlerFunc {
 
 		// return successful JSON response to user
 		default:
-			l.Info().Msgf("Deleting note %v was successful!", id)
-			httplib.JSON(w, httplib.Msg{"success": "note deleted"}, http.StatusOK)
+			l.Info().Msgf("Note %v has been moved to the trash", id)
+			httplib.JSON(w, httplib.Msg{"success": "note moved to the trash"}, http.StatusOK)
 			return
 		}
 	}
@@ -142,22 +363,31 @@ func UpdateNote(s NoteService) http.HandlerFunc {
 
 		var isTextValid bool = true
 
-		// parse URL path and take "id" and convert it to UUID
-		reqUUID, err := uuid.Parse(strings.Split(r.URL.Path, "/")[2])
-		if err != nil {
-			l.Info().Msgf("Could not convert ID to UUID.")
-			httplib.JSON(w, httplib.Msg{"error": "could not convert note id to uuid"}, http.StatusBadRequest)
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		reqUUID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		// the client must prove it has seen the latest version
+		version, ok := expectedVersion(w, r, l)
+		if !ok {
 			return
 		}
 
 		// create struct for decode
 		updateRequest := struct {
-			Title string `json:"title" validate:"required, min=4"`
-			Text  string `json:"text"`
+			Title string   `json:"title" validate:"required,min=4"`
+			Text  string   `json:"text"`
+			Tags  []string `json:"tags"`
 		}{}
 
 		// decode request body
-		err = json.NewDecoder(r.Body).Decode(&updateRequest)
+		err := json.NewDecoder(r.Body).Decode(&updateRequest)
 		if err != nil {
 			l.Error().Err(err).Msgf("error decoding the Note into httplib.JSON during registration. %v", err)
 			httplib.JSON(w, httplib.Msg{"error": "internal error decoding Note struct"}, http.StatusInternalServerError)
@@ -179,15 +409,36 @@ func UpdateNote(s NoteService) http.HandlerFunc {
 		}
 
 		// update struct in DB
-		id, err := s.UpdateNote(ctx, reqUUID, updateRequest.Title, updateRequest.Text, isTextValid)
+		// tags are left alone when the field is missing
+		newVersion, err := s.UpdateNote(ctx, username, reqUUID, updateRequest.Title, updateRequest.Text, isTextValid, updateRequest.Tags, version)
 		switch {
+		case errors.Is(err, note.ErrVersionMismatch):
+			versionMismatch(w, err, l)
+			return
+		case errors.Is(err, note.ErrInvalidTag):
+			l.Info().Msgf("Could not update Note %v, invalid tags %v", reqUUID, updateRequest.Tags)
+			httplib.JSON(w, httplib.Msg{"error": "tags must be 1-50 characters long"}, http.StatusBadRequest)
+			return
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note %v of user %s is not found!", reqUUID, username)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+			return
+		case errors.Is(err, note.ErrForbidden):
+			l.Info().Msgf("User %s may only read the shared Note %v", username, reqUUID)
+			httplib.JSON(w, httplib.Msg{"error": "the note is shared with you for reading only"}, http.StatusForbidden)
+			return
+		case errors.Is(err, note.ErrAlreadyExists):
+			l.Info().Msgf("Could not update Note %v, the title is already taken", reqUUID)
+			titleConflict(w, err)
+			return
 		case errors.Is(err, note.ErrDBInternal):
 			l.Info().Err(err).Msgf("Could not update Note %v", reqUUID)
 			httplib.JSON(w, httplib.Msg{"error": "could not update note"}, http.StatusInternalServerError)
 			return
 		default:
-			l.Info().Msgf("Updating note %v was successful!", id)
-			httplib.JSON(w, httplib.Msg{"success": "note deleted"}, http.StatusOK)
+			l.Info().Msgf("Updating note %v to version %d was successful!", reqUUID, newVersion)
+			w.Header().Set("ETag", noteETag(newVersion))
+			httplib.JSON(w, httplib.Msg{"success": "note updated"}, http.StatusOK)
 			return
 		}
 	}
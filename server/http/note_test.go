@@ -1,3 +1,482 @@
 package server
 
-//TODO add note tests
\ No newline at end of file
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Put the user into the request context the same way auth.AuthMiddleware does
+func withAuthUser(r *http.Request, username string) *http.Request {
+	return r.WithContext(auth.NewContext(r.Context(), &auth.PasetoPayload{Username: username}))
+}
+
+func TestCreateNote(t *testing.T) {
+	const username = "testuser1"
+	conflictID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		body          *models.Note
+		authUser      string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService, n *models.Note)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "note creation OK - owner taken from auth payload",
+			body:     &models.Note{Title: "testtitle", User: "otheruser1", Text: "testtext"},
+			authUser: username,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService, n *models.Note) {
+				mocksvc.EXPECT().CreateNote(gomock.Any(), n.Title, username, n.Text, n.Tags, uuid.NullUUID{}).Times(1).Return(uuid.New(), nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, rec.Code)
+			},
+		},
+		{
+			name:     "returns bad request - wrongly formatted note param",
+			body:     &models.Note{Title: "", Text: "test1"},
+			authUser: username,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService, n *models.Note) {
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns unauthorized - missing authenticated user",
+			body: &models.Note{Title: "testtitle", Text: "testtext"},
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService, n *models.Note) {
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:     "returns conflict - duplicate note title",
+			body:     &models.Note{Title: "testtitle", Text: "testtext"},
+			authUser: username,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService, n *models.Note) {
+				mocksvc.EXPECT().CreateNote(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, note.ErrAlreadyExists)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			name:     "returns conflict with the conflicting note",
+			body:     &models.Note{Title: "testtitle", Text: "testtext"},
+			authUser: username,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService, n *models.Note) {
+				mocksvc.EXPECT().CreateNote(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+					Return(uuid.Nil, &note.TitleConflictError{ID: conflictID})
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+
+				var resp map[string]string
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Equal(t, conflictID.String(), resp["conflictingId"])
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc, tc.body)
+
+			b, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/notes/create", bytes.NewReader(b))
+			if tc.authUser != "" {
+				req = withAuthUser(req, tc.authUser)
+			}
+
+			handler := CreateNote(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestGetNote(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+	n := note.Note{Note: db.Note{ID: id, Title: "title", Username: username, Version: 3}, Tags: []string{}}
+
+	testCases := []struct {
+		name          string
+		query         string
+		accept        string
+		ifNoneMatch   string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "getting note OK",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetNote(gomock.Any(), username, id).Times(1).Return(n, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+				var resp models.Note
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Equal(t, int32(3), resp.Version)
+			},
+		},
+		{
+			name:        "returns not modified - version is unchanged",
+			ifNoneMatch: `W/"3"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetNote(gomock.Any(), username, id).Times(1).Return(n, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotModified, rec.Code)
+				require.Zero(t, rec.Body.Len())
+			},
+		},
+		{
+			name: "returns not found",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetNote(gomock.Any(), username, id).Times(1).Return(note.Note{}, note.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:  "getting rendered note with format OK",
+			query: "?format=html",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenderNote(gomock.Any(), username, id).Times(1).Return(n, "<h1>title</h1>\n", nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+				require.Equal(t, `"3-html"`, rec.Header().Get("ETag"))
+				require.Equal(t, "Accept", rec.Header().Get("Vary"))
+				require.NotEmpty(t, rec.Header().Get("Content-Security-Policy"))
+				require.Equal(t, "<h1>title</h1>\n", rec.Body.String())
+			},
+		},
+		{
+			name:   "getting rendered note with accept header OK",
+			accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenderNote(gomock.Any(), username, id).Times(1).Return(n, "<p>text</p>\n", nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+			},
+		},
+		{
+			name:   "format wins over the accept header",
+			query:  "?format=json",
+			accept: "text/html",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetNote(gomock.Any(), username, id).Times(1).Return(n, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			},
+		},
+		{
+			name:        "returns not modified - rendered version is unchanged",
+			query:       "?format=html",
+			ifNoneMatch: `"2-html", "3-html"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenderNote(gomock.Any(), username, id).Times(1).Return(n, "<p>text</p>\n", nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotModified, rec.Code)
+				require.Zero(t, rec.Body.Len())
+			},
+		},
+		{
+			name:        "JSON ETag doesn't match the rendered note",
+			query:       "?format=html",
+			ifNoneMatch: `"3"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenderNote(gomock.Any(), username, id).Times(1).Return(n, "<p>text</p>\n", nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:  "returns bad request - unknown format",
+			query: "?format=pdf",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetNote(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				mocksvc.EXPECT().RenderNote(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/notes/"+id.String()+tc.query, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			req = withURLParam(withAuthUser(req, username), "id", id.String())
+
+			handler := GetNote(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestDeleteNote(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+	current := note.Note{Note: db.Note{ID: id, Title: "title", Username: username, Version: 5}, Tags: []string{}}
+
+	testCases := []struct {
+		name          string
+		ifMatch       string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "deleting note OK",
+			ifMatch: `"4"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteNote(gomock.Any(), username, id, int32(4)).Times(1).Return(id, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "deleting any version OK",
+			ifMatch: "*",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteNote(gomock.Any(), username, id, int32(0)).Times(1).Return(id, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "returns precondition required - no If-Match",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteNote(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionRequired, rec.Code)
+			},
+		},
+		{
+			name:    "returns precondition failed - outdated version",
+			ifMatch: `"4"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteNote(gomock.Any(), username, id, int32(4)).Times(1).Return(uuid.Nil, &note.VersionMismatchError{Current: current})
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+				require.Equal(t, `"5"`, rec.Header().Get("ETag"))
+
+				var resp models.Note
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Equal(t, id, resp.ID)
+				require.Equal(t, int32(5), resp.Version)
+			},
+		},
+		{
+			name:    "returns not found - note of another user",
+			ifMatch: `"4"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteNote(gomock.Any(), username, id, int32(4)).Times(1).Return(uuid.Nil, note.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:    "returns internal server error - db error",
+			ifMatch: `"4"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteNote(gomock.Any(), username, id, int32(4)).Times(1).Return(uuid.Nil, note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/notes/"+id.String(), nil)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			req = withURLParam(withAuthUser(req, username), "id", id.String())
+
+			handler := DeleteNote(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestUpdateNote(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+	current := note.Note{Note: db.Note{ID: id, Title: "theirs", Username: username, Version: 8}, Tags: []string{}}
+
+	testCases := []struct {
+		name          string
+		body          string
+		ifMatch       string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "updating note OK",
+			body:    `{"title":"newtitle","text":"newtext"}`,
+			ifMatch: `"7"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNote(gomock.Any(), username, id, "newtitle", "newtext", true, nil, int32(7)).Times(1).Return(int32(8), nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, `"8"`, rec.Header().Get("ETag"))
+			},
+		},
+		{
+			name:    "returns forbidden - note shared for reading",
+			body:    `{"title":"newtitle","text":"newtext"}`,
+			ifMatch: `"7"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNote(gomock.Any(), username, id, "newtitle", "newtext", true, nil, int32(7)).Times(1).Return(int32(0), note.ErrForbidden)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name: "returns precondition required - no If-Match",
+			body: `{"title":"newtitle","text":"newtext"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNote(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionRequired, rec.Code)
+			},
+		},
+		{
+			name:    "returns bad request - malformed If-Match",
+			body:    `{"title":"newtitle","text":"newtext"}`,
+			ifMatch: "7",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNote(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "returns precondition failed - outdated version",
+			body:    `{"title":"newtitle","text":"newtext"}`,
+			ifMatch: `"7"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNote(gomock.Any(), username, id, "newtitle", "newtext", true, nil, int32(7)).Times(1).Return(int32(0), &note.VersionMismatchError{Current: current})
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+				require.Equal(t, `"8"`, rec.Header().Get("ETag"))
+
+				var resp models.Note
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Equal(t, "theirs", resp.Title)
+				require.Equal(t, int32(8), resp.Version)
+			},
+		},
+		{
+			name:    "returns not found - note of another user",
+			body:    `{"title":"newtitle"}`,
+			ifMatch: `W/"7"`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNote(gomock.Any(), username, id, "newtitle", "", false, nil, int32(7)).Times(1).Return(int32(0), note.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:    "returns internal server error - db error",
+			body:    `{"title":"newtitle","text":"newtext"}`,
+			ifMatch: "*",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNote(gomock.Any(), username, id, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), int32(0)).Times(1).Return(int32(0), note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/notes/"+id.String(), bytes.NewReader([]byte(tc.body)))
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			req = withURLParam(withAuthUser(req, username), "id", id.String())
+
+			handler := UpdateNote(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
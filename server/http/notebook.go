@@ -0,0 +1,324 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type notebookRequest struct {
+	Name     string     `json:"name"`
+	ParentID *uuid.UUID `json:"parentId"`
+}
+
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+func uuidPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func toNoteModel(n note.Note) models.Note {
+	return models.Note{
+		ID:         n.ID,
+		Title:      n.Title,
+		User:       n.Username,
+		Text:       n.Text.String,
+		Tags:       n.Tags,
+		NotebookID: uuidPtr(n.NotebookID),
+		Version:    n.Version,
+		CreatedAt:  n.CreatedAt,
+		UpdatedAt:  n.UpdatedAt,
+		DeletedAt:  timePtr(n.DeletedAt),
+		Permission: string(n.Permission),
+	}
+}
+
+func toNotebookModel(nb db.Notebook) models.Notebook {
+	return models.Notebook{
+		ID:        nb.ID,
+		ParentID:  uuidPtr(nb.ParentID),
+		Name:      nb.Name,
+		CreatedAt: nb.CreatedAt,
+		UpdatedAt: nb.UpdatedAt,
+	}
+}
+
+func toNotebookTreeModel(tree *note.NotebookTree) models.NotebookTree {
+	resp := models.NotebookTree{
+		Notebook:  toNotebookModel(tree.Notebook),
+		Notebooks: make([]models.NotebookTree, 0, len(tree.Notebooks)),
+		Notes:     make([]models.Note, 0, len(tree.Notes)),
+	}
+
+	for _, child := range tree.Notebooks {
+		resp.Notebooks = append(resp.Notebooks, toNotebookTreeModel(child))
+	}
+	for _, n := range tree.Notes {
+		resp.Notes = append(resp.Notes, toNoteModel(n))
+	}
+
+	return resp
+}
+
+// Get the notebook ID from the URL, write 400 if it's not a UUID
+func notebookIDParam(w http.ResponseWriter, r *http.Request, l *zerolog.Logger) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		l.Info().Msgf("Could not convert ID to UUID.")
+		httplib.JSON(w, httplib.Msg{"error": "could not convert notebook id to uuid"}, http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// Map notebook errors of the note service to responses
+func writeNotebookError(w http.ResponseWriter, l *zerolog.Logger, err error) {
+	switch {
+	case errors.Is(err, note.ErrInvalidNotebookName):
+		l.Info().Msg("Invalid notebook name")
+		httplib.JSON(w, httplib.Msg{"error": "notebook name must be 1-100 characters long"}, http.StatusBadRequest)
+	case errors.Is(err, note.ErrNotebookNotFound):
+		l.Info().Msg("Notebook is not found")
+		httplib.JSON(w, httplib.Msg{"error": "notebook is not found"}, http.StatusNotFound)
+	case errors.Is(err, note.ErrNotebookAlreadyExists):
+		l.Info().Msg("Notebook with that name already exists")
+		httplib.JSON(w, httplib.Msg{"error": "a notebook with that name already exists here"}, http.StatusConflict)
+	case errors.Is(err, note.ErrNotebookCycle):
+		l.Info().Msg("Notebook can't be moved into itself")
+		httplib.JSON(w, httplib.Msg{"error": "a notebook can't be moved into itself or its children"}, http.StatusConflict)
+	default:
+		l.Error().Err(err).Msgf("Notebook operation failed. %v", err)
+		httplib.JSON(w, httplib.Msg{"error": "internal error during notebook operation"}, http.StatusInternalServerError)
+	}
+}
+
+// POST /notebooks
+func CreateNotebook(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		var req notebookRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			l.Error().Err(err).Msgf("error decoding the notebook request. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "wrongly formatted notebook request"}, http.StatusBadRequest)
+			return
+		}
+
+		notebook, err := s.CreateNotebook(ctx, username, req.Name, nullUUID(req.ParentID))
+		if err != nil {
+			writeNotebookError(w, l, err)
+			return
+		}
+
+		l.Info().Msgf("Notebook with ID %v has been created for user: %s", notebook.ID, username)
+		httplib.JSON(w, toNotebookModel(notebook), http.StatusCreated)
+	}
+}
+
+// GET /notebooks
+func ListNotebooks(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		notebooks, err := s.ListNotebooks(ctx, username)
+		if err != nil {
+			writeNotebookError(w, l, err)
+			return
+		}
+
+		resp := make([]models.Notebook, 0, len(notebooks))
+		for _, nb := range notebooks {
+			resp = append(resp, toNotebookModel(nb))
+		}
+
+		l.Info().Msgf("Retrieving notebooks for %s was successful!", username)
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
+
+// GET /notebooks/{id}
+func GetNotebook(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		id, ok := notebookIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		tree, err := s.GetNotebookTree(ctx, username, id)
+		if err != nil {
+			writeNotebookError(w, l, err)
+			return
+		}
+
+		l.Info().Msgf("Retrieving notebook %v of %s was successful!", id, username)
+		httplib.JSON(w, toNotebookTreeModel(tree), http.StatusOK)
+	}
+}
+
+// PUT /notebooks/{id}
+func UpdateNotebook(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		id, ok := notebookIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		var req notebookRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			l.Error().Err(err).Msgf("error decoding the notebook request. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "wrongly formatted notebook request"}, http.StatusBadRequest)
+			return
+		}
+
+		notebook, err := s.UpdateNotebook(ctx, username, id, req.Name, nullUUID(req.ParentID))
+		if err != nil {
+			writeNotebookError(w, l, err)
+			return
+		}
+
+		l.Info().Msgf("Updating notebook %v was successful!", id)
+		httplib.JSON(w, toNotebookModel(notebook), http.StatusOK)
+	}
+}
+
+// DELETE /notebooks/{id}
+func DeleteNotebook(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		id, ok := notebookIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		err := s.DeleteNotebook(ctx, username, id)
+		switch {
+		case errors.Is(err, note.ErrAlreadyExists):
+			l.Info().Msgf("Could not delete notebook %v, a title of its notes is already taken outside of it", id)
+			titleConflict(w, err)
+			return
+		case err != nil:
+			writeNotebookError(w, l, err)
+			return
+		}
+
+		l.Info().Msgf("Deleting notebook %v was successful!", id)
+		httplib.JSON(w, httplib.Msg{"success": "notebook deleted"}, http.StatusOK)
+	}
+}
+
+// PUT /notes/{id}/notebook
+func MoveNote(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		// a null notebookId takes the note out of its notebook
+		moveRequest := struct {
+			NotebookID *uuid.UUID `json:"notebookId"`
+		}{}
+
+		err := json.NewDecoder(r.Body).Decode(&moveRequest)
+		if err != nil {
+			l.Error().Err(err).Msgf("error decoding the note move request. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "wrongly formatted note move request"}, http.StatusBadRequest)
+			return
+		}
+
+		err = s.MoveNote(ctx, username, noteID, nullUUID(moveRequest.NotebookID))
+		switch {
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note %v of user %s is not found!", noteID, username)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+			return
+		case errors.Is(err, note.ErrAlreadyExists):
+			l.Info().Msgf("Could not move Note %v, the title is already taken in the notebook", noteID)
+			titleConflict(w, err)
+			return
+		case err != nil:
+			writeNotebookError(w, l, err)
+			return
+		}
+
+		l.Info().Msgf("Moving note %v was successful!", noteID)
+		httplib.JSON(w, httplib.Msg{"success": "note moved"}, http.StatusOK)
+	}
+}
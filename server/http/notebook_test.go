@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateNotebook(t *testing.T) {
+	const username = "testuser1"
+	parent := uuid.New()
+
+	testCases := []struct {
+		name          string
+		body          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "creating notebook OK",
+			body: `{"name": "Recipes", "parentId": "` + parent.String() + `"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateNotebook(gomock.Any(), username, "Recipes", uuid.NullUUID{UUID: parent, Valid: true}).Times(1).
+					Return(db.Notebook{ID: uuid.New(), Name: "Recipes", ParentID: uuid.NullUUID{UUID: parent, Valid: true}}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, rec.Code)
+
+				var notebook models.Notebook
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&notebook))
+				require.Equal(t, "Recipes", notebook.Name)
+				require.Equal(t, &parent, notebook.ParentID)
+			},
+		},
+		{
+			name: "returns not found - parent notebook of another user",
+			body: `{"name": "Recipes", "parentId": "` + parent.String() + `"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateNotebook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(db.Notebook{}, note.ErrNotebookNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "returns conflict - name taken",
+			body: `{"name": "Recipes"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateNotebook(gomock.Any(), username, "Recipes", uuid.NullUUID{}).Times(1).Return(db.Notebook{}, note.ErrNotebookAlreadyExists)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := withAuthUser(httptest.NewRequest(http.MethodPost, "/notebooks", bytes.NewBufferString(tc.body)), username)
+
+			CreateNotebook(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestGetNotebook(t *testing.T) {
+	const username = "testuser1"
+	root := uuid.New()
+	child := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().GetNotebookTree(gomock.Any(), username, root).Times(1).Return(&note.NotebookTree{
+		Notebook: db.Notebook{ID: root, Name: "root"},
+		Notebooks: []*note.NotebookTree{{
+			Notebook: db.Notebook{ID: child, Name: "child", ParentID: uuid.NullUUID{UUID: root, Valid: true}},
+			Notes:    []note.Note{{Note: db.Note{ID: uuid.New(), Title: "nested"}, Tags: []string{}}},
+		}},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/notebooks/"+root.String(), nil)
+	req = withURLParam(withAuthUser(req, username), "id", root.String())
+
+	GetNotebook(mocksvc)(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var tree models.NotebookTree
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&tree))
+	require.Equal(t, root, tree.ID)
+	require.Empty(t, tree.Notes)
+	require.Len(t, tree.Notebooks, 1)
+	require.Equal(t, "nested", tree.Notebooks[0].Notes[0].Title)
+}
+
+func TestUpdateNotebook(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+	child := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().UpdateNotebook(gomock.Any(), username, id, "name", uuid.NullUUID{UUID: child, Valid: true}).Times(1).Return(db.Notebook{}, note.ErrNotebookCycle)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/notebooks/"+id.String(), bytes.NewBufferString(`{"name": "name", "parentId": "`+child.String()+`"}`))
+	req = withURLParam(withAuthUser(req, username), "id", id.String())
+
+	UpdateNotebook(mocksvc)(rec, req)
+	require.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestDeleteNotebook(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+	conflictID := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().DeleteNotebook(gomock.Any(), username, id).Times(1).Return(&note.TitleConflictError{ID: conflictID})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/notebooks/"+id.String(), nil)
+	req = withURLParam(withAuthUser(req, username), "id", id.String())
+
+	DeleteNotebook(mocksvc)(rec, req)
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	require.Equal(t, conflictID.String(), body["conflictingId"])
+}
+
+func TestMoveNote(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+	notebookID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		body          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "moving note OK",
+			body: `{"notebookId": "` + notebookID.String() + `"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().MoveNote(gomock.Any(), username, noteID, uuid.NullUUID{UUID: notebookID, Valid: true}).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "moving note out of notebooks OK",
+			body: `{"notebookId": null}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().MoveNote(gomock.Any(), username, noteID, uuid.NullUUID{}).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "returns not found - note of another user",
+			body: `{"notebookId": null}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().MoveNote(gomock.Any(), username, noteID, uuid.NullUUID{}).Times(1).Return(note.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/notes/"+noteID.String()+"/notebook", bytes.NewBufferString(tc.body))
+			req = withURLParam(withAuthUser(req, username), "id", noteID.String())
+
+			MoveNote(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
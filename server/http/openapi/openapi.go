@@ -0,0 +1,77 @@
+// Package openapi holds the OpenAPI 3 document of the HTTP API and serves it
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Page rendering the document with Swagger UI, %s is the URL of the document
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>online-note-z API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+<script>
+window.onload = function () {
+	window.ui = SwaggerUIBundle({url: %q, dom_id: "#swagger-ui", withCredentials: true});
+};
+</script>
+</body>
+</html>
+`
+
+// Parse the embedded document and check it's a valid OpenAPI 3 document
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the OpenAPI document. %w", err)
+	}
+
+	err = doc.Validate(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("the OpenAPI document is invalid. %w", err)
+	}
+
+	return doc, nil
+}
+
+// GET /openapi.json
+func ServeSpec(doc *openapi3.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		body, err := doc.MarshalJSON()
+		if err != nil {
+			httplib.JSON(w, httplib.Msg{"error": "could not encode the API document"}, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+}
+
+// GET /docs, Swagger UI of the document served at specURL
+func ServeDocs(specURL string) http.HandlerFunc {
+	page := fmt.Sprintf(docsPage, specURL)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(page))
+	}
+}
@@ -0,0 +1,137 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	require.NoError(t, err)
+	require.NotNil(t, doc.Paths.Find("/notes/{id}"))
+	require.NotNil(t, doc.Components.SecuritySchemes["pasetoCookie"])
+}
+
+func TestServeSpec(t *testing.T) {
+	doc, err := Load()
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	ServeSpec(doc)(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	served, err := openapi3.NewLoader().LoadFromData(rec.Body.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, doc.Paths.Len(), served.Paths.Len())
+}
+
+func TestServeDocs(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ServeDocs("/openapi.json")(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(), `url: "/openapi.json"`)
+}
+
+func TestValidator(t *testing.T) {
+	doc, err := Load()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		method    string
+		target    string
+		handler   http.HandlerFunc
+		rejected  bool
+		checkCode int
+		reported  int
+	}{
+		{
+			name:   "matching response is not reported",
+			method: http.MethodGet, target: "/settings",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"allowDuplicateTitles":true}`))
+			},
+			checkCode: http.StatusOK,
+		},
+		{
+			name:   "response body not matching the schema is reported and sent",
+			method: http.MethodGet, target: "/settings",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"allowDuplicateTitles":"yes"}`))
+			},
+			checkCode: http.StatusOK,
+			reported:  1,
+		},
+		{
+			name:   "undocumented status is reported",
+			method: http.MethodGet, target: "/settings",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			},
+			checkCode: http.StatusTeapot,
+			reported:  1,
+		},
+		{
+			name:   "request not matching the document is rejected",
+			method: http.MethodGet, target: "/notes?order=sideways",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				t.Error("handler must not be called")
+			},
+			rejected:  true,
+			checkCode: http.StatusBadRequest,
+			reported:  1,
+		},
+		{
+			name:   "undocumented route is reported and served",
+			method: http.MethodGet, target: "/nope",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			checkCode: http.StatusNotFound,
+			reported:  1,
+		},
+		{
+			name:   "streams are passed through",
+			method: http.MethodGet, target: "/events",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.WriteHeader(http.StatusOK)
+				require.NoError(t, http.NewResponseController(w).Flush())
+			},
+			checkCode: http.StatusOK,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var reported []error
+			validate, err := Validator(doc, func(_ *http.Request, err error) {
+				reported = append(reported, err)
+			})
+			require.NoError(t, err)
+
+			called := false
+			h := validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				tc.handler(w, r)
+			}))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, nil))
+
+			require.Equal(t, tc.checkCode, rec.Code)
+			require.Equal(t, !tc.rejected, called)
+			require.Len(t, reported, tc.reported)
+		})
+	}
+}
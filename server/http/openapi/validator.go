@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// Operations marked with it keep the connection open, their responses are not checked
+const streamExtension = "x-stream"
+
+func init() {
+	// the spec has HTML and diff bodies, they are checked as plain strings
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/x-diff", openapi3filter.FileBodyDecoder)
+}
+
+// Buffers the response, so it can be checked before it's sent
+type recorder struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+
+	return r.body.Write(p)
+}
+
+// Nothing is sent before the handler returns
+func (r *recorder) Flush() {}
+
+// Let http.ResponseController reach the deadlines of the connection
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *recorder) status() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+
+	return r.code
+}
+
+func isStream(op *openapi3.Operation) bool {
+	stream, _ := op.Extensions[streamExtension].(bool)
+	return stream
+}
+
+// Bodies of media types kin-openapi can't decode, like attachments of any type, are not checked
+func canDecode(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return openapi3filter.RegisteredBodyDecoder(mediaType) != nil
+}
+
+// Check every request and response against the document, meant for tests so the document can't drift from
+// the handlers. Mismatches are passed to report. Requests not matching the document are answered with 400,
+// responses are sent as they are. Credentials are left to the handlers, so a missing cookie still gets its 401.
+func Validator(doc *openapi3.T, report func(r *http.Request, err error)) (func(http.Handler) http.Handler, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("could not route the OpenAPI document. %w", err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				report(r, fmt.Errorf("%s %s is not in the OpenAPI document. %w", r.Method, r.URL.Path, err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reqInput := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+					ExcludeRequestBody: r.ContentLength != 0 && !canDecode(r.Header.Get("Content-Type")),
+				},
+			}
+			err = openapi3filter.ValidateRequest(r.Context(), reqInput)
+			if err != nil {
+				report(r, fmt.Errorf("request does not match the OpenAPI document. %w", err))
+				w.Header().Set("Content-Type", "application/json")
+				httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
+				return
+			}
+
+			if isStream(route.Operation) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			respInput := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: reqInput,
+				Status:                 rec.status(),
+				Header:                 w.Header(),
+				Options: &openapi3filter.Options{
+					IncludeResponseStatus: true,
+					ExcludeResponseBody:   rec.body.Len() > 0 && !canDecode(w.Header().Get("Content-Type")),
+				},
+			}
+			respInput.SetBodyBytes(rec.body.Bytes())
+
+			err = openapi3filter.ValidateResponse(r.Context(), respInput)
+			if err != nil {
+				report(r, fmt.Errorf("response does not match the OpenAPI document. %w", err))
+			}
+
+			w.WriteHeader(rec.status())
+			w.Write(rec.body.Bytes())
+		})
+	}, nil
+}
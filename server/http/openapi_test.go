@@ -0,0 +1,789 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/password"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	"github.com/alekslesik/online-note-z/server/http/openapi"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+const openAPITestKey = "yuIXHzQp2ouQbZ9T6keVPqsT24V28Zkd"
+
+// Full router behind the OpenAPI validator, every mismatch with the document fails the test
+func newValidatedRouter(t *testing.T, s NoteService) http.Handler {
+	t.Helper()
+
+	l := zerolog.New(io.Discard)
+	r, err := NewChiRouter(s, openAPITestKey, time.Minute, time.Hour, &l)
+	require.NoError(t, err)
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	validate, err := openapi.Validator(doc, func(r *http.Request, err error) {
+		t.Errorf("%s %s: %v", r.Method, r.URL, err)
+	})
+	require.NoError(t, err)
+
+	return validate(r)
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	l := zerolog.New(io.Discard)
+	r, err := NewChiRouter(nil, openAPITestKey, time.Minute, time.Hour, &l)
+	require.NoError(t, err)
+
+	var routes []string
+	err = chi.Walk(r, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// chi registers "/" of a subrouter as "/notes/", RedirectSlashes serves it as "/notes"
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	require.NoError(t, err)
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	require.Equal(t, routes, documented)
+}
+
+func TestHandlersMatchOpenAPI(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+	notebookID := uuid.New()
+	attachmentID := uuid.New()
+	linkID := uuid.New()
+	sessionID := uuid.New()
+	now := time.Now()
+
+	pm, err := auth.NewPasetoManager(openAPITestKey)
+	require.NoError(t, err)
+	token, _, err := pm.CreateToken(username, sessionID, time.Minute)
+	require.NoError(t, err)
+	expiredToken, _, err := pm.CreateToken(username, sessionID, -time.Minute)
+	require.NoError(t, err)
+
+	hashed, err := password.Hash("password1")
+	require.NoError(t, err)
+
+	sample := note.Note{Note: db.Note{
+		ID:        noteID,
+		Title:     "title",
+		Username:  username,
+		Text:      sql.NullString{String: "text", Valid: true},
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   3,
+	}}
+	tagged := note.Note{Note: sample.Note, Tags: []string{"work"}, Permission: note.PermissionRead}
+	tagged.NotebookID = uuid.NullUUID{UUID: notebookID, Valid: true}
+	notebook := db.Notebook{ID: notebookID, Username: username, Name: "work", CreatedAt: now, UpdatedAt: now}
+	attachment := db.Attachment{ID: attachmentID, NoteID: noteID, Filename: "a.txt", ContentType: "text/plain", Size: 5, CreatedAt: now}
+
+	upload, uploadType := multipartFile(t, "file", "a.txt", "hello")
+	archive, archiveType := multipartFile(t, "file", "export.zip", "export")
+
+	testCases := []struct {
+		name        string
+		method      string
+		target      string
+		body        io.Reader
+		contentType string
+		header      map[string]string
+		cookie      string
+		mockSvcCall func(mocksvc *mocksvc.MockNoteService)
+		code        int
+	}{
+		{
+			name:   "GET /openapi.json",
+			method: http.MethodGet, target: "/openapi.json",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {},
+			code:        http.StatusOK,
+		},
+		{
+			name:   "GET /docs",
+			method: http.MethodGet, target: "/docs",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {},
+			code:        http.StatusOK,
+		},
+		{
+			name:   "POST /register",
+			method: http.MethodPost, target: "/register",
+			body: strings.NewReader(`{"username":"testuser1","password":"password1","email":"user1@example.com"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RegisterUser(gomock.Any(), gomock.Any()).Times(1).Return(username, nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name:   "POST /register - username taken",
+			method: http.MethodPost, target: "/register",
+			body: strings.NewReader(`{"username":"testuser1","password":"password1","email":"user1@example.com"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RegisterUser(gomock.Any(), gomock.Any()).Times(1).Return("", note.ErrUserAlreadyExists)
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name:   "POST /login",
+			method: http.MethodPost, target: "/login",
+			body: strings.NewReader(`{"username":"testuser1","password":"password1"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetUser(gomock.Any(), username).Times(1).Return(db.User{Username: username, Password: hashed}, nil)
+				mocksvc.EXPECT().CreateSession(gomock.Any(), username, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(sessionID, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /login - wrong password",
+			method: http.MethodPost, target: "/login",
+			body: strings.NewReader(`{"username":"testuser1","password":"password2"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetUser(gomock.Any(), username).Times(1).Return(db.User{Username: username, Password: hashed}, nil)
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name:   "POST /logout",
+			method: http.MethodPost, target: "/logout",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RevokeToken(gomock.Any(), username, gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				mocksvc.EXPECT().RevokeSession(gomock.Any(), username, sessionID).Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /token/refresh",
+			method: http.MethodPost, target: "/token/refresh",
+			header: map[string]string{"Cookie": "refresh_token=refreshtoken"},
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(username, sessionID, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /p/{token}",
+			method: http.MethodGet, target: "/p/linktoken",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenLink(gomock.Any(), "linktoken", "").Times(1).Return(sample, "<p>text</p>\n", nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /p/{token} - password required",
+			method: http.MethodGet, target: "/p/linktoken",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenLink(gomock.Any(), "linktoken", "").Times(1).Return(note.Note{}, "", note.ErrLinkPasswordRequired)
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name:   "missing token is answered in plain text",
+			method: http.MethodGet, target: "/tags",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {},
+			code:        http.StatusUnauthorized,
+		},
+		{
+			name:   "expired token is answered in plain text",
+			method: http.MethodGet, target: "/tags",
+			cookie:      expiredToken,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {},
+			code:        http.StatusUnauthorized,
+		},
+		{
+			name:   "POST /notes/create",
+			method: http.MethodPost, target: "/notes/create",
+			cookie: token,
+			body:   strings.NewReader(`{"title":"title","text":"text","tags":["work"],"notebookId":null}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateNote(gomock.Any(), "title", username, "text", []string{"work"}, uuid.NullUUID{}).Times(1).Return(noteID, nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name:   "POST /notes/create - title taken",
+			method: http.MethodPost, target: "/notes/create",
+			cookie: token,
+			body:   strings.NewReader(`{"title":"title"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateNote(gomock.Any(), "title", username, "", nil, uuid.NullUUID{}).Times(1).Return(uuid.Nil, &note.TitleConflictError{ID: noteID})
+			},
+			code: http.StatusConflict,
+		},
+		{
+			name:   "GET /notes",
+			method: http.MethodGet, target: "/notes?limit=2&sort=title&order=desc&tag=work&match=any&created_from=2024-01-02",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListNotes(gomock.Any(), username, gomock.Any()).Times(1).Return(note.NotePage{
+					Notes:      []note.Note{sample, tagged},
+					NextCursor: "next",
+					Total:      3,
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notes/search",
+			method: http.MethodGet, target: "/notes/search?q=text",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Search(gomock.Any(), username, "text", gomock.Any()).Times(1).Return([]db.SearchNotesRow{
+					{ID: noteID, Title: "title", Text: sql.NullString{String: "text", Valid: true}, Rank: 0.5, TitleHeadline: "title", TextHeadline: "<mark>text</mark>"},
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notes/shared",
+			method: http.MethodGet, target: "/notes/shared",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListSharedNotes(gomock.Any(), username).Times(1).Return([]note.Note{tagged}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notes/{id}",
+			method: http.MethodGet, target: "/notes/" + noteID.String(),
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetNote(gomock.Any(), username, noteID).Times(1).Return(sample, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notes/{id} - not modified",
+			method: http.MethodGet, target: "/notes/" + noteID.String(),
+			cookie: token,
+			header: map[string]string{"If-None-Match": `"3"`},
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetNote(gomock.Any(), username, noteID).Times(1).Return(sample, nil)
+			},
+			code: http.StatusNotModified,
+		},
+		{
+			name:   "GET /notes/{id} - HTML",
+			method: http.MethodGet, target: "/notes/" + noteID.String() + "?format=html",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenderNote(gomock.Any(), username, noteID).Times(1).Return(sample, "<p>text</p>\n", nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notes/{id} - not found",
+			method: http.MethodGet, target: "/notes/" + noteID.String(),
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetNote(gomock.Any(), username, noteID).Times(1).Return(note.Note{}, note.ErrNotFound)
+			},
+			code: http.StatusNotFound,
+		},
+		{
+			name:   "PUT /notes/{id}",
+			method: http.MethodPut, target: "/notes/" + noteID.String(),
+			cookie: token,
+			header: map[string]string{"If-Match": `"3"`},
+			body:   strings.NewReader(`{"title":"title","text":"text","tags":[]}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNote(gomock.Any(), username, noteID, "title", "text", true, []string{}, int32(3)).Times(1).Return(int32(4), nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "PUT /notes/{id} - outdated version",
+			method: http.MethodPut, target: "/notes/" + noteID.String(),
+			cookie: token,
+			header: map[string]string{"If-Match": `"2"`},
+			body:   strings.NewReader(`{"title":"title"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNote(gomock.Any(), username, noteID, "title", "", false, nil, int32(2)).Times(1).
+					Return(int32(0), &note.VersionMismatchError{Current: sample})
+			},
+			code: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "DELETE /notes/{id}",
+			method: http.MethodDelete, target: "/notes/" + noteID.String(),
+			cookie: token,
+			header: map[string]string{"If-Match": "*"},
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteNote(gomock.Any(), username, noteID, int32(0)).Times(1).Return(noteID, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "PUT /notes/{id}/notebook",
+			method: http.MethodPut, target: "/notes/" + noteID.String() + "/notebook",
+			cookie: token,
+			body:   strings.NewReader(`{"notebookId":"` + notebookID.String() + `"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().MoveNote(gomock.Any(), username, noteID, uuid.NullUUID{UUID: notebookID, Valid: true}).Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notes/{id}/revisions",
+			method: http.MethodGet, target: "/notes/" + noteID.String() + "/revisions",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListRevisions(gomock.Any(), username, noteID).Times(1).Return([]db.ListNoteRevisionsRow{
+					{Revision: 1, Title: "title", CreatedAt: now, TextLength: 4},
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notes/{id}/revisions/{rev}",
+			method: http.MethodGet, target: "/notes/" + noteID.String() + "/revisions/1",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetRevision(gomock.Any(), username, noteID, int32(1)).Times(1).
+					Return(db.NoteRevision{NoteID: noteID, Revision: 1, Title: "title", CreatedAt: now}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /notes/{id}/revisions/{rev}/restore",
+			method: http.MethodPost, target: "/notes/" + noteID.String() + "/revisions/1/restore",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RestoreRevision(gomock.Any(), username, noteID, int32(1)).Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notes/{id}/diff",
+			method: http.MethodGet, target: "/notes/" + noteID.String() + "/diff?from=1&to=2",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DiffRevisions(gomock.Any(), username, noteID, int32(1), int32(2)).Times(1).Return("-old\n+new\n", nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /notes/{id}/attachments",
+			method: http.MethodPost, target: "/notes/" + noteID.String() + "/attachments",
+			cookie: token,
+			body:   upload, contentType: uploadType,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().AddAttachment(gomock.Any(), username, noteID, "a.txt", gomock.Any(), int64(5), gomock.Any()).Times(1).Return(attachment, nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name:   "GET /notes/{id}/attachments",
+			method: http.MethodGet, target: "/notes/" + noteID.String() + "/attachments",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListAttachments(gomock.Any(), username, noteID).Times(1).Return([]db.Attachment{attachment}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notes/{id}/attachments/{attachmentID}",
+			method: http.MethodGet, target: "/notes/" + noteID.String() + "/attachments/" + attachmentID.String(),
+			cookie: token,
+			header: map[string]string{"Range": "bytes=0-1"},
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().OpenAttachment(gomock.Any(), username, noteID, attachmentID).Times(1).
+					Return(attachment, nopSeekCloser{strings.NewReader("hello")}, nil)
+			},
+			code: http.StatusPartialContent,
+		},
+		{
+			name:   "DELETE /notes/{id}/attachments/{attachmentID}",
+			method: http.MethodDelete, target: "/notes/" + noteID.String() + "/attachments/" + attachmentID.String(),
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteAttachment(gomock.Any(), username, noteID, attachmentID).Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /notes/{id}/shares",
+			method: http.MethodPost, target: "/notes/" + noteID.String() + "/shares",
+			cookie: token,
+			body:   strings.NewReader(`{"username":"testuser2","permission":"read"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ShareNote(gomock.Any(), username, noteID, "testuser2", note.PermissionRead).Times(1).
+					Return(db.NoteShare{NoteID: noteID, Username: "testuser2", Permission: "read", CreatedAt: now}, nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name:   "GET /notes/{id}/shares",
+			method: http.MethodGet, target: "/notes/" + noteID.String() + "/shares",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListShares(gomock.Any(), username, noteID).Times(1).
+					Return([]db.NoteShare{{NoteID: noteID, Username: "testuser2", Permission: "edit", CreatedAt: now}}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "DELETE /notes/{id}/shares/{username}",
+			method: http.MethodDelete, target: "/notes/" + noteID.String() + "/shares/testuser2",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RevokeShare(gomock.Any(), username, noteID, "testuser2").Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /notes/{id}/links",
+			method: http.MethodPost, target: "/notes/" + noteID.String() + "/links",
+			cookie: token,
+			body:   strings.NewReader(`{"password":"secret","expiresAt":"` + now.Add(time.Hour).Format(time.RFC3339) + `"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateLink(gomock.Any(), username, noteID, "secret", gomock.Any()).Times(1).
+					Return(db.NoteLink{ID: linkID, NoteID: noteID, PasswordHash: sql.NullString{String: "hash", Valid: true}, CreatedAt: now}, "linktoken", nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name:   "POST /notes/{id}/links - no body",
+			method: http.MethodPost, target: "/notes/" + noteID.String() + "/links",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateLink(gomock.Any(), username, noteID, "", time.Time{}).Times(1).
+					Return(db.NoteLink{ID: linkID, NoteID: noteID, CreatedAt: now}, "linktoken", nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name:   "GET /notes/{id}/links",
+			method: http.MethodGet, target: "/notes/" + noteID.String() + "/links",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListLinks(gomock.Any(), username, noteID).Times(1).Return([]db.NoteLink{
+					{ID: linkID, NoteID: noteID, ExpiresAt: sql.NullTime{Time: now, Valid: true}, Views: 2, CreatedAt: now},
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "DELETE /notes/{id}/links/{linkID}",
+			method: http.MethodDelete, target: "/notes/" + noteID.String() + "/links/" + linkID.String(),
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RevokeLink(gomock.Any(), username, noteID, linkID).Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /export",
+			method: http.MethodGet, target: "/export",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ExportNotes(gomock.Any(), username, gomock.Any()).Times(1).DoAndReturn(
+					func(_ context.Context, _ string, w io.Writer) error {
+						zw := zip.NewWriter(w)
+						f, err := zw.Create("title.md")
+						if err != nil {
+							return err
+						}
+						f.Write([]byte("text"))
+						return zw.Close()
+					})
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /import",
+			method: http.MethodPost, target: "/import?format=markdown",
+			cookie: token,
+			body:   archive, contentType: archiveType,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ImportNotes(gomock.Any(), username, note.ImportMarkdown, gomock.Any(), gomock.Any()).Times(1).Return(note.ImportReport{
+					Imported: 1,
+					Failed:   1,
+					Results: []note.ImportResult{
+						{Source: "a.md", Title: "a", ID: noteID},
+						{Source: "b.md", Title: "b", Err: note.ErrInvalidTag},
+					},
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /sync",
+			method: http.MethodPost, target: "/sync",
+			cookie: token,
+			body:   strings.NewReader(`{"token":"","changes":[{"id":"` + noteID.String() + `","op":"upsert","title":"title","baseVersion":2}]}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Sync(gomock.Any(), username, "", gomock.Any()).Times(1).Return(note.SyncResponse{
+					Results: []note.SyncResult{{ID: noteID, Status: note.SyncConflict, Current: &sample}},
+					Changes: []note.SyncServerChange{
+						{ID: noteID, Op: note.SyncUpsert, Note: &tagged},
+						{ID: uuid.New(), Op: note.SyncDelete},
+					},
+					Token: "synctoken",
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /trash",
+			method: http.MethodGet, target: "/trash",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				deleted := sample
+				deleted.DeletedAt = sql.NullTime{Time: now, Valid: true}
+				mocksvc.EXPECT().ListTrash(gomock.Any(), username).Times(1).Return([]note.Note{deleted}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "DELETE /trash",
+			method: http.MethodDelete, target: "/trash",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().EmptyTrash(gomock.Any(), username).Times(1).Return(int64(2), nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /trash/{id}/restore",
+			method: http.MethodPost, target: "/trash/" + noteID.String() + "/restore",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RestoreNote(gomock.Any(), username, noteID).Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /notebooks",
+			method: http.MethodPost, target: "/notebooks",
+			cookie: token,
+			body:   strings.NewReader(`{"name":"work","parentId":null}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().CreateNotebook(gomock.Any(), username, "work", uuid.NullUUID{}).Times(1).Return(notebook, nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name:   "GET /notebooks",
+			method: http.MethodGet, target: "/notebooks",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListNotebooks(gomock.Any(), username).Times(1).Return([]db.Notebook{notebook}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /notebooks/{id}",
+			method: http.MethodGet, target: "/notebooks/" + notebookID.String(),
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				child := db.Notebook{ID: uuid.New(), Username: username, ParentID: uuid.NullUUID{UUID: notebookID, Valid: true}, Name: "child"}
+				mocksvc.EXPECT().GetNotebookTree(gomock.Any(), username, notebookID).Times(1).Return(&note.NotebookTree{
+					Notebook:  notebook,
+					Notebooks: []*note.NotebookTree{{Notebook: child}},
+					Notes:     []note.Note{tagged},
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "PUT /notebooks/{id}",
+			method: http.MethodPut, target: "/notebooks/" + notebookID.String(),
+			cookie: token,
+			body:   strings.NewReader(`{"name":"work"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNotebook(gomock.Any(), username, notebookID, "work", uuid.NullUUID{}).Times(1).Return(notebook, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "PUT /notebooks/{id} - moved into itself",
+			method: http.MethodPut, target: "/notebooks/" + notebookID.String(),
+			cookie: token,
+			body:   strings.NewReader(`{"name":"work","parentId":"` + notebookID.String() + `"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateNotebook(gomock.Any(), username, notebookID, "work", uuid.NullUUID{UUID: notebookID, Valid: true}).Times(1).
+					Return(db.Notebook{}, note.ErrNotebookCycle)
+			},
+			code: http.StatusConflict,
+		},
+		{
+			name:   "DELETE /notebooks/{id}",
+			method: http.MethodDelete, target: "/notebooks/" + notebookID.String(),
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteNotebook(gomock.Any(), username, notebookID).Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "DELETE /notebooks/{id} - title taken outside of it",
+			method: http.MethodDelete, target: "/notebooks/" + notebookID.String(),
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DeleteNotebook(gomock.Any(), username, notebookID).Times(1).Return(&note.TitleConflictError{ID: noteID})
+			},
+			code: http.StatusConflict,
+		},
+		{
+			name:   "GET /tags",
+			method: http.MethodGet, target: "/tags",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListTags(gomock.Any(), username).Times(1).Return([]db.ListTagsRow{{Name: "work", NoteCount: 2}}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "PUT /tags/{name}",
+			method: http.MethodPut, target: "/tags/work",
+			cookie: token,
+			body:   strings.NewReader(`{"name":"job"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenameTag(gomock.Any(), username, "work", "job").Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "POST /tags/{name}/merge",
+			method: http.MethodPost, target: "/tags/work/merge",
+			cookie: token,
+			body:   strings.NewReader(`{"into":"job"}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().MergeTag(gomock.Any(), username, "work", "job").Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /settings",
+			method: http.MethodGet, target: "/settings",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetSettings(gomock.Any(), username).Times(1).Return(note.Settings{}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "PUT /settings",
+			method: http.MethodPut, target: "/settings",
+			cookie: token,
+			body:   strings.NewReader(`{"allowDuplicateTitles":true}`),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateSettings(gomock.Any(), username, note.Settings{AllowDuplicateTitles: true}).Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "GET /sessions",
+			method: http.MethodGet, target: "/sessions",
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListSessions(gomock.Any(), username).Times(1).Return([]db.Session{
+					{ID: sessionID, Username: username, UserAgent: "curl", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+				}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "DELETE /sessions/{id}",
+			method: http.MethodDelete, target: "/sessions/" + uuid.New().String(),
+			cookie: token,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RevokeSession(gomock.Any(), username, gomock.Any()).Times(1).Return(nil)
+			},
+			code: http.StatusOK,
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			mocksvc.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+			tc.mockSvcCall(mocksvc)
+
+			if tc.body == nil {
+				tc.body = http.NoBody
+			}
+			req := httptest.NewRequest(tc.method, tc.target, tc.body)
+			if tc.body != http.NoBody {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "paseto", Value: tc.cookie})
+			}
+
+			rec := httptest.NewRecorder()
+			newValidatedRouter(t, mocksvc).ServeHTTP(rec, req)
+			require.Equal(t, tc.code, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestOpenAPIValidatorRejectsRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+
+	l := zerolog.New(io.Discard)
+	r, err := NewChiRouter(mocksvc, openAPITestKey, time.Minute, time.Hour, &l)
+	require.NoError(t, err)
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	var reported []error
+	validate, err := openapi.Validator(doc, func(_ *http.Request, err error) {
+		reported = append(reported, err)
+	})
+	require.NoError(t, err)
+
+	// a registration without an email never reaches the handler
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString(`{"username":"testuser1","password":"password1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	validate(r).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Len(t, reported, 1)
+	require.Contains(t, reported[0].Error(), "email")
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errInvalidFormat = errors.New("format must be json or html")
+
+// Rendered notes may only show images, nothing in them runs
+const renderedNoteCSP = "default-src 'none'; img-src * data:; sandbox"
+
+// Report whether the client asked for the rendered HTML of a note.
+// ?format= wins over the Accept header, JSON is the default.
+func wantsHTML(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return true, nil
+	case "json":
+		return false, nil
+	case "":
+	default:
+		return false, errInvalidFormat
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false, nil
+	}
+
+	return acceptQuality(accept, "text/html") > acceptQuality(accept, "application/json"), nil
+}
+
+// Quality the Accept header gives to mediaType, the most specific match counts
+func acceptQuality(accept string, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		s := -1
+		switch mt {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+
+		specificity, q = s, 1
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+	}
+
+	return q
+}
+
+// ETag of the rendered HTML, differs from the JSON one of the same version
+func renderedETag(version int32) string {
+	return strconv.Quote(strconv.FormatInt(int64(version), 10) + "-html")
+}
+
+// Report whether If-None-Match lists etag, weak ETags compare equal to strong ones
+func etagMatches(r *http.Request, etag string) bool {
+	h := r.Header.Get("If-None-Match")
+	if strings.TrimSpace(h) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(h, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Write the rendered HTML of a note
+func writeRenderedNote(w http.ResponseWriter, html string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", renderedNoteCSP)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(html))
+}
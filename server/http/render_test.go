@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWantsHTML(t *testing.T) {
+	testCases := []struct {
+		name    string
+		query   string
+		accept  string
+		want    bool
+		wantErr error
+	}{
+		{name: "defaults to JSON", want: false},
+		{name: "format html", query: "?format=html", want: true},
+		{name: "format json", query: "?format=json", accept: "text/html", want: false},
+		{name: "unknown format", query: "?format=xml", wantErr: errInvalidFormat},
+		{name: "browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: true},
+		{name: "JSON client", accept: "application/json", want: false},
+		{name: "anything", accept: "*/*", want: false},
+		{name: "JSON preferred", accept: "text/html;q=0.5, application/json", want: false},
+		{name: "HTML preferred", accept: "text/html, application/json;q=0.9", want: true},
+		{name: "any text", accept: "text/*", want: true},
+		{name: "HTML refused", accept: "text/html;q=0, */*", want: false},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/notes/id"+tc.query, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			got, err := wantsHTML(req)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
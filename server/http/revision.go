@@ -0,0 +1,198 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Parse a revision number, empty means the latest revision
+func parseRevision(v string, optional bool) (int32, bool) {
+	if v == "" {
+		return 0, optional
+	}
+
+	rev, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || rev <= 0 {
+		return 0, false
+	}
+
+	return int32(rev), true
+}
+
+// Map revision errors of the note service to responses
+func writeRevisionError(w http.ResponseWriter, l *zerolog.Logger, noteID uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, note.ErrNotFound):
+		l.Info().Msgf("Note %v is not found!", noteID)
+		httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+	case errors.Is(err, note.ErrRevisionNotFound):
+		l.Info().Msgf("Revision of note %v is not found!", noteID)
+		httplib.JSON(w, httplib.Msg{"error": "revision is not found"}, http.StatusNotFound)
+	case errors.Is(err, note.ErrAlreadyExists):
+		l.Info().Msgf("Could not restore Note %v, the title is already taken", noteID)
+		titleConflict(w, err)
+	default:
+		l.Error().Err(err).Msgf("Revision operation on note %v failed. %v", noteID, err)
+		httplib.JSON(w, httplib.Msg{"error": "internal error during revision operation"}, http.StatusInternalServerError)
+	}
+}
+
+// GET /notes/{id}/revisions
+func ListRevisions(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		revs, err := s.ListRevisions(ctx, username, noteID)
+		if err != nil {
+			writeRevisionError(w, l, noteID, err)
+			return
+		}
+
+		resp := make([]models.RevisionInfo, 0, len(revs))
+		for _, rev := range revs {
+			resp = append(resp, models.RevisionInfo{
+				Revision:   rev.Revision,
+				Title:      rev.Title,
+				TextLength: rev.TextLength,
+				CreatedAt:  rev.CreatedAt,
+			})
+		}
+
+		l.Info().Msgf("Retrieving revisions of note %v was successful!", noteID)
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
+
+// GET /notes/{id}/revisions/{rev}
+func GetRevision(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		rev, ok := parseRevision(chi.URLParam(r, "rev"), false)
+		if !ok {
+			l.Info().Msgf("Invalid revision %q", chi.URLParam(r, "rev"))
+			httplib.JSON(w, httplib.Msg{"error": "revision must be a positive number"}, http.StatusBadRequest)
+			return
+		}
+
+		revision, err := s.GetRevision(ctx, username, noteID, rev)
+		if err != nil {
+			writeRevisionError(w, l, noteID, err)
+			return
+		}
+
+		l.Info().Msgf("Retrieving revision %d of note %v was successful!", rev, noteID)
+		httplib.JSON(w, models.Revision{
+			NoteID:    revision.NoteID,
+			Revision:  revision.Revision,
+			Title:     revision.Title,
+			Text:      revision.Text.String,
+			CreatedAt: revision.CreatedAt,
+		}, http.StatusOK)
+	}
+}
+
+// GET /notes/{id}/diff?from=&to=, to defaults to the latest revision
+func DiffRevisions(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		from, fromOK := parseRevision(r.URL.Query().Get("from"), false)
+		to, toOK := parseRevision(r.URL.Query().Get("to"), true)
+		if !fromOK || !toOK {
+			l.Info().Msgf("Invalid revisions to diff %q", r.URL.RawQuery)
+			httplib.JSON(w, httplib.Msg{"error": "from is required and revisions must be positive numbers"}, http.StatusBadRequest)
+			return
+		}
+
+		diff, err := s.DiffRevisions(ctx, username, noteID, from, to)
+		if err != nil {
+			writeRevisionError(w, l, noteID, err)
+			return
+		}
+
+		l.Info().Msgf("Diffing revisions of note %v was successful!", noteID)
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(diff))
+	}
+}
+
+// POST /notes/{id}/revisions/{rev}/restore
+func RestoreRevision(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		rev, ok := parseRevision(chi.URLParam(r, "rev"), false)
+		if !ok {
+			l.Info().Msgf("Invalid revision %q", chi.URLParam(r, "rev"))
+			httplib.JSON(w, httplib.Msg{"error": "revision must be a positive number"}, http.StatusBadRequest)
+			return
+		}
+
+		err := s.RestoreRevision(ctx, username, noteID, rev)
+		if err != nil {
+			writeRevisionError(w, l, noteID, err)
+			return
+		}
+
+		l.Info().Msgf("Restoring revision %d of note %v was successful!", rev, noteID)
+		httplib.JSON(w, httplib.Msg{"success": "revision restored"}, http.StatusOK)
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Set several chi URL params for handlers called without the router
+func withURLParams(r *http.Request, params map[string]string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestGetRevision(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		rev           string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "getting revision OK",
+			rev:  "2",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetRevision(gomock.Any(), username, noteID, int32(2)).Times(1).
+					Return(db.NoteRevision{NoteID: noteID, Revision: 2, Title: "title", Text: sql.NullString{String: "text", Valid: true}}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var rev models.Revision
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&rev))
+				require.Equal(t, int32(2), rev.Revision)
+				require.Equal(t, "text", rev.Text)
+			},
+		},
+		{
+			name: "returns bad request - invalid revision",
+			rev:  "0",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns not found",
+			rev:  "9",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().GetRevision(gomock.Any(), username, noteID, int32(9)).Times(1).Return(db.NoteRevision{}, note.ErrRevisionNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := withAuthUser(httptest.NewRequest(http.MethodGet, "/notes/"+noteID.String()+"/revisions/"+tc.rev, nil), username)
+			req = withURLParams(req, map[string]string{"id": noteID.String(), "rev": tc.rev})
+
+			GetRevision(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		query         string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "diff against the latest revision OK",
+			query: "from=1",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().DiffRevisions(gomock.Any(), username, noteID, int32(1), int32(0)).Times(1).Return("--- a\n+++ b\n", nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Equal(t, "text/x-diff; charset=utf-8", rec.Header().Get("Content-Type"))
+				require.Equal(t, "--- a\n+++ b\n", rec.Body.String())
+			},
+		},
+		{
+			name:  "returns bad request - missing from",
+			query: "to=2",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := withAuthUser(httptest.NewRequest(http.MethodGet, "/notes/"+noteID.String()+"/diff?"+tc.query, nil), username)
+			req = withURLParam(req, "id", noteID.String())
+
+			DiffRevisions(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().RestoreRevision(gomock.Any(), username, noteID, int32(1)).Times(1).Return(nil)
+
+	rec := httptest.NewRecorder()
+	req := withAuthUser(httptest.NewRequest(http.MethodPost, "/notes/"+noteID.String()+"/revisions/1/restore", nil), username)
+	req = withURLParams(req, map[string]string{"id": noteID.String(), "rev": "1"})
+
+	RestoreRevision(mocksvc)(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"errors"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+)
+
+// ts_headline doesn't escape the note text, so escape it and put back only our own markers
+var highlightReplacer = strings.NewReplacer("&lt;mark&gt;", "<mark>", "&lt;/mark&gt;", "</mark>")
+
+func escapeHighlight(s string) string {
+	return highlightReplacer.Replace(html.EscapeString(s))
+}
+
+// GET /notes/search?q=
+func SearchNotes(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		query := r.URL.Query().Get("q")
+		if strings.TrimSpace(query) == "" {
+			l.Info().Msg("Search query is empty.")
+			httplib.JSON(w, httplib.Msg{"error": "search query q is required"}, http.StatusBadRequest)
+			return
+		}
+
+		var limit int64
+		if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+			var err error
+			limit, err = strconv.ParseInt(rawLimit, 10, 32)
+			if err != nil || limit <= 0 {
+				l.Info().Msgf("Invalid search limit %q.", rawLimit)
+				httplib.JSON(w, httplib.Msg{"error": "limit must be a positive number"}, http.StatusBadRequest)
+				return
+			}
+		}
+
+		notes, err := s.Search(ctx, username, query, int32(limit))
+		switch {
+		case errors.Is(err, note.ErrInvalidSearchQuery):
+			l.Info().Msgf("Search query %q has no searchable terms.", query)
+			httplib.JSON(w, httplib.Msg{"error": "search query has no searchable terms"}, http.StatusBadRequest)
+			return
+		case errors.Is(err, note.ErrDBInternal):
+			l.Error().Err(err).Msgf("Could not search notes for user. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "could not search notes"}, http.StatusInternalServerError)
+			return
+		}
+
+		results := make([]models.SearchResult, 0, len(notes))
+		for _, n := range notes {
+			results = append(results, models.SearchResult{
+				ID:             n.ID,
+				Title:          n.Title,
+				Text:           n.Text.String,
+				CreatedAt:      n.CreatedAt,
+				UpdatedAt:      n.UpdatedAt,
+				Rank:           n.Rank,
+				TitleHighlight: escapeHighlight(n.TitleHeadline),
+				Snippet:        escapeHighlight(n.TextHeadline),
+			})
+		}
+
+		l.Info().Msgf("Searching notes of %s returned %d results", username, len(results))
+		httplib.JSON(w, results, http.StatusOK)
+	}
+}
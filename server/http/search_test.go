@@ -0,0 +1,109 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchNotes(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+
+	testCases := []struct {
+		name          string
+		url           string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "search OK",
+			url:  "/notes/search?q=milk&limit=5",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Search(gomock.Any(), username, "milk", int32(5)).Times(1).Return([]db.SearchNotesRow{{
+					ID:            id,
+					Title:         "Groceries",
+					Text:          sql.NullString{String: "<b>milk</b> and eggs", Valid: true},
+					Rank:          0.6,
+					TitleHeadline: "Groceries",
+					TextHeadline:  "<b><mark>milk</mark></b> and eggs",
+				}}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var results []models.SearchResult
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&results))
+				require.Len(t, results, 1)
+				require.Equal(t, id, results[0].ID)
+				require.Equal(t, "&lt;b&gt;<mark>milk</mark>&lt;/b&gt; and eggs", results[0].Snippet)
+			},
+		},
+		{
+			name: "returns bad request - missing query",
+			url:  "/notes/search",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - invalid limit",
+			url:  "/notes/search?q=milk&limit=-1",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - no searchable terms",
+			url:  "/notes/search?q=%26%26",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Search(gomock.Any(), username, "&&", int32(0)).Times(1).Return(nil, note.ErrInvalidSearchQuery)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns internal server error",
+			url:  "/notes/search?q=milk",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Search(gomock.Any(), username, "milk", int32(0)).Times(1).Return(nil, note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := withAuthUser(httptest.NewRequest(http.MethodGet, tc.url, nil), username)
+
+			SearchNotes(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
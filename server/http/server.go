@@ -2,16 +2,188 @@ package server
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
-	db "github.com/adykaaa/online-notes/db/sqlc"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/lib/events"
+	"github.com/alekslesik/online-note-z/note"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 )
 
+const (
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultReadTimeout       = 10 * time.Second
+	defaultWriteTimeout      = 10 * time.Second
+	defaultIdleTimeout       = 60 * time.Second
+	defaultShutdownTimeout   = 10 * time.Second
+)
+
+var ErrEmptyAddress = errors.New("server address cannot be empty")
+
+type stoppingCtxKey struct{}
+
+// Return a channel closed once the server starts shutting down, nil outside of a Server
+func serverStopping(ctx context.Context) <-chan struct{} {
+	stopping, _ := ctx.Value(stoppingCtxKey{}).(<-chan struct{})
+	return stopping
+}
+
 type NoteService interface {
-	CreateNote(ctx context.Context, title string, username string, text string) (uuid.UUID, error)
-	GetAllNotesFromUser(ctx context.Context, username string) ([]db.Note, error)
-	DeleteNote(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
-	UpdateNote(ctx context.Context, reqID uuid.UUID, title string, text string, isTextEmpty bool) (uuid.UUID, error)
+	CreateNote(ctx context.Context, title string, username string, text string, tags []string, notebookID uuid.NullUUID) (uuid.UUID, error)
+	GetAllNotesFromUser(ctx context.Context, username string) ([]note.Note, error)
+	ListNotes(ctx context.Context, username string, opts note.ListOptions) (note.NotePage, error)
+	Search(ctx context.Context, username string, query string, limit int32) ([]db.SearchNotesRow, error)
+	GetNote(ctx context.Context, username string, id uuid.UUID) (note.Note, error)
+	RenderNote(ctx context.Context, username string, id uuid.UUID) (note.Note, string, error)
+	ExportNotes(ctx context.Context, username string, w io.Writer) error
+	ImportNotes(ctx context.Context, username string, format string, r io.ReaderAt, size int64) (note.ImportReport, error)
+	DeleteNote(ctx context.Context, username string, id uuid.UUID, version int32) (uuid.UUID, error)
+	UpdateNote(ctx context.Context, username string, reqID uuid.UUID, title string, text string, isTextValid bool, tags []string, version int32) (int32, error)
+	ShareNote(ctx context.Context, owner string, noteID uuid.UUID, username string, perm note.Permission) (db.NoteShare, error)
+	ListShares(ctx context.Context, owner string, noteID uuid.UUID) ([]db.NoteShare, error)
+	RevokeShare(ctx context.Context, username string, noteID uuid.UUID, grantee string) error
+	ListSharedNotes(ctx context.Context, username string) ([]note.Note, error)
+	CreateLink(ctx context.Context, owner string, noteID uuid.UUID, password string, expiresAt time.Time) (db.NoteLink, string, error)
+	ListLinks(ctx context.Context, owner string, noteID uuid.UUID) ([]db.NoteLink, error)
+	RevokeLink(ctx context.Context, owner string, noteID uuid.UUID, linkID uuid.UUID) error
+	OpenLink(ctx context.Context, token string, password string) (note.Note, string, error)
+	ListTrash(ctx context.Context, username string) ([]note.Note, error)
+	AddAttachment(ctx context.Context, username string, noteID uuid.UUID, filename string, contentType string, size int64, r io.Reader) (db.Attachment, error)
+	ListAttachments(ctx context.Context, username string, noteID uuid.UUID) ([]db.Attachment, error)
+	OpenAttachment(ctx context.Context, username string, noteID uuid.UUID, id uuid.UUID) (db.Attachment, io.ReadSeekCloser, error)
+	DeleteAttachment(ctx context.Context, username string, noteID uuid.UUID, id uuid.UUID) error
+	RestoreNote(ctx context.Context, username string, id uuid.UUID) error
+	EmptyTrash(ctx context.Context, username string) (int64, error)
 	RegisterUser(ctx context.Context, args *db.RegisterUserParams) (string, error)
 	GetUser(ctx context.Context, username string) (db.User, error)
-}
\ No newline at end of file
+	GetSettings(ctx context.Context, username string) (note.Settings, error)
+	UpdateSettings(ctx context.Context, username string, settings note.Settings) error
+	RotateRefreshToken(ctx context.Context, oldHash string, newHash string, expiresAt time.Time) (string, uuid.UUID, error)
+	CreateSession(ctx context.Context, username string, userAgent string, clientIP string, refreshHash string, expiresAt time.Time) (uuid.UUID, error)
+	ListSessions(ctx context.Context, username string) ([]db.Session, error)
+	RevokeSession(ctx context.Context, username string, sessionID uuid.UUID) error
+	RevokeSessionByRefreshToken(ctx context.Context, refreshHash string) error
+	RevokeToken(ctx context.Context, username string, tokenID uuid.UUID, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error)
+	ListTags(ctx context.Context, username string) ([]db.ListTagsRow, error)
+	RenameTag(ctx context.Context, username string, name string, newName string) error
+	MergeTag(ctx context.Context, username string, name string, into string) error
+	CreateNotebook(ctx context.Context, username string, name string, parentID uuid.NullUUID) (db.Notebook, error)
+	ListNotebooks(ctx context.Context, username string) ([]db.Notebook, error)
+	UpdateNotebook(ctx context.Context, username string, id uuid.UUID, name string, parentID uuid.NullUUID) (db.Notebook, error)
+	DeleteNotebook(ctx context.Context, username string, id uuid.UUID) error
+	GetNotebookTree(ctx context.Context, username string, id uuid.UUID) (*note.NotebookTree, error)
+	MoveNote(ctx context.Context, username string, noteID uuid.UUID, notebookID uuid.NullUUID) error
+	ListRevisions(ctx context.Context, username string, noteID uuid.UUID) ([]db.ListNoteRevisionsRow, error)
+	GetRevision(ctx context.Context, username string, noteID uuid.UUID, rev int32) (db.NoteRevision, error)
+	DiffRevisions(ctx context.Context, username string, noteID uuid.UUID, from int32, to int32) (string, error)
+	RestoreRevision(ctx context.Context, username string, noteID uuid.UUID, rev int32) error
+	SubscribeEvents(username string) *events.Subscription
+	JoinCollab(ctx context.Context, username string, noteID uuid.UUID) (*note.CollabPeer, note.CollabState, error)
+	Sync(ctx context.Context, username string, token string, changes []note.SyncChange) (note.SyncResponse, error)
+}
+
+type Server struct {
+	server          *http.Server
+	logger          *zerolog.Logger
+	shutdownTimeout time.Duration
+}
+
+// Create new HTTP server. In-flight requests get shutdownTimeout to finish once the server is stopped.
+func NewHTTP(h http.Handler, address string, shutdownTimeout time.Duration, l *zerolog.Logger) (*Server, error) {
+	if address == "" {
+		l.Error().Msg("server address is empty")
+		return nil, ErrEmptyAddress
+	}
+
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
+	// Shutdown waits for requests to finish, event streams never do on their own
+	stopping := make(chan struct{})
+	var once sync.Once
+
+	s := &Server{
+		server: &http.Server{
+			Addr:              address,
+			Handler:           h,
+			ReadHeaderTimeout: defaultReadHeaderTimeout,
+			ReadTimeout:       defaultReadTimeout,
+			WriteTimeout:      defaultWriteTimeout,
+			IdleTimeout:       defaultIdleTimeout,
+			BaseContext: func(net.Listener) context.Context {
+				return context.WithValue(context.Background(), stoppingCtxKey{}, (<-chan struct{})(stopping))
+			},
+		},
+		logger:          l,
+		shutdownTimeout: shutdownTimeout,
+	}
+	s.server.RegisterOnShutdown(func() {
+		once.Do(func() { close(stopping) })
+	})
+
+	return s, nil
+}
+
+// Listen on the server address and block until ctx is done, SIGINT/SIGTERM is received
+// or the listener fails. In-flight requests are drained before returning.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		s.logger.Error().Err(err).Msgf("could not listen on %s. %v", s.server.Addr, err)
+		return err
+	}
+
+	return s.serve(ctx, ln)
+}
+
+func (s *Server) serve(ctx context.Context, ln net.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	notify := make(chan error, 1)
+	go func() {
+		s.logger.Info().Msgf("server is listening on %s", ln.Addr())
+		notify <- s.server.Serve(ln)
+		close(notify)
+	}()
+
+	select {
+	case err := <-notify:
+		s.logger.Error().Err(err).Msgf("error during server connection %v", err)
+		return err
+	case <-ctx.Done():
+		s.logger.Info().Msgf("server run interrupted. %v", context.Cause(ctx))
+	}
+
+	return s.Shutdown()
+}
+
+// Stop accepting new connections and wait for in-flight requests until the shutdown timeout.
+// Connections still open after the timeout are closed forcefully.
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	s.logger.Info().Msgf("shutting down the server, draining requests for at most %v", s.shutdownTimeout)
+
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Msgf("could not drain in-flight requests in time. %v", err)
+		s.server.Close()
+		return err
+	}
+
+	s.logger.Info().Msg("server stopped gracefully")
+	return nil
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTP(t *testing.T) {
+	l := zerolog.New(io.Discard)
+
+	t.Run("returns error on empty address", func(t *testing.T) {
+		s, err := NewHTTP(http.NewServeMux(), "", time.Second, &l)
+		require.ErrorIs(t, err, ErrEmptyAddress)
+		require.Nil(t, s)
+	})
+
+	t.Run("uses default shutdown timeout", func(t *testing.T) {
+		s, err := NewHTTP(http.NewServeMux(), ":8080", 0, &l)
+		require.NoError(t, err)
+		require.Equal(t, defaultShutdownTimeout, s.shutdownTimeout)
+		require.Equal(t, defaultWriteTimeout, s.server.WriteTimeout)
+	})
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	l := zerolog.New(io.Discard)
+
+	testCases := []struct {
+		name            string
+		handlerDelay    time.Duration
+		shutdownTimeout time.Duration
+		checkResult     func(t *testing.T, runErr error, resp *http.Response, reqErr error)
+	}{
+		{
+			name:            "drains in-flight request",
+			handlerDelay:    200 * time.Millisecond,
+			shutdownTimeout: 2 * time.Second,
+			checkResult: func(t *testing.T, runErr error, resp *http.Response, reqErr error) {
+				require.NoError(t, runErr)
+				require.NoError(t, reqErr)
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+			},
+		},
+		{
+			name:            "returns error when drain deadline is exceeded",
+			handlerDelay:    2 * time.Second,
+			shutdownTimeout: 100 * time.Millisecond,
+			checkResult: func(t *testing.T, runErr error, resp *http.Response, reqErr error) {
+				require.ErrorIs(t, runErr, context.DeadlineExceeded)
+				require.Error(t, reqErr)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			started := make(chan struct{})
+			h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(tc.handlerDelay)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			s, err := NewHTTP(h, "127.0.0.1:0", tc.shutdownTimeout, &l)
+			require.NoError(t, err)
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			runErr := make(chan error, 1)
+			go func() { runErr <- s.serve(ctx, ln) }()
+
+			type result struct {
+				resp *http.Response
+				err  error
+			}
+			reqDone := make(chan result, 1)
+			go func() {
+				resp, err := http.Get("http://" + ln.Addr().String())
+				if err == nil {
+					resp.Body.Close()
+				}
+				reqDone <- result{resp, err}
+			}()
+
+			<-started
+			cancel()
+
+			res := <-reqDone
+			tc.checkResult(t, <-runErr, res.resp, res.err)
+		})
+	}
+}
+
+func TestServerShutdownEndsStreams(t *testing.T) {
+	l := zerolog.New(io.Discard)
+
+	started := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		// like an event stream, only ends when the server stops
+		<-serverStopping(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s, err := NewHTTP(h, "127.0.0.1:0", 2*time.Second, &l)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- s.serve(ctx, ln) }()
+
+	reqErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		reqErr <- err
+	}()
+
+	<-started
+	cancel()
+
+	require.NoError(t, <-runErr)
+	require.NoError(t, <-reqErr)
+}
+
+func TestCORS(t *testing.T) {
+	l := zerolog.New(io.Discard)
+	r, err := NewChiRouter(nil, openAPITestKey, time.Minute, time.Hour, &l)
+	require.NoError(t, err)
+
+	t.Run("preflight allows the version headers", func(t *testing.T) {
+		for _, method := range []string{http.MethodPut, http.MethodDelete} {
+			req := httptest.NewRequest(http.MethodOptions, "/notes/"+uuid.NewString(), nil)
+			req.Header.Set("Origin", "http://localhost:3000")
+			req.Header.Set("Access-Control-Request-Method", method)
+			req.Header.Set("Access-Control-Request-Headers", "content-type,if-match")
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			require.Equal(t, "http://localhost:3000", rec.Header().Get("Access-Control-Allow-Origin"))
+			require.Contains(t, strings.ToLower(rec.Header().Get("Access-Control-Allow-Headers")), "if-match")
+		}
+	})
+
+	t.Run("ETag is readable by the frontend", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		require.Contains(t, strings.ToLower(rec.Header().Get("Access-Control-Expose-Headers")), "etag")
+	})
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GET /sessions
+func ListSessions(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		payload, ok := auth.PayloadFromContext(ctx)
+		if !ok {
+			l.Error().Msg("authenticated user is missing from the request context")
+			httplib.JSON(w, httplib.Msg{"error": "user is not authenticated"}, http.StatusUnauthorized)
+			return
+		}
+
+		sessions, err := s.ListSessions(ctx, payload.Username)
+		if err != nil {
+			l.Error().Err(err).Msgf("Could not retrieve sessions for user. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "could not retrieve sessions for user"}, http.StatusInternalServerError)
+			return
+		}
+
+		resp := make([]models.Session, 0, len(sessions))
+		for _, session := range sessions {
+			resp = append(resp, models.Session{
+				ID:         session.ID,
+				UserAgent:  session.UserAgent,
+				ClientIP:   session.ClientIp,
+				CreatedAt:  session.CreatedAt,
+				LastSeenAt: session.LastSeenAt,
+				ExpiresAt:  session.ExpiresAt,
+				Current:    session.ID == payload.SessionID,
+			})
+		}
+
+		l.Info().Msgf("Retrieving sessions for %s was successful!", payload.Username)
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
+
+// DELETE /sessions/{id}
+func RevokeSession(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		payload, ok := auth.PayloadFromContext(ctx)
+		if !ok {
+			l.Error().Msg("authenticated user is missing from the request context")
+			httplib.JSON(w, httplib.Msg{"error": "user is not authenticated"}, http.StatusUnauthorized)
+			return
+		}
+
+		sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			l.Info().Msgf("Could not convert ID to UUID.")
+			httplib.JSON(w, httplib.Msg{"error": "could not convert session id to uuid"}, http.StatusBadRequest)
+			return
+		}
+
+		err = s.RevokeSession(ctx, payload.Username, sessionID)
+		switch {
+		case errors.Is(err, note.ErrSessionNotFound):
+			l.Info().Msgf("Session %v of user %s is not found!", sessionID, payload.Username)
+			httplib.JSON(w, httplib.Msg{"error": "session is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not revoke session %v. %v", sessionID, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not revoke session"}, http.StatusInternalServerError)
+			return
+		}
+
+		// killing the current session is a logout
+		if sessionID == payload.SessionID {
+			clearAuthCookies(w)
+		}
+
+		l.Info().Msgf("Revoking session %v of %s was successful!", sessionID, payload.Username)
+		httplib.JSON(w, httplib.Msg{"success": "session revoked"}, http.StatusOK)
+	}
+}
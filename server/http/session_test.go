@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Put the user and its session into the request context the same way auth.AuthMiddleware does
+func withAuthSession(r *http.Request, username string, sessionID uuid.UUID) *http.Request {
+	return r.WithContext(auth.NewContext(r.Context(), &auth.PasetoPayload{ID: uuid.New(), Username: username, SessionID: sessionID}))
+}
+
+// Set chi URL params for handlers called without the router
+func withURLParam(r *http.Request, key string, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestListSessions(t *testing.T) {
+	const username = "testuser1"
+	current := uuid.New()
+	other := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().ListSessions(gomock.Any(), username).Times(1).Return([]db.Session{
+		{ID: current, Username: username, UserAgent: "firefox", ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: other, Username: username, UserAgent: "curl", ExpiresAt: time.Now().Add(time.Hour)},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	req := withAuthSession(httptest.NewRequest(http.MethodGet, "/sessions", nil), username, current)
+
+	ListSessions(mocksvc)(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var sessions []models.Session
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&sessions))
+	require.Len(t, sessions, 2)
+	require.True(t, sessions[0].Current)
+	require.False(t, sessions[1].Current)
+}
+
+func TestRevokeSession(t *testing.T) {
+	const username = "testuser1"
+	current := uuid.New()
+	other := uuid.New()
+
+	testCases := []struct {
+		name          string
+		sessionID     string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "revoking other session OK",
+			sessionID: other.String(),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RevokeSession(gomock.Any(), username, other).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Empty(t, rec.Result().Cookies())
+			},
+		},
+		{
+			name:      "revoking current session clears cookies",
+			sessionID: current.String(),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RevokeSession(gomock.Any(), username, current).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Len(t, rec.Result().Cookies(), 2)
+			},
+		},
+		{
+			name:      "returns bad request - invalid id",
+			sessionID: "notauuid",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:      "returns not found - session of another user",
+			sessionID: other.String(),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RevokeSession(gomock.Any(), username, other).Times(1).Return(note.ErrSessionNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/sessions/"+tc.sessionID, nil)
+			req = withURLParam(withAuthSession(req, username, current), "id", tc.sessionID)
+
+			RevokeSession(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestLogoutUser(t *testing.T) {
+	tm := &auth.MockTokenManager{Username: "testuser1"}
+
+	t.Run("logout revokes token and session", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mocksvc := mocksvc.NewMockNoteService(ctrl)
+		mocksvc.EXPECT().RevokeToken(gomock.Any(), "testuser1", gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		mocksvc.EXPECT().RevokeSession(gomock.Any(), "testuser1", gomock.Any()).Times(1).Return(nil)
+		mocksvc.EXPECT().RevokeSessionByRefreshToken(gomock.Any(), tm.HashRefreshToken("refresh")).Times(1).Return(note.ErrSessionNotFound)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+		req.AddCookie(&http.Cookie{Name: "paseto", Value: "test"})
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh"})
+
+		LogoutUser(mocksvc, tm)(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		for _, c := range rec.Result().Cookies() {
+			require.Empty(t, c.Value)
+		}
+	})
+
+	t.Run("logout without cookies only clears them", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mocksvc := mocksvc.NewMockNoteService(ctrl)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+
+		LogoutUser(mocksvc, tm)(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+)
+
+// GET /settings
+func GetSettings(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		settings, err := s.GetSettings(ctx, username)
+		switch {
+		case errors.Is(err, note.ErrUserNotFound):
+			l.Info().Msgf("User %s is not found!", username)
+			httplib.JSON(w, httplib.Msg{"error": "user is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not retrieve settings of user %s. %v", username, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not retrieve settings"}, http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("Retrieving settings for %s was successful!", username)
+		httplib.JSON(w, models.Settings{AllowDuplicateTitles: settings.AllowDuplicateTitles}, http.StatusOK)
+	}
+}
+
+// PUT /settings
+func UpdateSettings(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		// every setting must be given, a missing one would silently reset it
+		settingsRequest := struct {
+			AllowDuplicateTitles *bool `json:"allowDuplicateTitles"`
+		}{}
+
+		err := json.NewDecoder(r.Body).Decode(&settingsRequest)
+		if err != nil || settingsRequest.AllowDuplicateTitles == nil {
+			l.Info().Msgf("Invalid settings request of user %s. %v", username, err)
+			httplib.JSON(w, httplib.Msg{"error": "wrongly formatted or missing settings"}, http.StatusBadRequest)
+			return
+		}
+
+		settings := note.Settings{AllowDuplicateTitles: *settingsRequest.AllowDuplicateTitles}
+		err = s.UpdateSettings(ctx, username, settings)
+		switch {
+		case errors.Is(err, note.ErrDuplicateTitles):
+			l.Info().Msgf("Could not make titles unique for %s, some notebooks hold duplicates", username)
+			httplib.JSON(w, httplib.Msg{"error": "rename the notes sharing a title in the same notebook first"}, http.StatusConflict)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not update settings of user %s. %v", username, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not update settings"}, http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("Updating settings for %s was successful!", username)
+		httplib.JSON(w, models.Settings{AllowDuplicateTitles: settings.AllowDuplicateTitles}, http.StatusOK)
+	}
+}
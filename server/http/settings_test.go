@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSettings(t *testing.T) {
+	const username = "testuser1"
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().GetSettings(gomock.Any(), username).Times(1).Return(note.Settings{AllowDuplicateTitles: true}, nil)
+
+	rec := httptest.NewRecorder()
+	req := withAuthUser(httptest.NewRequest(http.MethodGet, "/settings", nil), username)
+
+	GetSettings(mocksvc)(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var settings models.Settings
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&settings))
+	require.True(t, settings.AllowDuplicateTitles)
+}
+
+func TestUpdateSettings(t *testing.T) {
+	const username = "testuser1"
+
+	testCases := []struct {
+		name          string
+		body          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "allowing duplicate titles OK",
+			body: `{"allowDuplicateTitles": true}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateSettings(gomock.Any(), username, note.Settings{AllowDuplicateTitles: true}).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "returns conflict - notebooks hold duplicate titles",
+			body: `{"allowDuplicateTitles": false}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().UpdateSettings(gomock.Any(), username, note.Settings{}).Times(1).Return(note.ErrDuplicateTitles)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			name:        "returns bad request - missing setting",
+			body:        `{}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := withAuthUser(httptest.NewRequest(http.MethodPut, "/settings", strings.NewReader(tc.body)), username)
+
+			UpdateSettings(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
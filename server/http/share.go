@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+func toShareModel(share db.NoteShare) models.Share {
+	return models.Share{
+		Username:   share.Username,
+		Permission: share.Permission,
+		CreatedAt:  share.CreatedAt,
+	}
+}
+
+// POST /notes/{id}/shares
+func ShareNote(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		var shareRequest models.Share
+		err := json.NewDecoder(r.Body).Decode(&shareRequest)
+		if err == nil {
+			err = validator.New().Struct(&shareRequest)
+		}
+		if err != nil {
+			l.Info().Msgf("Invalid share request for Note %v. %v", noteID, err)
+			httplib.JSON(w, httplib.Msg{"error": "a username and a permission of read or edit are required"}, http.StatusBadRequest)
+			return
+		}
+
+		share, err := s.ShareNote(ctx, username, noteID, shareRequest.Username, note.Permission(shareRequest.Permission))
+		switch {
+		case errors.Is(err, note.ErrInvalidPermission), errors.Is(err, note.ErrShareWithOwner):
+			l.Info().Msgf("Could not share Note %v. %v", noteID, err)
+			httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
+			return
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note %v of user %s is not found!", noteID, username)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+			return
+		case errors.Is(err, note.ErrUserNotFound):
+			l.Info().Msgf("Could not share Note %v, user %s is not found", noteID, shareRequest.Username)
+			httplib.JSON(w, httplib.Msg{"error": "user is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not share Note %v. %v", noteID, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not share note"}, http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("Note %v has been shared with %s for %s", noteID, share.Username, share.Permission)
+		httplib.JSON(w, toShareModel(share), http.StatusCreated)
+	}
+}
+
+// GET /notes/{id}/shares
+func ListShares(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		shares, err := s.ListShares(ctx, username, noteID)
+		switch {
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note %v of user %s is not found!", noteID, username)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not list the shares of Note %v. %v", noteID, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not retrieve note shares"}, http.StatusInternalServerError)
+			return
+		}
+
+		resp := make([]models.Share, 0, len(shares))
+		for _, share := range shares {
+			resp = append(resp, toShareModel(share))
+		}
+
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
+
+// DELETE /notes/{id}/shares/{username}
+func RevokeShare(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		noteID, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		grantee := chi.URLParam(r, "username")
+		err := s.RevokeShare(ctx, username, noteID, grantee)
+		switch {
+		case errors.Is(err, note.ErrShareNotFound):
+			l.Info().Msgf("Note %v is not shared with %s", noteID, grantee)
+			httplib.JSON(w, httplib.Msg{"error": "share is not found"}, http.StatusNotFound)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not revoke the share of Note %v. %v", noteID, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not revoke share"}, http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("Note %v is no longer shared with %s", noteID, grantee)
+		httplib.JSON(w, httplib.Msg{"success": "share revoked"}, http.StatusOK)
+	}
+}
+
+// GET /notes/shared
+func ListSharedNotes(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		notes, err := s.ListSharedNotes(ctx, username)
+		if err != nil {
+			l.Error().Err(err).Msgf("Could not list the notes shared with %s. %v", username, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not retrieve shared notes"}, http.StatusInternalServerError)
+			return
+		}
+
+		resp := make([]models.Note, 0, len(notes))
+		for _, n := range notes {
+			resp = append(resp, toNoteModel(n))
+		}
+
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareNote(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		body          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "sharing note OK",
+			body: `{"username": "testuser2", "permission": "edit"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ShareNote(gomock.Any(), username, noteID, "testuser2", note.PermissionEdit).Times(1).
+					Return(db.NoteShare{NoteID: noteID, Username: "testuser2", Permission: "edit"}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, rec.Code)
+
+				var share models.Share
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&share))
+				require.Equal(t, "testuser2", share.Username)
+				require.Equal(t, "edit", share.Permission)
+			},
+		},
+		{
+			name:        "returns bad request - unknown permission",
+			body:        `{"username": "testuser2", "permission": "admin"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - sharing with yourself",
+			body: `{"username": "testuser1", "permission": "read"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ShareNote(gomock.Any(), username, noteID, username, note.PermissionRead).Times(1).Return(db.NoteShare{}, note.ErrShareWithOwner)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns not found - unknown user",
+			body: `{"username": "nobody", "permission": "read"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ShareNote(gomock.Any(), username, noteID, "nobody", note.PermissionRead).Times(1).Return(db.NoteShare{}, note.ErrUserNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/notes/"+noteID.String()+"/shares", strings.NewReader(tc.body))
+			req = withURLParam(withAuthUser(req, username), "id", noteID.String())
+
+			ShareNote(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestRevokeShare(t *testing.T) {
+	const username = "testuser1"
+	noteID := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().RevokeShare(gomock.Any(), username, noteID, "testuser2").Times(1).Return(note.ErrShareNotFound)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/notes/"+noteID.String()+"/shares/testuser2", nil)
+	req = withURLParams(withAuthUser(req, username), map[string]string{"id": noteID.String(), "username": "testuser2"})
+
+	RevokeShare(mocksvc)(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestListSharedNotes(t *testing.T) {
+	const username = "testuser2"
+	id := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().ListSharedNotes(gomock.Any(), username).Times(1).Return([]note.Note{
+		{Note: db.Note{ID: id, Title: "plan", Username: "testuser1"}, Tags: []string{}, Permission: note.PermissionRead},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	req := withAuthUser(httptest.NewRequest(http.MethodGet, "/notes/shared", nil), username)
+
+	ListSharedNotes(mocksvc)(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var notes []models.Note
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&notes))
+	require.Len(t, notes, 1)
+	require.Equal(t, "testuser1", notes[0].User)
+	require.Equal(t, "read", notes[0].Permission)
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-playground/validator/v10"
+)
+
+// Text of the error of a client change that wasn't applied
+func syncError(err error) string {
+	switch {
+	case errors.Is(err, note.ErrVersionMismatch):
+		return "note has been changed since the base version"
+	case errors.Is(err, note.ErrNotFound):
+		return "note has been deleted"
+	case errors.Is(err, note.ErrNoteIDTaken):
+		return err.Error()
+	case errors.Is(err, note.ErrForbidden):
+		return "the note is shared with you for reading only"
+	case errors.Is(err, note.ErrAlreadyExists):
+		return "a note with that title already exists in this notebook"
+	case errors.Is(err, note.ErrInvalidTag):
+		return "tags must be 1-50 characters long"
+	case errors.Is(err, note.ErrNotebookNotFound):
+		return "notebook is not found"
+	default:
+		return "could not apply the change"
+	}
+}
+
+func toSyncChanges(changes []models.SyncChange) []note.SyncChange {
+	out := make([]note.SyncChange, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, note.SyncChange{
+			ID:          c.ID,
+			Op:          note.SyncOp(c.Op),
+			Title:       c.Title,
+			Text:        c.Text,
+			Tags:        c.Tags,
+			NotebookID:  nullUUID(c.NotebookID),
+			BaseVersion: c.BaseVersion,
+		})
+	}
+
+	return out
+}
+
+func toSyncResponseModel(resp note.SyncResponse) models.SyncResponse {
+	out := models.SyncResponse{
+		Token:   resp.Token,
+		HasMore: resp.HasMore,
+		Results: make([]models.SyncResult, 0, len(resp.Results)),
+		Changes: make([]models.SyncServerChange, 0, len(resp.Changes)),
+	}
+
+	for _, res := range resp.Results {
+		item := models.SyncResult{ID: res.ID, Status: string(res.Status), Version: res.Version}
+		if res.Current != nil {
+			current := toNoteModel(*res.Current)
+			item.Current = &current
+		}
+		if res.Err != nil {
+			item.Error = syncError(res.Err)
+		}
+		out.Results = append(out.Results, item)
+	}
+
+	for _, c := range resp.Changes {
+		item := models.SyncServerChange{ID: c.ID, Op: string(c.Op)}
+		if c.Note != nil {
+			n := toNoteModel(*c.Note)
+			item.Note = &n
+		}
+		out.Changes = append(out.Changes, item)
+	}
+
+	return out
+}
+
+// POST /sync, applies the changes of an offline client and returns the changes on the server
+// since the token of its last sync, deletes included. Changes made to a note since the base
+// version the client edited come back as conflicts along with the server copy.
+func SyncNotes(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		var syncRequest models.SyncRequest
+
+		err := json.NewDecoder(r.Body).Decode(&syncRequest)
+		if err != nil {
+			l.Error().Err(err).Msgf("error decoding the sync request. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "internal error decoding Sync struct"}, http.StatusInternalServerError)
+			return
+		}
+
+		err = validator.New().Struct(&syncRequest)
+		if err != nil {
+			l.Info().Msgf("Invalid sync request of user %s. %v", username, err)
+			httplib.JSON(w, httplib.Msg{"error": "wrongly formatted or missing sync change parameter"}, http.StatusBadRequest)
+			return
+		}
+
+		resp, err := s.Sync(ctx, username, syncRequest.Token, toSyncChanges(syncRequest.Changes))
+		switch {
+		case errors.Is(err, note.ErrInvalidSyncToken):
+			l.Info().Msgf("Invalid sync token of user %s", username)
+			httplib.JSON(w, httplib.Msg{"error": err.Error()}, http.StatusBadRequest)
+			return
+		case errors.Is(err, note.ErrTooManySyncChanges):
+			l.Info().Msgf("Sync of user %s has too many changes", username)
+			httplib.JSON(w, httplib.Msg{"error": "at most 500 changes can be synced at once"}, http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			// changes applied before the failure are picked up by the next sync
+			l.Error().Err(err).Msgf("Sync of user %s failed. %v", username, err)
+			httplib.JSON(w, httplib.Msg{"error": "internal error during sync"}, http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("Synced %d client and %d server changes of user %s", len(resp.Results), len(resp.Changes), username)
+		httplib.JSON(w, toSyncResponseModel(resp), http.StatusOK)
+	}
+}
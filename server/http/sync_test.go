@@ -0,0 +1,122 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncNotes(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+	goneID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		body          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "syncing OK",
+			body: `{"token": "tok", "changes": [{"id": "` + id.String() + `", "op": "upsert", "title": "mine", "tags": ["work"], "baseVersion": 3}]}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Sync(gomock.Any(), username, "tok", []note.SyncChange{
+					{ID: id, Op: note.SyncUpsert, Title: "mine", Tags: []string{"work"}, BaseVersion: 3},
+				}).Times(1).Return(note.SyncResponse{
+					Token: "next",
+					Results: []note.SyncResult{{
+						ID:      id,
+						Status:  note.SyncConflict,
+						Current: &note.Note{Note: db.Note{ID: id, Title: "theirs", Version: 5}},
+						Err:     &note.VersionMismatchError{},
+					}},
+					Changes: []note.SyncServerChange{
+						{ID: goneID, Op: note.SyncDelete},
+						{ID: id, Op: note.SyncUpsert, Note: &note.Note{Note: db.Note{ID: id, Title: "theirs", Version: 5}}},
+					},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp models.SyncResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Equal(t, "next", resp.Token)
+				require.False(t, resp.HasMore)
+
+				require.Len(t, resp.Results, 1)
+				require.Equal(t, "conflict", resp.Results[0].Status)
+				require.Equal(t, "note has been changed since the base version", resp.Results[0].Error)
+				require.Equal(t, int32(5), resp.Results[0].Current.Version)
+
+				require.Len(t, resp.Changes, 2)
+				require.Equal(t, models.SyncServerChange{ID: goneID, Op: "delete"}, resp.Changes[0])
+				require.Equal(t, "theirs", resp.Changes[1].Note.Title)
+			},
+		},
+		{
+			name:        "returns bad request - upsert without title",
+			body:        `{"changes": [{"id": "` + id.String() + `", "op": "upsert"}]}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:        "returns bad request - unknown op",
+			body:        `{"changes": [{"id": "` + id.String() + `", "op": "move"}]}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - invalid token",
+			body: `{"token": "!!"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Sync(gomock.Any(), username, "!!", []note.SyncChange{}).Times(1).Return(note.SyncResponse{}, note.ErrInvalidSyncToken)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns internal server error",
+			body: `{}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().Sync(gomock.Any(), username, "", []note.SyncChange{}).Times(1).Return(note.SyncResponse{}, note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/sync", strings.NewReader(tc.body))
+			req = withAuthUser(req, username)
+
+			SyncNotes(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// Get the tag name from the URL, it may be percent-encoded
+func tagNameParam(w http.ResponseWriter, r *http.Request, l *zerolog.Logger) (string, bool) {
+	name, err := url.PathUnescape(chi.URLParam(r, "name"))
+	if err != nil || name == "" {
+		l.Info().Msgf("Invalid tag name in URL %s", r.URL.Path)
+		httplib.JSON(w, httplib.Msg{"error": "invalid tag name"}, http.StatusBadRequest)
+		return "", false
+	}
+
+	return name, true
+}
+
+// Map tag errors of the note service to responses
+func writeTagError(w http.ResponseWriter, l *zerolog.Logger, name string, err error) {
+	switch {
+	case errors.Is(err, note.ErrInvalidTag):
+		l.Info().Msgf("Invalid tag name %q", name)
+		httplib.JSON(w, httplib.Msg{"error": "tags must be 1-50 characters long"}, http.StatusBadRequest)
+	case errors.Is(err, note.ErrTagNotFound):
+		l.Info().Msgf("Tag %q is not found", name)
+		httplib.JSON(w, httplib.Msg{"error": "tag is not found"}, http.StatusNotFound)
+	case errors.Is(err, note.ErrTagAlreadyExists):
+		l.Info().Msgf("Could not rename tag %q, the new name is taken", name)
+		httplib.JSON(w, httplib.Msg{"error": "a tag with that name already exists, merge the tags instead"}, http.StatusConflict)
+	default:
+		l.Error().Err(err).Msgf("Could not change tag %q. %v", name, err)
+		httplib.JSON(w, httplib.Msg{"error": "could not change tag"}, http.StatusInternalServerError)
+	}
+}
+
+// GET /tags
+func ListTags(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		tags, err := s.ListTags(ctx, username)
+		if err != nil {
+			l.Error().Err(err).Msgf("Could not retrieve tags for user. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "could not retrieve tags for user"}, http.StatusInternalServerError)
+			return
+		}
+
+		resp := make([]models.Tag, 0, len(tags))
+		for _, tag := range tags {
+			resp = append(resp, models.Tag{Name: tag.Name, NoteCount: tag.NoteCount})
+		}
+
+		l.Info().Msgf("Retrieving tags for %s was successful!", username)
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
+
+// PUT /tags/{name}
+func RenameTag(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		name, ok := tagNameParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		renameRequest := struct {
+			Name string `json:"name"`
+		}{}
+
+		err := json.NewDecoder(r.Body).Decode(&renameRequest)
+		if err != nil {
+			l.Error().Err(err).Msgf("error decoding the tag rename request. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "wrongly formatted tag rename request"}, http.StatusBadRequest)
+			return
+		}
+
+		err = s.RenameTag(ctx, username, name, renameRequest.Name)
+		if err != nil {
+			writeTagError(w, l, name, err)
+			return
+		}
+
+		l.Info().Msgf("Renaming tag %q to %q was successful!", name, renameRequest.Name)
+		httplib.JSON(w, httplib.Msg{"success": "tag renamed"}, http.StatusOK)
+	}
+}
+
+// POST /tags/{name}/merge
+func MergeTag(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		name, ok := tagNameParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		mergeRequest := struct {
+			Into string `json:"into"`
+		}{}
+
+		err := json.NewDecoder(r.Body).Decode(&mergeRequest)
+		if err != nil {
+			l.Error().Err(err).Msgf("error decoding the tag merge request. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "wrongly formatted tag merge request"}, http.StatusBadRequest)
+			return
+		}
+
+		err = s.MergeTag(ctx, username, name, mergeRequest.Into)
+		if err != nil {
+			writeTagError(w, l, name, err)
+			return
+		}
+
+		l.Info().Msgf("Merging tag %q into %q was successful!", name, mergeRequest.Into)
+		httplib.JSON(w, httplib.Msg{"success": "tags merged"}, http.StatusOK)
+	}
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTags(t *testing.T) {
+	const username = "testuser1"
+
+	ctrl := gomock.NewController(t)
+	mocksvc := mocksvc.NewMockNoteService(ctrl)
+	mocksvc.EXPECT().ListTags(gomock.Any(), username).Times(1).Return([]db.ListTagsRow{
+		{Name: "urgent", NoteCount: 1},
+		{Name: "work", NoteCount: 3},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	req := withAuthUser(httptest.NewRequest(http.MethodGet, "/tags", nil), username)
+
+	ListTags(mocksvc)(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var tags []models.Tag
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&tags))
+	require.Equal(t, []models.Tag{{Name: "urgent", NoteCount: 1}, {Name: "work", NoteCount: 3}}, tags)
+}
+
+func TestRenameTag(t *testing.T) {
+	const username = "testuser1"
+
+	testCases := []struct {
+		name          string
+		tag           string
+		body          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "renaming tag OK",
+			tag:  "to%20do",
+			body: `{"name": "todo"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenameTag(gomock.Any(), username, "to do", "todo").Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "returns conflict - new name taken",
+			tag:  "work",
+			body: `{"name": "job"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenameTag(gomock.Any(), username, "work", "job").Times(1).Return(note.ErrTagAlreadyExists)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			name: "returns not found",
+			tag:  "work",
+			body: `{"name": "job"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenameTag(gomock.Any(), username, "work", "job").Times(1).Return(note.ErrTagNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - invalid new name",
+			tag:  "work",
+			body: `{"name": ""}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RenameTag(gomock.Any(), username, "work", "").Times(1).Return(note.ErrInvalidTag)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/tags/"+tc.tag, bytes.NewBufferString(tc.body))
+			req = withURLParam(withAuthUser(req, username), "name", tc.tag)
+
+			RenameTag(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestMergeTag(t *testing.T) {
+	const username = "testuser1"
+
+	testCases := []struct {
+		name          string
+		body          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "merging tags OK",
+			body: `{"into": "job"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().MergeTag(gomock.Any(), username, "work", "job").Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - malformed body",
+			body: `{"into":`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns internal server error",
+			body: `{"into": "job"}`,
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().MergeTag(gomock.Any(), username, "work", "job").Times(1).Return(note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/tags/work/merge", bytes.NewBufferString(tc.body))
+			req = withURLParam(withAuthUser(req, username), "name", "work")
+
+			MergeTag(mocksvc)(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	httplib "github.com/alekslesik/online-note-z/lib/http"
+	"github.com/alekslesik/online-note-z/note"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+)
+
+// GET /trash
+func ListTrash(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		notes, err := s.ListTrash(ctx, username)
+		if err != nil {
+			l.Error().Err(err).Msgf("Could not list the trash of user %s. %v", username, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not retrieve the trash"}, http.StatusInternalServerError)
+			return
+		}
+
+		resp := make([]models.Note, 0, len(notes))
+		for _, n := range notes {
+			resp = append(resp, toNoteModel(n))
+		}
+
+		httplib.JSON(w, resp, http.StatusOK)
+	}
+}
+
+// POST /trash/{id}/restore
+func RestoreNote(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		id, ok := noteIDParam(w, r, l)
+		if !ok {
+			return
+		}
+
+		err := s.RestoreNote(ctx, username, id)
+		switch {
+		case errors.Is(err, note.ErrNotFound):
+			l.Info().Msgf("Note %v is not in the trash of user %s", id, username)
+			httplib.JSON(w, httplib.Msg{"error": "note is not found in the trash"}, http.StatusNotFound)
+			return
+		case errors.Is(err, note.ErrAlreadyExists):
+			l.Info().Msgf("Could not restore Note %v, the title is already taken in the notebook", id)
+			titleConflict(w, err)
+			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Could not restore Note %v. %v", id, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not restore note"}, http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("Note %v has been restored from the trash", id)
+		httplib.JSON(w, httplib.Msg{"success": "note restored"}, http.StatusOK)
+	}
+}
+
+// DELETE /trash
+func EmptyTrash(s NoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username, ok := authUsername(w, ctx, l)
+		if !ok {
+			return
+		}
+
+		n, err := s.EmptyTrash(ctx, username)
+		if err != nil {
+			l.Error().Err(err).Msgf("Could not empty the trash of user %s. %v", username, err)
+			httplib.JSON(w, httplib.Msg{"error": "could not empty the trash"}, http.StatusInternalServerError)
+			return
+		}
+
+		l.Info().Msgf("%d notes of user %s have been deleted permanently", n, username)
+		httplib.JSON(w, httplib.Msg{"success": fmt.Sprintf("trash emptied, %d notes deleted", n)}, http.StatusOK)
+	}
+}
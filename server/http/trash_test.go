@@ -0,0 +1,184 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	"github.com/alekslesik/online-note-z/note"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
+	models "github.com/alekslesik/online-note-z/server/http/models"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTrash(t *testing.T) {
+	const username = "testuser1"
+	deletedAt := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	trashed := note.Note{
+		Note: db.Note{ID: uuid.New(), Title: "old note", Username: username, DeletedAt: sql.NullTime{Time: deletedAt, Valid: true}},
+		Tags: []string{},
+	}
+
+	testCases := []struct {
+		name          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "listing trash OK",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListTrash(gomock.Any(), username).Times(1).Return([]note.Note{trashed}, nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var notes []models.Note
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&notes))
+				require.Len(t, notes, 1)
+				require.Equal(t, trashed.ID, notes[0].ID)
+				require.NotNil(t, notes[0].DeletedAt)
+				require.True(t, deletedAt.Equal(*notes[0].DeletedAt))
+			},
+		},
+		{
+			name: "returns internal server error - db error",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListTrash(gomock.Any(), username).Times(1).Return(nil, note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := withAuthUser(httptest.NewRequest(http.MethodGet, "/trash", nil), username)
+
+			handler := ListTrash(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestRestoreNote(t *testing.T) {
+	const username = "testuser1"
+	id := uuid.New()
+
+	testCases := []struct {
+		name          string
+		id            string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "restoring note OK",
+			id:   id.String(),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RestoreNote(gomock.Any(), username, id).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "returns not found - note is not in the trash",
+			id:   id.String(),
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RestoreNote(gomock.Any(), username, id).Times(1).Return(note.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - invalid id",
+			id:   "not-a-uuid",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RestoreNote(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := withAuthUser(httptest.NewRequest(http.MethodPost, "/trash/"+tc.id+"/restore", nil), username)
+			req = withURLParam(req, "id", tc.id)
+
+			handler := RestoreNote(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	const username = "testuser1"
+
+	testCases := []struct {
+		name          string
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "emptying trash OK",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().EmptyTrash(gomock.Any(), username).Times(1).Return(int64(4), nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "4 notes deleted")
+			},
+		},
+		{
+			name: "returns internal server error - db error",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().EmptyTrash(gomock.Any(), username).Times(1).Return(int64(0), note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := withAuthUser(httptest.NewRequest(http.MethodDelete, "/trash", nil), username)
+
+			handler := EmptyTrash(mocksvc)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}
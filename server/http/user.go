@@ -3,15 +3,15 @@ package server
 import (
 	"encoding/json"
 	"errors"
-	"io"
+	"net"
 	"net/http"
 	"time"
 
-	db "github.com/adykaaa/online-notes/db/sqlc"
-	auth "github.com/adykaaa/online-notes/server/http/auth"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
 	httplib "github.com/alekslesik/online-note-z/lib/http"
 	"github.com/alekslesik/online-note-z/lib/password"
 	"github.com/alekslesik/online-note-z/note"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
 	"github.com/alekslesik/online-note-z/server/http/models"
 	"github.com/go-playground/validator/v10"
 )
@@ -65,7 +65,7 @@ func RegisterUser(s NoteService) http.HandlerFunc {
 		})
 
 		switch {
-		case errors.Is(err, note.ErrAlreadyExists):
+		case errors.Is(err, note.ErrUserAlreadyExists):
 			l.Error().Err(err).Msgf("registration failed, username or email already in use for user %s", req.Username)
 			httplib.JSON(w, httplib.Msg{"error": "username or email already in use"}, http.StatusForbidden)
 			return
@@ -81,7 +81,7 @@ func RegisterUser(s NoteService) http.HandlerFunc {
 }
 
 // POST /login/
-func LoginUser(s NoteService, token auth.TokenManager, tokenDuration time.Duration) http.HandlerFunc {
+func LoginUser(s NoteService, token auth.TokenManager, tokenDuration time.Duration, refreshDuration time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// take logger and context
 		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
@@ -121,39 +121,152 @@ func LoginUser(s NoteService, token auth.TokenManager, tokenDuration time.Durati
 			return
 		}
 
-		// TODO read about paseto
+		// create long-lived refresh token, only its hash is stored
+		refreshToken, refreshHash, err := token.CreateRefreshToken()
+		if err != nil {
+			l.Info().Err(err).Msgf("Could not create refresh token for user. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "internal server error while creating the token"}, http.StatusInternalServerError)
+			return
+		}
+
+		// every login is a new session the user can see and revoke
+		refreshExpiresAt := time.Now().Add(refreshDuration)
+		sessionID, err := s.CreateSession(ctx, req.Username, r.UserAgent(), clientIP(r), refreshHash, refreshExpiresAt)
+		if err != nil {
+			l.Error().Err(err).Msgf("Could not create session for user. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "internal server error while creating the token"}, http.StatusInternalServerError)
+			return
+		}
+
 		// create PASETO token for requested user
-		token, payload, err := token.CreateToken(req.Username, tokenDuration)
+		accessToken, payload, err := token.CreateToken(req.Username, sessionID, tokenDuration)
 		if err != nil {
 			l.Info().Err(err).Msgf("Could not create PASETO for user. %v", err)
 			httplib.JSON(w, httplib.Msg{"error": "internal server error while creating the token"}, http.StatusInternalServerError)
 			return
 		}
 
-		httplib.SetCookie(w, "paseto", token, payload.ExpiresAt)
+		httplib.SetCookie(w, "paseto", accessToken, payload.ExpiresAt)
+		httplib.SetCookie(w, "refresh_token", refreshToken, refreshExpiresAt)
 		httplib.JSON(w, httplib.Msg{"success": "login successful"}, http.StatusOK)
 		l.Info().Msgf("User login for %s was successful!", req.Username)
 	}
 }
 
-// POST /logout/
-func LogoutUser() http.HandlerFunc {
+// POST /token/refresh
+func RefreshToken(s NoteService, token auth.TokenManager, tokenDuration time.Duration, refreshDuration time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// take logger and context
-		l, _, cancel := httplib.SetupHandler(w, r.Context())
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
 		defer cancel()
 
-		// read username
-		uname, err := io.ReadAll(r.Body)
+		refreshCookie, err := r.Cookie("refresh_token")
+		if err != nil || refreshCookie.Value == "" {
+			l.Info().Msg("refresh token cookie is not set")
+			httplib.JSON(w, httplib.Msg{"error": "refresh token is missing"}, http.StatusUnauthorized)
+			return
+		}
+
+		newRefreshToken, newRefreshHash, err := token.CreateRefreshToken()
 		if err != nil {
-			l.Info().Err(err).Msgf("Could not decode request body while logging out. %v", err)
-			httplib.JSON(w, httplib.Msg{"error": "couldn't decode request body"}, http.StatusInternalServerError)
+			l.Info().Err(err).Msgf("Could not create refresh token. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "internal server error while creating the token"}, http.StatusInternalServerError)
+			return
+		}
+
+		// rotate the refresh token, the presented one can't be used again
+		refreshExpiresAt := time.Now().Add(refreshDuration)
+		username, sessionID, err := s.RotateRefreshToken(ctx, token.HashRefreshToken(refreshCookie.Value), newRefreshHash, refreshExpiresAt)
+		switch {
+		case errors.Is(err, note.ErrRefreshTokenReused):
+			l.Warn().Msg("refresh token reuse detected, token family is revoked")
+			clearAuthCookies(w)
+			httplib.JSON(w, httplib.Msg{"error": "refresh token has already been used, please log in again"}, http.StatusUnauthorized)
+			return
+		case errors.Is(err, note.ErrRefreshTokenInvalid), errors.Is(err, note.ErrRefreshTokenExpired):
+			l.Info().Err(err).Msgf("refresh token rejected. %v", err)
+			clearAuthCookies(w)
+			httplib.JSON(w, httplib.Msg{"error": "refresh token is invalid or expired"}, http.StatusUnauthorized)
 			return
+		case err != nil:
+			l.Error().Err(err).Msgf("Error during refresh token rotation! %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "internal error during token refresh"}, http.StatusInternalServerError)
+			return
+		}
+
+		accessToken, payload, err := token.CreateToken(username, sessionID, tokenDuration)
+		if err != nil {
+			l.Info().Err(err).Msgf("Could not create PASETO for user. %v", err)
+			httplib.JSON(w, httplib.Msg{"error": "internal server error while creating the token"}, http.StatusInternalServerError)
+			return
+		}
+
+		httplib.SetCookie(w, "paseto", accessToken, payload.ExpiresAt)
+		httplib.SetCookie(w, "refresh_token", newRefreshToken, refreshExpiresAt)
+		httplib.JSON(w, httplib.Msg{"success": "token refresh successful"}, http.StatusOK)
+		l.Info().Msgf("Token refresh for %s was successful!", username)
+	}
+}
+
+// Client address without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// Reset the access and refresh token cookies
+func clearAuthCookies(w http.ResponseWriter) {
+	httplib.SetCookie(w, "paseto", "", time.Unix(0, 0))
+	httplib.SetCookie(w, "refresh_token", "", time.Unix(0, 0))
+}
+
+// POST /logout/
+func LogoutUser(s NoteService, token auth.TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// take logger and context
+		l, ctx, cancel := httplib.SetupHandler(w, r.Context())
+		defer cancel()
+
+		username := ""
+
+		// revoke the access token and its session, so a copied token stops working right away
+		if tokenCookie, err := r.Cookie("paseto"); err == nil {
+			if payload, err := token.VerifyToken(tokenCookie.Value); err == nil {
+				username = payload.Username
+
+				err = s.RevokeToken(ctx, payload.Username, payload.ID, payload.ExpiresAt)
+				if err != nil {
+					l.Error().Err(err).Msgf("Could not revoke PASETO %v. %v", payload.ID, err)
+					httplib.JSON(w, httplib.Msg{"error": "internal error during logout"}, http.StatusInternalServerError)
+					return
+				}
+
+				err = s.RevokeSession(ctx, payload.Username, payload.SessionID)
+				if err != nil && !errors.Is(err, note.ErrSessionNotFound) {
+					l.Error().Err(err).Msgf("Could not revoke session %v. %v", payload.SessionID, err)
+					httplib.JSON(w, httplib.Msg{"error": "internal error during logout"}, http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
+		// the access token may already be expired, the refresh token still identifies the session
+		if refreshCookie, err := r.Cookie("refresh_token"); err == nil && refreshCookie.Value != "" {
+			err = s.RevokeSessionByRefreshToken(ctx, token.HashRefreshToken(refreshCookie.Value))
+			if err != nil && !errors.Is(err, note.ErrSessionNotFound) {
+				l.Error().Err(err).Msgf("Could not revoke session of refresh token. %v", err)
+				httplib.JSON(w, httplib.Msg{"error": "internal error during logout"}, http.StatusInternalServerError)
+				return
+			}
 		}
 
-		// reset paseto cookie
-		httplib.SetCookie(w, "paseto", "", time.Unix(0, 0))
+		// reset auth cookies
+		clearAuthCookies(w)
 		httplib.JSON(w, httplib.Msg{"success": "user successfully logged out"}, http.StatusOK)
-		l.Info().Msgf("User logout for %s was successful!", string(uname))
+		l.Info().Msgf("User logout for %s was successful!", username)
 	}
 }
@@ -8,16 +8,17 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
-	// "time"
+	"time"
 
-	db "github.com/adykaaa/online-notes/db/sqlc"
-	mocksvc "github.com/adykaaa/online-notes/note/mock"
-	// auth "github.com/adykaaa/online-notes/server/http/auth"
+	db "github.com/alekslesik/online-note-z/db/sqlc"
+	mocksvc "github.com/alekslesik/online-note-z/note/mock"
 	"github.com/alekslesik/online-note-z/lib/password"
 	"github.com/alekslesik/online-note-z/note"
+	auth "github.com/alekslesik/online-note-z/server/http/auth"
 	models "github.com/alekslesik/online-note-z/server/http/models"
 	"github.com/go-playground/validator/v10"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -156,7 +157,7 @@ func TestRegisterUser(t *testing.T) {
 			},
 
 			mockSvcCall: func(mocksvc *mocksvc.MockNoteService, u *models.User) {
-				mocksvc.EXPECT().RegisterUser(gomock.Any(), gomock.Any()).Times(1).Return("", note.ErrAlreadyExists)
+				mocksvc.EXPECT().RegisterUser(gomock.Any(), gomock.Any()).Times(1).Return("", note.ErrUserAlreadyExists)
 			},
 
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -216,21 +217,116 @@ func TestGetAllNotesFromUser(t *testing.T) {
 
 	testCases := []struct {
 		name          string
-		addQuery      func(t *testing.T, r *http.Request)
+		setupRequest  func(t *testing.T, r *http.Request) *http.Request
 		mockSvcCall   func(svcmock *mocksvc.MockNoteService)
 		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
 	}{
 		{
 			name: "gettings notes from user OK",
 
-			addQuery: func(t *testing.T, r *http.Request) {
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
+				return withAuthUser(r, username)
+			},
+
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListNotes(gomock.Any(), username, note.ListOptions{MatchAllTags: true}).Times(1).
+					Return(note.NotePage{Notes: []note.Note{{Note: db.Note{ID: uuid.New(), Title: "title"}, Tags: []string{"work"}}}, NextCursor: "next", Total: 3}, nil)
+			},
+
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var page models.NotePage
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+				require.Len(t, page.Notes, 1)
+				require.Equal(t, []string{"work"}, page.Notes[0].Tags)
+				require.Equal(t, "next", page.NextCursor)
+				require.Equal(t, int64(3), page.Total)
+			},
+		},
+		{
+			name: "paging, sorting and date filters OK",
+
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
+				r.URL.RawQuery = "limit=10&cursor=abc&sort=updated_at&order=desc&created_from=2023-01-02&updated_to=2023-02-01T10:00:00Z"
+				return withAuthUser(r, username)
+			},
+
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListNotes(gomock.Any(), username, note.ListOptions{
+					Limit:        10,
+					Cursor:       "abc",
+					SortBy:       "updated_at",
+					Descending:   true,
+					CreatedFrom:  time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+					UpdatedTo:    time.Date(2023, 2, 1, 10, 0, 0, 0, time.UTC),
+					MatchAllTags: true,
+				}).Times(1).Return(note.NotePage{Notes: []note.Note{}}, nil)
+			},
+
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - invalid date",
+
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
+				r.URL.RawQuery = "created_to=yesterday"
+				return withAuthUser(r, username)
+			},
+
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+			},
+
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - invalid cursor",
+
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
+				r.URL.RawQuery = "cursor=garbage"
+				return withAuthUser(r, username)
+			},
+
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListNotes(gomock.Any(), username, gomock.Any()).Times(1).Return(note.NotePage{}, note.ErrInvalidCursor)
+			},
+
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "ignores username url param of another user",
+
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
 				q := r.URL.Query()
-				q.Add("username", username)
+				q.Add("username", "otheruser1")
 				r.URL.RawQuery = q.Encode()
+				return withAuthUser(r, username)
+			},
+
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListNotes(gomock.Any(), username, gomock.Any()).Times(1).Return(note.NotePage{Notes: []note.Note{}}, nil)
+			},
+
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "filtering notes by all tags OK",
+
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
+				r.URL.RawQuery = "tag=work&tag=urgent"
+				return withAuthUser(r, username)
 			},
 
 			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
-				mocksvc.EXPECT().GetAllNotesFromUser(gomock.Any(), username).Times(1).Return([]db.Note{}, nil)
+				mocksvc.EXPECT().ListNotes(gomock.Any(), username, note.ListOptions{Tags: []string{"work", "urgent"}, MatchAllTags: true}).Times(1).Return(note.NotePage{Notes: []note.Note{}}, nil)
 			},
 
 			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
@@ -238,9 +334,27 @@ func TestGetAllNotesFromUser(t *testing.T) {
 			},
 		},
 		{
-			name: "returns bad request - missing url param",
+			name: "filtering notes by any tag OK",
 
-			addQuery: func(t *testing.T, r *http.Request) {
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
+				r.URL.RawQuery = "tag=work&tag=urgent&match=any"
+				return withAuthUser(r, username)
+			},
+
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().ListNotes(gomock.Any(), username, note.ListOptions{Tags: []string{"work", "urgent"}}).Times(1).Return(note.NotePage{Notes: []note.Note{}}, nil)
+			},
+
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name: "returns bad request - invalid match mode",
+
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
+				r.URL.RawQuery = "tag=work&match=some"
+				return withAuthUser(r, username)
 			},
 
 			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
@@ -250,17 +364,29 @@ func TestGetAllNotesFromUser(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, rec.Code)
 			},
 		},
+		{
+			name: "returns unauthorized - missing authenticated user",
+
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
+				return r
+			},
+
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+			},
+
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
 		{
 			name: "returns internal server error - db error",
 
-			addQuery: func(t *testing.T, r *http.Request) {
-				q := r.URL.Query()
-				q.Add("username", username)
-				r.URL.RawQuery = q.Encode()
+			setupRequest: func(t *testing.T, r *http.Request) *http.Request {
+				return withAuthUser(r, username)
 			},
 
 			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
-				mocksvc.EXPECT().GetAllNotesFromUser(gomock.Any(), username).Times(1).Return(nil, note.ErrDBInternal)
+				mocksvc.EXPECT().ListNotes(gomock.Any(), username, gomock.Any()).Times(1).Return(note.NotePage{}, note.ErrDBInternal)
 			},
 
 			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
@@ -279,7 +405,7 @@ func TestGetAllNotesFromUser(t *testing.T) {
 			rec := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodGet, "/notes", nil)
 
-			tc.addQuery(t, req)
+			req = tc.setupRequest(t, req)
 			tc.mockSvcCall(mocksvc)
 
 			handler := GetAllNotesFromUser(mocksvc)
@@ -288,3 +414,95 @@ func TestGetAllNotesFromUser(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshToken(t *testing.T) {
+	const username = "testuser1"
+	tm := &auth.MockTokenManager{}
+
+	testCases := []struct {
+		name          string
+		cookie        *http.Cookie
+		mockSvcCall   func(mocksvc *mocksvc.MockNoteService)
+		checkResponse func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "token refresh OK",
+			cookie: &http.Cookie{Name: "refresh_token", Value: "oldtoken"},
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RotateRefreshToken(gomock.Any(), tm.HashRefreshToken("oldtoken"), tm.HashRefreshToken("testrefreshtoken"), gomock.Any()).
+					Times(1).Return(username, uuid.New(), nil)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				cookies := map[string]string{}
+				for _, c := range rec.Result().Cookies() {
+					cookies[c.Name] = c.Value
+				}
+				require.Equal(t, "testtoken", cookies["paseto"])
+				require.Equal(t, "testrefreshtoken", cookies["refresh_token"])
+			},
+		},
+		{
+			name: "returns unauthorized - missing cookie",
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:   "returns unauthorized - reused token",
+			cookie: &http.Cookie{Name: "refresh_token", Value: "oldtoken"},
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("", uuid.Nil, note.ErrRefreshTokenReused)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+				for _, c := range rec.Result().Cookies() {
+					require.Empty(t, c.Value)
+				}
+			},
+		},
+		{
+			name:   "returns unauthorized - expired token",
+			cookie: &http.Cookie{Name: "refresh_token", Value: "oldtoken"},
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("", uuid.Nil, note.ErrRefreshTokenExpired)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:   "returns internal server error - db error",
+			cookie: &http.Cookie{Name: "refresh_token", Value: "oldtoken"},
+			mockSvcCall: func(mocksvc *mocksvc.MockNoteService) {
+				mocksvc.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("", uuid.Nil, note.ErrDBInternal)
+			},
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for c := range testCases {
+		tc := testCases[c]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocksvc := mocksvc.NewMockNoteService(ctrl)
+			tc.mockSvcCall(mocksvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/token/refresh", nil)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+
+			handler := RefreshToken(mocksvc, tm, time.Minute, time.Hour)
+			handler(rec, req)
+			tc.checkResponse(t, rec)
+		})
+	}
+}